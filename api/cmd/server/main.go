@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
 	"syscall"
 
 	"golang.org/x/sync/errgroup"
@@ -17,6 +18,14 @@ import (
 	"github.com/playperu/cityquiz/internal/server"
 )
 
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -36,6 +45,15 @@ func run(ctx context.Context, stdout io.Writer) error {
 	logger := slog.New(slog.NewJSONHandler(stdout, &slog.HandlerOptions{
 		Level: cfg.LogLevel,
 	}))
+	slog.SetDefault(logger) // for handlers that log without a logger of their own
+
+	if cfg.DevRandomSeed != 0 {
+		if cfg.TLSCert != "" {
+			return fmt.Errorf("DEV_RANDOM_SEED makes session tokens predictable and must not be used with TLS")
+		}
+		server.SeedRandom(cfg.DevRandomSeed)
+		logger.Warn("DEV_RANDOM_SEED is set: IDs and tokens are predictable, do not use in production", "seed", cfg.DevRandomSeed)
+	}
 
 	// Derive data directory from DB_PATH.
 	dbDir := filepath.Dir(cfg.DBPath)
@@ -48,7 +66,7 @@ func run(ctx context.Context, stdout io.Writer) error {
 
 	// Open admin DB (sits alongside the client DBs).
 	adminDBPath := filepath.Join(dbDir, "_admin.db")
-	adminDB, err := database.Open(ctx, adminDBPath)
+	adminDB, err := database.Open(ctx, adminDBPath, cfg.DBMaxConns)
 	if err != nil {
 		return fmt.Errorf("opening admin db: %w", err)
 	}
@@ -61,7 +79,7 @@ func run(ctx context.Context, stdout io.Writer) error {
 	logger.Info("admin db ready", "path", adminDBPath)
 
 	// Create registry for per-client stores.
-	clients := server.NewRegistry(dbDir)
+	clients := server.NewRegistry(dbDir, cfg.DBMaxConns, cfg.DBReadConns)
 	defer clients.Close()
 
 	// Pre-open existing clients.
@@ -70,7 +88,7 @@ func run(ctx context.Context, stdout io.Writer) error {
 		return fmt.Errorf("listing clients: %w", err)
 	}
 	for _, c := range existing {
-		if _, err := clients.Get(ctx, c.Slug); err != nil {
+		if _, err := clients.Create(ctx, c.Slug); err != nil {
 			return fmt.Errorf("opening client %q: %w", c.Slug, err)
 		}
 		logger.Info("client db ready", "slug", c.Slug)
@@ -80,15 +98,45 @@ func run(ctx context.Context, stdout io.Writer) error {
 		return fmt.Errorf("seeding demo: %w", err)
 	}
 
-	srv := server.New(cfg.HTTPAddr, logger, admin, clients, adminDB, cfg.SPADir, dbDir, cfg.TLSCert, cfg.TLSKey)
+	srv := server.New(server.Options{
+		Addr:           cfg.HTTPAddr,
+		Logger:         logger,
+		Admin:          admin,
+		Clients:        clients,
+		AdminDB:        adminDB,
+		SPADir:         cfg.SPADir,
+		DataDir:        dbDir,
+		TLSCert:        cfg.TLSCert,
+		TLSKey:         cfg.TLSKey,
+		SSEMaxDuration: cfg.SSEMaxDuration,
+		SSEBuffer:      server.SSEBuffer{Default: cfg.SSEBuffer, Max: cfg.SSEMaxBuffer},
+		ReadTimeout:    cfg.HTTPReadTimeout,
+		WriteTimeout:   cfg.HTTPWriteTimeout,
+		Limits:         server.TimerLimits{GameMinutes: cfg.MaxTimerMinutes, StageMinutes: cfg.MaxStageTimerMinutes},
+		MaxAnswerLen:   cfg.MaxAnswerLength,
+		MinFreeDiskMB:  cfg.MinFreeDiskMB,
+		PublicOrigins:  cfg.PublicCORSOrigins,
+		GameRetention:  cfg.GameRetention,
+		Build:          server.BuildInfo{Version: version, Commit: commit, GoVersion: runtime.Version()},
+	})
 
 	g, gctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		logger.Info("starting server", "addr", cfg.HTTPAddr)
+		logger.Info("starting server", "addr", cfg.HTTPAddr, "version", version, "commit", commit)
 		return srv.Run(gctx)
 	})
 
+	g.Go(func() error {
+		srv.WarnTimers(gctx, cfg.TimerWarnings)
+		return nil
+	})
+
+	g.Go(func() error {
+		srv.CleanUpGames(gctx, cfg.GameRetention)
+		return nil
+	})
+
 	g.Go(func() error {
 		<-gctx.Done()
 		logger.Info("shutting down http server")
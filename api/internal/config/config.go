@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -14,6 +15,63 @@ type Config struct {
 	SPADir   string     `env:"SPA_DIR" envDefault:"../web/dist"`
 	TLSCert  string     `env:"TLS_CERT"`
 	TLSKey   string     `env:"TLS_KEY"`
+
+	// DBMaxConns caps each SQLite connection pool (see database.Open). Zero
+	// means unlimited.
+	DBMaxConns int `env:"DB_MAX_CONNS" envDefault:"1"`
+
+	// DBReadConns sizes a separate read pool on each client DB so state
+	// polling doesn't wait for the writer. Zero shares the writer pool.
+	DBReadConns int `env:"DB_READ_CONNS" envDefault:"4"`
+
+	// SSEMaxDuration caps how long a single SSE connection stays open before
+	// the server asks the client to reconnect.
+	SSEMaxDuration time.Duration `env:"SSE_MAX_DURATION" envDefault:"1h"`
+
+	// SSEBuffer is how many events each SSE connection queues before new ones
+	// are dropped for it; clients may ask for up to SSEMaxBuffer. Each queued
+	// event is a small JSON payload held in memory per connection.
+	SSEBuffer    int `env:"SSE_BUFFER" envDefault:"16"`
+	SSEMaxBuffer int `env:"SSE_MAX_BUFFER" envDefault:"256"`
+
+	// HTTPReadTimeout and HTTPWriteTimeout bound how long a request may take
+	// to send its body and to receive its response. The SSE stream is exempt
+	// from both. Zero disables the limit.
+	HTTPReadTimeout  time.Duration `env:"HTTP_READ_TIMEOUT" envDefault:"30s"`
+	HTTPWriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT" envDefault:"30s"`
+
+	// Upper bounds for game and stage timers set by operators. Zero disables
+	// the cap.
+	MaxTimerMinutes      int `env:"MAX_TIMER_MINUTES" envDefault:"1440"`
+	MaxStageTimerMinutes int `env:"MAX_STAGE_TIMER_MINUTES" envDefault:"120"`
+
+	// TimerWarnings are the times left on a game timer at which every team
+	// gets a timer_warning event, comma-separated. 0 disables the warnings.
+	TimerWarnings []time.Duration `env:"TIMER_WARNINGS" envDefault:"10m,5m,1m"`
+
+	// GameRetention is how long ended games are kept before a background
+	// cleanup deletes them, with their player sessions. Zero keeps them
+	// forever.
+	GameRetention time.Duration `env:"GAME_RETENTION"`
+
+	// MaxAnswerLength caps, in characters, the answers and unlock codes
+	// players submit; every answer is stored in the game document. Zero
+	// disables the cap.
+	MaxAnswerLength int `env:"MAX_ANSWER_LENGTH" envDefault:"200"`
+
+	// MinFreeDiskMB is the free space, in MiB, below which /healthz reports
+	// the client DB directory as unhealthy. Zero disables the check.
+	MinFreeDiskMB int `env:"MIN_FREE_DISK_MB" envDefault:"512"`
+
+	// PublicCORSOrigins are the sites whose pages may read the public
+	// endpoints (GET /api/public/...) from the browser, comma-separated;
+	// "*" allows any. Empty allows same-origin pages only.
+	PublicCORSOrigins []string `env:"PUBLIC_CORS_ORIGINS"`
+
+	// DevRandomSeed, when set, replaces crypto/rand for IDs, tokens and team
+	// secrets so demos come out the same every run (see server.SeedRandom).
+	// Dev only; refused together with TLS.
+	DevRandomSeed uint64 `env:"DEV_RANDOM_SEED"`
 }
 
 func Load() (*Config, error) {
@@ -10,11 +10,22 @@ import (
 
 // Open creates a SQLite connection via libSQL and configures it for
 // concurrent use: WAL journal mode, 5 s busy timeout, foreign keys enabled.
-func Open(ctx context.Context, path string) (*sql.DB, error) {
+//
+// maxConns caps the connection pool; 0 leaves it unlimited. SQLite allows a
+// single writer at a time even in WAL mode, so extra connections only add
+// writers that wait on each other until busy_timeout runs out ("database is
+// locked"). The PRAGMAs below are also per connection and only reach the one
+// that runs them. One connection serializes every query in-process instead,
+// which is what the callers want.
+func Open(ctx context.Context, path string, maxConns int) (*sql.DB, error) {
 	db, err := sql.Open("libsql", "file:"+path)
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
+	if maxConns > 0 {
+		db.SetMaxOpenConns(maxConns)
+		db.SetMaxIdleConns(maxConns)
+	}
 
 	// libSQL rejects Exec for PRAGMAs that return rows, but some PRAGMAs
 	// (like foreign_keys=ON) return nothing. Use QueryContext and drain rows
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkWriteContention runs concurrent read-modify-write transactions,
+// the shape of DocStore.modifyGame, against one file DB with different pool
+// sizes. Compare ns/op and the reported busy errors:
+//
+//	go test ./internal/database -bench WriteContention -cpu 8
+func BenchmarkWriteContention(b *testing.B) {
+	for _, conns := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("conns=%d", conns), func(b *testing.B) {
+			ctx := context.Background()
+			db, err := Open(ctx, filepath.Join(b.TempDir(), "bench.db"), conns)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+			if _, err := db.ExecContext(ctx, `CREATE TABLE counters (id INTEGER PRIMARY KEY, n INTEGER NOT NULL)`); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := db.ExecContext(ctx, `INSERT INTO counters (id, n) VALUES (1, 0)`); err != nil {
+				b.Fatal(err)
+			}
+
+			var failed atomic.Int64
+			errs := make(chan error, 1)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := increment(ctx, db); err != nil {
+						select {
+						case errs <- err:
+						default:
+						}
+						failed.Add(1)
+					}
+				}
+			})
+			b.StopTimer()
+
+			close(errs)
+			if err := <-errs; err != nil {
+				b.Logf("first error: %v", err)
+			}
+			b.ReportMetric(float64(failed.Load())/float64(b.N), "errors/op")
+		})
+	}
+}
+
+// increment reads and rewrites a row inside one transaction.
+func increment(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT n FROM counters WHERE id = 1`).Scan(&n); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE counters SET n = ? WHERE id = 1`, n+1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BenchmarkReadsDuringWrites measures point reads, the shape of game state
+// polling, while another goroutine keeps writing. "shared" reads through the
+// single writer connection; "separate" reads through a second pool on the
+// same file, as DocStore does.
+func BenchmarkReadsDuringWrites(b *testing.B) {
+	for _, tc := range []struct {
+		name      string
+		readConns int // 0 = read through the writer
+	}{
+		{"shared", 0},
+		{"separate", 4},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			ctx := context.Background()
+			path := filepath.Join(b.TempDir(), "bench.db")
+			writer, err := Open(ctx, path, 1)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer writer.Close()
+			if _, err := writer.ExecContext(ctx, `CREATE TABLE counters (id INTEGER PRIMARY KEY, n INTEGER NOT NULL)`); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := writer.ExecContext(ctx, `INSERT INTO counters (id, n) VALUES (1, 0)`); err != nil {
+				b.Fatal(err)
+			}
+			reader := writer
+			if tc.readConns > 0 {
+				if reader, err = Open(ctx, path, tc.readConns); err != nil {
+					b.Fatal(err)
+				}
+				defer reader.Close()
+			}
+
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						increment(ctx, writer)
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var n int
+				for pb.Next() {
+					if err := reader.QueryRowContext(ctx, `SELECT n FROM counters WHERE id = 1`).Scan(&n); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+			close(stop)
+			<-done
+		})
+	}
+}
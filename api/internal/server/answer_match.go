@@ -0,0 +1,196 @@
+package server
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// answerRules are the leniencies applied when comparing answers.
+// TrimPunctuation is per game; forStage sets IgnoreAccents on every stage
+// that isn't strict; CaseSensitive and Strict come from the stage.
+type answerRules struct {
+	IgnoreAccents   bool // "Martin" matches "Martín"
+	TrimPunctuation bool // "catacombs." matches "catacombs"
+	CaseSensitive   bool // "abc" does not match "AbC"
+	Strict          bool // "a  b" does not match "a b"; set alone with CaseSensitive
+}
+
+// forStage returns the game's rules with the stage's settings applied.
+// Accents are folded by default, since players rarely type them ("Jiron" for
+// "Jirón"). A strict stage drops that and the game's leniencies, for answers
+// such as formulas where spacing and punctuation matter.
+func (r answerRules) forStage(caseSensitive, strict bool) answerRules {
+	if strict {
+		return answerRules{CaseSensitive: caseSensitive, Strict: true}
+	}
+	r.IgnoreAccents = true
+	r.CaseSensitive = caseSensitive
+	return r
+}
+
+// answerMatches reports whether a submitted answer matches the stage's
+// correct answer, ignoring surrounding whitespace, runs of spaces inside it
+// and, unless CaseSensitive, case. Strict keeps the inner spacing. With
+// IgnoreAccents, diacritics are folded away on both sides first so "Martin"
+// matches "Martín"; with TrimPunctuation, trailing punctuation is dropped
+// from both sides so "catacombs." matches "catacombs". Only the comparison is
+// normalized; stored and displayed answers keep their original spelling.
+func answerMatches(answer, correct string, rules answerRules) bool {
+	answer, correct = normalizeAnswer(answer, rules), normalizeAnswer(correct, rules)
+	if rules.CaseSensitive {
+		return answer == correct
+	}
+	return strings.EqualFold(answer, correct)
+}
+
+// answerTypeList marks a stage whose answer has several parts in one
+// submission ("red, green, blue"), compared part by part against
+// AcceptedAnswers.
+const answerTypeList = "list"
+
+// answerTypeNumber marks a stage whose answer is a number, matched within
+// the stage's NumericTolerance ("how many meters high?").
+const answerTypeNumber = "number"
+
+// parseNumber reads a numeric answer. A comma works as the decimal separator
+// ("3,5"), since that's what many players type; NaN and infinities don't
+// count as numbers.
+func parseNumber(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// numericAnswerMatches reports whether answer is a number within tolerance of
+// correct. An answer that isn't a number is simply wrong.
+func numericAnswerMatches(answer, correct string, tolerance float64) bool {
+	got, ok := parseNumber(answer)
+	if !ok {
+		return false
+	}
+	want, ok := parseNumber(correct)
+	return ok && math.Abs(got-want) <= tolerance
+}
+
+// numericAnswerKey is answerKey for numeric answers: the same number written
+// differently ("3.50", "3,5") shares a key. Answers that aren't numbers fall
+// back to the text key.
+func numericAnswerKey(answer string, rules answerRules) string {
+	if f, ok := parseNumber(answer); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return answerKey(answer, rules)
+}
+
+// defaultListDelimiter separates the parts of a list answer when the stage
+// doesn't set one.
+const defaultListDelimiter = ","
+
+// splitListAnswer cuts a list answer into its trimmed, non-empty parts, so a
+// trailing delimiter or doubled space doesn't count as an extra part.
+func splitListAnswer(answer, delimiter string) []string {
+	if delimiter == "" {
+		delimiter = defaultListDelimiter
+	}
+	var parts []string
+	for _, p := range strings.Split(answer, delimiter) {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// listAnswerKey is answerKey for list answers: each part is normalized on its
+// own and, for unordered lists, the parts are sorted, so two submissions
+// share a key exactly when listAnswerMatches treats them as the same.
+func listAnswerKey(answer, delimiter string, unordered bool, rules answerRules) string {
+	parts := splitListAnswer(answer, delimiter)
+	for i, p := range parts {
+		parts[i] = answerKey(p, rules)
+	}
+	if unordered {
+		slices.Sort(parts)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// listAnswerMatches reports whether a delimited answer has exactly the
+// accepted parts, each compared with answerMatches. Ordered lists need the
+// parts in the same order; unordered ones any order. A missing or extra part
+// never matches.
+func listAnswerMatches(answer string, accepted []string, delimiter string, unordered bool, rules answerRules) bool {
+	if len(accepted) == 0 {
+		return false
+	}
+	want := make([]string, len(accepted))
+	for i, a := range accepted {
+		want[i] = answerKey(a, rules)
+	}
+	if unordered {
+		slices.Sort(want)
+	}
+	return listAnswerKey(answer, delimiter, unordered, rules) == strings.Join(want, "\x00")
+}
+
+// answerKey is the form answers are grouped by: two answers share a key
+// exactly when answerMatches would treat them as the same.
+func answerKey(answer string, rules answerRules) string {
+	if rules.CaseSensitive {
+		return normalizeAnswer(answer, rules)
+	}
+	return strings.ToLower(normalizeAnswer(answer, rules))
+}
+
+// normalizeAnswer trims an answer, collapses inner whitespace to single
+// spaces unless Strict, and applies the game's leniencies; case is left to
+// the caller.
+func normalizeAnswer(s string, rules answerRules) string {
+	s = strings.TrimSpace(s)
+	if !rules.Strict {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if rules.IgnoreAccents {
+		s = foldAccents(s)
+	}
+	if rules.TrimPunctuation {
+		s = trimTrailingPunct(s)
+	}
+	return s
+}
+
+// foldAccents strips combining marks after canonical decomposition, turning
+// "á" into "a" and "ñ" into "n". Characters without a decomposition (e.g. "ø")
+// are left alone.
+func foldAccents(s string) string {
+	// Transformers carry state, so build a fresh chain per call.
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return folded
+}
+
+// trimTrailingPunct drops trailing punctuation and any whitespace it leaves
+// behind, so "catacombs ?!" becomes "catacombs". An answer that is nothing
+// but punctuation is left as is.
+func trimTrailingPunct(s string) string {
+	trimmed := strings.TrimRightFunc(s, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	if trimmed == "" {
+		return s
+	}
+	return trimmed
+}
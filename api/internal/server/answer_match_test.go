@@ -0,0 +1,171 @@
+package server
+
+import "testing"
+
+func TestAnswerMatches(t *testing.T) {
+	// Stages fold accents unless they are strict; no game setting changes that.
+	stage := answerRules{}.forStage(false, false)
+	strict := answerRules{}.forStage(false, true)
+	tests := []struct {
+		answer, correct string
+		rules           answerRules
+		want            bool
+	}{
+		{"lima", "Lima", answerRules{}, true},
+		{"  Lima ", "lima", answerRules{}, true},
+		{"Martin", "Martín", strict, false},
+		{"Martin", "Martín", stage, true},
+		{"MARTÍN", "martin", stage, true},
+		{"pena", "Peña", stage, true},
+		{"PEÑA", "peña", answerRules{}, true},
+		{"Canon", "Cañón", stage, true},
+		{"Cusco", "Cuzco", stage, false},
+		{"Jiron", "Jirón", stage, true},
+		{"Sacsayhuaman", "Sacsayhuamán", stage, true},
+
+		// Whitespace: surrounding spaces go, and runs inside count as one.
+		{"Jirón  ", "Jirón", answerRules{}, true},
+		{"Plaza  de\tArmas", "plaza de armas", answerRules{}, true},
+		{"Plazade Armas", "plaza de armas", answerRules{}, false},
+
+		// Trailing punctuation.
+		{"catacombs.", "catacombs", answerRules{}, false},
+		{"catacombs.", "catacombs", answerRules{TrimPunctuation: true}, true},
+		{"Catacombs ?!", "catacombs", answerRules{TrimPunctuation: true}, true},
+		{"¿catacombs", "catacombs", answerRules{TrimPunctuation: true}, false}, // leading punctuation is kept
+		{"San Martín.", "san martin", answerRules{TrimPunctuation: true}.forStage(false, false), true},
+		{"!", "?", answerRules{TrimPunctuation: true}, false},
+
+		// An answer that legitimately ends in punctuation: exact answers keep
+		// matching, and only with the option on does the bare form match too.
+		{"Yahoo!", "Yahoo!", answerRules{}, true},
+		{"Yahoo", "Yahoo!", answerRules{}, false},
+		{"Yahoo!", "Yahoo!", answerRules{TrimPunctuation: true}, true},
+		{"Yahoo", "Yahoo!", answerRules{TrimPunctuation: true}, true},
+
+		// Case-sensitive stages still trim and apply the game's leniencies.
+		{"AbC", "AbC", answerRules{CaseSensitive: true}, true},
+		{"abc", "AbC", answerRules{CaseSensitive: true}, false},
+		{" AbC ", "AbC", answerRules{CaseSensitive: true}, true},
+		{"Martin.", "Martín", answerRules{TrimPunctuation: true}.forStage(true, false), true},
+		{"martin", "Martín", answerRules{}.forStage(true, false), false},
+
+		// Strict stages compare the answer as typed, bar surrounding spaces.
+		{" x^2 + 1 ", "x^2 + 1", answerRules{Strict: true}, true},
+		{"x^2  + 1", "x^2 + 1", answerRules{Strict: true}, false},
+		{"X^2 + 1", "x^2 + 1", answerRules{Strict: true}, true},
+		{"X^2 + 1", "x^2 + 1", answerRules{Strict: true, CaseSensitive: true}, false},
+	}
+	for _, tt := range tests {
+		if got := answerMatches(tt.answer, tt.correct, tt.rules); got != tt.want {
+			t.Errorf("answerMatches(%q, %q, %+v) = %v, want %v", tt.answer, tt.correct, tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestForStageStrict(t *testing.T) {
+	// Accents fold by default, without the game asking for it.
+	lenient := answerRules{}.forStage(false, false)
+	if !lenient.IgnoreAccents || !answerMatches("Jiron", "Jirón", lenient) {
+		t.Errorf("forStage(false, false) = %+v, want Jiron to match Jirón", lenient)
+	}
+	if answerMatches("Jiron", "Jirón", answerRules{}.forStage(false, true)) {
+		t.Error("a strict stage should not fold accents")
+	}
+
+	game := answerRules{TrimPunctuation: true}
+	if got := game.forStage(true, false); got != (answerRules{IgnoreAccents: true, TrimPunctuation: true, CaseSensitive: true}) {
+		t.Errorf("forStage(true, false) = %+v, want the game's rules with accents folded and CaseSensitive", got)
+	}
+	strict := game.forStage(false, true)
+	if strict != (answerRules{Strict: true}) {
+		t.Errorf("forStage(false, true) = %+v, want Strict alone", strict)
+	}
+	if answerMatches("Jiron.", "Jirón", strict) {
+		t.Error("a strict stage should not fold accents or trim punctuation")
+	}
+}
+
+func TestListAnswerMatches(t *testing.T) {
+	colors := []string{"red", "green", "blue"}
+	tests := []struct {
+		answer    string
+		delimiter string
+		unordered bool
+		rules     answerRules
+		want      bool
+	}{
+		{"red, green, blue", "", false, answerRules{}, true},
+		{"Red,GREEN ,  blue", ",", false, answerRules{}, true},
+		{"red, green, blue,", "", false, answerRules{}, true}, // trailing delimiter is not a part
+		{"blue, green, red", "", false, answerRules{}, false},
+		{"blue, green, red", "", true, answerRules{}, true},
+		{"red; green; blue", ";", false, answerRules{}, true},
+		{"red; green; blue", ",", false, answerRules{}, false},
+
+		// Missing and extra parts never match, in either order.
+		{"red, green", "", false, answerRules{}, false},
+		{"red, green", "", true, answerRules{}, false},
+		{"red, green, blue, pink", "", false, answerRules{}, false},
+		{"red, green, blue, pink", "", true, answerRules{}, false},
+		{"red, red, green, blue", "", true, answerRules{}, false},
+		{"red, green, green", "", true, answerRules{}, false},
+		{"", "", false, answerRules{}, false},
+
+		// Each part goes through the shared matcher.
+		{"red., green!, blue", "", false, answerRules{TrimPunctuation: true}, true},
+		{"RED, green, blue", "", false, answerRules{CaseSensitive: true}, false},
+	}
+	for _, tt := range tests {
+		if got := listAnswerMatches(tt.answer, colors, tt.delimiter, tt.unordered, tt.rules); got != tt.want {
+			t.Errorf("listAnswerMatches(%q, delimiter %q, unordered %v, %+v) = %v, want %v", tt.answer, tt.delimiter, tt.unordered, tt.rules, got, tt.want)
+		}
+	}
+
+	accents := []string{"Perú", "Bolivia"}
+	if !listAnswerMatches("bolivia, peru", accents, "", true, answerRules{}.forStage(false, false)) {
+		t.Error("unordered list with accents folded: expected a match")
+	}
+	if listAnswerMatches("red", nil, "", false, answerRules{}) {
+		t.Error("empty accepted list must never match")
+	}
+}
+
+func TestListAnswerKeyGroupsLikeMatches(t *testing.T) {
+	if listAnswerKey("Red, green", ",", true, answerRules{}) != listAnswerKey("green,red", ",", true, answerRules{}) {
+		t.Error("unordered lists with the same parts should share a key")
+	}
+	if listAnswerKey("red, green", ",", false, answerRules{}) == listAnswerKey("green, red", ",", false, answerRules{}) {
+		t.Error("ordered lists in a different order should not share a key")
+	}
+}
+
+func TestNumericAnswerMatches(t *testing.T) {
+	tests := []struct {
+		answer    string
+		correct   string
+		tolerance float64
+		want      bool
+	}{
+		{"42", "42", 0, true},
+		{" 42.0 ", "42", 0, true},
+		{"43", "42", 0, false},
+		{"43", "42", 1, true},
+		{"40.5", "42", 1, false},
+		{"3,5", "3.5", 0, true}, // comma decimal separator
+		{"-2", "2", 3, false},
+		{"about 42", "42", 5, false}, // not a number: simply wrong
+		{"", "42", 5, false},
+		{"NaN", "42", 5, false},
+		{"42", "n/a", 5, false},
+	}
+	for _, tt := range tests {
+		if got := numericAnswerMatches(tt.answer, tt.correct, tt.tolerance); got != tt.want {
+			t.Errorf("numericAnswerMatches(%q, %q, %v) = %v, want %v", tt.answer, tt.correct, tt.tolerance, got, tt.want)
+		}
+	}
+
+	if numericAnswerKey("3.50", answerRules{}) != numericAnswerKey("3,5", answerRules{}) {
+		t.Error("the same number written differently should share a key")
+	}
+}
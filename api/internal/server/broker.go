@@ -2,32 +2,105 @@ package server
 
 import (
 	"encoding/json"
+	"slices"
 	"sync"
+	"time"
 )
 
-// SSEEvent is the payload published to team subscribers.
+// coalesceWindow bounds how long PublishCoalesced holds an event back.
+const coalesceWindow = 50 * time.Millisecond
+
+// defaultSubscriberBuffer is the channel size Subscribe uses when none is given.
+const defaultSubscriberBuffer = 16
+
+// SSEBuffer sizes subscriber channels. An event that finds a subscriber's
+// channel full is dropped for that subscriber rather than blocking the
+// publisher, so a bigger buffer lets a slow client fall further behind
+// without missing events, at the cost of holding that many encoded events in
+// memory per connection. Clients may ask for up to Max with ?buffer=N.
+type SSEBuffer struct {
+	Default int
+	Max     int
+}
+
+// size is the buffer for a connection that asked for requested events
+// (0 = no preference).
+func (b SSEBuffer) size(requested int) int {
+	size := b.Default
+	if requested > 0 {
+		size = requested
+	}
+	if b.Max > 0 && size > b.Max {
+		size = b.Max
+	}
+	if size < 1 {
+		size = defaultSubscriberBuffer
+	}
+	return size
+}
+
+// SSEEvent is the payload published to team subscribers: its type and only
+// the fields that type needs, with empty ones left out. Most events tell
+// clients to refetch game state. player_joined, wrong_attempt, timer_warning
+// and announcement are deltas instead: they carry everything that changed, so
+// clients apply them without a refetch, which is what a join costs every
+// device of a big team otherwise.
 type SSEEvent struct {
 	Type        string `json:"type"`
 	StageNumber int    `json:"stageNumber,omitempty"`
 	PlayerName  string `json:"playerName,omitempty"`
-	IsCorrect   bool   `json:"isCorrect,omitempty"`
+	// Set on player_joined: the player as game state lists them.
+	Player    PlayerInfo `json:"player,omitzero"`
+	IsCorrect bool       `json:"isCorrect,omitempty"`
+	// Set on timer_extended and timer_warning: seconds left on the game timer.
+	RemainingSeconds int `json:"remainingSeconds,omitempty"`
+	// Set on wrong_attempt: wrong answers the team has left on the stage.
+	AttemptsRemaining int `json:"attemptsRemaining,omitempty"`
+	// Set on stage_unlocked in supervised games: the unlocked question with
+	// its image, answer type and attachments, as in the unlock response.
+	*StageQuestion
+	// Set on stage_unlocked in supervised games and on stage_started: when
+	// the stage timer started (unset while the team is still confirming).
+	StageUnlockedAt *string `json:"stageUnlockedAt,omitempty"`
+	// Set on stage_advanced: the clue of the stage the team moved on to, and
+	// whether it still has to be unlocked.
+	Clue   string `json:"clue,omitempty"`
+	Locked bool   `json:"locked,omitempty"`
+	// Set on announcement: the admin's message and their email.
+	Message string `json:"message,omitempty"`
+	From    string `json:"from,omitempty"`
 }
 
 // Broker is an in-process pub/sub for SSE events, keyed by team ID.
 type Broker struct {
 	mu   sync.RWMutex
 	subs map[string]map[chan []byte]struct{}
+
+	// Events held back by PublishCoalesced, per team, until the team's flush
+	// timer fires.
+	pendingMu sync.Mutex
+	pending   map[string][]SSEEvent
+
+	// Events dropped on full subscriber channels, per team, since startup.
+	droppedMu sync.Mutex
+	dropped   map[string]uint64
 }
 
 func NewBroker() *Broker {
 	return &Broker{
-		subs: make(map[string]map[chan []byte]struct{}),
+		subs:    make(map[string]map[chan []byte]struct{}),
+		pending: make(map[string][]SSEEvent),
+		dropped: make(map[string]uint64),
 	}
 }
 
-// Subscribe returns a channel that receives JSON-encoded SSE events for the given team.
-func (b *Broker) Subscribe(teamID string) chan []byte {
-	ch := make(chan []byte, 16)
+// Subscribe returns a channel that receives JSON-encoded SSE events for the
+// given team, buffering up to size events (0 = defaultSubscriberBuffer).
+func (b *Broker) Subscribe(teamID string, size int) chan []byte {
+	if size < 1 {
+		size = defaultSubscriberBuffer
+	}
+	ch := make(chan []byte, size)
 	b.mu.Lock()
 	if b.subs[teamID] == nil {
 		b.subs[teamID] = make(map[chan []byte]struct{})
@@ -47,16 +120,92 @@ func (b *Broker) Unsubscribe(teamID string, ch chan []byte) {
 	b.mu.Unlock()
 }
 
-// Publish sends an event to all subscribers of the given team.
+// Publish sends an event to all subscribers of the given team. Events the
+// team has pending from PublishCoalesced go out first, so order is kept.
 func (b *Broker) Publish(teamID string, event SSEEvent) {
-	data, _ := json.Marshal(event)
+	b.flush(teamID)
+	b.send(teamID, event)
+}
+
+// PublishGame sends an event to every team of a game.
+func (b *Broker) PublishGame(teamIDs []string, event SSEEvent) {
+	for _, teamID := range teamIDs {
+		b.Publish(teamID, event)
+	}
+}
+
+// PublishCoalesced is Publish for frequent, informational events such as
+// players joining or answering. A team's events are held for up to
+// coalesceWindow and sent together, with exact duplicates dropped; clients
+// refetch state on any event, so a burst costs them one refetch per batch
+// rather than one per player.
+func (b *Broker) PublishCoalesced(teamID string, event SSEEvent) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	queued, scheduled := b.pending[teamID]
+	if slices.Contains(queued, event) {
+		return
+	}
+	b.pending[teamID] = append(queued, event)
+	if !scheduled {
+		time.AfterFunc(coalesceWindow, func() { b.flush(teamID) })
+	}
+}
+
+// flush sends the team's pending coalesced events, if any. A timer firing
+// after Publish already flushed finds nothing, or sends a later batch early.
+func (b *Broker) flush(teamID string) {
+	b.pendingMu.Lock()
+	events := b.pending[teamID]
+	delete(b.pending, teamID)
+	b.pendingMu.Unlock()
+
+	if len(events) > 0 {
+		b.send(teamID, events...)
+	}
+}
+
+// send delivers events, in order, to every subscriber of the team under a
+// single read lock.
+func (b *Broker) send(teamID string, events ...SSEEvent) {
+	payloads := make([][]byte, len(events))
+	for i, e := range events {
+		payloads[i], _ = json.Marshal(e)
+	}
+
+	var dropped uint64
 	b.mu.RLock()
 	for ch := range b.subs[teamID] {
-		select {
-		case ch <- data:
-		default:
-			// Drop if subscriber is slow.
+		for _, data := range payloads {
+			select {
+			case ch <- data:
+			default:
+				// Drop if subscriber is slow.
+				dropped++
+			}
 		}
 	}
 	b.mu.RUnlock()
+
+	if dropped > 0 {
+		b.droppedMu.Lock()
+		b.dropped[teamID] += dropped
+		b.droppedMu.Unlock()
+	}
+}
+
+// Dropped reports how many events the team's subscribers have missed because
+// their buffers were full, counted once per subscriber.
+func (b *Broker) Dropped(teamID string) uint64 {
+	b.droppedMu.Lock()
+	defer b.droppedMu.Unlock()
+	return b.dropped[teamID]
+}
+
+// Subscribers reports how many SSE connections the team has open.
+func (b *Broker) Subscribers(teamID string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[teamID])
 }
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPublishCoalesced(t *testing.T) {
+	b := NewBroker()
+	ch := b.Subscribe("team", 0)
+	defer b.Unsubscribe("team", ch)
+
+	recv := func() SSEEvent {
+		t.Helper()
+		select {
+		case data := <-ch:
+			var e SSEEvent
+			json.Unmarshal(data, &e)
+			return e
+		case <-time.After(10 * coalesceWindow):
+			t.Fatal("timed out waiting for an event")
+			return SSEEvent{}
+		}
+	}
+
+	ana := SSEEvent{Type: "player_joined", PlayerName: "Ana"}
+	b.PublishCoalesced("team", ana)
+	b.PublishCoalesced("team", ana) // duplicate, dropped
+	b.PublishCoalesced("team", SSEEvent{Type: "player_joined", PlayerName: "Luis"})
+	if len(ch) != 0 {
+		t.Fatalf("coalesced events were sent immediately: %d queued", len(ch))
+	}
+	if e := recv(); e != ana {
+		t.Errorf("first event = %+v, want %+v", e, ana)
+	}
+	if e := recv(); e.PlayerName != "Luis" {
+		t.Errorf("second event = %+v, want Luis", e)
+	}
+	if len(ch) != 0 {
+		t.Errorf("expected the duplicate to be dropped, %d more queued", len(ch))
+	}
+
+	// A direct publish sends what's pending first, without waiting.
+	b.PublishCoalesced("team", SSEEvent{Type: "player_answered", StageNumber: 1, PlayerName: "Ana"})
+	b.Publish("team", SSEEvent{Type: "stage_completed", StageNumber: 1})
+	if len(ch) != 2 {
+		t.Fatalf("expected both events queued right away, got %d", len(ch))
+	}
+	if e := recv(); e.Type != "player_answered" {
+		t.Errorf("first event = %q, want player_answered", e.Type)
+	}
+	if e := recv(); e.Type != "stage_completed" {
+		t.Errorf("second event = %q, want stage_completed", e.Type)
+	}
+}
+
+func TestSubscriberBufferDrops(t *testing.T) {
+	const size = 4
+	b := NewBroker()
+	ch := b.Subscribe("team", size)
+	defer b.Unsubscribe("team", ch)
+
+	// Nobody reads: the first size events fill the buffer, the rest drop.
+	for n := 1; n <= size+3; n++ {
+		b.Publish("team", SSEEvent{Type: "stage_completed", StageNumber: n})
+	}
+	if len(ch) != size {
+		t.Errorf("buffered %d events, want %d", len(ch), size)
+	}
+	if got := b.Dropped("team"); got != 3 {
+		t.Errorf("dropped = %d, want 3", got)
+	}
+	if got := b.Dropped("other"); got != 0 {
+		t.Errorf("dropped for another team = %d, want 0", got)
+	}
+	var first SSEEvent
+	json.Unmarshal(<-ch, &first)
+	if first.StageNumber != 1 {
+		t.Errorf("oldest buffered event is stage %d, want 1 (drops hit new events)", first.StageNumber)
+	}
+
+	for _, tc := range []struct {
+		buffer    SSEBuffer
+		requested int
+		want      int
+	}{
+		{SSEBuffer{Default: 16, Max: 256}, 0, 16},
+		{SSEBuffer{Default: 16, Max: 256}, 64, 64},
+		{SSEBuffer{Default: 16, Max: 256}, 1000, 256},
+		{SSEBuffer{Default: 16}, 1000, 1000},
+		{SSEBuffer{}, 0, defaultSubscriberBuffer},
+	} {
+		if got := tc.buffer.size(tc.requested); got != tc.want {
+			t.Errorf("%+v.size(%d) = %d, want %d", tc.buffer, tc.requested, got, tc.want)
+		}
+	}
+}
+
+// BenchmarkPublish sends bursts of player events to a team with several
+// subscribers, one Publish per event versus coalesced. Each op is a burst of
+// 8 distinct events plus 8 repeats, the shape of a team joining at once.
+func BenchmarkPublish(b *testing.B) {
+	const subscribers = 8
+	burst := make([]SSEEvent, 16)
+	for i := range burst {
+		burst[i] = SSEEvent{Type: "player_joined", PlayerName: fmt.Sprintf("player-%d", i%8)}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		publish func(b *Broker, teamID string, e SSEEvent)
+	}{
+		{"per-event", (*Broker).Publish},
+		{"coalesced", (*Broker).PublishCoalesced},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			broker := NewBroker()
+			done := make(chan struct{})
+			defer close(done)
+			for range subscribers {
+				ch := broker.Subscribe("team", 0)
+				go func() {
+					for {
+						select {
+						case <-ch:
+						case <-done:
+							return
+						}
+					}
+				}()
+			}
+
+			for b.Loop() {
+				for _, e := range burst {
+					tc.publish(broker, "team", e)
+				}
+				// Flush like the timer would, so every op pays for delivery.
+				broker.flush("team")
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/playperu/cityquiz/internal/database"
+)
+
+// Baseline for the player lifecycle: join, poll state, then answer each stage
+// and poll again, all through the HTTP handlers. Every join and answer is a
+// modifyGame transaction on the one game document, so this is what the
+// per-team storage and pool tuning work should move. Run with
+//
+//	go test ./internal/server -run '^$' -bench PlayerFlow -benchtime 200x
+//
+// Besides ns/op it reports flows/s and the p99 latency of a single flow.
+
+const (
+	benchStages = 5
+	benchTeams  = 16
+)
+
+// BenchmarkPlayerFlow runs one lifecycle at a time, against an in-memory
+// database and a WAL file.
+func BenchmarkPlayerFlow(b *testing.B) {
+	for _, backend := range []string{"memory", "file"} {
+		b.Run(backend, func(b *testing.B) {
+			benchFlows(b, backend, 1)
+		})
+	}
+}
+
+// BenchmarkPlayerFlowConcurrent runs a lifecycle on every team of the game
+// at once, so joins and answers contend for the same game document.
+func BenchmarkPlayerFlowConcurrent(b *testing.B) {
+	for _, backend := range []string{"memory", "file"} {
+		b.Run(backend, func(b *testing.B) {
+			benchFlows(b, backend, benchTeams)
+		})
+	}
+}
+
+// benchFlows times b.N rounds of concurrent lifecycles, each on its own team.
+// Teams are reset between rounds, outside the timer.
+func benchFlows(b *testing.B, backend string, concurrent int) {
+	r, store, gameID, tokens := benchGame(b, backend)
+	ctx := context.Background()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+	)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for n := range concurrent {
+			wg.Go(func() {
+				start := time.Now()
+				if err := playFlow(r, tokens[n], fmt.Sprintf("p%d-%d", i, n)); err != nil {
+					b.Error(err)
+					return
+				}
+				mu.Lock()
+				latencies = append(latencies, time.Since(start))
+				mu.Unlock()
+			})
+		}
+		wg.Wait()
+
+		b.StopTimer()
+		if err := store.modifyGame(ctx, gameID, func(g *game) error {
+			for j := range g.Teams {
+				g.Teams[j].Players = nil
+				g.Teams[j].Results = nil
+			}
+			return nil
+		}); err != nil {
+			b.Fatalf("reset teams: %v", err)
+		}
+		b.StartTimer()
+	}
+	b.StopTimer()
+
+	if len(latencies) == 0 {
+		return
+	}
+	slices.Sort(latencies)
+	p99 := latencies[min(len(latencies)-1, len(latencies)*99/100)]
+	b.ReportMetric(float64(p99.Microseconds())/1000, "p99-ms/flow")
+	b.ReportMetric(float64(len(latencies))/b.Elapsed().Seconds(), "flows/s")
+}
+
+// benchGame creates a classic game with benchStages stages and benchTeams
+// teams on a fresh store and returns the player router and join tokens.
+func benchGame(b *testing.B, backend string) (*chi.Mux, *DocStore, string, []string) {
+	b.Helper()
+	ctx := context.Background()
+
+	path := ":memory:"
+	if backend == "file" {
+		path = filepath.Join(b.TempDir(), "bench.db")
+	}
+	db, err := database.Open(ctx, path, 1)
+	if err != nil {
+		b.Fatalf("open db: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	store, err := NewDocStore(ctx, db, nil)
+	if err != nil {
+		b.Fatalf("init store: %v", err)
+	}
+
+	stages := make([]AdminStage, benchStages)
+	for i := range stages {
+		stages[i] = AdminStage{
+			StageNumber:   i + 1,
+			Location:      fmt.Sprintf("Stop %d", i+1),
+			Clue:          "Walk to the next square.",
+			Question:      "What is written on the plaque?",
+			CorrectAnswer: "bronze", // same everywhere, so stage rotation doesn't matter
+		}
+	}
+	g, err := store.CreateGame(ctx, AdminGameRequest{
+		ScenarioID: "s-bench", ScenarioName: "Bench", Mode: "classic", Status: "active",
+	}, stages)
+	if err != nil {
+		b.Fatalf("create game: %v", err)
+	}
+	reqs := make([]AdminTeamRequest, benchTeams)
+	for i := range reqs {
+		reqs[i] = AdminTeamRequest{Name: fmt.Sprintf("Team %d", i+1), JoinToken: fmt.Sprintf("bench-%02d", i+1)}
+	}
+	teams, err := store.CreateTeams(ctx, g.ID, reqs, func() string { return generateJoinToken(JoinTokenFormat{Style: tokenStyleHex}) })
+	if err != nil {
+		b.Fatalf("create teams: %v", err)
+	}
+	tokens := make([]string, len(teams))
+	for i, t := range teams {
+		tokens[i] = t.JoinToken
+	}
+
+	broker := NewBroker()
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ctxKeyStore, Store(store))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Post("/api/{client}/join", handleJoin(broker))
+	r.Get("/api/{client}/game/state", handleGameState(broker))
+	r.Post("/api/{client}/game/answer", handleAnswer(broker, testMaxAnswerLen))
+	return r, store, g.ID, tokens
+}
+
+// playFlow joins a team and answers every stage, polling state after each
+// step like the client does on SSE events.
+func playFlow(r *chi.Mux, joinToken, name string) error {
+	do := func(method, path, token string, body any, dest any) error {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			return fmt.Errorf("%s %s: %d %s", method, path, w.Code, w.Body.String())
+		}
+		if dest != nil {
+			return json.NewDecoder(w.Body).Decode(dest)
+		}
+		return nil
+	}
+
+	var joined JoinResponse
+	if err := do(http.MethodPost, "/api/demo/join", "", JoinRequest{JoinToken: joinToken, PlayerName: name}, &joined); err != nil {
+		return err
+	}
+	var state GameStateResponse
+	if err := do(http.MethodGet, "/api/demo/game/state", joined.Token, nil, &state); err != nil {
+		return err
+	}
+	for state.CurrentStage != nil {
+		if err := do(http.MethodPost, "/api/demo/game/answer", joined.Token, AnswerRequest{Answer: "bronze"}, nil); err != nil {
+			return err
+		}
+		state = GameStateResponse{}
+		if err := do(http.MethodGet, "/api/demo/game/state", joined.Token, nil, &state); err != nil {
+			return err
+		}
+	}
+	if len(state.CompletedStages) != benchStages {
+		return fmt.Errorf("team %s finished with %d of %d stages", joinToken, len(state.CompletedStages), benchStages)
+	}
+	return nil
+}
+
+// BenchmarkTeamProgress compares loading the whole game document for one
+// team's state with picking the team out in SQL, on a game whose teams have
+// all played through. The difference grows with the number of teams.
+//
+//	go test ./internal/server -run '^$' -bench TeamProgress
+func BenchmarkTeamProgress(b *testing.B) {
+	r, store, gameID, tokens := benchGame(b, "memory")
+	for n, token := range tokens {
+		if err := playFlow(r, token, fmt.Sprintf("p%d", n)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	full, err := store.getGame(ctx, gameID)
+	if err != nil {
+		b.Fatal(err)
+	}
+	teamID := full.Teams[len(full.Teams)/2].ID
+
+	b.Run("whole-game", func(b *testing.B) {
+		for b.Loop() {
+			g, err := store.getGame(ctx, gameID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = g.stateData(teamID)
+		}
+	})
+	b.Run("team", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := store.TeamProgress(ctx, gameID, teamID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
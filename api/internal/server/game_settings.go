@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// GameSettings are a game's on/off features, all off by default. The game
+// document embeds them, so they are stored flat exactly as before they were
+// grouped. Admins set them through the flat AdminGameRequest fields or by
+// name through its settings map.
+type GameSettings struct {
+	RequireAllPlayers       bool `json:"requireAllPlayers,omitempty"`
+	TrimPunctuation         bool `json:"trimPunctuation,omitempty"`
+	HideLockedClue          bool `json:"hideLockedClue,omitempty"`
+	HideLocationFromPlayers bool `json:"hideLocationFromPlayers,omitempty"`
+	PlayersCanAnswer        bool `json:"playersCanAnswer,omitempty"`
+	ManualAdvance           bool `json:"manualAdvance,omitempty"`
+	WaitForConfirmations    bool `json:"waitForConfirmations,omitempty"`
+	AllowGiveUp             bool `json:"allowGiveUp,omitempty"`
+	IndividualScoring       bool `json:"individualScoring,omitempty"`
+	FirstStageUnlocked      bool `json:"firstStageUnlocked,omitempty"`
+	PointsDecay             bool `json:"pointsDecay,omitempty"`
+}
+
+// individualScoring reports whether each player answers for themselves, with
+// their own progress through the stages. Only modes whose stages are never
+// locked can have it: unlocking is something the team does together.
+func (s GameSettings) individualScoring(mode string) bool {
+	return s.IndividualScoring && modeHasQuestion(mode) && !modeRequiresUnlock(mode)
+}
+
+// firstStageUnlocked reports whether each team's first stage opens without
+// an unlock. Only modes whose unlock opens a question can have it: in qr_hunt
+// and math_puzzle unlocking a stage completes it.
+func (s GameSettings) firstStageUnlocked(mode string) bool {
+	return s.FirstStageUnlocked && modeRequiresUnlock(mode) && modeHasQuestion(mode)
+}
+
+// gameSetting ties a setting's name in a settings map to its field.
+type gameSetting struct {
+	key   string
+	field func(*GameSettings) *bool
+}
+
+// gameSettingKeys lists the settings by name. A new setting needs a
+// GameSettings field and an entry here.
+var gameSettingKeys = []gameSetting{
+	{"requireAllPlayers", func(s *GameSettings) *bool { return &s.RequireAllPlayers }},
+	{"trimPunctuation", func(s *GameSettings) *bool { return &s.TrimPunctuation }},
+	{"hideLockedClue", func(s *GameSettings) *bool { return &s.HideLockedClue }},
+	{"hideLocationFromPlayers", func(s *GameSettings) *bool { return &s.HideLocationFromPlayers }},
+	{"playersCanAnswer", func(s *GameSettings) *bool { return &s.PlayersCanAnswer }},
+	{"manualAdvance", func(s *GameSettings) *bool { return &s.ManualAdvance }},
+	{"waitForConfirmations", func(s *GameSettings) *bool { return &s.WaitForConfirmations }},
+	{"allowGiveUp", func(s *GameSettings) *bool { return &s.AllowGiveUp }},
+	{"individualScoring", func(s *GameSettings) *bool { return &s.IndividualScoring }},
+	{"firstStageUnlocked", func(s *GameSettings) *bool { return &s.FirstStageUnlocked }},
+	{"pointsDecay", func(s *GameSettings) *bool { return &s.PointsDecay }},
+}
+
+// settingsMap lists every setting by name with its value, so clients can see
+// which settings exist as well as how they are set.
+func (s GameSettings) settingsMap() map[string]bool {
+	m := make(map[string]bool, len(gameSettingKeys))
+	for _, k := range gameSettingKeys {
+		m[k.key] = *k.field(&s)
+	}
+	return m
+}
+
+// apply sets the named settings on s. Names it doesn't know are an error, so
+// a typo doesn't silently leave a feature off.
+func (s *GameSettings) apply(m map[string]bool) string {
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		i := slices.IndexFunc(gameSettingKeys, func(k gameSetting) bool { return k.key == name })
+		if i < 0 {
+			return fmt.Sprintf("unknown setting %q", name)
+		}
+		*gameSettingKeys[i].field(s) = m[name]
+	}
+	return ""
+}
+
+// gameSettings collects the request's flat setting fields.
+func (req *AdminGameRequest) gameSettings() GameSettings {
+	return GameSettings{
+		RequireAllPlayers:       req.RequireAllPlayers,
+		TrimPunctuation:         req.TrimPunctuation,
+		HideLockedClue:          req.HideLockedClue,
+		HideLocationFromPlayers: req.HideLocationFromPlayers,
+		PlayersCanAnswer:        req.PlayersCanAnswer,
+		ManualAdvance:           req.ManualAdvance,
+		WaitForConfirmations:    req.WaitForConfirmations,
+		AllowGiveUp:             req.AllowGiveUp,
+		IndividualScoring:       req.IndividualScoring,
+		FirstStageUnlocked:      req.FirstStageUnlocked,
+		PointsDecay:             req.PointsDecay,
+	}
+}
+
+// setGameSettings writes s back to the request's flat fields, so validation
+// after the settings map is applied sees the effective values.
+func (req *AdminGameRequest) setGameSettings(s GameSettings) {
+	req.RequireAllPlayers = s.RequireAllPlayers
+	req.TrimPunctuation = s.TrimPunctuation
+	req.HideLockedClue = s.HideLockedClue
+	req.HideLocationFromPlayers = s.HideLocationFromPlayers
+	req.PlayersCanAnswer = s.PlayersCanAnswer
+	req.ManualAdvance = s.ManualAdvance
+	req.WaitForConfirmations = s.WaitForConfirmations
+	req.AllowGiveUp = s.AllowGiveUp
+	req.IndividualScoring = s.IndividualScoring
+	req.FirstStageUnlocked = s.FirstStageUnlocked
+	req.PointsDecay = s.PointsDecay
+}
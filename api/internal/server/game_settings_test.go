@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+func TestGameSettingsApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     AdminGameRequest
+		want    GameSettings
+		wantErr string
+	}{
+		{
+			name: "defaults off",
+			req:  AdminGameRequest{ScenarioID: "s1"},
+		},
+		{
+			name: "flat fields",
+			req:  AdminGameRequest{ScenarioID: "s1", TrimPunctuation: true, AllowGiveUp: true},
+			want: GameSettings{TrimPunctuation: true, AllowGiveUp: true},
+		},
+		{
+			name: "map turns on",
+			req:  AdminGameRequest{ScenarioID: "s1", Settings: map[string]bool{"manualAdvance": true}},
+			want: GameSettings{ManualAdvance: true},
+		},
+		{
+			name: "map overrides flat",
+			req:  AdminGameRequest{ScenarioID: "s1", TrimPunctuation: true, HideLockedClue: true, Settings: map[string]bool{"trimPunctuation": false}},
+			want: GameSettings{HideLockedClue: true},
+		},
+		{
+			name:    "unknown key",
+			req:     AdminGameRequest{ScenarioID: "s1", Settings: map[string]bool{"ignoreAccent": true}},
+			wantErr: `unknown setting "ignoreAccent"`,
+		},
+		{
+			// Accents fold on every stage that isn't strict; a game can't
+			// turn that off.
+			name:    "accent folding is not a setting",
+			req:     AdminGameRequest{ScenarioID: "s1", Settings: map[string]bool{"ignoreAccents": false}},
+			wantErr: `unknown setting "ignoreAccents"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			msg := req.validate(TimerLimits{})
+			if msg != tt.wantErr {
+				t.Fatalf("validate = %q, want %q", msg, tt.wantErr)
+			}
+			if msg != "" {
+				return
+			}
+			if got := req.gameSettings(); got != tt.want {
+				t.Errorf("settings = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGameSettingsMapListsEveryKey(t *testing.T) {
+	m := GameSettings{AllowGiveUp: true}.settingsMap()
+	if len(m) != len(gameSettingKeys) {
+		t.Fatalf("settingsMap has %d keys, want %d", len(m), len(gameSettingKeys))
+	}
+	for k, v := range m {
+		if v != (k == "allowGiveUp") {
+			t.Errorf("settings[%q] = %v", k, v)
+		}
+	}
+
+	// Every key must round-trip through apply to its own field.
+	for _, k := range gameSettingKeys {
+		var s GameSettings
+		if msg := s.apply(map[string]bool{k.key: true}); msg != "" {
+			t.Fatalf("apply %q: %s", k.key, msg)
+		}
+		got := s.settingsMap()
+		want := map[string]bool{}
+		for _, k2 := range gameSettingKeys {
+			want[k2.key] = k2.key == k.key
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("apply %q set %v", k.key, got)
+		}
+	}
+}
+
+func TestGameSettingsStoredFlat(t *testing.T) {
+	// Documents written before settings were grouped keep loading, including
+	// ones with the retired ignoreAccents setting.
+	var g game
+	if err := json.Unmarshal([]byte(`{"id":"g1","ignoreAccents":true,"trimPunctuation":true,"manualAdvance":true}`), &g); err != nil {
+		t.Fatal(err)
+	}
+	if !g.TrimPunctuation || !g.ManualAdvance || g.AllowGiveUp {
+		t.Errorf("decoded settings = %+v", g.GameSettings)
+	}
+
+	b, err := json.Marshal(game{ID: "g1", GameSettings: GameSettings{HideLockedClue: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["hideLockedClue"] != true {
+		t.Errorf("encoded game = %s, want hideLockedClue at top level", b)
+	}
+}
+
+func TestGameSettingsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+
+	req := AdminGameRequest{ScenarioID: "s1", ScenarioName: "S", Mode: "classic", Status: "draft",
+		Settings: map[string]bool{"trimPunctuation": true, "allowGiveUp": true}}
+	if msg := req.validate(TimerLimits{}); msg != "" {
+		t.Fatalf("validate: %s", msg)
+	}
+	created, err := store.CreateGame(ctx, req, []AdminStage{{StageNumber: 1, Clue: "c", Question: "q", CorrectAnswer: "a"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !created.Settings["trimPunctuation"] || !created.AllowGiveUp {
+		t.Errorf("created settings = %v", created.Settings)
+	}
+
+	got, err := store.GetGame(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !maps.Equal(got.Settings, created.Settings) {
+		t.Errorf("get settings = %v, want %v", got.Settings, created.Settings)
+	}
+	if !got.TrimPunctuation || !got.AllowGiveUp || got.ManualAdvance {
+		t.Errorf("flat fields = %+v", got)
+	}
+}
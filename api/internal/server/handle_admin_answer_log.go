@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminStageAnswers lists the distinct answers teams gave for one scenario
+// stage, for moderators looking for collusion (several teams with the same
+// odd wrong answer). Answers are grouped the way the game compares them —
+// ignoring case, surrounding spaces and accents (except on strict stages),
+// plus trailing punctuation when the game ignores it — and shown as first
+// recorded.
+// Groups are ordered by team count, most first.
+type AdminStageAnswers struct {
+	StageNumber int                `json:"stageNumber"`
+	Location    string             `json:"location"`
+	Answers     []AdminAnswerGroup `json:"answers"`
+}
+
+type AdminAnswerGroup struct {
+	Answer    string            `json:"answer"`
+	IsCorrect bool              `json:"isCorrect"`
+	TeamCount int               `json:"teamCount"`
+	Teams     []AdminAnswerTeam `json:"teams"`
+}
+
+type AdminAnswerTeam struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// handleAdminAnswerLog serves the answer groups for a game. ?wrongOnly=true
+// drops correct answers and ?minTeams=N keeps only answers shared by at least
+// N teams; stages left without answers are still listed.
+func handleAdminAnswerLog() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		q := r.URL.Query()
+		wrongOnly := q.Get("wrongOnly") == "true"
+		minTeams := 1
+		if v := q.Get("minTeams"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeError(w, http.StatusBadRequest, "minTeams must be a positive integer")
+				return
+			}
+			minTeams = n
+		}
+
+		stages, err := store.AnswerLog(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		for i := range stages {
+			kept := []AdminAnswerGroup{}
+			for _, a := range stages[i].Answers {
+				if (wrongOnly && a.IsCorrect) || a.TeamCount < minTeams {
+					continue
+				}
+				kept = append(kept, a)
+			}
+			stages[i].Answers = kept
+		}
+
+		writeJSON(w, http.StatusOK, stages)
+	}
+}
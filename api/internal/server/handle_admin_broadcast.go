@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxBroadcastLen caps an announcement, in characters: it is shown as a
+// banner on players' phones.
+const maxBroadcastLen = 500
+
+// BroadcastRequest is the request body for POST .../games/{gameID}/broadcast.
+type BroadcastRequest struct {
+	Message string `json:"message"`
+}
+
+// BroadcastResponse reports how many teams the announcement went to.
+type BroadcastResponse struct {
+	Teams int `json:"teams"`
+}
+
+// handleAdminBroadcast publishes an announcement event to every team of a
+// game, e.g. "dinner at the plaza in 10 minutes". The event carries the
+// sending admin's email so players can tell it came from the organisers.
+func handleAdminBroadcast(broker *Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		var req BroadcastRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		req.Message = strings.TrimSpace(req.Message)
+		if req.Message == "" {
+			writeError(w, http.StatusBadRequest, "message is required")
+			return
+		}
+		if utf8.RuneCountInString(req.Message) > maxBroadcastLen {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("message must be at most %d characters", maxBroadcastLen))
+			return
+		}
+
+		game, err := store.GetGame(r.Context(), gameID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if game.Status == "ended" {
+			writeError(w, http.StatusConflict, "game has ended")
+			return
+		}
+
+		teamIDs := make([]string, len(game.Teams))
+		for i, t := range game.Teams {
+			teamIDs[i] = t.ID
+		}
+		broker.PublishGame(teamIDs, SSEEvent{
+			Type:    "announcement",
+			Message: req.Message,
+			From:    adminFrom(r).Email,
+		})
+
+		writeJSON(w, http.StatusOK, BroadcastResponse{Teams: len(teamIDs)})
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// CleanupPreviewResponse lists the games the retention cleanup would remove.
+// Enabled reports whether GAME_RETENTION is set, i.e. whether the cleanup
+// actually runs.
+type CleanupPreviewResponse struct {
+	Enabled     bool          `json:"enabled"`
+	Retention   string        `json:"retention"`
+	EndedBefore string        `json:"endedBefore"`
+	Games       []ExpiredGame `json:"games"`
+}
+
+// handleAdminCleanupPreview lists, without removing anything, the client's
+// games the retention cleanup would remove now. ?retention= (a Go duration,
+// e.g. 720h) previews another period than GAME_RETENTION, which is how to
+// try one out before enabling the cleanup.
+func handleAdminCleanupPreview(broker *Broker, gameRetention time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+
+		retention := gameRetention
+		if v := r.URL.Query().Get("retention"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				writeError(w, http.StatusBadRequest, "retention must be a positive duration, e.g. 720h")
+				return
+			}
+			retention = d
+		}
+		if retention <= 0 {
+			writeError(w, http.StatusBadRequest, "game retention is not enabled; pass ?retention= to preview a period")
+			return
+		}
+
+		endedBefore := time.Now().Add(-retention)
+		games, err := expiredGames(r.Context(), store, broker, endedBefore)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, CleanupPreviewResponse{
+			Enabled:     gameRetention > 0,
+			Retention:   retention.String(),
+			EndedBefore: endedBefore.UTC().Format(time.RFC3339),
+			Games:       games,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handleAdminCloneGame creates a fresh draft copy of a game for re-running an
+// event: same scenario snapshot, mode and settings, and the same teams with
+// new join tokens (in the client's token format) but no players or results.
+func handleAdminCloneGame(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		src, err := store.GetGame(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		format, err := clientTokenFormat(r.Context(), admin, chi.URLParam(r, "client"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		clone, err := store.CreateGame(r.Context(), AdminGameRequest{
+			ScenarioID:              src.ScenarioID,
+			ScenarioName:            src.ScenarioName,
+			Mode:                    src.Mode,
+			Status:                  "draft",
+			Language:                src.Language,
+			Supervised:              src.Supervised,
+			TimerEnabled:            src.TimerEnabled,
+			TimerMinutes:            src.TimerMinutes,
+			StageTimerMinutes:       src.StageTimerMinutes,
+			Notes:                   src.Notes,
+			Outro:                   src.Outro,
+			RequireAllPlayers:       src.RequireAllPlayers,
+			AllPlayersGrading:       src.AllPlayersGrading,
+			HideLockedClue:          src.HideLockedClue,
+			HideLocationFromPlayers: src.HideLocationFromPlayers,
+			TrimPunctuation:         src.TrimPunctuation,
+			PlayersCanAnswer:        src.PlayersCanAnswer,
+			ManualAdvance:           src.ManualAdvance,
+			WaitForConfirmations:    src.WaitForConfirmations,
+			MinPlayersToStart:       src.MinPlayersToStart,
+			MaxAttempts:             src.MaxAttempts,
+			AllowGiveUp:             src.AllowGiveUp,
+			IndividualScoring:       src.IndividualScoring,
+			FirstStageUnlocked:      src.FirstStageUnlocked,
+			PointsDecay:             src.PointsDecay,
+			PlayCount:               src.PlayCount,
+		}, src.Stages)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		teams := make([]AdminTeamRequest, len(src.Teams))
+		for i, t := range src.Teams {
+			teams[i] = AdminTeamRequest{Name: t.Name, GuideName: t.GuideName, StartStage: t.StartStage}
+		}
+		generate := func() string { return generateJoinToken(format) }
+		if _, err := store.CreateTeams(r.Context(), clone.ID, teams, generate); err != nil {
+			// The teams were all-or-nothing; drop the empty clone too.
+			_ = store.DeleteGame(r.Context(), clone.ID)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		clone, err = store.GetGame(r.Context(), clone.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, clone)
+	}
+}
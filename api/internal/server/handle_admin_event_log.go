@@ -0,0 +1,34 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// logGameEvent appends ev to the game's event log. The action it records has
+// already happened by then, so a failed write doesn't fail the request.
+func logGameEvent(r *http.Request, store Store, gameID string, ev GameEvent) {
+	_ = store.LogGameEvent(r.Context(), gameID, ev)
+}
+
+// handleAdminEventLog serves a game's event log: every join, unlock, answer
+// and advance of its teams, oldest first.
+func handleAdminEventLog() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		events, err := store.GameEventLog(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, events)
+	}
+}
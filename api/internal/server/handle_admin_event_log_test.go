@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminEventLog(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Logged",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "A", Clue: "Go to A", Question: "1+1?", CorrectAnswer: "2", UnlockCode: "CODE-A"},
+			{Location: "B", Clue: "Go to B", Question: "2+2?", CorrectAnswer: "4", UnlockCode: "CODE-B"},
+		},
+	}
+	r, store, gameID, first := gameRouter(t, sc, AdminGameRequest{ManualAdvance: true})
+	r.Get("/games/{gameID}/event-log", handleAdminEventLog())
+	second, err := store.CreateTeam(context.Background(), gameID, AdminTeamRequest{Name: "Team Two"}, "join-second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ana := join(t, r, first.JoinToken, "Ana")
+	luis := join(t, r, second.JoinToken, "Luis")
+	for _, step := range []struct {
+		path, token string
+		body        any
+	}{
+		{"/api/demo/game/unlock", ana.Token, UnlockRequest{Code: "CODE-A"}},
+		{"/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "2"}},
+		{"/api/demo/game/unlock", luis.Token, UnlockRequest{Code: "CODE-A"}},
+		{"/api/demo/game/answer", luis.Token, AnswerRequest{Answer: "3"}},
+		{"/api/demo/game/next", ana.Token, nil},
+		// Rejected requests aren't logged.
+		{"/api/demo/game/unlock", ana.Token, UnlockRequest{Code: "WRONG"}},
+	} {
+		postJSON(t, r, step.path, step.token, step.body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/games/"+gameID+"/event-log", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("event log: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var events []GameEvent
+	json.NewDecoder(w.Body).Decode(&events)
+
+	want := []GameEvent{
+		{Type: "joined", TeamName: "Team Test", PlayerName: "Ana", Role: "player"},
+		{Type: "joined", TeamName: "Team Two", PlayerName: "Luis", Role: "player"},
+		{Type: "unlocked", TeamName: "Team Test", PlayerName: "Ana", StageNumber: 1},
+		{Type: "answered", TeamName: "Team Test", PlayerName: "Ana", StageNumber: 1, Answer: "2", IsCorrect: true},
+		{Type: "unlocked", TeamName: "Team Two", PlayerName: "Luis", StageNumber: 1},
+		{Type: "answered", TeamName: "Team Two", PlayerName: "Luis", StageNumber: 1, Answer: "3"},
+		{Type: "advanced", TeamName: "Team Test", PlayerName: "Ana", StageNumber: 2},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, got := range events {
+		if got.At == "" || (i > 0 && got.Seq <= events[i-1].Seq) {
+			t.Errorf("event %d: expected a timestamp and increasing seq, got %+v", i, got)
+		}
+		got.Seq, got.At, got.TeamID, got.PlayerID = 0, "", "", ""
+		if got != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/games/nope/event-log", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+}
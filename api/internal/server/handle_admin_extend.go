@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ExtendGameRequest is the request body for POST .../games/{gameID}/extend.
+type ExtendGameRequest struct {
+	AddMinutes int `json:"addMinutes"`
+}
+
+// ExtendGameResponse reports the game's new timer. Deadline and
+// RemainingSeconds are empty for games that haven't started yet.
+type ExtendGameResponse struct {
+	TimerMinutes     int     `json:"timerMinutes"`
+	Deadline         *string `json:"deadline"`
+	RemainingSeconds int     `json:"remainingSeconds"`
+}
+
+func handleAdminExtendGame(broker *Broker, limits TimerLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		var req ExtendGameRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.AddMinutes <= 0 {
+			writeError(w, http.StatusBadRequest, "addMinutes must be positive")
+			return
+		}
+
+		game, err := store.ExtendGameTimer(r.Context(), gameID, req.AddMinutes, limits.GameMinutes)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		case errors.Is(err, ErrGameEnded):
+			writeError(w, http.StatusConflict, "game has ended")
+			return
+		case errors.Is(err, ErrTimerDisabled):
+			writeError(w, http.StatusConflict, "game has no timer")
+			return
+		case errors.Is(err, ErrTimerLimit):
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("timerMinutes must be at most %d", limits.GameMinutes))
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		resp := ExtendGameResponse{TimerMinutes: game.TimerMinutes}
+		if game.StartedAt != nil {
+			start, _ := time.Parse(time.RFC3339Nano, *game.StartedAt)
+			deadline := start.Add(time.Duration(game.TimerMinutes) * time.Minute)
+			d := deadline.UTC().Format(time.RFC3339)
+			resp.Deadline = &d
+			resp.RemainingSeconds = max(0, int(time.Until(deadline).Seconds()))
+		}
+
+		for _, t := range game.Teams {
+			broker.Publish(t.ID, SSEEvent{
+				Type:             "timer_extended",
+				RemainingSeconds: resp.RemainingSeconds,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -1,12 +1,12 @@
 package server
 
 import (
-	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -24,25 +24,45 @@ type AdminGameSummary struct {
 	StageTimerMinutes int    `json:"stageTimerMinutes"`
 	Notes             string `json:"notes,omitempty"`
 	TeamCount         int    `json:"teamCount"`
+	ProgressPercent   int    `json:"progressPercent"` // stages answered across all teams, 0-100
 	CreatedAt         string `json:"createdAt"`
 }
 
 type AdminGameDetail struct {
-	ID                string          `json:"id"`
-	ScenarioID        string          `json:"scenarioId"`
-	ScenarioName      string          `json:"scenarioName"`
-	Status            string          `json:"status"`
-	Mode              string          `json:"mode"`
-	Language          string          `json:"language,omitempty"`
-	Supervised        bool            `json:"supervised"`
-	TimerEnabled      bool            `json:"timerEnabled"`
-	TimerMinutes      int             `json:"timerMinutes"`
-	StageTimerMinutes int             `json:"stageTimerMinutes"`
-	Notes             string          `json:"notes,omitempty"`
-	StartedAt         *string         `json:"startedAt"`
-	Stages            []AdminStage    `json:"stages"`
-	Teams             []AdminTeamItem `json:"teams"`
-	CreatedAt         string          `json:"createdAt"`
+	ID                      string          `json:"id"`
+	ScenarioID              string          `json:"scenarioId"`
+	ScenarioName            string          `json:"scenarioName"`
+	Status                  string          `json:"status"`
+	Mode                    string          `json:"mode"`
+	Language                string          `json:"language,omitempty"`
+	Supervised              bool            `json:"supervised"`
+	TimerEnabled            bool            `json:"timerEnabled"`
+	TimerMinutes            int             `json:"timerMinutes"`
+	StageTimerMinutes       int             `json:"stageTimerMinutes"`
+	Notes                   string          `json:"notes,omitempty"`
+	Outro                   string          `json:"outro,omitempty"`
+	RequireAllPlayers       bool            `json:"requireAllPlayers"`
+	AllPlayersGrading       string          `json:"allPlayersGrading,omitempty"`
+	HideLockedClue          bool            `json:"hideLockedClue"`
+	HideLocationFromPlayers bool            `json:"hideLocationFromPlayers"`
+	TrimPunctuation         bool            `json:"trimPunctuation"`
+	PlayersCanAnswer        bool            `json:"playersCanAnswer"`
+	ManualAdvance           bool            `json:"manualAdvance"`
+	WaitForConfirmations    bool            `json:"waitForConfirmations"`
+	MinPlayersToStart       int             `json:"minPlayersToStart"`
+	MaxAttempts             int             `json:"maxAttempts"`
+	AllowGiveUp             bool            `json:"allowGiveUp"`
+	IndividualScoring       bool            `json:"individualScoring"`
+	FirstStageUnlocked      bool            `json:"firstStageUnlocked"`
+	PointsDecay             bool            `json:"pointsDecay"`
+	Settings                map[string]bool `json:"settings"` // every game setting by name, with the flat fields' values
+	PlayCount               int             `json:"playCount,omitempty"`
+	PIN                     string          `json:"pin,omitempty"` // quick-entry PIN while in the lobby, active or paused
+	StartedAt               *string         `json:"startedAt"`
+	Stages                  []AdminStage    `json:"stages"`
+	Teams                   []AdminTeamItem `json:"teams"`
+	CreatedAt               string          `json:"createdAt"`
+	Version                 int             `json:"version"`
 }
 
 type AdminTeamItem struct {
@@ -50,24 +70,45 @@ type AdminTeamItem struct {
 	Name            string `json:"name"`
 	JoinToken       string `json:"joinToken"`
 	SupervisorToken string `json:"supervisorToken,omitempty"`
+	SpectatorToken  string `json:"spectatorToken,omitempty"`
 	GuideName       string `json:"guideName"`
 	TeamSecret      int    `json:"teamSecret,omitempty"`
 	StartStage      int    `json:"startStage"`
 	PlayerCount     int    `json:"playerCount"`
+	AdminUnlocks    []int  `json:"adminUnlocks,omitempty"` // stages unlocked via POST .../teams/{teamID}/unlock
 	CreatedAt       string `json:"createdAt"`
+	Version         int    `json:"version"`
 }
 
 type AdminGameRequest struct {
-	ScenarioID        string `json:"scenarioId"`
-	ScenarioName      string `json:"-"`  // set by handler after validation
-	Mode              string `json:"-"`  // set by handler from scenario
-	Language          string `json:"language"`
-	Status            string `json:"status"`
-	Supervised        bool   `json:"supervised"`
-	TimerEnabled      bool   `json:"timerEnabled"`
-	TimerMinutes      int    `json:"timerMinutes"`
-	StageTimerMinutes int    `json:"stageTimerMinutes"`
-	Notes             string `json:"notes"`
+	ScenarioID              string          `json:"scenarioId"`
+	ScenarioName            string          `json:"-"` // set by handler after validation
+	Mode                    string          `json:"-"` // set by handler from scenario
+	PlayCount               int             `json:"-"` // set by handler from scenario
+	Language                string          `json:"language"`
+	Status                  string          `json:"status"`
+	Supervised              bool            `json:"supervised"`
+	TimerEnabled            bool            `json:"timerEnabled"`
+	TimerMinutes            int             `json:"timerMinutes"`
+	StageTimerMinutes       int             `json:"stageTimerMinutes"`
+	Notes                   string          `json:"notes"`
+	Outro                   string          `json:"outro"` // shown to teams that finish every stage
+	RequireAllPlayers       bool            `json:"requireAllPlayers"`
+	AllPlayersGrading       string          `json:"allPlayersGrading"`       // "majority" (default) or "first_correct"
+	HideLockedClue          bool            `json:"hideLockedClue"`          // omit a locked next stage's clue from the answer response
+	HideLocationFromPlayers bool            `json:"hideLocationFromPlayers"` // show stage locations to the supervisor only
+	TrimPunctuation         bool            `json:"trimPunctuation"`         // accept "catacombs." for "catacombs"
+	PlayersCanAnswer        bool            `json:"playersCanAnswer"`        // supervised: players answer, supervisor still unlocks
+	ManualAdvance           bool            `json:"manualAdvance"`           // hold each result until POST /game/next (the supervisor's, if supervised)
+	WaitForConfirmations    bool            `json:"waitForConfirmations"`    // supervised: start the stage timer once every player has called POST /game/confirm
+	MinPlayersToStart       int             `json:"minPlayersToStart"`       // hold answering and unlocking until this many players have joined a team (0 = off)
+	MaxAttempts             int             `json:"maxAttempts"`             // wrong answers a team gets per stage before it fails it (0 = off: the first answer settles the stage)
+	AllowGiveUp             bool            `json:"allowGiveUp"`             // players may give up a question with POST /game/giveup, scoring it wrong
+	IndividualScoring       bool            `json:"individualScoring"`       // classic: each player answers for themselves and has their own progress
+	FirstStageUnlocked      bool            `json:"firstStageUnlocked"`      // qr_quiz, supervised: each team's first stage starts unlocked
+	PointsDecay             bool            `json:"pointsDecay"`             // correct answers score less the longer the stage timer has run and after wrong attempts
+	Settings                map[string]bool `json:"settings,omitempty"`      // settings by name; entries override the flat fields above
+	Version                 int             `json:"version,omitempty"`       // required on update: the version the edit started from
 }
 
 type AdminTeamRequest struct {
@@ -75,6 +116,23 @@ type AdminTeamRequest struct {
 	JoinToken  string `json:"joinToken"`
 	GuideName  string `json:"guideName"`
 	StartStage int    `json:"startStage"`
+	Version    int    `json:"version,omitempty"` // required on update
+}
+
+// AdminTeamSessions is the response for GET .../teams/{teamID}/sessions.
+type AdminTeamSessions struct {
+	TeamID       string             `json:"teamId"`
+	SessionCount int                `json:"sessionCount"`
+	Sessions     []AdminTeamSession `json:"sessions"`
+}
+
+// AdminTeamSession describes one device session on a team. Spectator
+// sessions have no player, so PlayerID, PlayerName and JoinedAt are empty.
+type AdminTeamSession struct {
+	PlayerID   string `json:"playerId,omitempty"`
+	PlayerName string `json:"playerName,omitempty"`
+	Role       string `json:"role"`
+	JoinedAt   string `json:"joinedAt,omitempty"`
 }
 
 type AdminGameStatus struct {
@@ -95,8 +153,25 @@ type AdminTeamStatus struct {
 	ID              string              `json:"id"`
 	Name            string              `json:"name"`
 	GuideName       string              `json:"guideName"`
-	CompletedStages int                 `json:"completedStages"`
+	CompletedStages int                 `json:"completedStages"` // answered correctly
+	Score           int                 `json:"score"`           // points from correct results; the scoreboard ranks by it
+	ProgressPercent int                 `json:"progressPercent"` // stages answered, right or wrong, 0-100
 	Players         []AdminPlayerStatus `json:"players"`
+	Results         []AdminStageResult  `json:"results"`
+	// Live events the team's connections missed on full buffers since the
+	// server started; a growing count means clients are falling behind.
+	DroppedEvents uint64 `json:"droppedEvents"`
+}
+
+// AdminStageResult is one recorded answer in a team's history. PlayerName is
+// empty for auto-completed stages and answers recorded before attribution.
+type AdminStageResult struct {
+	StageNumber int    `json:"stageNumber"`
+	Answer      string `json:"answer"`
+	IsCorrect   bool   `json:"isCorrect"`
+	Points      int    `json:"points"`
+	PlayerName  string `json:"playerName,omitempty"`
+	AnsweredAt  string `json:"answeredAt"`
 }
 
 type AdminPlayerStatus struct {
@@ -107,12 +182,39 @@ type AdminPlayerStatus struct {
 
 var validGameStatuses = map[string]bool{
 	"draft":  true,
+	"lobby":  true,
 	"active": true,
 	"paused": true,
 	"ended":  true,
 }
 
-func (req *AdminGameRequest) validate() string {
+// validGradings are the ways a team result is derived from every player's
+// answer when RequireAllPlayers is set.
+var validGradings = map[string]bool{
+	"majority":      true,
+	"first_correct": true,
+}
+
+// TimerLimits caps the game and stage timers an operator can set, so a typo
+// can't produce a 10,000-minute game. Zero means no cap.
+type TimerLimits struct {
+	GameMinutes  int
+	StageMinutes int
+}
+
+// maxOutroLen caps a game's outro, in characters: a closing note on the
+// completion screen, not a page of text.
+const maxOutroLen = 2000
+
+// maxMinPlayersToStart caps minPlayersToStart at a number of people that
+// still fits one walking group.
+const maxMinPlayersToStart = 50
+
+// maxMaxAttempts caps maxAttempts; past a handful of tries a stage is a
+// guessing game.
+const maxMaxAttempts = 10
+
+func (req *AdminGameRequest) validate(limits TimerLimits) string {
 	req.ScenarioID = strings.TrimSpace(req.ScenarioID)
 	req.Status = strings.TrimSpace(req.Status)
 	if req.ScenarioID == "" {
@@ -122,8 +224,13 @@ func (req *AdminGameRequest) validate() string {
 		req.Status = "draft"
 	}
 	if !validGameStatuses[req.Status] {
-		return "status must be draft, active, paused, or ended"
+		return "status must be draft, lobby, active, paused, or ended"
+	}
+	settings := req.gameSettings()
+	if msg := settings.apply(req.Settings); msg != "" {
+		return msg
 	}
+	req.setGameSettings(settings)
 	if req.TimerEnabled {
 		if req.TimerMinutes <= 0 {
 			req.TimerMinutes = 120
@@ -131,10 +238,42 @@ func (req *AdminGameRequest) validate() string {
 		if req.StageTimerMinutes <= 0 {
 			req.StageTimerMinutes = 10
 		}
+		if limits.GameMinutes > 0 && req.TimerMinutes > limits.GameMinutes {
+			return fmt.Sprintf("timerMinutes must be at most %d", limits.GameMinutes)
+		}
+		if limits.StageMinutes > 0 && req.StageTimerMinutes > limits.StageMinutes {
+			return fmt.Sprintf("stageTimerMinutes must be at most %d", limits.StageMinutes)
+		}
 	} else {
 		req.TimerMinutes = 0
 		req.StageTimerMinutes = 0
 	}
+	if req.MinPlayersToStart < 0 || req.MinPlayersToStart > maxMinPlayersToStart {
+		return fmt.Sprintf("minPlayersToStart must be between 0 and %d", maxMinPlayersToStart)
+	}
+	if req.MaxAttempts < 0 || req.MaxAttempts > maxMaxAttempts {
+		return fmt.Sprintf("maxAttempts must be between 0 and %d", maxMaxAttempts)
+	}
+	if req.MaxAttempts > 0 && req.RequireAllPlayers {
+		return "maxAttempts cannot be combined with requireAllPlayers"
+	}
+	if req.IndividualScoring && (req.RequireAllPlayers || req.ManualAdvance || req.MaxAttempts > 0) {
+		return "individualScoring cannot be combined with requireAllPlayers, manualAdvance or maxAttempts"
+	}
+	req.Outro = strings.TrimSpace(req.Outro)
+	if utf8.RuneCountInString(req.Outro) > maxOutroLen {
+		return fmt.Sprintf("outro must be at most %d characters", maxOutroLen)
+	}
+	if req.RequireAllPlayers {
+		if req.AllPlayersGrading == "" {
+			req.AllPlayersGrading = "majority"
+		}
+		if !validGradings[req.AllPlayersGrading] {
+			return "allPlayersGrading must be majority or first_correct"
+		}
+	} else {
+		req.AllPlayersGrading = ""
+	}
 	return ""
 }
 
@@ -148,16 +287,16 @@ func (req *AdminTeamRequest) validate() string {
 	return ""
 }
 
-func generateJoinToken() string {
+func generateSupervisorToken() string {
 	b := make([]byte, 4)
-	rand.Read(b)
-	return "team-" + hex.EncodeToString(b)
+	randRead(b)
+	return "super-" + hex.EncodeToString(b)
 }
 
-func generateSupervisorToken() string {
+func generateSpectatorToken() string {
 	b := make([]byte, 4)
-	rand.Read(b)
-	return "super-" + hex.EncodeToString(b)
+	randRead(b)
+	return "watch-" + hex.EncodeToString(b)
 }
 
 func handleAdminListGames() http.HandlerFunc {
@@ -177,7 +316,7 @@ func handleAdminListGames() http.HandlerFunc {
 	}
 }
 
-func handleAdminCreateGame(admin AdminStore) http.HandlerFunc {
+func handleAdminCreateGame(admin AdminStore, limits TimerLimits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		store := clientStore(r)
 
@@ -186,7 +325,7 @@ func handleAdminCreateGame(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
-		if msg := req.validate(); msg != "" {
+		if msg := req.validate(limits); msg != "" {
 			writeError(w, http.StatusBadRequest, msg)
 			return
 		}
@@ -200,8 +339,15 @@ func handleAdminCreateGame(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		if len(scenario.Stages) == 0 {
+			writeError(w, http.StatusBadRequest, "scenario has no stages")
+			return
+		}
 		req.ScenarioName = scenario.Name
 		req.Mode = scenario.Mode
+		req.PlayCount = scenario.PlayCount
+		// Only supervisors unlock in supervised mode, so such a game is
+		// always played supervised.
 		if req.Mode == "supervised" {
 			req.Supervised = true
 		}
@@ -235,7 +381,7 @@ func handleAdminGetGame() http.HandlerFunc {
 	}
 }
 
-func handleAdminUpdateGame(admin AdminStore) http.HandlerFunc {
+func handleAdminUpdateGame(admin AdminStore, limits TimerLimits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		store := clientStore(r)
 		gameID := chi.URLParam(r, "gameID")
@@ -245,10 +391,14 @@ func handleAdminUpdateGame(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
-		if msg := req.validate(); msg != "" {
+		if msg := req.validate(limits); msg != "" {
 			writeError(w, http.StatusBadRequest, msg)
 			return
 		}
+		if req.Version < 1 {
+			writeError(w, http.StatusBadRequest, "version is required")
+			return
+		}
 
 		scenario, err := admin.GetScenario(r.Context(), req.ScenarioID)
 		if errors.Is(err, ErrNotFound) {
@@ -259,8 +409,15 @@ func handleAdminUpdateGame(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		if len(scenario.Stages) == 0 {
+			writeError(w, http.StatusBadRequest, "scenario has no stages")
+			return
+		}
 		req.ScenarioName = scenario.Name
 		req.Mode = scenario.Mode
+		req.PlayCount = scenario.PlayCount
+		// Only supervisors unlock in supervised mode, so such a game is
+		// always played supervised.
 		if req.Mode == "supervised" {
 			req.Supervised = true
 		}
@@ -270,6 +427,10 @@ func handleAdminUpdateGame(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusNotFound, "game not found")
 			return
 		}
+		if errors.Is(err, ErrVersionConflict) {
+			writeError(w, http.StatusConflict, "game was modified by someone else; reload and try again")
+			return
+		}
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
@@ -355,7 +516,31 @@ func handleAdminListTeams() http.HandlerFunc {
 	}
 }
 
-func handleAdminCreateTeam() http.HandlerFunc {
+func handleAdminTeamSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+		teamID := chi.URLParam(r, "teamID")
+
+		sessions, err := store.TeamSessions(r.Context(), gameID, teamID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "team not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, AdminTeamSessions{
+			TeamID:       teamID,
+			SessionCount: len(sessions),
+			Sessions:     sessions,
+		})
+	}
+}
+
+func handleAdminCreateTeam(admin AdminStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		store := clientStore(r)
 		gameID := chi.URLParam(r, "gameID")
@@ -380,15 +565,25 @@ func handleAdminCreateTeam() http.HandlerFunc {
 			return
 		}
 
-		token := req.JoinToken
-		if token == "" {
-			token = generateJoinToken()
+		if req.JoinToken == "" {
+			format, err := clientTokenFormat(r.Context(), admin, chi.URLParam(r, "client"))
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			team, err := createTeamWithGeneratedToken(r.Context(), store, gameID, req, format)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			writeJSON(w, http.StatusCreated, team)
+			return
 		}
 
-		team, err := store.CreateTeam(r.Context(), gameID, req, token)
+		team, err := store.CreateTeam(r.Context(), gameID, req, req.JoinToken)
 		if err != nil {
 			if strings.Contains(err.Error(), "UNIQUE") {
-				writeError(w, http.StatusConflict, fmt.Sprintf("join token %q already exists", token))
+				writeError(w, http.StatusConflict, fmt.Sprintf("join token %q already exists", req.JoinToken))
 				return
 			}
 			writeError(w, http.StatusInternalServerError, "internal error")
@@ -415,11 +610,24 @@ func handleAdminUpdateTeam() http.HandlerFunc {
 			return
 		}
 
+		if req.Version < 1 {
+			writeError(w, http.StatusBadRequest, "version is required")
+			return
+		}
+
 		team, err := store.UpdateTeam(r.Context(), gameID, teamID, req)
+		if err != nil && strings.Contains(err.Error(), "UNIQUE") {
+			writeError(w, http.StatusConflict, fmt.Sprintf("join token %q already exists", req.JoinToken))
+			return
+		}
 		if errors.Is(err, ErrNotFound) {
 			writeError(w, http.StatusNotFound, "team not found")
 			return
 		}
+		if errors.Is(err, ErrVersionConflict) {
+			writeError(w, http.StatusConflict, "team was modified by someone else; reload and try again")
+			return
+		}
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
@@ -469,7 +677,7 @@ func handleAdminDeleteTeam() http.HandlerFunc {
 	}
 }
 
-func handleAdminGameStatus() http.HandlerFunc {
+func handleAdminGameStatus(broker *Broker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		store := clientStore(r)
 		gameID := chi.URLParam(r, "gameID")
@@ -484,6 +692,9 @@ func handleAdminGameStatus() http.HandlerFunc {
 			return
 		}
 
+		for i := range status.Teams {
+			status.Teams[i].DroppedEvents = broker.Dropped(status.Teams[i].ID)
+		}
 		writeJSON(w, http.StatusOK, status)
 	}
 }
@@ -120,8 +120,11 @@ func handleAdminListClients(admin AdminStore) http.HandlerFunc {
 }
 
 type CreateClientRequest struct {
-	Slug string `json:"slug"`
-	Name string `json:"name"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	TokenStyle  string `json:"tokenStyle,omitempty"`  // "hex" (default), "words", or "pin"
+	PINLength   int    `json:"pinLength,omitempty"`   // digits for "pin" tokens, at least 6
+	DefaultMode string `json:"defaultMode,omitempty"` // mode for the client's new scenarios that don't name one
 }
 
 func handleAdminCreateClient(admin AdminStore, clients *Registry) http.HandlerFunc {
@@ -149,8 +152,20 @@ func handleAdminCreateClient(admin AdminStore, clients *Registry) http.HandlerFu
 			writeError(w, http.StatusBadRequest, "slug and name are required")
 			return
 		}
+		format := JoinTokenFormat{Style: req.TokenStyle, PINLength: req.PINLength}
+		if msg := format.validate(); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		req.DefaultMode = strings.TrimSpace(req.DefaultMode)
+		if req.DefaultMode != "" && !validModes[req.DefaultMode] {
+			writeError(w, http.StatusBadRequest, "defaultMode must be one of: classic, qr_quiz, qr_hunt, math_puzzle, supervised")
+			return
+		}
 
-		if err := admin.CreateClient(r.Context(), req.Slug, req.Name); err != nil {
+		client := ClientInfo{Slug: req.Slug, Name: req.Name, TokenStyle: format.Style, PINLength: format.PINLength, DefaultMode: req.DefaultMode}
+		if err := admin.CreateClient(r.Context(), client); err != nil {
 			if strings.Contains(err.Error(), "UNIQUE") {
 				writeError(w, http.StatusConflict, "client slug already exists")
 				return
@@ -164,6 +179,6 @@ func handleAdminCreateClient(admin AdminStore, clients *Registry) http.HandlerFu
 			return
 		}
 
-		writeJSON(w, http.StatusCreated, ClientInfo{Slug: req.Slug, Name: req.Name})
+		writeJSON(w, http.StatusCreated, client)
 	}
 }
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegradeRequest is the request body for POST .../games/{gameID}/regrade.
+// CorrectAnswers optionally fixes the answer key (scenario stage number →
+// answer) before regrading; the fix is saved to the game's stage snapshot.
+type RegradeRequest struct {
+	Confirm        bool           `json:"confirm"`
+	CorrectAnswers map[int]string `json:"correctAnswers,omitempty"`
+}
+
+// RegradeResponse reports how many recorded answers were re-evaluated and how
+// many changed correctness.
+type RegradeResponse struct {
+	Checked int `json:"checked"`
+	Flipped int `json:"flipped"`
+}
+
+func handleAdminRegradeGame() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		var req RegradeRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !req.Confirm {
+			writeError(w, http.StatusBadRequest, "confirm must be true to regrade")
+			return
+		}
+
+		game, err := store.GetGame(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		known := make(map[int]AdminStage, len(game.Stages))
+		for _, s := range game.Stages {
+			known[s.StageNumber] = s
+		}
+		for n, answer := range req.CorrectAnswers {
+			stage, ok := known[n]
+			if !ok {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("stage %d does not exist", n))
+				return
+			}
+			if strings.TrimSpace(answer) == "" {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("stage %d: correct answer must not be empty", n))
+				return
+			}
+			if _, ok := parseNumber(answer); stage.AnswerType == answerTypeNumber && !ok {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("stage %d: correct answer must be a number", n))
+				return
+			}
+		}
+
+		flipped, checked, err := store.RegradeGame(r.Context(), gameID, req.CorrectAnswers)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, RegradeResponse{Checked: checked, Flipped: flipped})
+	}
+}
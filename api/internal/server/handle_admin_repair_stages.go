@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RepairStagesRequest is the request body for POST
+// .../games/{gameID}/repair-stages. Confirm is required for active games,
+// where teams are playing the stages being replaced.
+type RepairStagesRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+// RepairStagesResponse reports the game's stages after a repair.
+type RepairStagesResponse struct {
+	StageCount int `json:"stageCount"`
+	Version    int `json:"version"`
+}
+
+// handleAdminRepairStages replaces a game's stage snapshot with its
+// scenario's current stages, whatever the game's status. It is the repair
+// path for games whose stored stages are missing or no longer decode
+// (players get "game stages are unreadable"), and works on those because it
+// never decodes the old stages. Team progress is kept; if the scenario's
+// stages changed since the game was created, results now refer to the new
+// ones.
+func handleAdminRepairStages(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		var req RepairStagesRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		scenarioID, status, err := store.GameScenarioStatus(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if status == "active" && !req.Confirm {
+			writeError(w, http.StatusConflict, "game is active; set confirm to replace its stages")
+			return
+		}
+
+		scenario, err := admin.GetScenario(r.Context(), scenarioID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusConflict, "the game's scenario no longer exists")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if len(scenario.Stages) == 0 {
+			writeError(w, http.StatusConflict, "scenario has no stages")
+			return
+		}
+
+		version, err := store.ResnapshotStages(r.Context(), gameID, scenario.Stages)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		slog.Info("game stages repaired", "game", gameID, "scenario", scenarioID, "status", status, "stages", len(scenario.Stages))
+
+		writeJSON(w, http.StatusOK, RepairStagesResponse{StageCount: len(scenario.Stages), Version: version})
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCorruptStagesRepair breaks a game's stored stages, checks players get a
+// clean, coded 500 instead of a panic, and that repairing from the scenario
+// brings the game back.
+func TestCorruptStagesRepair(t *testing.T) {
+	r, login, store, _ := adminRouterWithStore(t)
+	cookies := login()
+	ctx := context.Background()
+
+	_, token, err := store.JoinTeam(ctx, demoSeed.GameID, demoIncas.ID, "Ana", "player")
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if err := store.RecordAnswer(ctx, demoSeed.GameID, demoIncas.ID, "", 1, "1650", true); err != nil {
+		t.Fatalf("record answer: %v", err)
+	}
+
+	state := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/demo/game/state", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req = req.WithContext(context.WithValue(req.Context(), ctxKeyStore, Store(store)))
+		w := httptest.NewRecorder()
+		handleGameState(NewBroker())(w, req)
+		return w
+	}
+	if w := state(); w.Code != http.StatusOK {
+		t.Fatalf("state before: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A stage number written as a string, as a bad hand edit might leave it.
+	if _, err := store.db.ExecContext(ctx,
+		`UPDATE games SET data = jsonb_set(data, '$.stages[0].stageNumber', 'one') WHERE id = ?`, demoSeed.GameID,
+	); err != nil {
+		t.Fatalf("corrupt stages: %v", err)
+	}
+	if _, err := store.GameState(ctx, demoSeed.GameID, demoIncas.ID); !errors.Is(err, ErrCorruptStages) {
+		t.Fatalf("GameState: err = %v, want ErrCorruptStages", err)
+	}
+
+	w := state()
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("state with corrupt stages: expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Code != "game_stages_corrupt" {
+		t.Errorf("error code = %q, want game_stages_corrupt", resp.Code)
+	}
+
+	repair := repairStages(t, r, cookies)
+	if w := repair("no-such-game", true); w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+	// The demo game is active: replacing its stages needs confirm.
+	if w := repair(demoSeed.GameID, false); w.Code != http.StatusConflict {
+		t.Errorf("active game without confirm: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = repair(demoSeed.GameID, true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("repair: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var repaired RepairStagesResponse
+	json.NewDecoder(w.Body).Decode(&repaired)
+	if repaired.StageCount == 0 || repaired.Version < 2 {
+		t.Errorf("repair response = %+v, want stages and a bumped version", repaired)
+	}
+
+	w = state()
+	if w.Code != http.StatusOK {
+		t.Fatalf("state after repair: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var st GameStateResponse
+	json.NewDecoder(w.Body).Decode(&st)
+	if len(st.CompletedStages) != 1 {
+		t.Errorf("completed stages after repair = %d, want progress kept", len(st.CompletedStages))
+	}
+}
+
+func TestRepairBlankedStages(t *testing.T) {
+	r, login, store, _ := adminRouterWithStore(t)
+	ctx := context.Background()
+
+	if _, err := store.db.ExecContext(ctx,
+		`UPDATE games SET status = 'paused', data = jsonb_remove(jsonb_set(data, '$.status', 'paused'), '$.stages') WHERE id = ?`, demoSeed.GameID,
+	); err != nil {
+		t.Fatalf("blank stages: %v", err)
+	}
+	if g, _ := store.GetGame(ctx, demoSeed.GameID); len(g.Stages) != 0 {
+		t.Fatalf("stages after blanking = %d", len(g.Stages))
+	}
+
+	// Paused: no confirm needed.
+	w := repairStages(t, r, login())(demoSeed.GameID, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("repair: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var repaired RepairStagesResponse
+	json.NewDecoder(w.Body).Decode(&repaired)
+
+	g, err := store.GetGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	if len(g.Stages) == 0 || repaired.StageCount != len(g.Stages) {
+		t.Errorf("stageCount = %d, game has %d stages", repaired.StageCount, len(g.Stages))
+	}
+	if repaired.Version != g.Version {
+		t.Errorf("version = %d, game is at %d", repaired.Version, g.Version)
+	}
+}
+
+// repairStages returns a function posting to a demo game's repair-stages
+// endpoint as the logged-in admin.
+func repairStages(t *testing.T, r http.Handler, cookies []*http.Cookie) func(gameID string, confirm bool) *httptest.ResponseRecorder {
+	t.Helper()
+	return func(gameID string, confirm bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(RepairStagesRequest{Confirm: confirm})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/"+gameID+"/repair-stages", bytes.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestScenarioStagesCorrupt(t *testing.T) {
+	d := gameStateData{StagesJSON: `[{"stageNumber":"one"}]`}
+	if _, err := d.scenarioStages(); !errors.Is(err, ErrCorruptStages) {
+		t.Errorf("err = %v, want ErrCorruptStages", err)
+	}
+	d.StagesJSON = `[{"stageNumber":1,"question":"q"}]`
+	stages, err := d.scenarioStages()
+	if err != nil || len(stages) != 1 {
+		t.Errorf("valid stages: %v, %v", stages, err)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminGameResults is every recorded result of a game, for the CSV export.
+type AdminGameResults struct {
+	ScenarioName string
+	Rows         []AdminResultRow
+}
+
+// AdminResultRow is one team's result on one stage. StageNumber is the
+// scenario stage, so rows line up across teams with staggered starts or
+// stage pools. Auto-completed stages have no answer.
+type AdminResultRow struct {
+	Team        string
+	StageNumber int
+	Answer      string
+	IsCorrect   bool
+	AnsweredAt  string
+}
+
+// resultsCSVHeader is the first row of every results export.
+var resultsCSVHeader = []string{"team", "stageNumber", "answer", "isCorrect", "answeredAt"}
+
+// handleAdminGameResultsCSV streams a game's results as a CSV download named
+// after its scenario. A game without results is just the header row.
+func handleAdminGameResultsCSV() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		results, err := store.GameResults(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		filename := slugify(results.ScenarioName) + "-results.csv"
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.WriteHeader(http.StatusOK)
+
+		// Headers are out, so a failure from here on can only cut the file short.
+		cw := csv.NewWriter(w)
+		cw.Write(resultsCSVHeader)
+		for _, row := range results.Rows {
+			cw.Write([]string{row.Team, strconv.Itoa(row.StageNumber), row.Answer, strconv.FormatBool(row.IsCorrect), row.AnsweredAt})
+		}
+		cw.Flush()
+	}
+}
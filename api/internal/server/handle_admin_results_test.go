@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestAdminGameResultsCSV(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	results := func(gameID string) [][]string {
+		t.Helper()
+		w := do(http.MethodGet, "/api/admin/clients/demo/games/"+gameID+"/results.csv", "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("results.csv: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("Content-Type = %q, want text/csv", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="lima-centro-historico-results.csv"` {
+			t.Errorf("Content-Disposition = %q, want the scenario's name", cd)
+		}
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("parse csv: %v", err)
+		}
+		if len(rows) == 0 || !slices.Equal(rows[0], resultsCSVHeader) {
+			t.Fatalf("rows = %q, want the header first", rows)
+		}
+		return rows[1:]
+	}
+
+	// A game without teams, and one whose teams haven't answered, are just
+	// the header.
+	w := do(http.MethodPost, "/api/admin/clients/demo/games", "", AdminGameRequest{ScenarioID: demoSeed.ScenarioID, Status: "draft"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create game: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var empty AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&empty)
+	if rows := results(empty.ID); len(rows) != 0 {
+		t.Errorf("game without teams: rows = %q, want none", rows)
+	}
+	if rows := results(demoSeed.GameID); len(rows) != 0 {
+		t.Errorf("game without answers: rows = %q, want none", rows)
+	}
+
+	var jr JoinResponse
+	json.NewDecoder(do(http.MethodPost, "/api/demo/join", "", JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Rosa"}).Body).Decode(&jr)
+	for _, a := range []string{"1651", "tunnels, \"old\""} {
+		if w := do(http.MethodPost, "/api/demo/game/answer", jr.Token, AnswerRequest{Answer: a}); w.Code != http.StatusOK {
+			t.Fatalf("answer %q: expected 200, got %d: %s", a, w.Code, w.Body.String())
+		}
+	}
+
+	rows := results(demoSeed.GameID)
+	if len(rows) != 2 {
+		t.Fatalf("rows = %q, want Los Incas' two results", rows)
+	}
+	want := [][]string{
+		{"Los Incas", "1", "1651", "true"},
+		{"Los Incas", "2", "tunnels, \"old\"", "false"},
+	}
+	for i, row := range rows {
+		if len(row) != 5 || !slices.Equal(row[:4], want[i]) || row[4] == "" {
+			t.Errorf("row %d = %q, want %q and the answer time", i+1, row, want[i])
+		}
+	}
+
+	if w := do(http.MethodGet, "/api/admin/clients/demo/games/nope/results.csv", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+	cookies = nil
+	if w := do(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/results.csv", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without a session: expected 401, got %d", w.Code)
+	}
+}
@@ -1,14 +1,18 @@
 package server
 
 import (
+	"archive/zip"
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -18,6 +22,10 @@ import (
 
 const maxImportSize = 32 << 20 // 32 MB
 
+// maxZipEntries caps the files in an imported zip, so an archive of a
+// million empty entries is refused before any of them is opened.
+const maxZipEntries = 1000
+
 func handleAdminExportScenario(admin AdminStore, dataDir string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -32,32 +40,11 @@ func handleAdminExportScenario(admin AdminStore, dataDir string) http.HandlerFun
 			return
 		}
 
-		// Build AdminScenarioRequest for the JSON block.
-		req := AdminScenarioRequest{
-			Name:        scenario.Name,
-			City:        scenario.City,
-			Description: scenario.Description,
-			Mode:        scenario.Mode,
-			Stages:      make([]AdminStage, len(scenario.Stages)),
-		}
-		copy(req.Stages, scenario.Stages)
-
-		// Convert file paths to data URIs in the request copy.
-		for i := range req.Stages {
-			req.Stages[i].ClueImage = imageToDataURI(dataDir, req.Stages[i].ClueImage)
-			req.Stages[i].QuestionImage = imageToDataURI(dataDir, req.Stages[i].QuestionImage)
-			for j := range req.Stages[i].FunFacts {
-				req.Stages[i].FunFacts[j].Image = imageToDataURI(dataDir, req.Stages[i].FunFacts[j].Image)
-			}
-		}
-
-		jsonBytes, err := json.MarshalIndent(req, "", "  ")
+		md, err := exportScenarioMarkdown(scenario, dataDir)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
-
-		md := buildExportMarkdown(req, string(jsonBytes))
 		filename := slugify(scenario.Name) + ".md"
 
 		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
@@ -67,6 +54,59 @@ func handleAdminExportScenario(admin AdminStore, dataDir string) http.HandlerFun
 	}
 }
 
+// handleAdminExportAllScenarios streams a zip with one file per scenario, each
+// in the single-export markdown format, for backup or moving scenarios to
+// another instance. The zip can be fed back to the import endpoint.
+func handleAdminExportAllScenarios(admin AdminStore, dataDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		summaries, err := admin.ListScenarios(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		scenarios := make([]AdminScenarioDetail, 0, len(summaries))
+		for _, s := range summaries {
+			sc, err := admin.GetScenario(r.Context(), s.ID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			scenarios = append(scenarios, sc)
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="scenarios.zip"`)
+		w.WriteHeader(http.StatusOK)
+
+		// Headers are out, so a failure from here on can only cut the zip short.
+		zw := zip.NewWriter(w)
+		used := map[string]bool{}
+		for _, sc := range scenarios {
+			md, err := exportScenarioMarkdown(sc, dataDir)
+			if err != nil {
+				return
+			}
+			f, err := zw.Create(uniqueFilename(used, slugify(sc.Name), ".md"))
+			if err != nil {
+				return
+			}
+			if _, err := io.WriteString(f, md); err != nil {
+				return
+			}
+		}
+		zw.Close()
+	}
+}
+
+// ScenarioImportResult reports what happened to one file of a zip import.
+type ScenarioImportResult struct {
+	File   string `json:"file"`
+	Status string `json:"status"` // "created", "collision", or "invalid"
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
 func handleAdminImportScenario(admin AdminStore, dataDir string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1024)
@@ -84,67 +124,259 @@ func handleAdminImportScenario(admin AdminStore, dataDir string) http.HandlerFun
 			return
 		}
 
-		content := string(data)
-
-		// Extract JSON from <!-- SCENARIO_JSON ... -->
-		const startMarker = "<!-- SCENARIO_JSON"
-		const endMarker = "-->"
-
-		startIdx := strings.Index(content, startMarker)
-		if startIdx == -1 {
-			writeError(w, http.StatusBadRequest, "no SCENARIO_JSON block found")
+		scenarios, err := admin.ListScenarios(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
-		jsonStart := startIdx + len(startMarker)
-
-		endIdx := strings.Index(content[jsonStart:], endMarker)
-		if endIdx == -1 {
-			writeError(w, http.StatusBadRequest, "malformed SCENARIO_JSON block")
-			return
+		names := make(map[string]bool, len(scenarios))
+		for _, s := range scenarios {
+			names[strings.ToLower(s.Name)] = true
 		}
-		jsonStr := strings.TrimSpace(content[jsonStart : jsonStart+endIdx])
 
-		var req AdminScenarioRequest
-		if err := json.Unmarshal([]byte(jsonStr), &req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON in SCENARIO_JSON block")
+		if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			results, err := importScenarioZip(r, admin, dataDir, data, names)
+			if err != nil {
+				if errors.Is(err, errInvalidZip) {
+					writeError(w, http.StatusBadRequest, "invalid zip file")
+					return
+				}
+				if errors.Is(err, errZipTooManyFiles) {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("zip must have at most %d files", maxZipEntries))
+					return
+				}
+				if errors.Is(err, errZipTooLarge) {
+					writeError(w, http.StatusBadRequest, "zip contents too large")
+					return
+				}
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			writeJSON(w, http.StatusOK, results)
 			return
 		}
 
-		// Convert data URIs to files.
-		for i := range req.Stages {
-			req.Stages[i].ClueImage = dataURIToFile(dataDir, req.Stages[i].ClueImage)
-			req.Stages[i].QuestionImage = dataURIToFile(dataDir, req.Stages[i].QuestionImage)
-			for j := range req.Stages[i].FunFacts {
-				req.Stages[i].FunFacts[j].Image = dataURIToFile(dataDir, req.Stages[i].FunFacts[j].Image)
-			}
+		req, msg := parseScenarioMarkdown(string(data))
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
 		}
+		restoreScenarioImages(dataDir, &req)
 
 		if msg := req.validate(); msg != "" {
 			writeError(w, http.StatusBadRequest, msg)
 			return
 		}
 
-		// Check name collision.
-		scenarios, err := admin.ListScenarios(r.Context())
+		if names[strings.ToLower(req.Name)] {
+			writeError(w, http.StatusConflict, "scenario with this name already exists")
+			return
+		}
+
+		scenario, err := admin.CreateScenario(r.Context(), req)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
-		for _, s := range scenarios {
-			if strings.EqualFold(s.Name, req.Name) {
-				writeError(w, http.StatusConflict, "scenario with this name already exists")
-				return
+
+		writeJSON(w, http.StatusCreated, scenario)
+	}
+}
+
+var (
+	errInvalidZip      = errors.New("invalid zip")
+	errZipTooManyFiles = errors.New("too many files in zip")
+	errZipTooLarge     = errors.New("zip contents too large")
+)
+
+// zipScenarioFile is one .md or .json entry read out of an imported zip.
+// err is set when the entry couldn't be read.
+type zipScenarioFile struct {
+	name    string
+	ext     string
+	content []byte
+	err     error
+}
+
+// importScenarioZip creates a scenario from every .md or .json file in the
+// zip. A bad or colliding file doesn't stop the rest; each gets a result, in
+// zip order. names holds the lowercased existing scenario names and is
+// updated as scenarios are created, so duplicates inside the zip collide too.
+//
+// A zip with more than maxZipEntries entries, or whose scenario files inflate
+// past maxImportSize together, is refused whole: every file is read before
+// any scenario is created.
+func importScenarioZip(r *http.Request, admin AdminStore, dataDir string, data []byte, names map[string]bool) ([]ScenarioImportResult, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errInvalidZip
+	}
+	if len(zr.File) > maxZipEntries {
+		return nil, errZipTooManyFiles
+	}
+
+	var files []zipScenarioFile
+	var total int64
+	for _, f := range zr.File {
+		ext := strings.ToLower(path.Ext(f.Name))
+		if f.FileInfo().IsDir() || (ext != ".md" && ext != ".json") {
+			continue
+		}
+		content, err := readZipFile(f, maxImportSize-total)
+		if errors.Is(err, errZipTooLarge) {
+			return nil, err
+		}
+		total += int64(len(content))
+		files = append(files, zipScenarioFile{name: f.Name, ext: ext, content: content, err: err})
+	}
+
+	results := []ScenarioImportResult{}
+	for _, f := range files {
+		res := ScenarioImportResult{File: f.name}
+		if f.err != nil {
+			res.Status, res.Error = "invalid", "failed to read file"
+			results = append(results, res)
+			continue
+		}
+
+		var req AdminScenarioRequest
+		msg := ""
+		if f.ext == ".json" {
+			if err := json.Unmarshal(f.content, &req); err != nil {
+				msg = "invalid JSON"
 			}
+		} else {
+			req, msg = parseScenarioMarkdown(string(f.content))
+		}
+		if msg == "" {
+			msg = req.validate()
+		}
+		res.Name = req.Name
+		if msg != "" {
+			res.Status, res.Error = "invalid", msg
+			results = append(results, res)
+			continue
+		}
+
+		if names[strings.ToLower(req.Name)] {
+			res.Status, res.Error = "collision", "scenario with this name already exists"
+			results = append(results, res)
+			continue
 		}
 
+		// Only write images out for scenarios that will actually be created.
+		restoreScenarioImages(dataDir, &req)
 		scenario, err := admin.CreateScenario(r.Context(), req)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
-			return
+			return nil, err
 		}
+		names[strings.ToLower(req.Name)] = true
+		res.Status, res.ID = "created", scenario.ID
+		results = append(results, res)
+	}
+	return results, nil
+}
 
-		writeJSON(w, http.StatusCreated, scenario)
+// readZipFile reads one zip entry, returning errZipTooLarge if it inflates
+// past limit bytes.
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errZipTooLarge
+	}
+	return data, nil
+}
+
+// exportScenarioMarkdown renders a scenario in the export format: readable
+// markdown followed by a SCENARIO_JSON block, with images inlined as data URIs.
+func exportScenarioMarkdown(scenario AdminScenarioDetail, dataDir string) (string, error) {
+	// Build AdminScenarioRequest for the JSON block.
+	req := AdminScenarioRequest{
+		Name:        scenario.Name,
+		City:        scenario.City,
+		Description: scenario.Description,
+		Mode:        scenario.Mode,
+		PlayCount:   scenario.PlayCount,
+		Stages:      make([]AdminStage, len(scenario.Stages)),
+	}
+	copy(req.Stages, scenario.Stages)
+
+	// Convert file paths to data URIs in the request copy.
+	for i := range req.Stages {
+		req.Stages[i].ClueImage = imageToDataURI(dataDir, req.Stages[i].ClueImage)
+		req.Stages[i].QuestionImage = imageToDataURI(dataDir, req.Stages[i].QuestionImage)
+		for j := range req.Stages[i].FunFacts {
+			req.Stages[i].FunFacts[j].Image = imageToDataURI(dataDir, req.Stages[i].FunFacts[j].Image)
+		}
+		for j := range req.Stages[i].Attachments {
+			req.Stages[i].Attachments[j].URL = imageToDataURI(dataDir, req.Stages[i].Attachments[j].URL)
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return "", err
 	}
+	return buildExportMarkdown(req, string(jsonBytes)), nil
+}
+
+// parseScenarioMarkdown extracts the scenario from an export's SCENARIO_JSON
+// block. On failure it returns an error message for the client.
+func parseScenarioMarkdown(content string) (AdminScenarioRequest, string) {
+	// Extract JSON from <!-- SCENARIO_JSON ... -->
+	const startMarker = "<!-- SCENARIO_JSON"
+	const endMarker = "-->"
+
+	startIdx := strings.Index(content, startMarker)
+	if startIdx == -1 {
+		return AdminScenarioRequest{}, "no SCENARIO_JSON block found"
+	}
+	jsonStart := startIdx + len(startMarker)
+
+	endIdx := strings.Index(content[jsonStart:], endMarker)
+	if endIdx == -1 {
+		return AdminScenarioRequest{}, "malformed SCENARIO_JSON block"
+	}
+	jsonStr := strings.TrimSpace(content[jsonStart : jsonStart+endIdx])
+
+	var req AdminScenarioRequest
+	if err := json.Unmarshal([]byte(jsonStr), &req); err != nil {
+		return AdminScenarioRequest{}, "invalid JSON in SCENARIO_JSON block"
+	}
+	return req, ""
+}
+
+// restoreScenarioImages saves the data-URI images of an imported scenario
+// under uploads/ and points the stages at the files.
+func restoreScenarioImages(dataDir string, req *AdminScenarioRequest) {
+	for i := range req.Stages {
+		req.Stages[i].ClueImage = dataURIToFile(dataDir, req.Stages[i].ClueImage)
+		req.Stages[i].QuestionImage = dataURIToFile(dataDir, req.Stages[i].QuestionImage)
+		for j := range req.Stages[i].FunFacts {
+			req.Stages[i].FunFacts[j].Image = dataURIToFile(dataDir, req.Stages[i].FunFacts[j].Image)
+		}
+		for j := range req.Stages[i].Attachments {
+			req.Stages[i].Attachments[j].URL = dataURIToFile(dataDir, req.Stages[i].Attachments[j].URL)
+		}
+	}
+}
+
+// uniqueFilename returns base+ext, or base-2+ext, base-3+ext, ... if taken.
+func uniqueFilename(used map[string]bool, base, ext string) string {
+	name := base + ext
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	used[name] = true
+	return name
 }
 
 // imageToDataURI reads an image file from disk and returns a data URI, or empty string.
@@ -273,6 +505,13 @@ func buildExportMarkdown(req AdminScenarioRequest, jsonBlock string) string {
 		if stage.QuestionImage != "" {
 			b.WriteString(fmt.Sprintf("![question](%s)\n\n", stage.QuestionImage))
 		}
+		if len(stage.Attachments) > 0 {
+			b.WriteString("**Attachments:**\n\n")
+			for _, a := range stage.Attachments {
+				b.WriteString(fmt.Sprintf("- [%s](%s)\n", a.Label, a.URL))
+			}
+			b.WriteString("\n")
+		}
 
 		if stage.CorrectAnswer != "" {
 			b.WriteString("**Answer:** ")
@@ -280,6 +519,12 @@ func buildExportMarkdown(req AdminScenarioRequest, jsonBlock string) string {
 			b.WriteString("\n\n")
 		}
 
+		if stage.Explanation != "" {
+			b.WriteString("**Explanation:** ")
+			b.WriteString(stage.Explanation)
+			b.WriteString("\n\n")
+		}
+
 		if stage.Lat != 0 || stage.Lng != 0 {
 			b.WriteString(fmt.Sprintf("**Coordinates:** %.6f, %.6f\n\n", stage.Lat, stage.Lng))
 		}
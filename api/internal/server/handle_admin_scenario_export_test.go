@@ -0,0 +1,203 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAdminScenarioZipRoundTrip(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	importZip := func(data []byte) []ScenarioImportResult {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, _ := mw.CreateFormFile("file", "scenarios.zip")
+		fw.Write(data)
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scenarios/import", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := do(req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("import: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var results []ScenarioImportResult
+		json.NewDecoder(w.Body).Decode(&results)
+		return results
+	}
+
+	originals := map[string]AdminScenarioRequest{
+		"Callao Docks": {Name: "Callao Docks", City: "Callao", Mode: "classic", Stages: []AdminStage{
+			{Location: "Pier", Clue: "Where ships rest", Question: "Year?", CorrectAnswer: "1537"},
+			{Location: "Fortress", Clue: "Stone walls", Question: "Name?", CorrectAnswer: "Real Felipe"},
+		}},
+		"Cusco Walk": {Name: "Cusco Walk", City: "Cusco", Mode: "classic", PlayCount: 1, Stages: []AdminStage{
+			{Location: "Plaza", Clue: "Main square", Question: "Color?", CorrectAnswer: "red"},
+			{Location: "Temple", Clue: "Sun", Question: "God?", CorrectAnswer: "Inti"},
+		}},
+	}
+	ids := map[string]string{}
+	for name, sc := range originals {
+		b, _ := json.Marshal(sc)
+		w := do(httptest.NewRequest(http.MethodPost, "/api/admin/scenarios", bytes.NewReader(b)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create %s: expected 201, got %d: %s", name, w.Code, w.Body.String())
+		}
+		var created AdminScenarioDetail
+		json.NewDecoder(w.Body).Decode(&created)
+		ids[name] = created.ID
+	}
+
+	w := do(httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/export-all", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, `filename="scenarios.zip"`) {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	zipData := w.Body.Bytes()
+
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+	var files []string
+	for _, f := range zr.File {
+		files = append(files, f.Name)
+	}
+	sort.Strings(files)
+	// The seeded demo scenario is exported too.
+	want := []string{"callao-docks.md", "cusco-walk.md", "lima-centro-historico.md"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Fatalf("zip files = %v, want %v", files, want)
+	}
+
+	// Remove the two scenarios, then restore them from the zip.
+	for _, id := range ids {
+		if w := do(httptest.NewRequest(http.MethodDelete, "/api/admin/scenarios/"+id, nil)); w.Code != http.StatusOK {
+			t.Fatalf("delete: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	status := map[string]string{}
+	for _, res := range importZip(zipData) {
+		status[res.File] = res.Status
+		if res.Status == "created" && res.ID == "" {
+			t.Errorf("%s: created without an ID", res.File)
+		}
+	}
+	if status["callao-docks.md"] != "created" || status["cusco-walk.md"] != "created" || status["lima-centro-historico.md"] != "collision" {
+		t.Fatalf("unexpected import results: %v", status)
+	}
+
+	var list []AdminScenarioSummary
+	json.NewDecoder(do(httptest.NewRequest(http.MethodGet, "/api/admin/scenarios", nil)).Body).Decode(&list)
+	for _, s := range list {
+		orig, ok := originals[s.Name]
+		if !ok {
+			continue
+		}
+		var got AdminScenarioDetail
+		json.NewDecoder(do(httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/"+s.ID, nil)).Body).Decode(&got)
+		if got.City != orig.City || got.PlayCount != orig.PlayCount || len(got.Stages) != len(orig.Stages) {
+			t.Errorf("%s: round trip changed scenario: %+v", s.Name, got)
+			continue
+		}
+		for i, st := range got.Stages {
+			if st.Location != orig.Stages[i].Location || st.CorrectAnswer != orig.Stages[i].CorrectAnswer {
+				t.Errorf("%s stage %d: got %+v", s.Name, i+1, st)
+			}
+		}
+		delete(originals, s.Name)
+	}
+	if len(originals) != 0 {
+		t.Errorf("scenarios missing after import: %v", originals)
+	}
+
+	// A second import of the same zip only collides.
+	for _, res := range importZip(zipData) {
+		if res.Status != "collision" {
+			t.Errorf("%s: expected collision on re-import, got %q", res.File, res.Status)
+		}
+	}
+}
+
+func TestAdminScenarioZipLimits(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	importZip := func(files map[string][]byte) *httptest.ResponseRecorder {
+		var zipData bytes.Buffer
+		zw := zip.NewWriter(&zipData)
+		for name, content := range files {
+			fw, _ := zw.Create(name)
+			fw.Write(content)
+		}
+		zw.Close()
+
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, _ := mw.CreateFormFile("file", "scenarios.zip")
+		fw.Write(zipData.Bytes())
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scenarios/import", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	many := map[string][]byte{}
+	for i := range maxZipEntries + 1 {
+		many[fmt.Sprintf("notes-%d.txt", i)] = nil
+	}
+	if w := importZip(many); w.Code != http.StatusBadRequest {
+		t.Errorf("zip with %d files: expected 400, got %d: %s", len(many), w.Code, w.Body.String())
+	}
+
+	// Each file fits on its own; together they inflate past the cap, and
+	// neither scenario is created.
+	big := map[string][]byte{}
+	for _, name := range []string{"Big A", "Big B"} {
+		b, _ := json.Marshal(AdminScenarioRequest{Name: name, City: "Lima", Mode: "classic", Stages: []AdminStage{
+			{Location: "Plaza", Clue: "Main square", Question: "Year?", CorrectAnswer: "1651"},
+		}})
+		big[name+".json"] = append(b, bytes.Repeat([]byte(" "), maxImportSize/2+1)...)
+	}
+	if w := importZip(big); w.Code != http.StatusBadRequest {
+		t.Errorf("zip inflating past the cap: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/scenarios", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var list []AdminScenarioSummary
+	json.NewDecoder(w.Body).Decode(&list)
+	for _, s := range list {
+		if strings.HasPrefix(s.Name, "Big ") {
+			t.Errorf("scenario %q created from a refused zip", s.Name)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ScenarioModeRequest is the body of POST /api/admin/scenarios/{id}/mode.
+type ScenarioModeRequest struct {
+	Mode    string `json:"mode"`
+	Version int    `json:"version"` // the version the change started from
+}
+
+// changeMode switches req to mode and carries its stages over. Fields the new
+// mode rejects (explanations, case-sensitive and strict answers outside
+// question modes) are cleared. Unlock codes and location numbers are kept, so switching
+// back doesn't invalidate printed QR codes or signs; validate generates any
+// unlock codes a QR mode is missing. Stages the new mode can't play are all
+// named in the returned message rather than one at a time.
+// Attachments go with the explanations.
+func (req *AdminScenarioRequest) changeMode(mode string) string {
+	if !validModes[mode] {
+		return "mode must be one of: classic, qr_quiz, qr_hunt, math_puzzle, supervised"
+	}
+	req.Mode = mode
+
+	hasQuestion := modeHasQuestion(mode)
+	var noQuestion, noLocationNumber []string
+	for i := range req.Stages {
+		s := &req.Stages[i]
+		if !hasQuestion {
+			s.Explanation = ""
+			s.CaseSensitive = false
+			s.StrictAnswers = false
+			s.AnswerType, s.AcceptedAnswers, s.ListDelimiter, s.ListUnordered = "", nil, "", false
+			s.NumericTolerance = 0
+			s.Attachments = nil
+		} else if strings.TrimSpace(s.Question) == "" || strings.TrimSpace(s.CorrectAnswer) == "" {
+			noQuestion = append(noQuestion, strconv.Itoa(i+1))
+		}
+		if mode == "math_puzzle" && s.LocationNumber == 0 {
+			noLocationNumber = append(noLocationNumber, strconv.Itoa(i+1))
+		}
+	}
+	if len(noQuestion) > 0 {
+		return fmt.Sprintf("%s requires a question and correctAnswer on every stage; missing on stages %s", mode, strings.Join(noQuestion, ", "))
+	}
+	if len(noLocationNumber) > 0 {
+		return fmt.Sprintf("math_puzzle requires a locationNumber on every stage; missing on stages %s", strings.Join(noLocationNumber, ", "))
+	}
+	return ""
+}
+
+// handleAdminChangeScenarioMode moves a saved scenario to another mode,
+// adjusting its stages (see changeMode) and re-validating before saving.
+// Games already created from the scenario keep the mode they were created
+// with.
+func handleAdminChangeScenarioMode(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var body ScenarioModeRequest
+		if err := readJSON(r, &body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if body.Version < 1 {
+			writeError(w, http.StatusBadRequest, "version is required")
+			return
+		}
+
+		sc, err := admin.GetScenario(r.Context(), id)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "scenario not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		req := AdminScenarioRequest{
+			Name:        sc.Name,
+			City:        sc.City,
+			Description: sc.Description,
+			PlayCount:   sc.PlayCount,
+			Public:      sc.Public,
+			Stages:      sc.Stages,
+			Version:     body.Version,
+		}
+		if msg := req.changeMode(strings.TrimSpace(body.Mode)); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		if msg := req.validate(); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		scenario, err := admin.UpdateScenario(r.Context(), id, req)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "scenario not found")
+			return
+		}
+		if errors.Is(err, ErrVersionConflict) {
+			writeError(w, http.StatusConflict, "scenario was modified by someone else; reload and try again")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		scenario.Warnings = req.warnings()
+
+		writeJSON(w, http.StatusOK, scenario)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminChangeScenarioMode(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/admin/scenarios/", AdminScenarioRequest{
+		Name: "Switching",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "Plaza", Question: "Year?", CorrectAnswer: "1651", Explanation: "Rebuilt.", CaseSensitive: true, Attachments: []Attachment{{Label: "Map", URL: "/uploads/map.png"}}},
+			{Location: "Church", Question: "Saint?", CorrectAnswer: "Rosa", LocationNumber: 20},
+		},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create scenario: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var sc AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&sc)
+	path := "/api/admin/scenarios/" + sc.ID + "/mode"
+
+	change := func(mode string) AdminScenarioDetail {
+		t.Helper()
+		w := post(path, ScenarioModeRequest{Mode: mode, Version: sc.Version})
+		if w.Code != http.StatusOK {
+			t.Fatalf("change to %s: expected 200, got %d: %s", mode, w.Code, w.Body.String())
+		}
+		var got AdminScenarioDetail
+		json.NewDecoder(w.Body).Decode(&got)
+		if got.Mode != mode {
+			t.Fatalf("change to %s: mode is %q", mode, got.Mode)
+		}
+		sc = got
+		return got
+	}
+
+	// classic → qr_quiz: every stage gets an unlock code, answers stay.
+	got := change("qr_quiz")
+	codes := make([]string, len(got.Stages))
+	for i, s := range got.Stages {
+		if s.UnlockCode == "" || s.CorrectAnswer == "" {
+			t.Errorf("qr_quiz stage %d = %+v, want an unlock code and the answer kept", i+1, s)
+		}
+		codes[i] = s.UnlockCode
+	}
+
+	// qr_quiz → qr_hunt: answer-only fields go, the unlock codes stay.
+	got = change("qr_hunt")
+	for i, s := range got.Stages {
+		if s.Explanation != "" || s.CaseSensitive || s.Attachments != nil {
+			t.Errorf("qr_hunt stage %d = %+v, want explanation, caseSensitive and attachments cleared", i+1, s)
+		}
+		if s.UnlockCode != codes[i] {
+			t.Errorf("qr_hunt stage %d: unlock code %q, want %q kept", i+1, s.UnlockCode, codes[i])
+		}
+	}
+
+	// qr_hunt → math_puzzle: stage 1 has no location number, and the error says so.
+	w = post(path, ScenarioModeRequest{Mode: "math_puzzle", Version: sc.Version})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "locationNumber on every stage; missing on stages 1") {
+		t.Errorf("change to math_puzzle: expected 400 naming stage 1, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// qr_hunt → supervised: the questions are still there, so it goes back.
+	change("supervised")
+
+	// A stale version or an unknown mode changes nothing.
+	if w := post(path, ScenarioModeRequest{Mode: "classic", Version: sc.Version - 1}); w.Code != http.StatusConflict {
+		t.Errorf("stale version: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(path, ScenarioModeRequest{Mode: "treasure", Version: sc.Version}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown mode: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post("/api/admin/scenarios/nope/mode", ScenarioModeRequest{Mode: "classic", Version: 1}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown scenario: expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestChangeModeMissingQuestions(t *testing.T) {
+	req := AdminScenarioRequest{
+		Name: "Hunt",
+		City: "Lima",
+		Mode: "qr_hunt",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Year?", CorrectAnswer: "1651"},
+			{Location: "B"},
+			{Location: "C", Question: "Saint?"},
+		},
+	}
+	msg := req.changeMode("classic")
+	if msg != "classic requires a question and correctAnswer on every stage; missing on stages 2, 3" {
+		t.Errorf("changeMode = %q", msg)
+	}
+}
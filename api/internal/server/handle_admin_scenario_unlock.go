@@ -0,0 +1,87 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestUnlockRequest is the body of POST /api/admin/scenarios/{id}/test-unlock:
+// a scanned code and how many stages the simulated team has done.
+type TestUnlockRequest struct {
+	Code     string `json:"code"`
+	Progress int    `json:"progress"` // stages already done; 0 = on stage 1
+}
+
+// TestUnlockResponse says what scanning the code would do for a team on
+// StageNumber. UnlockedStages lists the stages it would open (several when
+// they share the code); StageComplete is set in qr_hunt, where the scan also
+// completes them. A code that doesn't match names the stage it belongs to in
+// CodeStage, if any, so a misplaced QR code is easy to spot.
+type TestUnlockResponse struct {
+	StageNumber    int   `json:"stageNumber"`
+	Matches        bool  `json:"matches"`
+	UnlockedStages []int `json:"unlockedStages,omitempty"`
+	StageComplete  bool  `json:"stageComplete,omitempty"`
+	CodeStage      int   `json:"codeStage,omitempty"`
+}
+
+// handleAdminTestUnlock checks a QR code against a saved qr_quiz or qr_hunt
+// scenario without a game: it compares the code with the stage a team would
+// be on the way handleUnlock does and changes nothing. Stages are in scenario
+// order, as for a team starting on stage 1.
+func handleAdminTestUnlock(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var req TestUnlockRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		req.Code = strings.TrimSpace(req.Code)
+		if req.Code == "" {
+			writeError(w, http.StatusBadRequest, "code is required")
+			return
+		}
+
+		sc, err := admin.GetScenario(r.Context(), id)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "scenario not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if unlockMethod(sc.Mode) != "scan" {
+			writeError(w, http.StatusConflict, "scenario mode does not unlock with QR codes")
+			return
+		}
+		if req.Progress < 0 || req.Progress >= len(sc.Stages) {
+			writeError(w, http.StatusBadRequest, "progress must be between 0 and the number of stages minus one")
+			return
+		}
+
+		stages := make([]scenarioStage, len(sc.Stages))
+		for i, s := range sc.Stages {
+			stages[i] = scenarioStage{StageNumber: i + 1, UnlockCode: s.UnlockCode}
+		}
+		resp := TestUnlockResponse{StageNumber: req.Progress + 1}
+		if unlockCodeMatches(req.Code, stages[req.Progress].UnlockCode) {
+			resp.Matches = true
+			resp.UnlockedStages = sharedCodeRun(stages, resp.StageNumber, 0)
+			resp.StageComplete = sc.Mode == "qr_hunt"
+		} else {
+			for _, s := range stages {
+				if unlockCodeMatches(req.Code, s.UnlockCode) {
+					resp.CodeStage = s.StageNumber
+					break
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
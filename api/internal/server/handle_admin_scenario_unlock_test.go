@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestAdminTestUnlock(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	create := func(mode string, stages []AdminStage) string {
+		t.Helper()
+		w := post("/api/admin/scenarios/", AdminScenarioRequest{Name: "Codes " + mode, City: "Lima", Mode: mode, Stages: stages})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create %s scenario: expected 201, got %d: %s", mode, w.Code, w.Body.String())
+		}
+		var sc AdminScenarioDetail
+		json.NewDecoder(w.Body).Decode(&sc)
+		return sc.ID
+	}
+
+	// Stages 2 and 3 share a code, so one scan opens both.
+	quiz := create("qr_quiz", []AdminStage{
+		{Location: "A", Question: "1?", CorrectAnswer: "1", UnlockCode: "CODE-A"},
+		{Location: "B", Question: "2?", CorrectAnswer: "2", UnlockCode: "CODE-B"},
+		{Location: "B", Question: "3?", CorrectAnswer: "3", UnlockCode: "CODE-B"},
+	})
+	hunt := create("qr_hunt", []AdminStage{
+		{Location: "A", UnlockCode: "HUNT-A"},
+		{Location: "B", UnlockCode: "HUNT-B"},
+	})
+	classic := create("classic", []AdminStage{
+		{Location: "A", Question: "1?", CorrectAnswer: "1"},
+	})
+
+	for _, tc := range []struct {
+		name string
+		id   string
+		req  TestUnlockRequest
+		want TestUnlockResponse
+	}{
+		{"match", quiz, TestUnlockRequest{Code: "CODE-A"}, TestUnlockResponse{StageNumber: 1, Matches: true, UnlockedStages: []int{1}}},
+		{"case-insensitive", quiz, TestUnlockRequest{Code: " code-a "}, TestUnlockResponse{StageNumber: 1, Matches: true, UnlockedStages: []int{1}}},
+		{"shared code", quiz, TestUnlockRequest{Code: "CODE-B", Progress: 1}, TestUnlockResponse{StageNumber: 2, Matches: true, UnlockedStages: []int{2, 3}}},
+		{"another stage's code", quiz, TestUnlockRequest{Code: "CODE-B"}, TestUnlockResponse{StageNumber: 1, CodeStage: 2}},
+		{"unknown code", quiz, TestUnlockRequest{Code: "NOPE", Progress: 2}, TestUnlockResponse{StageNumber: 3}},
+		{"hunt completes", hunt, TestUnlockRequest{Code: "HUNT-B", Progress: 1}, TestUnlockResponse{StageNumber: 2, Matches: true, UnlockedStages: []int{2}, StageComplete: true}},
+	} {
+		w := post("/api/admin/scenarios/"+tc.id+"/test-unlock", tc.req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", tc.name, w.Code, w.Body.String())
+			continue
+		}
+		var got TestUnlockResponse
+		json.NewDecoder(w.Body).Decode(&got)
+		if got.StageNumber != tc.want.StageNumber || got.Matches != tc.want.Matches || got.StageComplete != tc.want.StageComplete ||
+			got.CodeStage != tc.want.CodeStage || !slices.Equal(got.UnlockedStages, tc.want.UnlockedStages) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		id   string
+		req  TestUnlockRequest
+		code int
+	}{
+		{"no code", quiz, TestUnlockRequest{}, http.StatusBadRequest},
+		{"progress past the end", quiz, TestUnlockRequest{Code: "CODE-A", Progress: 3}, http.StatusBadRequest},
+		{"negative progress", quiz, TestUnlockRequest{Code: "CODE-A", Progress: -1}, http.StatusBadRequest},
+		{"mode without QR codes", classic, TestUnlockRequest{Code: "CODE-A"}, http.StatusConflict},
+		{"unknown scenario", "nope", TestUnlockRequest{Code: "CODE-A"}, http.StatusNotFound},
+	} {
+		if w := post("/api/admin/scenarios/"+tc.id+"/test-unlock", tc.req); w.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.code, w.Code, w.Body.String())
+		}
+	}
+}
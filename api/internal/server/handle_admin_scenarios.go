@@ -1,13 +1,14 @@
 package server
 
 import (
-	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -29,6 +30,13 @@ func (f *FunFact) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, (*plain)(f))
 }
 
+// Attachment is a file players can download with a stage's question, such
+// as a map or a worksheet.
+type Attachment struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
 var validModes = map[string]bool{
 	"classic":      true,
 	"qr_quiz":      true,
@@ -44,6 +52,7 @@ type AdminScenarioSummary struct {
 	Description  string `json:"description"`
 	Mode         string `json:"mode"`
 	StageCount   int    `json:"stageCount"`
+	Public       bool   `json:"public"`
 	CreatedAt    string `json:"createdAt"`
 }
 
@@ -53,23 +62,125 @@ type AdminScenarioDetail struct {
 	City         string       `json:"city"`
 	Description  string       `json:"description"`
 	Mode         string       `json:"mode"`
+	PlayCount    int          `json:"playCount,omitempty"`
+	Public       bool         `json:"public"`
 	Stages       []AdminStage `json:"stages"`
 	CreatedAt    string       `json:"createdAt"`
+	Version      int          `json:"version"`
+	Warnings     []string     `json:"warnings,omitempty"` // set on create/update only
 }
 
 type AdminStage struct {
-	StageNumber    int       `json:"stageNumber"`
-	Location       string    `json:"location"`
-	Clue           string    `json:"clue"`
-	ClueImage      string    `json:"clueImage,omitempty"`
-	Question       string    `json:"question"`
-	QuestionImage  string    `json:"questionImage,omitempty"`
-	CorrectAnswer  string    `json:"correctAnswer"`
-	UnlockCode     string    `json:"unlockCode,omitempty"`
-	LocationNumber int       `json:"locationNumber,omitempty"`
-	FunFacts       []FunFact `json:"funFacts,omitempty"`
-	Lat            float64   `json:"lat"`
-	Lng            float64   `json:"lng"`
+	StageNumber    int    `json:"stageNumber"`
+	Location       string `json:"location"`
+	Clue           string `json:"clue"`
+	ClueImage      string `json:"clueImage,omitempty"`
+	Question       string `json:"question"`
+	QuestionImage  string `json:"questionImage,omitempty"`
+	CorrectAnswer  string `json:"correctAnswer"`
+	Explanation    string `json:"explanation,omitempty"` // shown with the result, right or wrong
+	UnlockCode     string `json:"unlockCode,omitempty"`
+	LocationNumber int    `json:"locationNumber,omitempty"`
+	CaseSensitive  bool   `json:"caseSensitive,omitempty"` // compare the answer with exact case
+	StrictAnswers  bool   `json:"strictAnswers,omitempty"` // compare the answer as typed: no space collapsing, no game leniencies
+	// AnswerType "list" takes several parts in one answer, split on
+	// ListDelimiter (default ",") and compared with AcceptedAnswers, in order
+	// unless ListUnordered. CorrectAnswer is then derived from the parts.
+	// AnswerType "number" accepts any number within NumericTolerance of
+	// CorrectAnswer.
+	AnswerType       string       `json:"answerType,omitempty" enum:"text,list,number"`
+	AcceptedAnswers  []string     `json:"acceptedAnswers,omitempty"`
+	ListDelimiter    string       `json:"listDelimiter,omitempty"`
+	ListUnordered    bool         `json:"listUnordered,omitempty"`
+	NumericTolerance float64      `json:"numericTolerance,omitempty"`
+	Points           int          `json:"points,omitempty"`      // scored for a correct result; 0 means defaultStagePoints
+	Attachments      []Attachment `json:"attachments,omitempty"` // shown with the question
+	FunFacts         []FunFact    `json:"funFacts,omitempty"`
+	Lat              float64      `json:"lat"`
+	Lng              float64      `json:"lng"`
+}
+
+// points is what a correct result on the stage scores before any decay.
+func (s AdminStage) points() int {
+	if s.Points > 0 {
+		return s.Points
+	}
+	return defaultStagePoints
+}
+
+// matchesAnswer is scenarioStage.matchesAnswer for a game's stage snapshot.
+func (s AdminStage) matchesAnswer(answer string, rules answerRules) bool {
+	rules = rules.forStage(s.CaseSensitive, s.StrictAnswers)
+	switch s.AnswerType {
+	case answerTypeList:
+		return listAnswerMatches(answer, s.AcceptedAnswers, s.ListDelimiter, s.ListUnordered, rules)
+	case answerTypeNumber:
+		return numericAnswerMatches(answer, s.CorrectAnswer, s.NumericTolerance)
+	}
+	return answerMatches(answer, s.CorrectAnswer, rules)
+}
+
+// answerKey groups answers to the stage the way matchesAnswer compares them.
+func (s AdminStage) answerKey(answer string, rules answerRules) string {
+	rules = rules.forStage(s.CaseSensitive, s.StrictAnswers)
+	switch s.AnswerType {
+	case answerTypeList:
+		return listAnswerKey(answer, s.ListDelimiter, s.ListUnordered, rules)
+	case answerTypeNumber:
+		return numericAnswerKey(answer, rules)
+	}
+	return answerKey(answer, rules)
+}
+
+// tidyAttachments trims a stage's attachments and checks them. Their URLs
+// follow the stage images' rules, so exports can embed uploaded files.
+func (s *AdminStage) tidyAttachments() string {
+	if len(s.Attachments) > maxAttachments {
+		return fmt.Sprintf("stage %d: at most %d attachments are allowed", s.StageNumber, maxAttachments)
+	}
+	for j := range s.Attachments {
+		a := &s.Attachments[j]
+		a.Label = strings.TrimSpace(a.Label)
+		a.URL = strings.TrimSpace(a.URL)
+		if a.Label == "" || a.URL == "" {
+			return fmt.Sprintf("stage %d: each attachment needs a label and a url", s.StageNumber)
+		}
+		if utf8.RuneCountInString(a.Label) > maxAttachmentLabelLen {
+			return fmt.Sprintf("stage %d: attachment labels must be at most %d characters", s.StageNumber, maxAttachmentLabelLen)
+		}
+		if !validImageURL(a.URL) {
+			return fmt.Sprintf("stage %d: attachment %q must be an upload or an http(s) URL", s.StageNumber, a.Label)
+		}
+	}
+	return ""
+}
+
+// setListAnswer checks and tidies a list stage's parts and derives
+// CorrectAnswer from them, so results and recaps show the expected list.
+func (s *AdminStage) setListAnswer() string {
+	if s.ListDelimiter == "" {
+		s.ListDelimiter = defaultListDelimiter
+	}
+	var parts []string
+	for _, p := range s.AcceptedAnswers {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if strings.Contains(p, s.ListDelimiter) {
+			return fmt.Sprintf("stage %d: answer part %q contains the delimiter %q", s.StageNumber, p, s.ListDelimiter)
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("stage %d: a list answer needs at least one acceptedAnswers entry", s.StageNumber)
+	}
+	s.AcceptedAnswers = parts
+	sep := s.ListDelimiter
+	if strings.TrimSpace(sep) != "" {
+		sep += " "
+	}
+	s.CorrectAnswer = strings.Join(parts, sep)
+	return ""
 }
 
 type AdminScenarioRequest struct {
@@ -77,15 +188,66 @@ type AdminScenarioRequest struct {
 	City         string       `json:"city"`
 	Description  string       `json:"description"`
 	Mode         string       `json:"mode"`
+	PlayCount    int          `json:"playCount,omitempty"` // stages each team plays from the pool; 0 = all
+	Public       bool         `json:"public"`              // listed on GET /api/public/scenarios
 	Stages       []AdminStage `json:"stages"`
+	Version      int          `json:"version,omitempty"` // required on update: the version the edit started from
+
+	defaultMode string // the client's default mode, used when Mode is empty
+}
+
+// ScenarioValidation is the response for POST /api/admin/scenarios/validate.
+// Error is the first blocking problem; warnings never block a save.
+type ScenarioValidation struct {
+	Valid    bool     `json:"valid"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings"`
 }
 
+// maxExplanationLen caps a stage's explanation, in characters. It is shown
+// on a phone screen under the result, so it should stay a short note.
+const maxExplanationLen = 1000
+
+// maxAttachments caps a stage's attachments, and maxAttachmentLabelLen the
+// characters of each one's label.
+const (
+	maxAttachments        = 10
+	maxAttachmentLabelLen = 100
+)
+
+// defaultStagePoints is what a correct result scores on a stage without its
+// own points, so unset points rank teams by correct answers as before.
+// maxStagePoints caps a stage's points.
+const (
+	defaultStagePoints = 100
+	maxStagePoints     = 10000
+)
+
+// shortAnswerLen is the answer length (in characters) below which a
+// correctAnswer is flagged as easy to guess.
+const shortAnswerLen = 3
+
 func generateUnlockCode() string {
 	b := make([]byte, 6)
-	rand.Read(b)
+	randRead(b)
 	return hex.EncodeToString(b)
 }
 
+// validImageURL reports whether a stage image is one players can load: a
+// file from POST /api/admin/uploads, an absolute http(s) URL, or an image
+// data URI from a scenario export, which import saves as an upload. Empty
+// means no image.
+func validImageURL(s string) bool {
+	if s == "" || strings.HasPrefix(s, "data:image/") {
+		return true
+	}
+	if name, ok := strings.CutPrefix(s, "/uploads/"); ok {
+		return name != "" && !strings.ContainsAny(name, "/\\") && !strings.HasPrefix(name, ".")
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
 func (req *AdminScenarioRequest) validate() string {
 	req.Name = strings.TrimSpace(req.Name)
 	req.City = strings.TrimSpace(req.City)
@@ -96,6 +258,9 @@ func (req *AdminScenarioRequest) validate() string {
 	if req.City == "" {
 		return "city is required"
 	}
+	if req.Mode == "" {
+		req.Mode = req.defaultMode
+	}
 	if req.Mode == "" {
 		req.Mode = "supervised"
 	}
@@ -105,6 +270,9 @@ func (req *AdminScenarioRequest) validate() string {
 	if len(req.Stages) == 0 {
 		return "at least one stage is required"
 	}
+	if req.PlayCount < 0 || (req.PlayCount > 0 && req.PlayCount >= len(req.Stages)) {
+		return "playCount must be less than the number of stages"
+	}
 
 	needsQuestion := req.Mode == "classic" || req.Mode == "qr_quiz" || req.Mode == "supervised"
 	needsUnlockCode := req.Mode == "qr_quiz" || req.Mode == "qr_hunt"
@@ -115,6 +283,53 @@ func (req *AdminScenarioRequest) validate() string {
 		if strings.TrimSpace(req.Stages[i].Location) == "" {
 			return "each stage must have a location"
 		}
+		req.Stages[i].ClueImage = strings.TrimSpace(req.Stages[i].ClueImage)
+		if !validImageURL(req.Stages[i].ClueImage) {
+			return fmt.Sprintf("stage %d: clueImage must be an uploaded image or an http(s) URL", i+1)
+		}
+		req.Stages[i].QuestionImage = strings.TrimSpace(req.Stages[i].QuestionImage)
+		if !validImageURL(req.Stages[i].QuestionImage) {
+			return fmt.Sprintf("stage %d: questionImage must be an uploaded image or an http(s) URL", i+1)
+		}
+		if req.Stages[i].Points < 0 || req.Stages[i].Points > maxStagePoints {
+			return fmt.Sprintf("stage %d: points must be between 0 and %d", i+1, maxStagePoints)
+		}
+		if msg := req.Stages[i].tidyAttachments(); msg != "" {
+			return msg
+		}
+		if len(req.Stages[i].Attachments) > 0 && !needsQuestion {
+			return fmt.Sprintf("stage %d: attachments only apply to modes with answers", i+1)
+		}
+		switch req.Stages[i].AnswerType {
+		case "", "text":
+			req.Stages[i].AnswerType = ""
+			if len(req.Stages[i].AcceptedAnswers) > 0 || req.Stages[i].ListDelimiter != "" || req.Stages[i].ListUnordered {
+				return fmt.Sprintf("stage %d: acceptedAnswers, listDelimiter and listUnordered only apply to list answers", i+1)
+			}
+		case answerTypeList:
+			if !needsQuestion {
+				return fmt.Sprintf("stage %d: answerType only applies to modes with answers", i+1)
+			}
+			if msg := req.Stages[i].setListAnswer(); msg != "" {
+				return msg
+			}
+		case answerTypeNumber:
+			if !needsQuestion {
+				return fmt.Sprintf("stage %d: answerType only applies to modes with answers", i+1)
+			}
+			req.Stages[i].CorrectAnswer = strings.TrimSpace(req.Stages[i].CorrectAnswer)
+			if _, ok := parseNumber(req.Stages[i].CorrectAnswer); !ok {
+				return fmt.Sprintf("stage %d: correctAnswer %q is not a number", i+1, req.Stages[i].CorrectAnswer)
+			}
+			if req.Stages[i].NumericTolerance < 0 {
+				return fmt.Sprintf("stage %d: numericTolerance must not be negative", i+1)
+			}
+		default:
+			return fmt.Sprintf("stage %d: answerType must be text, list or number", i+1)
+		}
+		if req.Stages[i].AnswerType != answerTypeNumber && req.Stages[i].NumericTolerance != 0 {
+			return fmt.Sprintf("stage %d: numericTolerance only applies to number answers", i+1)
+		}
 		if needsQuestion {
 			if strings.TrimSpace(req.Stages[i].Question) == "" {
 				return "each stage must have a question"
@@ -122,6 +337,17 @@ func (req *AdminScenarioRequest) validate() string {
 			if strings.TrimSpace(req.Stages[i].CorrectAnswer) == "" {
 				return "each stage must have a correctAnswer"
 			}
+		} else if req.Stages[i].CaseSensitive {
+			return fmt.Sprintf("stage %d: caseSensitive only applies to modes with answers", i+1)
+		} else if req.Stages[i].StrictAnswers {
+			return fmt.Sprintf("stage %d: strictAnswers only applies to modes with answers", i+1)
+		}
+		req.Stages[i].Explanation = strings.TrimSpace(req.Stages[i].Explanation)
+		if req.Stages[i].Explanation != "" && !needsQuestion {
+			return fmt.Sprintf("stage %d: explanation only applies to modes with answers", i+1)
+		}
+		if utf8.RuneCountInString(req.Stages[i].Explanation) > maxExplanationLen {
+			return fmt.Sprintf("stage %d: explanation must be at most %d characters", i+1, maxExplanationLen)
 		}
 		if needsUnlockCode {
 			req.Stages[i].UnlockCode = strings.TrimSpace(req.Stages[i].UnlockCode)
@@ -136,6 +362,35 @@ func (req *AdminScenarioRequest) validate() string {
 	return ""
 }
 
+// warnings flags things in a valid scenario that are allowed but probably
+// unintended: stages without a clue, stages sharing a location, and answers
+// short enough to be guessed. Call after validate.
+func (req *AdminScenarioRequest) warnings() []string {
+	warnings := []string{}
+	needsQuestion := req.Mode == "classic" || req.Mode == "qr_quiz" || req.Mode == "supervised"
+
+	firstAt := make(map[string]int, len(req.Stages))
+	for i, s := range req.Stages {
+		n := i + 1
+		if strings.TrimSpace(s.Clue) == "" && s.ClueImage == "" {
+			warnings = append(warnings, fmt.Sprintf("stage %d has no clue", n))
+		}
+
+		loc := strings.ToLower(strings.TrimSpace(s.Location))
+		if prev, ok := firstAt[loc]; ok {
+			warnings = append(warnings, fmt.Sprintf("stage %d has the same location as stage %d (%q)", n, prev, strings.TrimSpace(s.Location)))
+		} else {
+			firstAt[loc] = n
+		}
+
+		answer := strings.TrimSpace(s.CorrectAnswer)
+		if needsQuestion && utf8.RuneCountInString(answer) < shortAnswerLen {
+			warnings = append(warnings, fmt.Sprintf("stage %d has a very short answer (%q) that is easy to guess", n, answer))
+		}
+	}
+	return warnings
+}
+
 func handleAdminListScenarios(admin AdminStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		scenarios, err := admin.ListScenarios(r.Context())
@@ -151,6 +406,22 @@ func handleAdminListScenarios(admin AdminStore) http.HandlerFunc {
 	}
 }
 
+// clientDefaultMode looks up the default scenario mode of the client named
+// by the ?client= query parameter. Scenarios are global, so the parameter is
+// how the admin UI says which client it is creating one for. It returns ""
+// without the parameter.
+func clientDefaultMode(r *http.Request, admin AdminStore) (string, error) {
+	slug := r.URL.Query().Get("client")
+	if slug == "" {
+		return "", nil
+	}
+	c, err := admin.GetClient(r.Context(), slug)
+	if err != nil {
+		return "", err
+	}
+	return c.DefaultMode, nil
+}
+
 func handleAdminCreateScenario(admin AdminStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req AdminScenarioRequest
@@ -158,6 +429,16 @@ func handleAdminCreateScenario(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
+		mode, err := clientDefaultMode(r, admin)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "client not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		req.defaultMode = mode
 		if msg := req.validate(); msg != "" {
 			writeError(w, http.StatusBadRequest, msg)
 			return
@@ -168,11 +449,40 @@ func handleAdminCreateScenario(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		scenario.Warnings = req.warnings()
 
 		writeJSON(w, http.StatusCreated, scenario)
 	}
 }
 
+// handleAdminValidateScenario checks a scenario without saving it, returning
+// the blocking error (if any) and non-blocking warnings.
+func handleAdminValidateScenario(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AdminScenarioRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		mode, err := clientDefaultMode(r, admin)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "client not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		req.defaultMode = mode
+
+		if msg := req.validate(); msg != "" {
+			writeJSON(w, http.StatusOK, ScenarioValidation{Error: msg, Warnings: []string{}})
+			return
+		}
+		writeJSON(w, http.StatusOK, ScenarioValidation{Valid: true, Warnings: req.warnings()})
+	}
+}
+
 func handleAdminGetScenario(admin AdminStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -204,21 +514,55 @@ func handleAdminUpdateScenario(admin AdminStore) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, msg)
 			return
 		}
+		if req.Version < 1 {
+			writeError(w, http.StatusBadRequest, "version is required")
+			return
+		}
 
 		scenario, err := admin.UpdateScenario(r.Context(), id, req)
 		if errors.Is(err, ErrNotFound) {
 			writeError(w, http.StatusNotFound, "scenario not found")
 			return
 		}
+		if errors.Is(err, ErrVersionConflict) {
+			writeError(w, http.StatusConflict, "scenario was modified by someone else; reload and try again")
+			return
+		}
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
+		scenario.Warnings = req.warnings()
 
 		writeJSON(w, http.StatusOK, scenario)
 	}
 }
 
+// handleAdminScenarioUsage lists the games, across all clients, that were
+// created from a scenario — the ones that block deleting it.
+func handleAdminScenarioUsage(admin AdminStore, clients *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		if _, err := admin.GetScenario(r.Context(), id); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				writeError(w, http.StatusNotFound, "scenario not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		usage, err := admin.ScenarioUsage(r.Context(), id, clients)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, usage)
+	}
+}
+
 func handleAdminDeleteScenario(admin AdminStore, clients *Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -229,6 +573,7 @@ func handleAdminDeleteScenario(admin AdminStore, clients *Registry) http.Handler
 			return
 		}
 		if hasGames {
+			// GET .../usage lists the games in the way.
 			writeError(w, http.StatusConflict, "cannot delete scenario with existing games")
 			return
 		}
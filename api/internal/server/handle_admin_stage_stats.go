@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminStageStats aggregates every team's recorded answers for one scenario
+// stage. Teams reach stages in different orders (start-stage rotation,
+// question pools), so results are mapped back to the scenario stage before
+// counting. Each team records one answer per stage, which makes
+// AvgAttemptsToCorrect attempts per correct answer: 1 means every team that
+// answered got it right, 0 means nobody did (or nobody got there yet).
+type AdminStageStats struct {
+	StageNumber          int     `json:"stageNumber"`
+	Location             string  `json:"location"`
+	Attempts             int     `json:"attempts"`
+	Correct              int     `json:"correct"`
+	Wrong                int     `json:"wrong"`
+	AvgAttemptsToCorrect float64 `json:"avgAttemptsToCorrect"`
+}
+
+func handleAdminStageStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		stats, err := store.StageStats(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, stats)
+	}
+}
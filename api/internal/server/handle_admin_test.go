@@ -4,25 +4,38 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
 func adminRouter(t *testing.T) (*chi.Mux, func() []*http.Cookie) {
+	t.Helper()
+	r, login, _, _ := adminRouterWithStore(t)
+	return r, login
+}
+
+// adminRouterWithStore is adminRouter also returning the demo client's store
+// and the broker its handlers publish to.
+func adminRouterWithStore(t *testing.T) (*chi.Mux, func() []*http.Cookie, *DocStore, *Broker) {
 	t.Helper()
 	admin, store := setupStores(t)
 
 	// Build a minimal registry with "demo" pointing to the test store.
-	registry := NewRegistry(t.TempDir())
+	registry := NewRegistry(t.TempDir(), 1, 0)
 	registry.mu.Lock()
 	registry.stores["demo"] = store
 	registry.mu.Unlock()
 
 	r := chi.NewRouter()
+	idem := NewIdempotencyCache()
+	dataDir := t.TempDir()
 
 	// Inject store into context for client-scoped routes.
 	injectStore := func(next http.Handler) http.Handler {
@@ -36,38 +49,64 @@ func adminRouter(t *testing.T) (*chi.Mux, func() []*http.Cookie) {
 	r.Post("/api/admin/login", handleAdminLogin(admin))
 	r.Post("/api/admin/logout", handleAdminLogout(admin))
 	r.Get("/api/admin/me", handleAdminMe(admin))
+	r.Post("/api/admin/clients", handleAdminCreateClient(admin, registry))
 
 	// Admin scenarios — global.
 	r.Route("/api/admin/scenarios", func(r chi.Router) {
 		r.Use(adminAuthMiddleware(admin))
 		r.Get("/", handleAdminListScenarios(admin))
-		r.Post("/", handleAdminCreateScenario(admin))
+		r.With(idempotent(idem)).Post("/", handleAdminCreateScenario(admin))
+		r.Post("/validate", handleAdminValidateScenario(admin))
+		r.Get("/export-all", handleAdminExportAllScenarios(admin, dataDir))
+		r.Post("/import", handleAdminImportScenario(admin, dataDir))
 		r.Get("/{id}", handleAdminGetScenario(admin))
+		r.Get("/{id}/usage", handleAdminScenarioUsage(admin, registry))
 		r.Put("/{id}", handleAdminUpdateScenario(admin))
+		r.Post("/{id}/mode", handleAdminChangeScenarioMode(admin))
+		r.Post("/{id}/test-unlock", handleAdminTestUnlock(admin))
 		r.Delete("/{id}", handleAdminDeleteScenario(admin, registry))
 	})
 
+	broker := NewBroker()
+
 	// Admin CRUD — per-client (inject store + admin middleware).
 	r.Route("/api/admin/clients/{client}", func(r chi.Router) {
 		r.Use(adminAuthMiddleware(admin))
 		r.Use(injectStore)
 
 		r.Get("/games", handleAdminListGames())
-		r.Post("/games", handleAdminCreateGame(admin))
+		r.With(idempotent(idem)).Post("/games", handleAdminCreateGame(admin, TimerLimits{GameMinutes: 1440, StageMinutes: 120}))
 		r.Get("/games/{gameID}", handleAdminGetGame())
-		r.Put("/games/{gameID}", handleAdminUpdateGame(admin))
+		r.Put("/games/{gameID}", handleAdminUpdateGame(admin, TimerLimits{GameMinutes: 1440, StageMinutes: 120}))
 		r.Delete("/games/{gameID}", handleAdminDeleteGame())
 		r.Get("/games/{gameID}/teams", handleAdminListTeams())
-		r.Post("/games/{gameID}/teams", handleAdminCreateTeam())
+		r.With(idempotent(idem)).Post("/games/{gameID}/teams", handleAdminCreateTeam(admin))
 		r.Put("/games/{gameID}/teams/{teamID}", handleAdminUpdateTeam())
 		r.Delete("/games/{gameID}/teams/{teamID}", handleAdminDeleteTeam())
+		r.Get("/games/{gameID}/status", handleAdminGameStatus(broker))
+		r.Post("/games/{gameID}/regrade", handleAdminRegradeGame())
+		r.Post("/games/{gameID}/repair-stages", handleAdminRepairStages(admin))
+		r.Post("/games/{gameID}/clone", handleAdminCloneGame(admin))
+		r.Get("/games/{gameID}/stage-stats", handleAdminStageStats())
+		r.Get("/games/{gameID}/answers", handleAdminAnswerLog())
+		r.Get("/games/{gameID}/results.csv", handleAdminGameResultsCSV())
+		r.Get("/games/{gameID}/event-log", handleAdminEventLog())
+		r.Get("/games/{gameID}/timeline", handleAdminTimeline())
+		r.Get("/games/{gameID}/teams/{teamID}/sessions", handleAdminTeamSessions())
+		r.Post("/games/{gameID}/teams/{teamID}/unlock", handleAdminUnlockTeamStage(broker))
+		r.Post("/games/{gameID}/broadcast", handleAdminBroadcast(broker))
+		r.Get("/cleanup-preview", handleAdminCleanupPreview(broker, 0))
+		r.Get("/branding", handleAdminGetBranding(admin))
+		r.Put("/branding", handleAdminUpdateBranding(admin))
+		r.Delete("/branding", handleAdminDeleteBranding(admin))
 	})
 
-	// Player join (for tests that need to add players).
-	broker := NewBroker()
+	// Player routes (for tests that need to add players and answers).
 	r.Route("/api/{client}", func(r chi.Router) {
 		r.Use(injectStore)
+		r.Get("/branding", handleBranding(admin))
 		r.Post("/join", handleJoin(broker))
+		r.Post("/game/answer", handleAnswer(broker, testMaxAnswerLen))
 	})
 
 	// Login helper that returns cookies.
@@ -82,7 +121,7 @@ func adminRouter(t *testing.T) (*chi.Mux, func() []*http.Cookie) {
 		return w.Result().Cookies()
 	}
 
-	return r, login
+	return r, login, store, broker
 }
 
 func TestAdminLoginGoodCredentials(t *testing.T) {
@@ -289,6 +328,7 @@ func TestAdminScenarioCRUD(t *testing.T) {
 		Stages: []AdminStage{
 			{Location: "Plaza de Armas", Clue: "Go to the main square", Question: "What year?", CorrectAnswer: "1534"},
 		},
+		Version: got.Version,
 	}
 	body, _ = json.Marshal(updateReq)
 	req = httptest.NewRequest(http.MethodPut, "/api/admin/scenarios/"+created.ID, bytes.NewReader(body))
@@ -308,6 +348,9 @@ func TestAdminScenarioCRUD(t *testing.T) {
 	if len(updated.Stages) != 1 {
 		t.Errorf("update: expected 1 stage, got %d", len(updated.Stages))
 	}
+	if created.Version != 1 || updated.Version != 2 {
+		t.Errorf("versions: created %d, updated %d; want 1, 2", created.Version, updated.Version)
+	}
 
 	// Delete — should succeed (no games reference it).
 	req = httptest.NewRequest(http.MethodDelete, "/api/admin/scenarios/"+created.ID, nil)
@@ -330,6 +373,75 @@ func TestAdminScenarioCRUD(t *testing.T) {
 	}
 }
 
+func TestAdminUpdateScenarioConflict(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	put := func(id string, req AdminScenarioRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPut, "/api/admin/scenarios/"+id, bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+		for _, c := range cookies {
+			httpReq.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httpReq)
+		return w
+	}
+
+	// Two operators load the seeded scenario (written before versioning,
+	// so it reports version 1).
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/"+demoSeed.ScenarioID, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var loaded AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&loaded)
+	if loaded.Version != 1 {
+		t.Fatalf("loaded version = %d, want 1", loaded.Version)
+	}
+
+	edit := func(name string) AdminScenarioRequest {
+		return AdminScenarioRequest{Name: name, City: loaded.City, Mode: loaded.Mode, Stages: loaded.Stages, Version: loaded.Version}
+	}
+
+	// The first save wins and bumps the version.
+	w = put(loaded.ID, edit("First Edit"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var first AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&first)
+	if first.Version != 2 {
+		t.Errorf("first update: version = %d, want 2", first.Version)
+	}
+
+	// The second, still based on version 1, is rejected and changes nothing.
+	if w := put(loaded.ID, edit("Second Edit")); w.Code != http.StatusConflict {
+		t.Fatalf("stale update: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/"+loaded.ID, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var current AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&current)
+	if current.Name != "First Edit" || current.Version != 2 {
+		t.Errorf("after conflict: got %q v%d, want \"First Edit\" v2", current.Name, current.Version)
+	}
+
+	// Updates without a version are refused outright.
+	noVersion := edit("No Version")
+	noVersion.Version = 0
+	if w := put(loaded.ID, noVersion); w.Code != http.StatusBadRequest {
+		t.Errorf("missing version: expected 400, got %d", w.Code)
+	}
+}
+
 func TestAdminDeleteScenarioWithGames(t *testing.T) {
 	r, login := adminRouter(t)
 	cookies := login()
@@ -370,6 +482,50 @@ func TestAdminDeleteScenarioWithGames(t *testing.T) {
 	if w.Code != http.StatusConflict {
 		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
 	}
+
+	// The usage endpoint names the game in the way.
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/"+seededID+"/usage", nil)
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("usage: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var usage []ScenarioUsage
+	json.NewDecoder(w.Body).Decode(&usage)
+	want := ScenarioUsage{Client: "demo", GameID: demoSeed.GameID, Status: "active"}
+	if len(usage) != 1 || usage[0] != want {
+		t.Fatalf("usage = %+v, want [%+v]", usage, want)
+	}
+
+	// Unused scenarios report an empty list; unknown ones are 404.
+	body, _ := json.Marshal(AdminScenarioRequest{Name: "Unused", City: "Lima", Mode: "classic", Stages: []AdminStage{
+		{Location: "A", Clue: "a", Question: "q?", CorrectAnswer: "x"},
+		{Location: "B", Clue: "b", Question: "q?", CorrectAnswer: "y"},
+	}})
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/scenarios", bytes.NewReader(body))
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var unused AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&unused)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/"+unused.ID+"/usage", nil)
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("unused usage: expected 200 [], got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/scenarios/nope/usage", nil)
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown usage: expected 404, got %d", w.Code)
+	}
 }
 
 func TestAdminScenariosUnauthenticated(t *testing.T) {
@@ -500,6 +656,7 @@ func TestAdminGameCRUD(t *testing.T) {
 	// Update game.
 	gameReq.Status = "active"
 	gameReq.TimerMinutes = 60
+	gameReq.Version = got.Version
 	body, _ = json.Marshal(gameReq)
 	req = httptest.NewRequest(http.MethodPut, "/api/admin/clients/demo/games/"+game.ID, bytes.NewReader(body))
 	addCookies(req)
@@ -517,6 +674,9 @@ func TestAdminGameCRUD(t *testing.T) {
 	if updatedGame.TimerMinutes != 60 {
 		t.Errorf("update game: expected 60 minutes, got %d", updatedGame.TimerMinutes)
 	}
+	if updatedGame.Version != got.Version+1 {
+		t.Errorf("update game: expected version %d, got %d", got.Version+1, updatedGame.Version)
+	}
 
 	// Add a team.
 	teamReq := AdminTeamRequest{Name: "Los Alpacas", GuideName: "Pedro"}
@@ -559,6 +719,7 @@ func TestAdminGameCRUD(t *testing.T) {
 	// Update team.
 	teamReq.Name = "Los Alpacas Updated"
 	teamReq.GuideName = "Pedro Jr"
+	teamReq.Version = team.Version
 	body, _ = json.Marshal(teamReq)
 	req = httptest.NewRequest(http.MethodPut, "/api/admin/clients/demo/games/"+game.ID+"/teams/"+team.ID, bytes.NewReader(body))
 	addCookies(req)
@@ -605,6 +766,48 @@ func TestAdminGameCRUD(t *testing.T) {
 	}
 }
 
+func TestAdminLobbyGame(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Teams gather in the lobby with a PIN to join by, but the game's clock
+	// only starts once it goes active.
+	w := do(http.MethodPost, "/api/admin/clients/demo/games", AdminGameRequest{ScenarioID: demoSeed.ScenarioID, Status: "lobby", TimerEnabled: true, TimerMinutes: 60})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create lobby game: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var game AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&game)
+	if game.Status != "lobby" || game.StartedAt != nil || game.PIN == "" {
+		t.Errorf("lobby game: status %q, startedAt %v, pin %q, want lobby, not started, with a PIN", game.Status, game.StartedAt, game.PIN)
+	}
+
+	w = do(http.MethodPut, "/api/admin/clients/demo/games/"+game.ID, AdminGameRequest{ScenarioID: demoSeed.ScenarioID, Status: "active", TimerEnabled: true, TimerMinutes: 60, Version: game.Version})
+	if w.Code != http.StatusOK {
+		t.Fatalf("start game: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var started AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&started)
+	if started.Status != "active" || started.StartedAt == nil || started.PIN != game.PIN {
+		t.Errorf("started game: status %q, startedAt %v, pin %q, want active, started, keeping pin %q", started.Status, started.StartedAt, started.PIN, game.PIN)
+	}
+
+	if w := do(http.MethodPost, "/api/admin/clients/demo/games", AdminGameRequest{ScenarioID: demoSeed.ScenarioID, Status: "gathering"}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown status: expected 400, got %d", w.Code)
+	}
+}
+
 func TestAdminDeleteGameWithPlayers(t *testing.T) {
 	r, login := adminRouter(t)
 	cookies := login()
@@ -616,7 +819,7 @@ func TestAdminDeleteGameWithPlayers(t *testing.T) {
 	}
 
 	// Join a player to the seeded team.
-	joinBody, _ := json.Marshal(JoinRequest{JoinToken: "incas-2025", PlayerName: "TestPlayer"})
+	joinBody, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "TestPlayer"})
 	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(joinBody))
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -625,7 +828,7 @@ func TestAdminDeleteGameWithPlayers(t *testing.T) {
 	}
 
 	// Try to delete the seeded game — should fail with 409.
-	req = httptest.NewRequest(http.MethodDelete, "/api/admin/clients/demo/games/g0000000deadbeef", nil)
+	req = httptest.NewRequest(http.MethodDelete, "/api/admin/clients/demo/games/"+demoSeed.GameID, nil)
 	addCookies(req)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -646,7 +849,7 @@ func TestAdminDeleteTeamWithPlayers(t *testing.T) {
 	}
 
 	// Join a player to the seeded team.
-	joinBody, _ := json.Marshal(JoinRequest{JoinToken: "condores-2025", PlayerName: "TestPlayer2"})
+	joinBody, _ := json.Marshal(JoinRequest{JoinToken: demoCondores.JoinToken, PlayerName: "TestPlayer2"})
 	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(joinBody))
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -655,7 +858,7 @@ func TestAdminDeleteTeamWithPlayers(t *testing.T) {
 	}
 
 	// Try to delete the team — should fail with 409.
-	req = httptest.NewRequest(http.MethodDelete, "/api/admin/clients/demo/games/g0000000deadbeef/teams/t00000000condor", nil)
+	req = httptest.NewRequest(http.MethodDelete, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/teams/"+demoCondores.ID, nil)
 	addCookies(req)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -675,10 +878,10 @@ func TestAdminCreateTeamDuplicateToken(t *testing.T) {
 		}
 	}
 
-	// Create a team with a custom token that already exists (incas-2025).
-	teamReq := AdminTeamRequest{Name: "Duplicate Team", JoinToken: "incas-2025"}
+	// Create a team with a custom token that already exists (the seeded Incas token).
+	teamReq := AdminTeamRequest{Name: "Duplicate Team", JoinToken: demoIncas.JoinToken}
 	body, _ := json.Marshal(teamReq)
-	req := httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/g0000000deadbeef/teams", bytes.NewReader(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/teams", bytes.NewReader(body))
 	addCookies(req)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -754,6 +957,30 @@ func TestScenarioModeValidation(t *testing.T) {
 			},
 			wantErr: "must have a locationNumber",
 		},
+		{
+			name: "qr_hunt rejects caseSensitive",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "qr_hunt",
+				Stages: []AdminStage{{Location: "A", CaseSensitive: true}},
+			},
+			wantErr: "caseSensitive only applies",
+		},
+		{
+			name: "qr_hunt rejects explanation",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "qr_hunt",
+				Stages: []AdminStage{{Location: "A", Explanation: "Built in 1651."}},
+			},
+			wantErr: "explanation only applies",
+		},
+		{
+			name: "explanation too long",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "Q?", CorrectAnswer: "A", Explanation: strings.Repeat("я", maxExplanationLen+1)}},
+			},
+			wantErr: "explanation must be at most",
+		},
 		{
 			name: "math_puzzle valid",
 			req: AdminScenarioRequest{
@@ -769,6 +996,77 @@ func TestScenarioModeValidation(t *testing.T) {
 			},
 			wantErr: "each stage must have a question",
 		},
+		{
+			name: "list answer needs parts",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "Colors?", AnswerType: "list", AcceptedAnswers: []string{" ", ""}}},
+			},
+			wantErr: "needs at least one acceptedAnswers entry",
+		},
+		{
+			name: "list part contains the delimiter",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "Colors?", AnswerType: "list", AcceptedAnswers: []string{"red, green"}}},
+			},
+			wantErr: "contains the delimiter",
+		},
+		{
+			name: "list options on a text answer",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "Q?", CorrectAnswer: "A", ListUnordered: true}},
+			},
+			wantErr: "only apply to list answers",
+		},
+		{
+			name: "qr_hunt rejects list answers",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "qr_hunt",
+				Stages: []AdminStage{{Location: "A", AnswerType: "list", AcceptedAnswers: []string{"red"}}},
+			},
+			wantErr: "answerType only applies",
+		},
+		{
+			name: "unknown answerType",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "Q?", CorrectAnswer: "A", AnswerType: "date"}},
+			},
+			wantErr: "answerType must be text, list or number",
+		},
+		{
+			name: "number answer",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "How high?", CorrectAnswer: " 3,5 ", AnswerType: "number", NumericTolerance: 0.5}},
+			},
+		},
+		{
+			name: "number answer must parse",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "How high?", CorrectAnswer: "about 40", AnswerType: "number"}},
+			},
+			wantErr: "is not a number",
+		},
+		{
+			name: "negative tolerance",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "How high?", CorrectAnswer: "40", AnswerType: "number", NumericTolerance: -1}},
+			},
+			wantErr: "must not be negative",
+		},
+		{
+			name: "tolerance on a text answer",
+			req: AdminScenarioRequest{
+				Name: "Test", City: "Lima", Mode: "classic",
+				Stages: []AdminStage{{Location: "A", Question: "Q?", CorrectAnswer: "40", NumericTolerance: 1}},
+			},
+			wantErr: "only applies to number answers",
+		},
 	}
 
 	for _, tt := range tests {
@@ -826,6 +1124,25 @@ func TestScenarioModeValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("list answer derives correctAnswer", func(t *testing.T) {
+		req := AdminScenarioRequest{
+			Name: "Test", City: "Lima", Mode: "classic",
+			Stages: []AdminStage{
+				{Location: "A", Question: "Colors?", AnswerType: "list", AcceptedAnswers: []string{" red", "", "green ", "blue"}},
+				{Location: "B", Question: "Steps?", AnswerType: "list", ListDelimiter: ";", AcceptedAnswers: []string{"left", "right"}},
+			},
+		}
+		if msg := req.validate(); msg != "" {
+			t.Fatalf("unexpected error: %s", msg)
+		}
+		if s := req.Stages[0]; s.CorrectAnswer != "red, green, blue" || s.ListDelimiter != "," || len(s.AcceptedAnswers) != 3 {
+			t.Errorf("stage 1 = %+v, want parts trimmed and correctAnswer %q", s, "red, green, blue")
+		}
+		if got := req.Stages[1].CorrectAnswer; got != "left; right" {
+			t.Errorf("stage 2 correctAnswer = %q, want %q", got, "left; right")
+		}
+	})
+
 	// Verify default mode is set.
 	t.Run("empty mode defaults to supervised", func(t *testing.T) {
 		req := AdminScenarioRequest{
@@ -992,3 +1309,1041 @@ func TestAdminGamesUnauthenticated(t *testing.T) {
 		}
 	}
 }
+
+func TestAdminRegradeGame(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	addCookies := func(req *http.Request) {
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+	}
+
+	// Join the seeded demo game and answer stage 1 with a near-miss.
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Rosa"})
+	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var joinResp JoinResponse
+	json.NewDecoder(w.Body).Decode(&joinResp)
+
+	body, _ = json.Marshal(AnswerRequest{Answer: "1650"})
+	req = httptest.NewRequest(http.MethodPost, "/api/demo/game/answer", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+joinResp.Token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var ansResp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&ansResp)
+	if ansResp.IsCorrect {
+		t.Fatal("answer: expected 1650 to be graded wrong under the original key")
+	}
+
+	regrade := func(rr RegradeRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(rr)
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/regrade", bytes.NewReader(body))
+		addCookies(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without confirmation nothing happens.
+	w = regrade(RegradeRequest{CorrectAnswers: map[int]string{1: "1650"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("regrade without confirm: expected 400, got %d", w.Code)
+	}
+
+	// Unknown stage is rejected.
+	w = regrade(RegradeRequest{Confirm: true, CorrectAnswers: map[int]string{99: "x"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("regrade unknown stage: expected 400, got %d", w.Code)
+	}
+
+	// Fix the key and regrade.
+	w = regrade(RegradeRequest{Confirm: true, CorrectAnswers: map[int]string{1: "1650"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("regrade: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp RegradeResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Flipped != 1 || resp.Checked != 1 {
+		t.Errorf("regrade: expected 1 checked / 1 flipped, got %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/status", nil)
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var status AdminGameStatus
+	json.NewDecoder(w.Body).Decode(&status)
+	for _, team := range status.Teams {
+		if team.ID == joinResp.TeamID && team.CompletedStages != 1 {
+			t.Errorf("status: expected 1 correct stage after regrade, got %d", team.CompletedStages)
+		}
+	}
+
+	// Regrading again is a no-op.
+	w = regrade(RegradeRequest{Confirm: true})
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Flipped != 0 {
+		t.Errorf("second regrade: expected 0 flipped, got %d", resp.Flipped)
+	}
+}
+
+func TestAdminCloneGame(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	addCookies := func(req *http.Request) {
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+	}
+
+	// Give the source game a player so we can check it isn't copied.
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Rosa"})
+	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID, nil)
+	addCookies(req)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var src AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&src)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/clone", nil)
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("clone: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var clone AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&clone)
+
+	if clone.ID == src.ID {
+		t.Error("expected clone to have a new ID")
+	}
+	if clone.Status != "draft" {
+		t.Errorf("expected draft clone, got %q", clone.Status)
+	}
+	if clone.ScenarioID != src.ScenarioID || clone.Mode != src.Mode || len(clone.Stages) != len(src.Stages) {
+		t.Errorf("expected same scenario snapshot, got %+v", clone)
+	}
+	if len(clone.Teams) != len(src.Teams) {
+		t.Fatalf("expected %d teams, got %d", len(src.Teams), len(clone.Teams))
+	}
+	for i, tm := range clone.Teams {
+		if tm.Name != src.Teams[i].Name {
+			t.Errorf("team %d: expected name %q, got %q", i, src.Teams[i].Name, tm.Name)
+		}
+		if tm.JoinToken == src.Teams[i].JoinToken {
+			t.Errorf("team %d: expected a fresh join token, got %q", i, tm.JoinToken)
+		}
+		if tm.PlayerCount != 0 {
+			t.Errorf("team %d: expected 0 players, got %d", i, tm.PlayerCount)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/nope/clone", nil)
+	addCookies(req)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("clone missing game: expected 404, got %d", w.Code)
+	}
+}
+
+func TestAdminTeamSessions(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The same player name on two devices is two sessions.
+	for _, name := range []string{"Rosa", "Rosa"} {
+		body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: name})
+		req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := get("/api/admin/clients/demo/games/" + demoSeed.GameID + "/teams/" + demoIncas.ID + "/sessions")
+	if w.Code != http.StatusOK {
+		t.Fatalf("sessions: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp AdminTeamSessions
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.SessionCount != 2 || len(resp.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", resp)
+	}
+	for _, s := range resp.Sessions {
+		if s.PlayerName != "Rosa" || s.Role != "player" {
+			t.Errorf("unexpected session %+v", s)
+		}
+	}
+
+	w = get("/api/admin/clients/demo/games/" + demoSeed.GameID + "/teams/nope/sessions")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown team: expected 404, got %d", w.Code)
+	}
+}
+
+func TestGameTimerLimits(t *testing.T) {
+	limits := TimerLimits{GameMinutes: 240, StageMinutes: 30}
+
+	tests := []struct {
+		name               string
+		timer, stageTimer  int
+		wantErr            string
+		wantTimer, wantSTM int
+	}{
+		{name: "zero uses defaults", wantTimer: 120, wantSTM: 10},
+		{name: "at limits", timer: 240, stageTimer: 30, wantTimer: 240, wantSTM: 30},
+		{name: "game over limit", timer: 241, stageTimer: 10, wantErr: "timerMinutes must be at most 240"},
+		{name: "stage over limit", timer: 60, stageTimer: 31, wantErr: "stageTimerMinutes must be at most 30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AdminGameRequest{ScenarioID: "s1", TimerEnabled: true, TimerMinutes: tt.timer, StageTimerMinutes: tt.stageTimer}
+			msg := req.validate(limits)
+			if msg != tt.wantErr {
+				t.Fatalf("expected error %q, got %q", tt.wantErr, msg)
+			}
+			if tt.wantErr == "" && (req.TimerMinutes != tt.wantTimer || req.StageTimerMinutes != tt.wantSTM) {
+				t.Errorf("expected timers %d/%d, got %d/%d", tt.wantTimer, tt.wantSTM, req.TimerMinutes, req.StageTimerMinutes)
+			}
+		})
+	}
+
+	// Timers are ignored (and reset) when disabled, so no cap applies.
+	req := AdminGameRequest{ScenarioID: "s1", TimerMinutes: 10000}
+	if msg := req.validate(limits); msg != "" || req.TimerMinutes != 0 {
+		t.Errorf("disabled timer: expected no error and 0 minutes, got %q / %d", msg, req.TimerMinutes)
+	}
+
+	// Zero limits mean no cap.
+	req = AdminGameRequest{ScenarioID: "s1", TimerEnabled: true, TimerMinutes: 10000}
+	if msg := req.validate(TimerLimits{}); msg != "" {
+		t.Errorf("no limits: expected no error, got %q", msg)
+	}
+}
+
+func TestAdminIdempotentCreate(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path, key string, body any) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	sc := AdminScenarioRequest{
+		Name:   "Retry Tour",
+		City:   "Cusco",
+		Mode:   "classic",
+		Stages: []AdminStage{{Location: "Plaza", Question: "Q?", CorrectAnswer: "a"}},
+	}
+	var first, second AdminScenarioDetail
+	w := do(http.MethodPost, "/api/admin/scenarios", "sc-key-1", sc)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create scenario: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	json.NewDecoder(w.Body).Decode(&first)
+	w = do(http.MethodPost, "/api/admin/scenarios", "sc-key-1", sc)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("replay scenario: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected Idempotent-Replayed header on replay")
+	}
+	json.NewDecoder(w.Body).Decode(&second)
+	if first.ID == "" || first.ID != second.ID {
+		t.Errorf("expected replay to return scenario %q, got %q", first.ID, second.ID)
+	}
+
+	var list []AdminScenarioSummary
+	json.NewDecoder(do(http.MethodGet, "/api/admin/scenarios", "", nil).Body).Decode(&list)
+	count := 0
+	for _, s := range list {
+		if s.Name == "Retry Tour" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected 1 scenario after replay, got %d", count)
+	}
+
+	// A new key creates a new object.
+	w = do(http.MethodPost, "/api/admin/scenarios", "sc-key-2", sc)
+	var third AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&third)
+	if third.ID == first.ID {
+		t.Error("expected a different key to create a new scenario")
+	}
+
+	// Teams: the same key twice yields one team.
+	teamsPath := "/api/admin/clients/demo/games/" + demoSeed.GameID + "/teams"
+	var before []AdminTeamItem
+	json.NewDecoder(do(http.MethodGet, teamsPath, "", nil).Body).Decode(&before)
+
+	team := AdminTeamRequest{Name: "Condors", JoinToken: "condors-retry"}
+	var t1, t2 AdminTeamItem
+	w = do(http.MethodPost, teamsPath, "team-key", team)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create team: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	json.NewDecoder(w.Body).Decode(&t1)
+	json.NewDecoder(do(http.MethodPost, teamsPath, "team-key", team).Body).Decode(&t2)
+	if t1.ID != t2.ID {
+		t.Errorf("expected replay to return team %q, got %q", t1.ID, t2.ID)
+	}
+
+	var after []AdminTeamItem
+	json.NewDecoder(do(http.MethodGet, teamsPath, "", nil).Body).Decode(&after)
+	if len(after) != len(before)+1 {
+		t.Errorf("expected %d teams, got %d", len(before)+1, len(after))
+	}
+
+	// Failed creates aren't remembered, so a corrected retry goes through.
+	w = do(http.MethodPost, teamsPath, "bad-key", AdminTeamRequest{})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid team: expected 400, got %d", w.Code)
+	}
+	w = do(http.MethodPost, teamsPath, "bad-key", AdminTeamRequest{Name: "Pumas", JoinToken: "pumas-retry"})
+	if w.Code != http.StatusCreated {
+		t.Errorf("retry after failure: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestScenarioWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		stages []AdminStage
+		want   []string
+	}{
+		{
+			name: "clean scenario",
+			mode: "classic",
+			stages: []AdminStage{
+				{Location: "Plaza", Clue: "Fountain", Question: "Q?", CorrectAnswer: "pizarro"},
+				{Location: "Church", Clue: "Bells", Question: "Q?", CorrectAnswer: "bronze"},
+			},
+			want: []string{},
+		},
+		{
+			name: "missing clue",
+			mode: "classic",
+			stages: []AdminStage{
+				{Location: "Plaza", Question: "Q?", CorrectAnswer: "pizarro"},
+				{Location: "Church", ClueImage: "/uploads/bells.jpg", Question: "Q?", CorrectAnswer: "bronze"},
+			},
+			want: []string{"stage 1 has no clue"},
+		},
+		{
+			name: "duplicate location",
+			mode: "classic",
+			stages: []AdminStage{
+				{Location: "Plaza", Clue: "North side", Question: "Q?", CorrectAnswer: "pizarro"},
+				{Location: " plaza ", Clue: "South side", Question: "Q?", CorrectAnswer: "bronze"},
+			},
+			want: []string{`stage 2 has the same location as stage 1 ("plaza")`},
+		},
+		{
+			name: "short answer",
+			mode: "classic",
+			stages: []AdminStage{
+				{Location: "Plaza", Clue: "Fountain", Question: "How many lions?", CorrectAnswer: "4"},
+				{Location: "Church", Clue: "Bells", Question: "Q?", CorrectAnswer: "sí"},
+			},
+			want: []string{
+				`stage 1 has a very short answer ("4") that is easy to guess`,
+				`stage 2 has a very short answer ("sí") that is easy to guess`,
+			},
+		},
+		{
+			name: "answers ignored without questions",
+			mode: "qr_hunt",
+			stages: []AdminStage{
+				{Location: "Plaza", Clue: "Fountain"},
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AdminScenarioRequest{Name: "Test", City: "Lima", Mode: tt.mode, Stages: tt.stages}
+			if msg := req.validate(); msg != "" {
+				t.Fatalf("validate: %s", msg)
+			}
+			if got := req.warnings(); !slices.Equal(got, tt.want) {
+				t.Errorf("expected warnings %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAdminScenarioWarningsEndpoints(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	sc := AdminScenarioRequest{
+		Name:   "Quick Tour",
+		City:   "Lima",
+		Mode:   "classic",
+		Stages: []AdminStage{{Location: "Plaza", Question: "Q?", CorrectAnswer: "pizarro"}},
+	}
+
+	// Warnings don't block the create.
+	w := post("/api/admin/scenarios", sc)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&created)
+	if len(created.Warnings) != 1 || created.Warnings[0] != "stage 1 has no clue" {
+		t.Errorf("create: expected clue warning, got %q", created.Warnings)
+	}
+
+	w = post("/api/admin/scenarios/validate", sc)
+	if w.Code != http.StatusOK {
+		t.Fatalf("validate: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var v ScenarioValidation
+	json.NewDecoder(w.Body).Decode(&v)
+	if !v.Valid || v.Error != "" || len(v.Warnings) != 1 {
+		t.Errorf("validate: expected valid with 1 warning, got %+v", v)
+	}
+
+	sc.City = ""
+	w = post("/api/admin/scenarios/validate", sc)
+	v = ScenarioValidation{}
+	json.NewDecoder(w.Body).Decode(&v)
+	if v.Valid || v.Error != "city is required" {
+		t.Errorf("validate: expected city error, got %+v", v)
+	}
+}
+
+func TestAdminStageStats(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Condores start at scenario stage 2, so their team stage 1 is stage 2.
+	w := do(http.MethodPut, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/teams/"+demoCondores.ID, "", AdminTeamRequest{Name: "Los Condores", JoinToken: demoCondores.JoinToken, StartStage: 2, Version: 1})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update team: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	play := func(joinToken, name string, answers ...string) {
+		var jr JoinResponse
+		json.NewDecoder(do(http.MethodPost, "/api/demo/join", "", JoinRequest{JoinToken: joinToken, PlayerName: name}).Body).Decode(&jr)
+		for _, a := range answers {
+			if w := do(http.MethodPost, "/api/demo/game/answer", jr.Token, AnswerRequest{Answer: a}); w.Code != http.StatusOK {
+				t.Fatalf("%s answer %q: expected 200, got %d: %s", name, a, w.Code, w.Body.String())
+			}
+		}
+	}
+	play(demoIncas.JoinToken, "Rosa", "1651", "tunnels")         // stage 1 right, stage 2 wrong
+	play(demoCondores.JoinToken, "Luis", "catacombs", "Bolivar") // stage 2 right, stage 3 wrong
+
+	w = do(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/stage-stats", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("stage stats: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var stats []AdminStageStats
+	json.NewDecoder(w.Body).Decode(&stats)
+
+	want := []AdminStageStats{
+		{StageNumber: 1, Location: "Plaza Mayor", Attempts: 1, Correct: 1, AvgAttemptsToCorrect: 1},
+		{StageNumber: 2, Location: "Iglesia de San Francisco", Attempts: 2, Correct: 1, Wrong: 1, AvgAttemptsToCorrect: 2},
+		{StageNumber: 3, Location: "Jiron de la Union", Attempts: 1, Wrong: 1},
+		{StageNumber: 4, Location: "Parque de la Muralla"},
+	}
+	if !slices.Equal(stats, want) {
+		t.Errorf("expected stats\n%+v\ngot\n%+v", want, stats)
+	}
+
+	w = do(http.MethodGet, "/api/admin/clients/demo/games/nope/stage-stats", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+}
+
+func TestAdminAnswerLog(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	play := func(joinToken, name string, answers ...string) {
+		var jr JoinResponse
+		json.NewDecoder(do(http.MethodPost, "/api/demo/join", "", JoinRequest{JoinToken: joinToken, PlayerName: name}).Body).Decode(&jr)
+		for _, a := range answers {
+			if w := do(http.MethodPost, "/api/demo/game/answer", jr.Token, AnswerRequest{Answer: a}); w.Code != http.StatusOK {
+				t.Fatalf("%s answer %q: expected 200, got %d: %s", name, a, w.Code, w.Body.String())
+			}
+		}
+	}
+	// Both teams give the same odd wrong answer on stage 2, spelled differently.
+	play(demoIncas.JoinToken, "Rosa", "1651", "tunnels")
+	play(demoCondores.JoinToken, "Luis", "1651", " TUNNELS ")
+
+	get := func(query string) []AdminStageAnswers {
+		t.Helper()
+		w := do(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/answers"+query, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("answers%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var stages []AdminStageAnswers
+		json.NewDecoder(w.Body).Decode(&stages)
+		if len(stages) != 4 {
+			t.Fatalf("answers%s: expected 4 stages, got %d", query, len(stages))
+		}
+		return stages
+	}
+
+	stages := get("")
+	if a := stages[0].Answers; len(a) != 1 || a[0].Answer != "1651" || !a[0].IsCorrect || a[0].TeamCount != 2 {
+		t.Errorf("stage 1: expected one correct group of 2 teams, got %+v", a)
+	}
+	shared := stages[1].Answers
+	if len(shared) != 1 || shared[0].Answer != "tunnels" || shared[0].IsCorrect || shared[0].TeamCount != 2 {
+		t.Fatalf("stage 2: expected the wrong answer grouped across 2 teams, got %+v", shared)
+	}
+	var names []string
+	for _, tm := range shared[0].Teams {
+		names = append(names, tm.Name)
+	}
+	slices.Sort(names)
+	if strings.Join(names, ",") != "Los Condores,Los Incas" {
+		t.Errorf("stage 2: expected both teams named, got %v", names)
+	}
+	if len(stages[2].Answers) != 0 || len(stages[3].Answers) != 0 {
+		t.Errorf("stages 3-4: expected no answers, got %+v / %+v", stages[2].Answers, stages[3].Answers)
+	}
+
+	// Filtered to shared wrong answers, only the suspicious one is left.
+	stages = get("?wrongOnly=true&minTeams=2")
+	if len(stages[0].Answers) != 0 || len(stages[1].Answers) != 1 || stages[1].Answers[0].Answer != "tunnels" {
+		t.Errorf("filtered: got %+v / %+v", stages[0].Answers, stages[1].Answers)
+	}
+
+	if w := do(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/answers?minTeams=0", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("minTeams=0: expected 400, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/admin/clients/demo/games/nope/answers", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+}
+
+func TestUniqueToken(t *testing.T) {
+	existing := map[string]bool{"super-aaaa": true, "team-join": true}
+
+	// The generator collides twice before producing a fresh token.
+	queue := []string{"super-aaaa", "team-join", "super-bbbb"}
+	calls := 0
+	gen := func() string {
+		tok := queue[calls]
+		calls++
+		return tok
+	}
+
+	if got := uniqueToken(existing, gen); got != "super-bbbb" {
+		t.Fatalf("expected super-bbbb, got %q", got)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 generator calls, got %d", calls)
+	}
+	if !existing["super-bbbb"] {
+		t.Error("expected the new token to be recorded")
+	}
+
+	// A second call can't return the token the first one handed out.
+	queue = append(queue, "super-bbbb", "super-cccc")
+	if got := uniqueToken(existing, gen); got != "super-cccc" {
+		t.Errorf("expected super-cccc, got %q", got)
+	}
+}
+
+func TestCreateTeamTokensDistinct(t *testing.T) {
+	_, store := setupStores(t)
+	ctx := context.Background()
+
+	g, err := store.CreateGame(ctx, AdminGameRequest{ScenarioID: "s1", ScenarioName: "S", Mode: "supervised", Status: "draft", Supervised: true},
+		[]AdminStage{{StageNumber: 1, Location: "A", Question: "Q?", CorrectAnswer: "a"}})
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for i := range 5 {
+		team, err := store.CreateTeam(ctx, g.ID, AdminTeamRequest{Name: fmt.Sprintf("Team %d", i)}, generateJoinToken(JoinTokenFormat{Style: tokenStyleHex}))
+		if err != nil {
+			t.Fatalf("create team: %v", err)
+		}
+		for _, tok := range []string{team.JoinToken, team.SupervisorToken, team.SpectatorToken} {
+			if tok == "" || seen[tok] {
+				t.Fatalf("token %q empty or reused (team %+v)", tok, team)
+			}
+			seen[tok] = true
+		}
+	}
+
+	// A join token may not reuse another team's supervisor or spectator token.
+	teams, _ := store.ListTeams(ctx, g.ID)
+	if _, err := store.CreateTeam(ctx, g.ID, AdminTeamRequest{Name: "Copycat"}, teams[0].SupervisorToken); err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+		t.Errorf("expected UNIQUE error reusing a supervisor token, got %v", err)
+	}
+	if _, err := store.CreateTeam(ctx, g.ID, AdminTeamRequest{Name: "Copycat"}, teams[0].SpectatorToken); err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+		t.Errorf("expected UNIQUE error reusing a spectator token, got %v", err)
+	}
+}
+
+func TestAdminExtendGame(t *testing.T) {
+	_, store := setupStores(t)
+	ctx := context.Background()
+
+	broker := NewBroker()
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyStore, Store(store))))
+		})
+	})
+	r.Post("/games/{gameID}/extend", handleAdminExtendGame(broker, TimerLimits{GameMinutes: 240}))
+
+	extend := func(gameID string, minutes int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ExtendGameRequest{AddMinutes: minutes})
+		req := httptest.NewRequest(http.MethodPost, "/games/"+gameID+"/extend", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	before, err := store.GetGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	start, _ := time.Parse(time.RFC3339Nano, *before.StartedAt)
+
+	events := broker.Subscribe(demoIncas.ID, 0)
+	defer broker.Unsubscribe(demoIncas.ID, events)
+
+	w := extend(demoSeed.GameID, 30)
+	if w.Code != http.StatusOK {
+		t.Fatalf("extend: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ExtendGameResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.TimerMinutes != before.TimerMinutes+30 {
+		t.Errorf("expected %d timer minutes, got %d", before.TimerMinutes+30, resp.TimerMinutes)
+	}
+	wantDeadline := start.Add(time.Duration(before.TimerMinutes+30) * time.Minute).UTC().Format(time.RFC3339)
+	if resp.Deadline == nil || *resp.Deadline != wantDeadline {
+		t.Errorf("expected deadline %s, got %v", wantDeadline, resp.Deadline)
+	}
+	if resp.RemainingSeconds <= before.TimerMinutes*60 {
+		t.Errorf("expected more than %d seconds remaining, got %d", before.TimerMinutes*60, resp.RemainingSeconds)
+	}
+
+	after, _ := store.GetGame(ctx, demoSeed.GameID)
+	if after.StartedAt == nil || *after.StartedAt != *before.StartedAt {
+		t.Errorf("expected startedAt unchanged, got %v", after.StartedAt)
+	}
+
+	select {
+	case msg := <-events:
+		var ev SSEEvent
+		json.Unmarshal(msg, &ev)
+		if ev.Type != "timer_extended" || ev.RemainingSeconds != resp.RemainingSeconds {
+			t.Errorf("expected timer_extended with %d seconds, got %+v", resp.RemainingSeconds, ev)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected a timer_extended event")
+	}
+
+	// Over the configured cap.
+	if w := extend(demoSeed.GameID, 100); w.Code != http.StatusBadRequest {
+		t.Errorf("over cap: expected 400, got %d", w.Code)
+	}
+	if w := extend(demoSeed.GameID, 0); w.Code != http.StatusBadRequest {
+		t.Errorf("zero minutes: expected 400, got %d", w.Code)
+	}
+
+	// Ended games can't be extended.
+	if err := store.ExpireGame(ctx, demoSeed.GameID); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if w := extend(demoSeed.GameID, 10); w.Code != http.StatusConflict {
+		t.Errorf("ended game: expected 409, got %d", w.Code)
+	}
+	if w := extend("nope", 10); w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+}
+
+func TestAdminBroadcast(t *testing.T) {
+	r, login, store, broker := adminRouterWithStore(t)
+	cookies := login()
+
+	incas := broker.Subscribe(demoIncas.ID, 0)
+	defer broker.Unsubscribe(demoIncas.ID, incas)
+	condores := broker.Subscribe(demoCondores.ID, 0)
+	defer broker.Unsubscribe(demoCondores.ID, condores)
+
+	broadcast := func(gameID, message string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(BroadcastRequest{Message: message})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/clients/demo/games/"+gameID+"/broadcast", bytes.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := broadcast(demoSeed.GameID, "  Dinner at the plaza in 10 minutes  ")
+	if w.Code != http.StatusOK {
+		t.Fatalf("broadcast: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp BroadcastResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Teams != len(demoSeed.Teams) {
+		t.Errorf("expected %d teams, got %d", len(demoSeed.Teams), resp.Teams)
+	}
+
+	for team, ch := range map[string]chan []byte{"incas": incas, "condores": condores} {
+		select {
+		case msg := <-ch:
+			var ev SSEEvent
+			json.Unmarshal(msg, &ev)
+			if ev.Type != "announcement" || ev.Message != "Dinner at the plaza in 10 minutes" || ev.From != "admin@playperu.com" {
+				t.Errorf("%s: expected the announcement from admin@playperu.com, got %+v", team, ev)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: expected an announcement event", team)
+		}
+	}
+
+	if w := broadcast(demoSeed.GameID, "   "); w.Code != http.StatusBadRequest {
+		t.Errorf("empty message: expected 400, got %d", w.Code)
+	}
+	if w := broadcast(demoSeed.GameID, strings.Repeat("a", maxBroadcastLen+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("long message: expected 400, got %d", w.Code)
+	}
+	if w := broadcast("nope", "hello"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+
+	if err := store.ExpireGame(context.Background(), demoSeed.GameID); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if w := broadcast(demoSeed.GameID, "hello"); w.Code != http.StatusConflict {
+		t.Errorf("ended game: expected 409, got %d", w.Code)
+	}
+}
+
+func TestAdminUnlockTeamStage(t *testing.T) {
+	r, login, store, broker := adminRouterWithStore(t)
+	cookies := login()
+	ctx := context.Background()
+
+	// The seeded game is classic; play it as a QR quiz so stages need unlocking.
+	if err := store.modifyGame(ctx, demoSeed.GameID, func(g *game) error { g.Mode = "qr_quiz"; return nil }); err != nil {
+		t.Fatalf("set mode: %v", err)
+	}
+	ch := broker.Subscribe(demoIncas.ID, 0)
+	defer broker.Unsubscribe(demoIncas.ID, ch)
+
+	path := "/api/admin/clients/demo/games/" + demoSeed.GameID + "/teams/" + demoIncas.ID + "/unlock"
+	post := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(path, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without admin cookie: expected 401, got %d", w.Code)
+	}
+
+	w := post(path, cookies)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp AdminUnlockResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.StageNumber != 1 || resp.Completed {
+		t.Errorf("unlock = %+v, want stage 1 unlocked", resp)
+	}
+
+	// The team's current stage is unlocked and its players are told.
+	data, err := store.GameState(ctx, demoSeed.GameID, demoIncas.ID)
+	if err != nil {
+		t.Fatalf("game state: %v", err)
+	}
+	if !isStageUnlocked(data.UnlockedStages, 1) || data.StageUnlockedAt == nil {
+		t.Errorf("unlocked stages %v, stageUnlockedAt %v; want stage 1 unlocked with its timer started", data.UnlockedStages, data.StageUnlockedAt)
+	}
+	if len(ch) != 1 {
+		t.Fatalf("expected one event, got %d", len(ch))
+	}
+	var e SSEEvent
+	json.Unmarshal(<-ch, &e)
+	if e.Type != "stage_unlocked" || e.StageNumber != 1 || e.Question != "What year was the fountain in Plaza Mayor built?" {
+		t.Errorf("event = %+v, want stage_unlocked for stage 1 with its question", e)
+	}
+
+	// The override is recorded on the team.
+	teams, err := store.ListTeams(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatalf("list teams: %v", err)
+	}
+	for _, team := range teams {
+		if team.ID == demoIncas.ID && !slices.Equal(team.AdminUnlocks, []int{1}) {
+			t.Errorf("adminUnlocks = %v, want [1]", team.AdminUnlocks)
+		}
+	}
+
+	if w := post(path, cookies); w.Code != http.StatusConflict {
+		t.Errorf("second unlock: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post("/api/admin/clients/demo/games/"+demoSeed.GameID+"/teams/nope/unlock", cookies); w.Code != http.StatusNotFound {
+		t.Errorf("unknown team: expected 404, got %d", w.Code)
+	}
+}
+
+func TestSupervisedModeEnforced(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/admin/scenarios/", AdminScenarioRequest{
+		Name:   "Guided Lima",
+		City:   "Lima",
+		Mode:   "supervised",
+		Stages: []AdminStage{{Location: "Plaza", Question: "Year?", CorrectAnswer: "1651"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create scenario: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var sc AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&sc)
+
+	// Asking for an unsupervised game still gets a supervised one, and its
+	// teams get supervisor tokens.
+	w = do(http.MethodPost, "/api/admin/clients/demo/games", AdminGameRequest{ScenarioID: sc.ID, Status: "active"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create game: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var game AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&game)
+	if !game.Supervised {
+		t.Error("create game: expected a supervised game")
+	}
+	w = do(http.MethodPost, "/api/admin/clients/demo/games/"+game.ID+"/teams", AdminTeamRequest{Name: "Guided Team"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create team: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var team AdminTeamItem
+	json.NewDecoder(w.Body).Decode(&team)
+	if team.SupervisorToken == "" {
+		t.Error("create team: expected a supervisor token")
+	}
+
+	// Switching an existing game to the scenario gives its teams supervisor
+	// tokens too.
+	var seed AdminGameDetail
+	json.NewDecoder(do(http.MethodGet, "/api/admin/clients/demo/games/"+demoSeed.GameID, nil).Body).Decode(&seed)
+	w = do(http.MethodPut, "/api/admin/clients/demo/games/"+demoSeed.GameID, AdminGameRequest{ScenarioID: sc.ID, Status: "active", Version: seed.Version})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update game: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated AdminGameDetail
+	json.NewDecoder(w.Body).Decode(&updated)
+	if !updated.Supervised || len(updated.Teams) == 0 {
+		t.Fatalf("update game: supervised=%v with %d teams, want supervised with the seeded teams", updated.Supervised, len(updated.Teams))
+	}
+	tokens := map[string]bool{}
+	for _, tm := range updated.Teams {
+		if tm.SupervisorToken == "" || tokens[tm.SupervisorToken] {
+			t.Errorf("team %s: supervisor token %q, want a distinct token", tm.ID, tm.SupervisorToken)
+		}
+		tokens[tm.SupervisorToken] = true
+	}
+}
+
+func TestClientDefaultMode(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("/api/admin/clients", CreateClientRequest{Slug: "oops", Name: "Oops", DefaultMode: "treasure"}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown defaultMode: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	w := post("/api/admin/clients", CreateClientRequest{Slug: "hunts", Name: "Hunts", DefaultMode: "qr_hunt"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create client: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var client ClientInfo
+	json.NewDecoder(w.Body).Decode(&client)
+	if client.DefaultMode != "qr_hunt" {
+		t.Errorf("client defaultMode = %q, want qr_hunt", client.DefaultMode)
+	}
+
+	sc := func(name string) AdminScenarioRequest {
+		// With a question, so the scenario is valid in the supervised default too.
+		return AdminScenarioRequest{Name: name, City: "Lima", Stages: []AdminStage{{Location: "Plaza", Question: "Year?", CorrectAnswer: "1651"}}}
+	}
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{"/api/admin/scenarios/?client=hunts", "qr_hunt"},
+		{"/api/admin/scenarios/", "supervised"}, // no client: the global default
+	} {
+		w := post(tc.path, sc("Scenario for "+tc.path))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: expected 201, got %d: %s", tc.path, w.Code, w.Body.String())
+		}
+		var got AdminScenarioDetail
+		json.NewDecoder(w.Body).Decode(&got)
+		if got.Mode != tc.want {
+			t.Errorf("%s: mode = %q, want %q", tc.path, got.Mode, tc.want)
+		}
+	}
+
+	// A mode given explicitly wins over the client's default.
+	explicit := sc("Explicit")
+	explicit.Mode = "math_puzzle"
+	explicit.Stages[0].LocationNumber = 7
+	w = post("/api/admin/scenarios/?client=hunts", explicit)
+	var got AdminScenarioDetail
+	json.NewDecoder(w.Body).Decode(&got)
+	if w.Code != http.StatusCreated || got.Mode != "math_puzzle" {
+		t.Errorf("explicit mode: %d with mode %q, want 201 with math_puzzle", w.Code, got.Mode)
+	}
+
+	if w := post("/api/admin/scenarios/?client=nope", sc("Nowhere")); w.Code != http.StatusNotFound {
+		t.Errorf("unknown client: expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestValidImageURL(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		want bool
+	}{
+		{"", true},
+		{"/uploads/3f2a.jpg", true},
+		{"https://example.com/a.png", true},
+		{"http://example.com/a.png", true},
+		{"data:image/png;base64,iVBORw0KGgo=", true},
+		{"/uploads/", false},
+		{"/uploads/../admin.db", false},
+		{"/uploads/a/b.jpg", false},
+		{"/etc/passwd", false},
+		{"javascript:alert(1)", false},
+		{"https:///a.png", false},
+		{"example.com/a.png", false},
+	} {
+		if got := validImageURL(tc.url); got != tc.want {
+			t.Errorf("validImageURL(%q) = %v, want %v", tc.url, got, tc.want)
+		}
+	}
+
+	sc := AdminScenarioRequest{
+		Name: "Test", City: "Lima", Mode: "classic",
+		Stages: []AdminStage{{Location: "A", Question: "Q?", CorrectAnswer: "A", QuestionImage: "javascript:alert(1)"}},
+	}
+	if msg := sc.validate(); !strings.Contains(msg, "questionImage") {
+		t.Errorf("validate = %q, want a questionImage error", msg)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TimelineEntry is one line of a game's timeline. Time is At as HH:MM (UTC);
+// Text says what happened, e.g. "Incas unlocked stage 2 (Ana)".
+type TimelineEntry struct {
+	At          string `json:"at"`
+	Time        string `json:"time"`
+	TeamID      string `json:"teamId"`
+	TeamName    string `json:"teamName"`
+	StageNumber int    `json:"stageNumber,omitempty"`
+	Text        string `json:"text"`
+}
+
+// gameTimeline turns a game's event log into its timeline, one entry per
+// event in the order they happened, every team's merged.
+func gameTimeline(events []GameEvent) []TimelineEntry {
+	entries := make([]TimelineEntry, 0, len(events))
+	for _, ev := range events {
+		team := ev.TeamName
+		if team == "" {
+			team = "Team " + ev.TeamID // deleted since
+		}
+		player := ev.PlayerName
+		if player == "" {
+			player = "a removed player"
+		}
+		by := " (" + player + ")"
+
+		var text string
+		switch ev.Type {
+		case "joined":
+			text = fmt.Sprintf("%s joined %s", player, team)
+			if ev.Role != "" && ev.Role != "player" {
+				text += " as " + ev.Role
+			}
+		case "unlocked":
+			verb := "unlocked"
+			if ev.Completed {
+				verb = "completed"
+			}
+			if ev.Admin != "" {
+				by = " (by " + ev.Admin + ")"
+			}
+			text = fmt.Sprintf("%s %s stage %d%s", team, verb, ev.StageNumber, by)
+		case "answered":
+			result := "wrongly"
+			if ev.IsCorrect {
+				result = "correctly"
+			}
+			text = fmt.Sprintf("%s answered stage %d %s: %q (%s)", team, ev.StageNumber, result, ev.Answer, player)
+		case "attempted":
+			text = fmt.Sprintf("%s tried %q on stage %d (%s)", team, ev.Answer, ev.StageNumber, player)
+		case "player_answered":
+			text = fmt.Sprintf("%s of %s answered stage %d, waiting for teammates", player, team, ev.StageNumber)
+		case "advanced":
+			text = fmt.Sprintf("%s moved on to stage %d%s", team, ev.StageNumber, by)
+		case "timed_out":
+			text = fmt.Sprintf("%s ran out of time on stage %d", team, ev.StageNumber)
+		case "gave_up":
+			text = fmt.Sprintf("%s gave up stage %d%s", team, ev.StageNumber, by)
+		default:
+			text = fmt.Sprintf("%s: %s", team, ev.Type)
+		}
+
+		hhmm := ev.At
+		if at, err := time.Parse(time.RFC3339Nano, ev.At); err == nil {
+			hhmm = at.UTC().Format("15:04")
+		}
+		entries = append(entries, TimelineEntry{
+			At:          ev.At,
+			Time:        hhmm,
+			TeamID:      ev.TeamID,
+			TeamName:    team,
+			StageNumber: ev.StageNumber,
+			Text:        text,
+		})
+	}
+	return entries
+}
+
+// handleAdminTimeline serves a game's timeline, rebuilt from its event log,
+// for debriefs and disputes. ?format=text returns it as plain text, one
+// "HH:MM text" line per entry.
+func handleAdminTimeline() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+
+		events, err := store.GameEventLog(r.Context(), gameID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		timeline := gameTimeline(events)
+
+		if r.URL.Query().Get("format") == "text" {
+			var b strings.Builder
+			for _, e := range timeline {
+				fmt.Fprintf(&b, "%s %s\n", e.Time, e.Text)
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(b.String()))
+			return
+		}
+		writeJSON(w, http.StatusOK, timeline)
+	}
+}
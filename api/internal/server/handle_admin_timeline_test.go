@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminTimeline(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Timeline",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "A", Clue: "Go to A", Question: "1+1?", CorrectAnswer: "2", UnlockCode: "CODE-A"},
+			{Location: "B", Clue: "Go to B", Question: "2+2?", CorrectAnswer: "4", UnlockCode: "CODE-B"},
+		},
+	}
+	r, store, gameID, first := gameRouter(t, sc, AdminGameRequest{})
+	r.Get("/games/{gameID}/timeline", handleAdminTimeline())
+	second, err := store.CreateTeam(context.Background(), gameID, AdminTeamRequest{Name: "Team Two"}, "join-second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The two teams take turns, so their entries interleave.
+	ana := join(t, r, first.JoinToken, "Ana")
+	luis := join(t, r, second.JoinToken, "Luis")
+	postJSON(t, r, "/api/demo/game/unlock", luis.Token, UnlockRequest{Code: "CODE-A"})
+	postJSON(t, r, "/api/demo/game/unlock", ana.Token, UnlockRequest{Code: "CODE-A"})
+	postJSON(t, r, "/api/demo/game/answer", luis.Token, AnswerRequest{Answer: "2"})
+	postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "3"})
+	postJSON(t, r, "/api/demo/game/unlock", ana.Token, UnlockRequest{Code: "CODE-B"})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/games/"+gameID+"/timeline"+query, nil))
+		return w
+	}
+
+	w := get("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("timeline: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var timeline []TimelineEntry
+	json.NewDecoder(w.Body).Decode(&timeline)
+
+	want := []string{
+		"Ana joined Team Test",
+		"Luis joined Team Two",
+		"Team Two unlocked stage 1 (Luis)",
+		"Team Test unlocked stage 1 (Ana)",
+		`Team Two answered stage 1 correctly: "2" (Luis)`,
+		`Team Test answered stage 1 wrongly: "3" (Ana)`,
+		"Team Test unlocked stage 2 (Ana)",
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(timeline), timeline)
+	}
+	for i, e := range timeline {
+		if e.Text != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, e.Text, want[i])
+		}
+		if len(e.Time) != len("15:04") || (i > 0 && e.At < timeline[i-1].At) {
+			t.Errorf("entry %d: time %q at %q out of order", i, e.Time, e.At)
+		}
+	}
+
+	w = get("?format=text")
+	if got := w.Header().Get("Content-Type"); w.Code != http.StatusOK || !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("text format: got %d with content type %q", w.Code, got)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != len(want) || lines[2] != timeline[2].Time+" "+want[2] {
+		t.Errorf("text format = %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/games/nope/timeline", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminUnlockResponse reports the stage an operator unlocked. Completed is
+// set in modes without questions, where unlocking a stage completes it.
+type AdminUnlockResponse struct {
+	StageNumber int  `json:"stageNumber"`
+	Completed   bool `json:"completed"`
+}
+
+// handleAdminUnlockTeamStage unlocks a team's current stage out-of-band, for
+// a lost QR code or a stuck team, without the in-game supervisor. The team
+// is notified as if it had unlocked the stage itself, and the unlock is
+// recorded as an admin override on the team.
+func handleAdminUnlockTeamStage(broker *Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := clientStore(r)
+		gameID := chi.URLParam(r, "gameID")
+		teamID := chi.URLParam(r, "teamID")
+
+		if _, err := store.SessionTeam(r.Context(), gameID, teamID); errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "team not found")
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		data, err := store.GameState(r.Context(), gameID, teamID)
+		if err != nil {
+			writeGameError(w, gameID, err)
+			return
+		}
+		if data.Status != "active" {
+			writeError(w, http.StatusConflict, "game is not active")
+			return
+		}
+		if !modeRequiresUnlock(data.Mode) {
+			writeError(w, http.StatusConflict, "classic mode does not use unlock")
+			return
+		}
+		if data.PendingAdvance {
+			writeError(w, http.StatusConflict, "team has not moved on from its last result")
+			return
+		}
+
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, gameID, err)
+			return
+		}
+		answered, err := store.CountAnsweredStages(r.Context(), gameID, teamID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		stageNumber := answered + 1
+		if stageNumber > len(stages) {
+			writeError(w, http.StatusConflict, "all stages completed")
+			return
+		}
+		if isStageUnlocked(data.UnlockedStages, stageNumber) {
+			writeError(w, http.StatusConflict, "stage already unlocked")
+			return
+		}
+		stage := stages[rotatedStageIndex(stageNumber, data.StartStage, len(stages))]
+
+		resp := AdminUnlockResponse{StageNumber: stageNumber}
+		if modeHasQuestion(data.Mode) {
+			err = store.UnlockStage(r.Context(), gameID, teamID, stageNumber)
+		} else {
+			err = store.UnlockAndCompleteStage(r.Context(), gameID, teamID, stageNumber)
+			resp.Completed = true
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if err := store.RecordAdminUnlock(r.Context(), gameID, teamID, stageNumber); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		logGameEvent(r, store, gameID, GameEvent{
+			Type:        "unlocked",
+			TeamID:      teamID,
+			Admin:       adminFrom(r).Email,
+			StageNumber: stageNumber,
+			Completed:   resp.Completed,
+		})
+
+		if resp.Completed {
+			broker.Publish(teamID, SSEEvent{
+				Type:        "stage_completed",
+				StageNumber: stageNumber,
+			})
+		} else {
+			unlocked, err := store.GameState(r.Context(), gameID, teamID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			question := unlockedQuestion(stage)
+			broker.Publish(teamID, SSEEvent{
+				Type:            "stage_unlocked",
+				StageNumber:     stageNumber,
+				StageQuestion:   &question,
+				StageUnlockedAt: unlocked.StageUnlockedAt,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
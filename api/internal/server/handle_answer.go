@@ -1,10 +1,11 @@
 package server
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type AnswerRequest struct {
@@ -17,10 +18,67 @@ type AnswerResponse struct {
 	NextStage     *StageInfo `json:"nextStage"`
 	GameComplete  bool       `json:"gameComplete"`
 	CorrectAnswer string     `json:"correctAnswer"`
+	Explanation   string     `json:"explanation"`
 	FunFacts      []FunFact  `json:"funFacts,omitempty"`
+	// Set in manualAdvance games when this player may call POST /game/next
+	// to leave the result screen; otherwise the team waits for whoever can.
+	CanAdvance bool `json:"canAdvance,omitempty"`
+	// Set while a game requiring every player's answer is still waiting on
+	// teammates; the result fields stay empty until the team is graded.
+	Waiting         bool `json:"waiting,omitempty"`
+	AnsweredPlayers int  `json:"answeredPlayers,omitempty"`
+	RequiredPlayers int  `json:"requiredPlayers,omitempty"`
+	// Set in maxAttempts games when a wrong answer leaves the stage open:
+	// the team may answer again this many more times. The result fields stay
+	// empty until the stage is settled.
+	AttemptsRemaining int `json:"attemptsRemaining,omitempty"`
 }
 
-func handleAnswer(broker *Broker) http.HandlerFunc {
+// setResult fills in what every answer result shows: the stage's answer and
+// explanation, and the team's next stage or that the game is complete.
+func (resp *AnswerResponse) setResult(data gameStateData, stages []scenarioStage, stage scenarioStage, role string) {
+	nextStageNum := resp.StageNumber + 1
+	if nextStageNum <= len(stages) {
+		nextIdx := rotatedStageIndex(nextStageNum, data.StartStage, len(stages))
+		s := stages[nextIdx]
+		ns := StageInfo{
+			StageNumber:  nextStageNum,
+			Clue:         s.Clue,
+			ClueImage:    s.ClueImage,
+			Location:     data.stageLocation(role, s.Location),
+			Locked:       modeRequiresUnlock(data.Mode),
+			UnlockMethod: unlockMethod(data.Mode),
+		}
+		if !ns.Locked {
+			ns.Question = s.Question
+			ns.QuestionImage = s.QuestionImage
+			ns.AnswerType = s.inputType()
+			ns.Attachments = s.Attachments
+		} else if data.HideLockedClue {
+			// The client fetches the clue from game state once it's ready.
+			ns.Clue = ""
+			ns.ClueImage = ""
+		}
+		resp.NextStage = &ns
+	} else {
+		resp.GameComplete = true
+	}
+
+	resp.CorrectAnswer = stage.CorrectAnswer
+	resp.Explanation = stage.Explanation
+	if len(stage.FunFacts) > 0 {
+		resp.FunFacts = stage.FunFacts
+	}
+}
+
+// tooLong reports whether s is over maxLen characters; maxLen 0 means no cap.
+func tooLong(s string, maxLen int) bool {
+	return maxLen > 0 && utf8.RuneCountInString(s) > maxLen
+}
+
+// handleAnswer records a team's answer. Answers over maxLen characters are
+// rejected, since each one is kept in the game document (0 = no cap).
+func handleAnswer(broker *Broker, maxLen int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sess, err := playerFromRequest(r)
 		if err != nil {
@@ -28,6 +86,11 @@ func handleAnswer(broker *Broker) http.HandlerFunc {
 			return
 		}
 
+		if sess.Role == "spectator" {
+			writeError(w, http.StatusForbidden, "spectators cannot submit answers")
+			return
+		}
+
 		var req AnswerRequest
 		if err := readJSON(r, &req); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid request body")
@@ -38,14 +101,19 @@ func handleAnswer(broker *Broker) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, "answer is required")
 			return
 		}
+		if tooLong(req.Answer, maxLen) {
+			writeError(w, http.StatusBadRequest, "answer is too long")
+			return
+		}
 
 		store := clientStore(r)
 
-		data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+		progress, err := store.TeamProgress(r.Context(), sess.GameID, sess.TeamID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+			writeGameError(w, sess.GameID, err)
 			return
 		}
+		data := progress.forPlayer(sess.PlayerID)
 
 		if data.TimerEnabled && data.Status == "active" && data.StartedAt != nil {
 			start, _ := time.Parse(time.RFC3339Nano, *data.StartedAt)
@@ -56,28 +124,40 @@ func handleAnswer(broker *Broker) http.HandlerFunc {
 			}
 		}
 
+		if data.Status == "lobby" {
+			writeError(w, http.StatusConflict, "game has not started yet")
+			return
+		}
 		if data.Status != "active" {
 			writeError(w, http.StatusConflict, "game is not active")
 			return
 		}
 
-		if data.Supervised && sess.Role != "supervisor" {
+		if data.Supervised && !data.PlayersCanAnswer && sess.Role != "supervisor" {
 			writeError(w, http.StatusForbidden, "only the supervisor can submit answers")
 			return
 		}
 
-		var stages []scenarioStage
-		if err := json.Unmarshal([]byte(data.StagesJSON), &stages); err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
 			return
 		}
 
-		answeredCount, err := store.CountAnsweredStages(r.Context(), sess.GameID, sess.TeamID)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+		answeredCount := data.answered()
+
+		if len(stages) == 0 {
+			writeError(w, http.StatusConflict, "game has no stages")
+			return
+		}
+		if data.PendingAdvance {
+			writeError(w, http.StatusConflict, "move on to the next stage first")
+			return
+		}
+		if data.waitingForTeammates() {
+			writeError(w, http.StatusConflict, "waiting for teammates")
 			return
 		}
-
 		currentStageNum := answeredCount + 1
 		if currentStageNum > len(stages) {
 			writeError(w, http.StatusConflict, "all stages completed")
@@ -95,63 +175,129 @@ func handleAnswer(broker *Broker) http.HandlerFunc {
 		}
 
 		// Enforce stage timer: if unlocked and stage timer expired, auto-record wrong.
-		stageTimerExpired := false
-		if data.StageTimerMinutes > 0 && data.StageUnlockedAt != nil {
-			unlockTime, _ := time.Parse(time.RFC3339Nano, *data.StageUnlockedAt)
-			if time.Since(unlockTime) > time.Duration(data.StageTimerMinutes)*time.Minute {
-				stageTimerExpired = true
-			}
-		}
+		remaining, running := data.stageRemaining(time.Now())
+		stageTimerExpired := running && remaining < 0
 
 		idx := rotatedStageIndex(currentStageNum, data.StartStage, len(stages))
 		stage := stages[idx]
-		isCorrect := !stageTimerExpired && strings.EqualFold(
-			strings.TrimSpace(req.Answer),
-			strings.TrimSpace(stage.CorrectAnswer),
-		)
+		isCorrect := !stageTimerExpired && stage.matchesAnswer(req.Answer, data.answerRules())
+
+		// With maxAttempts a wrong answer only settles the stage once it's the
+		// last attempt; the stage then fails. A correct answer or a stage
+		// timer that ran out settles it straight away.
+		var stageFailed bool
 
-		if err := store.RecordAnswer(r.Context(), sess.GameID, sess.TeamID, currentStageNum, req.Answer, isCorrect); err != nil {
+		if stageTimerExpired {
+			// Too late: the stage is over for the team, as when game state
+			// finds the timer run out. The answer is kept for the record.
+			if _, err := store.TimeOutStage(r.Context(), sess.GameID, sess.TeamID, sess.PlayerID, currentStageNum, req.Answer); err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+		} else if data.RequireAllPlayers && !data.Supervised {
+			// In supervised games only the supervisor answers, so the
+			// per-player requirement doesn't apply.
+			progress, err := store.RecordPlayerAnswer(r.Context(), sess.GameID, sess.TeamID, sess.PlayerID, currentStageNum, req.Answer, isCorrect)
+			if errors.Is(err, ErrAlreadyAnswered) {
+				writeError(w, http.StatusConflict, "you already answered this stage")
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if !progress.Complete {
+				logGameEvent(r, store, sess.GameID, GameEvent{
+					Type:        "player_answered",
+					TeamID:      sess.TeamID,
+					PlayerID:    sess.PlayerID,
+					StageNumber: currentStageNum,
+					Answer:      req.Answer,
+					IsCorrect:   isCorrect,
+				})
+				broker.PublishCoalesced(sess.TeamID, SSEEvent{
+					Type:        "player_answered",
+					StageNumber: currentStageNum,
+					PlayerName:  progress.PlayerName,
+				})
+				writeJSON(w, http.StatusOK, AnswerResponse{
+					StageNumber:     currentStageNum,
+					Waiting:         true,
+					AnsweredPlayers: progress.Answered,
+					RequiredPlayers: progress.Required,
+				})
+				return
+			}
+			isCorrect = progress.IsCorrect
+		} else if data.maxAttempts() > 0 && !isCorrect {
+			attempts, failed, err := store.RecordAttempt(r.Context(), sess.GameID, sess.TeamID, sess.PlayerID, currentStageNum, req.Answer)
+			if errors.Is(err, ErrAlreadyAnswered) {
+				writeError(w, http.StatusConflict, "stage already answered")
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if !failed {
+				logGameEvent(r, store, sess.GameID, GameEvent{
+					Type:        "attempted",
+					TeamID:      sess.TeamID,
+					PlayerID:    sess.PlayerID,
+					StageNumber: currentStageNum,
+					Answer:      req.Answer,
+				})
+				remaining := data.maxAttempts() - attempts
+				broker.Publish(sess.TeamID, SSEEvent{
+					Type:              "wrong_attempt",
+					StageNumber:       currentStageNum,
+					AttemptsRemaining: remaining,
+				})
+				writeJSON(w, http.StatusOK, AnswerResponse{
+					StageNumber:       currentStageNum,
+					AttemptsRemaining: remaining,
+				})
+				return
+			}
+			stageFailed = true
+		} else if err := store.RecordAnswer(r.Context(), sess.GameID, sess.TeamID, sess.PlayerID, currentStageNum, req.Answer, isCorrect); err != nil {
 			writeError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
 
+		logGameEvent(r, store, sess.GameID, GameEvent{
+			Type:        "answered",
+			TeamID:      sess.TeamID,
+			PlayerID:    sess.PlayerID,
+			StageNumber: currentStageNum,
+			Answer:      req.Answer,
+			IsCorrect:   isCorrect,
+		})
+
 		resp := AnswerResponse{
 			IsCorrect:   isCorrect,
 			StageNumber: currentStageNum,
+			CanAdvance:  data.canAdvance(sess.Role),
 		}
 
 		// Both correct and incorrect answers advance to the next stage.
-		nextStageNum := currentStageNum + 1
-		if nextStageNum <= len(stages) {
-			nextIdx := rotatedStageIndex(nextStageNum, data.StartStage, len(stages))
-			s := stages[nextIdx]
-			ns := StageInfo{
-				StageNumber: nextStageNum,
-				Clue:        s.Clue,
-				ClueImage:   s.ClueImage,
-				Location:    s.Location,
-				Locked:      modeRequiresUnlock(data.Mode),
-			}
-			if !ns.Locked {
-				ns.Question = s.Question
-				ns.QuestionImage = s.QuestionImage
-			}
-			resp.NextStage = &ns
-		} else {
-			resp.GameComplete = true
-		}
-
-		resp.CorrectAnswer = stage.CorrectAnswer
-		if len(stage.FunFacts) > 0 {
-			resp.FunFacts = stage.FunFacts
-		}
+		resp.setResult(data.gameStateData, stages, stage, sess.Role)
 
-		if isCorrect {
+		switch {
+		case data.individualScoring(data.Mode):
+			// The result is this player's alone; teammates stay on their own
+			// stages.
+		case isCorrect:
 			broker.Publish(sess.TeamID, SSEEvent{
 				Type:        "stage_completed",
 				StageNumber: currentStageNum,
 			})
-		} else {
+		case stageFailed:
+			broker.Publish(sess.TeamID, SSEEvent{
+				Type:        "stage_failed",
+				StageNumber: currentStageNum,
+			})
+		default:
 			broker.Publish(sess.TeamID, SSEEvent{
 				Type:        "wrong_answer",
 				StageNumber: currentStageNum,
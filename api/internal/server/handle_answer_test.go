@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// nextEvent returns the next event published to a team other than the
+// coalesced player_joined.
+func nextEvent(t *testing.T, events chan []byte) SSEEvent {
+	t.Helper()
+	for {
+		select {
+		case data := <-events:
+			var ev SSEEvent
+			json.Unmarshal(data, &ev)
+			if ev.Type != "player_joined" {
+				return ev
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+}
+
+// answer submits an answer that must be accepted and returns the result.
+func answer(t *testing.T, r *chi.Mux, token, text string) AnswerResponse {
+	t.Helper()
+	w := postJSON(t, r, "/api/demo/game/answer", token, AnswerRequest{Answer: text})
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer %q: expected 200, got %d: %s", text, w.Code, w.Body.String())
+	}
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp
+}
+
+func TestMaxAttempts(t *testing.T) {
+	broker := NewBroker()
+	r, store, gameID, team := brokerRouter(t, giveUpScenario(), AdminGameRequest{MaxAttempts: 2}, broker)
+	player := join(t, r, team.JoinToken, "Ana")
+
+	state := gameState(t, r, player.Token)
+	if state.Game.MaxAttempts != 2 || state.AttemptsRemaining != 2 {
+		t.Errorf("game state: maxAttempts %d, attemptsRemaining %d, want 2 and 2", state.Game.MaxAttempts, state.AttemptsRemaining)
+	}
+
+	events := broker.Subscribe(team.ID, 0)
+	defer broker.Unsubscribe(team.ID, events)
+
+	// A wrong answer with attempts left keeps the team on the stage.
+	resp := answer(t, r, player.Token, "1650")
+	if resp.IsCorrect || resp.AttemptsRemaining != 1 || resp.NextStage != nil || resp.CorrectAnswer != "" {
+		t.Errorf("first wrong answer = %+v, want 1 attempt left and no result", resp)
+	}
+	if got, want := nextEvent(t, events), (SSEEvent{Type: "wrong_attempt", StageNumber: 1, AttemptsRemaining: 1}); got != want {
+		t.Errorf("event = %+v, want %+v", got, want)
+	}
+	if n, _ := store.CountAttempts(context.Background(), gameID, team.ID, 1); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+	state = gameState(t, r, player.Token)
+	if state.CurrentStage == nil || state.CurrentStage.StageNumber != 1 || state.AttemptsRemaining != 1 {
+		t.Errorf("after a wrong attempt: stage %+v with %d attempts left, want stage 1 with 1", state.CurrentStage, state.AttemptsRemaining)
+	}
+
+	// The last attempt fails the stage and moves the team on.
+	resp = answer(t, r, player.Token, "1652")
+	if resp.IsCorrect || resp.AttemptsRemaining != 0 || resp.CorrectAnswer != "1651" || resp.NextStage == nil || resp.NextStage.StageNumber != 2 {
+		t.Errorf("last wrong answer = %+v, want stage 1 failed with its answer", resp)
+	}
+	if got, want := nextEvent(t, events), (SSEEvent{Type: "stage_failed", StageNumber: 1}); got != want {
+		t.Errorf("event = %+v, want %+v", got, want)
+	}
+	state = gameState(t, r, player.Token)
+	if len(state.CompletedStages) != 1 || state.CompletedStages[0].IsCorrect || state.AttemptsRemaining != 2 {
+		t.Errorf("after failing: completed %+v with %d attempts left, want stage 1 wrong and 2 fresh attempts", state.CompletedStages, state.AttemptsRemaining)
+	}
+
+	// A correct answer isn't an attempt, even after a wrong one.
+	answer(t, r, player.Token, "Lima")
+	resp = answer(t, r, player.Token, "Rosa")
+	if !resp.IsCorrect || !resp.GameComplete {
+		t.Errorf("correct answer after a wrong one = %+v, want it correct and the game complete", resp)
+	}
+	if n, _ := store.CountAttempts(context.Background(), gameID, team.ID, 2); n != 0 {
+		t.Errorf("attempts on a settled stage = %d, want 0", n)
+	}
+}
+
+func TestMaxAttemptsZero(t *testing.T) {
+	// 0 isn't unlimited: games without the setting keep settling a stage on
+	// its first answer, right or wrong.
+	r, store, gameID, team := gameRouter(t, giveUpScenario(), AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	if state := gameState(t, r, player.Token); state.Game.MaxAttempts != 0 || state.AttemptsRemaining != 0 {
+		t.Errorf("game state: maxAttempts %d, attemptsRemaining %d, want neither", state.Game.MaxAttempts, state.AttemptsRemaining)
+	}
+	resp := answer(t, r, player.Token, "1650")
+	if resp.IsCorrect || resp.AttemptsRemaining != 0 || resp.CorrectAnswer != "1651" || resp.NextStage == nil || resp.NextStage.StageNumber != 2 {
+		t.Errorf("wrong answer = %+v, want stage 1 settled wrong and stage 2 next", resp)
+	}
+	if n, _ := store.CountAttempts(context.Background(), gameID, team.ID, 1); n != 0 {
+		t.Errorf("attempts = %d, want none kept", n)
+	}
+}
+
+func TestMaxAttemptsValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		req  AdminGameRequest
+		ok   bool
+	}{
+		{"off", AdminGameRequest{}, true},
+		{"limit", AdminGameRequest{MaxAttempts: 3}, true},
+		{"negative", AdminGameRequest{MaxAttempts: -1}, false},
+		{"too many", AdminGameRequest{MaxAttempts: maxMaxAttempts + 1}, false},
+		{"with requireAllPlayers", AdminGameRequest{MaxAttempts: 3, RequireAllPlayers: true}, false},
+	} {
+		tc.req.ScenarioID = "sc"
+		if msg := tc.req.validate(TimerLimits{}); (msg == "") != tc.ok {
+			t.Errorf("%s: validate = %q, want ok %v", tc.name, msg, tc.ok)
+		}
+	}
+}
+
+func TestIndividualScoring(t *testing.T) {
+	t.Run("team", func(t *testing.T) {
+		r, _, _, team := gameRouter(t, giveUpScenario(), AdminGameRequest{})
+		ana := join(t, r, team.JoinToken, "Ana")
+		luis := join(t, r, team.JoinToken, "Luis")
+
+		// Ana's answer is the team's: Luis moves on with her.
+		answer(t, r, ana.Token, "1651")
+		state := gameState(t, r, luis.Token)
+		if state.Game.IndividualScoring || state.CurrentStage == nil || state.CurrentStage.StageNumber != 2 {
+			t.Errorf("Luis after Ana answered: stage %+v, want stage 2", state.CurrentStage)
+		}
+		if w := postJSON(t, r, "/api/demo/game/answer", luis.Token, AnswerRequest{Answer: "Rosa"}); w.Code != http.StatusOK {
+			t.Errorf("Luis answering stage 2: expected 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("individual", func(t *testing.T) {
+		broker := NewBroker()
+		r, store, gameID, team := brokerRouter(t, giveUpScenario(), AdminGameRequest{IndividualScoring: true, AllowGiveUp: true}, broker)
+		ana := join(t, r, team.JoinToken, "Ana")
+		luis := join(t, r, team.JoinToken, "Luis")
+
+		events := broker.Subscribe(team.ID, 0)
+		defer broker.Unsubscribe(team.ID, events)
+
+		if resp := answer(t, r, ana.Token, "1651"); !resp.IsCorrect || resp.NextStage == nil || resp.NextStage.StageNumber != 2 {
+			t.Errorf("Ana's answer = %+v, want stage 1 correct", resp)
+		}
+		state := gameState(t, r, luis.Token)
+		if !state.Game.IndividualScoring || state.CurrentStage == nil || state.CurrentStage.StageNumber != 1 || len(state.CompletedStages) != 0 {
+			t.Errorf("Luis after Ana answered: stage %+v, completed %+v, want stage 1 and nothing done", state.CurrentStage, state.CompletedStages)
+		}
+
+		// Luis answers stage 1 for himself, and gives up stage 2.
+		if resp := answer(t, r, luis.Token, "1650"); resp.IsCorrect || resp.StageNumber != 1 {
+			t.Errorf("Luis's answer = %+v, want stage 1 wrong", resp)
+		}
+		if w := postJSON(t, r, "/api/demo/game/giveup", luis.Token, nil); w.Code != http.StatusOK {
+			t.Fatalf("Luis giving up stage 2: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		state = gameState(t, r, luis.Token)
+		if state.Game.Phase != "finished" || len(state.CompletedStages) != 2 || state.CompletedStages[0].IsCorrect {
+			t.Errorf("Luis after both stages: phase %q, completed %+v", state.Game.Phase, state.CompletedStages)
+		}
+		state = gameState(t, r, ana.Token)
+		if state.CurrentStage == nil || state.CurrentStage.StageNumber != 2 || len(state.CompletedStages) != 1 || !state.CompletedStages[0].IsCorrect {
+			t.Errorf("Ana: stage %+v, completed %+v, want on stage 2 with stage 1 correct", state.CurrentStage, state.CompletedStages)
+		}
+
+		// Results are a player's own, so teammates don't get them over SSE.
+		select {
+		case data := <-events:
+			var ev SSEEvent
+			json.Unmarshal(data, &ev)
+			if ev.Type != "player_joined" {
+				t.Errorf("unexpected event %+v", ev)
+			}
+		default:
+		}
+
+		status, err := store.GameStatus(context.Background(), gameID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st := status.Teams[0]; st.CompletedStages != 1 || st.ProgressPercent != 75 || len(st.Results) != 3 {
+			t.Errorf("team status: %d correct, %d%%, %d results, want 1, 75%% and 3", st.CompletedStages, st.ProgressPercent, len(st.Results))
+		}
+	})
+
+	if msg := (&AdminGameRequest{ScenarioID: "sc", IndividualScoring: true, ManualAdvance: true}).validate(TimerLimits{}); msg == "" {
+		t.Error("individualScoring with manualAdvance should be rejected")
+	}
+}
+
+func TestNumericAnswer(t *testing.T) {
+	sc := giveUpScenario()
+	sc.Stages[0].AnswerType = "number"
+	sc.Stages[0].NumericTolerance = 5
+	sc.Stages[1].AnswerType = "number"
+	sc.Stages[1].CorrectAnswer = "12.5"
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	state := gameState(t, r, player.Token)
+	if state.CurrentStage == nil || state.CurrentStage.AnswerType != "number" {
+		t.Fatalf("current stage = %+v, want a number answer", state.CurrentStage)
+	}
+
+	if resp := answer(t, r, player.Token, "1648"); !resp.IsCorrect || resp.NextStage == nil || resp.NextStage.AnswerType != "number" {
+		t.Errorf("answer within tolerance = %+v, want it correct", resp)
+	}
+	// Text on a numeric stage is a wrong answer, not a bad request.
+	if resp := answer(t, r, player.Token, "twelve"); resp.IsCorrect || resp.CorrectAnswer != "12.5" {
+		t.Errorf("non-numeric answer = %+v, want it wrong", resp)
+	}
+}
+
+func TestPoints(t *testing.T) {
+	ctx := context.Background()
+
+	// By default every correct stage scores defaultStagePoints, whenever it's
+	// answered, and a wrong one nothing.
+	r, store, gameID, team := gameRouter(t, giveUpScenario(), AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+	answer(t, r, player.Token, "1651")
+	answer(t, r, player.Token, "Lima")
+	state := gameState(t, r, player.Token)
+	if len(state.CompletedStages) != 2 || state.CompletedStages[0].Points != 100 || state.CompletedStages[1].Points != 0 || state.TeamScore != 100 {
+		t.Errorf("default points: completed %+v, team score %d, want 100 and 0 for a score of 100", state.CompletedStages, state.TeamScore)
+	}
+
+	// Regrading keeps the points in step with the result.
+	if _, _, err := store.RegradeGame(ctx, gameID, map[int]string{2: "Lima"}); err != nil {
+		t.Fatalf("regrade: %v", err)
+	}
+	if state := gameState(t, r, player.Token); state.CompletedStages[1].Points != 100 || state.TeamScore != 200 {
+		t.Errorf("after accepting Lima: completed %+v, team score %d, want stage 2 at 100 and 200", state.CompletedStages, state.TeamScore)
+	}
+	if _, _, err := store.RegradeGame(ctx, gameID, map[int]string{1: "1650"}); err != nil {
+		t.Fatalf("regrade: %v", err)
+	}
+	if state := gameState(t, r, player.Token); state.CompletedStages[0].Points != 0 || state.TeamScore != 100 {
+		t.Errorf("after rejecting 1651: completed %+v, team score %d, want stage 1 at 0 and 100", state.CompletedStages, state.TeamScore)
+	}
+
+	// With pointsDecay each wrong attempt costs a quarter of the stage's
+	// points, down to half of them.
+	sc := giveUpScenario()
+	sc.Stages[0].Points = 200
+	r, _, _, team = gameRouter(t, sc, AdminGameRequest{MaxAttempts: 5, PointsDecay: true})
+	player = join(t, r, team.JoinToken, "Ana")
+	answer(t, r, player.Token, "1650")
+	answer(t, r, player.Token, "1651")
+	for _, wrong := range []string{"Lima", "Cusco", "Puno"} {
+		answer(t, r, player.Token, wrong)
+	}
+	answer(t, r, player.Token, "Rosa")
+	state = gameState(t, r, player.Token)
+	if len(state.CompletedStages) != 2 || state.CompletedStages[0].Points != 150 || state.CompletedStages[1].Points != 50 || state.TeamScore != 200 {
+		t.Errorf("decayed points: completed %+v, team score %d, want 150 and the floor of 50 for 200", state.CompletedStages, state.TeamScore)
+	}
+
+	sc.Stages[0].Points = -1
+	if msg := sc.validate(); msg != "stage 1: points must be between 0 and 10000" {
+		t.Errorf("validate negative points = %q", msg)
+	}
+}
+
+func TestDecayedPoints(t *testing.T) {
+	for _, tc := range []struct {
+		points int
+		taken  float64
+		wrong  int
+		want   int
+	}{
+		{100, 0, 0, 100},
+		{100, 0.5, 0, 75},
+		{100, 1, 0, 50},
+		{100, 3, 0, 50}, // answered after the timer ran out
+		{100, 0, 1, 75},
+		{100, 0.5, 1, 50},
+		{100, 0, 4, 50},
+		{1, 1, 2, 1},
+		{15, 0, 2, 8}, // half rounds up
+	} {
+		if got := decayedPoints(tc.points, tc.taken, tc.wrong); got != tc.want {
+			t.Errorf("decayedPoints(%d, %v, %d) = %d, want %d", tc.points, tc.taken, tc.wrong, got, tc.want)
+		}
+	}
+}
+
+func TestLateAnswerNotRegraded(t *testing.T) {
+	sc := giveUpScenario()
+	r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{TimerEnabled: true, StageTimerMinutes: 5})
+	ctx := context.Background()
+	player := join(t, r, team.JoinToken, "Ana")
+
+	// The right answer, a minute after the stage timer ran out.
+	gameState(t, r, player.Token)
+	err := store.modifyGame(ctx, gameID, func(g *game) error {
+		at := time.Now().Add(-6 * time.Minute).UTC().Format(time.RFC3339Nano)
+		g.Teams[0].StageUnlockedAt = &at
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("backdate stage: %v", err)
+	}
+	if resp := answer(t, r, player.Token, "1651"); resp.IsCorrect || resp.NextStage == nil || resp.NextStage.StageNumber != 2 {
+		t.Fatalf("late answer = %+v, want wrong and on to stage 2", resp)
+	}
+
+	// Regrading doesn't turn it correct, though the answer matches.
+	flipped, checked, err := store.RegradeGame(ctx, gameID, map[int]string{1: "1651"})
+	if err != nil {
+		t.Fatalf("regrade: %v", err)
+	}
+	if flipped != 0 || checked != 0 {
+		t.Errorf("regrade flipped %d of %d, want the late answer skipped", flipped, checked)
+	}
+	g, _ := store.getGame(ctx, gameID)
+	if res := g.Teams[0].Results; len(res) != 1 || res[0].IsCorrect || !res[0].TimedOut || res[0].Answer != "1651" {
+		t.Errorf("results = %+v, want the late answer kept, wrong and timed out", res)
+	}
+}
+
+func TestRegradeTeamVote(t *testing.T) {
+	r, store, gameID, team := gameRouter(t, giveUpScenario(), AdminGameRequest{RequireAllPlayers: true})
+	ctx := context.Background()
+	ana := join(t, r, team.JoinToken, "Ana")
+	luis := join(t, r, team.JoinToken, "Luis")
+	rosa := join(t, r, team.JoinToken, "Rosa")
+
+	// One player has it right, so the team keeps that answer but is outvoted.
+	postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "1651"})
+	postJSON(t, r, "/api/demo/game/answer", luis.Token, AnswerRequest{Answer: "1650"})
+	if resp := answer(t, r, rosa.Token, "1650"); resp.Waiting || resp.IsCorrect {
+		t.Fatalf("1 of 3 correct = %+v, want the team wrong", resp)
+	}
+
+	teamResult := func() stageResult {
+		t.Helper()
+		g, _ := store.getGame(ctx, gameID)
+		if res := g.Teams[0].Results; len(res) == 1 {
+			return res[0]
+		}
+		t.Fatalf("results = %+v, want one", g.Teams[0].Results)
+		return stageResult{}
+	}
+	if res := teamResult(); res.Answer != "1651" || len(res.PlayerAnswers) != 3 {
+		t.Fatalf("team result = %+v, want Ana's answer and all three kept", res)
+	}
+
+	// With the key fixed to the majority's answer the team vote carries.
+	flipped, checked, err := store.RegradeGame(ctx, gameID, map[int]string{1: "1650"})
+	if err != nil || flipped != 1 || checked != 1 {
+		t.Fatalf("regrade: flipped %d of %d (%v), want 1 of 1", flipped, checked, err)
+	}
+	if res := teamResult(); !res.IsCorrect || res.Answer != "1650" || res.PointsAwarded != defaultStagePoints {
+		t.Errorf("after regrade = %+v, want correct with the majority's answer", res)
+	}
+
+	// And back: a single right answer doesn't outvote the rest.
+	if flipped, _, _ := store.RegradeGame(ctx, gameID, map[int]string{1: "1651"}); flipped != 1 {
+		t.Errorf("regrade back flipped %d, want 1", flipped)
+	}
+	if res := teamResult(); res.IsCorrect || res.Answer != "1651" {
+		t.Errorf("after regrading back = %+v, want wrong, keeping Ana's answer", res)
+	}
+}
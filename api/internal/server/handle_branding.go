@@ -0,0 +1,122 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxBrandingNameLen caps the display name shown on the join screen.
+const maxBrandingNameLen = 60
+
+// hexColor matches the colors branding accepts: #rgb or #rrggbb.
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Branding is how a client's player UI looks before anyone joins: the SPA
+// themes the join screen with it. Empty fields keep the default look.
+type Branding struct {
+	Name         string `json:"name,omitempty"`
+	LogoURL      string `json:"logoUrl,omitempty"`
+	PrimaryColor string `json:"primaryColor,omitempty"` // #rgb or #rrggbb
+}
+
+// validate trims the fields and checks them. The logo must be an upload or
+// an http(s) URL: data URIs would bloat every client lookup.
+func (b *Branding) validate() string {
+	b.Name = strings.TrimSpace(b.Name)
+	b.LogoURL = strings.TrimSpace(b.LogoURL)
+	b.PrimaryColor = strings.TrimSpace(b.PrimaryColor)
+	if utf8.RuneCountInString(b.Name) > maxBrandingNameLen {
+		return fmt.Sprintf("name must be at most %d characters", maxBrandingNameLen)
+	}
+	if !validImageURL(b.LogoURL) || strings.HasPrefix(b.LogoURL, "data:") {
+		return "logoUrl must be an uploaded image or an http(s) URL"
+	}
+	if b.PrimaryColor != "" && !hexColor.MatchString(b.PrimaryColor) {
+		return "primaryColor must be a hex color like #1a73e8"
+	}
+	return ""
+}
+
+// handleBranding serves a client's branding to the join screen. It needs no
+// auth since players haven't joined yet. Without a branded name, the
+// client's own name is shown.
+func handleBranding(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, err := admin.GetClient(r.Context(), chi.URLParam(r, "client"))
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "client not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		b := client.Branding
+		if b.Name == "" {
+			b.Name = client.Name
+		}
+		writeJSON(w, http.StatusOK, b)
+	}
+}
+
+// handleAdminGetBranding returns the branding as stored, without defaults.
+func handleAdminGetBranding(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, err := admin.GetClient(r.Context(), chi.URLParam(r, "client"))
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "client not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, client.Branding)
+	}
+}
+
+func handleAdminUpdateBranding(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Branding
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := req.validate(); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		err := admin.SetClientBranding(r.Context(), chi.URLParam(r, "client"), req)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "client not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, req)
+	}
+}
+
+// handleAdminDeleteBranding puts the client back on the default look.
+func handleAdminDeleteBranding(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := admin.SetClientBranding(r.Context(), chi.URLParam(r, "client"), Branding{})
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "client not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
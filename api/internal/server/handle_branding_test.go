@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBranding(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path string, body any, auth bool) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if auth {
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	branding := func() Branding {
+		t.Helper()
+		w := do(http.MethodGet, "/api/demo/branding", nil, false)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get branding: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var b Branding
+		json.NewDecoder(w.Body).Decode(&b)
+		return b
+	}
+
+	// The branding lives on the admin DB's client record.
+	if w := do(http.MethodGet, "/api/demo/branding", nil, false); w.Code != http.StatusNotFound {
+		t.Errorf("branding before the client exists: expected 404, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/admin/clients", CreateClientRequest{Slug: "demo", Name: "Demo"}, true); w.Code != http.StatusCreated {
+		t.Fatalf("create client: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := branding(); got != (Branding{Name: "Demo"}) {
+		t.Errorf("unbranded client = %+v, want only its name", got)
+	}
+
+	want := Branding{Name: "Lima Tours", LogoURL: "/uploads/logo.png", PrimaryColor: "#1a73e8"}
+	if w := do(http.MethodPut, "/api/admin/clients/demo/branding", want, false); w.Code != http.StatusUnauthorized {
+		t.Errorf("update without a session: expected 401, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/api/admin/clients/demo/branding", Branding{Name: " Lima Tours ", LogoURL: want.LogoURL, PrimaryColor: want.PrimaryColor}, true); w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := branding(); got != want {
+		t.Errorf("branding = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []Branding{
+		{PrimaryColor: "blue"},
+		{PrimaryColor: "#12345"},
+		{LogoURL: "javascript:alert(1)"},
+		{LogoURL: "data:image/png;base64,AAAA"},
+	} {
+		if w := do(http.MethodPut, "/api/admin/clients/demo/branding", bad, true); w.Code != http.StatusBadRequest {
+			t.Errorf("update with %+v: expected 400, got %d", bad, w.Code)
+		}
+	}
+
+	if w := do(http.MethodDelete, "/api/admin/clients/demo/branding", nil, true); w.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w := do(http.MethodGet, "/api/admin/clients/demo/branding", nil, true)
+	var stored Branding
+	json.NewDecoder(w.Body).Decode(&stored)
+	if w.Code != http.StatusOK || stored != (Branding{}) {
+		t.Errorf("after delete: %d %+v, want 200 and no branding", w.Code, stored)
+	}
+}
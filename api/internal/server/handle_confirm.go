@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ConfirmResponse reports how many players have the unlocked stage in front
+// of them. Started is set once they all do and the stage timer is running.
+type ConfirmResponse struct {
+	StageNumber     int     `json:"stageNumber"`
+	Confirmed       int     `json:"confirmed"`
+	Required        int     `json:"required"`
+	Started         bool    `json:"started"`
+	StageUnlockedAt *string `json:"stageUnlockedAt,omitempty"`
+}
+
+// handleConfirm acknowledges a stage the supervisor just unlocked in a
+// waitForConfirmations game. The supervisor gets a player_confirmed event
+// per player; the last confirmation starts the stage timer and sends
+// everyone stage_started, so the whole team races the same clock.
+func handleConfirm(broker *Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, err := playerFromRequest(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+
+		if sess.Role != "player" {
+			writeError(w, http.StatusForbidden, "only players confirm a stage")
+			return
+		}
+
+		store := clientStore(r)
+
+		data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+
+		if data.Status != "active" {
+			writeError(w, http.StatusConflict, "game is not active")
+			return
+		}
+		if !data.Supervised || !data.WaitForConfirmations {
+			writeError(w, http.StatusConflict, "this game starts stages without confirmation")
+			return
+		}
+
+		progress, err := store.ConfirmStage(r.Context(), sess.GameID, sess.TeamID, sess.PlayerID)
+		if errors.Is(err, ErrNothingToConfirm) {
+			writeError(w, http.StatusConflict, "no unlocked stage to confirm")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		resp := ConfirmResponse{
+			StageNumber: progress.StageNumber,
+			Confirmed:   progress.Confirmed,
+			Required:    progress.Required,
+			Started:     progress.Started,
+		}
+		if progress.Started {
+			data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			resp.StageUnlockedAt = data.StageUnlockedAt
+		}
+
+		// A repeat confirmation changes nothing, so it announces nothing.
+		if progress.PlayerName != "" {
+			broker.PublishCoalesced(sess.TeamID, SSEEvent{
+				Type:        "player_confirmed",
+				StageNumber: progress.StageNumber,
+				PlayerName:  progress.PlayerName,
+			})
+			if progress.Started {
+				broker.Publish(sess.TeamID, SSEEvent{
+					Type:            "stage_started",
+					StageNumber:     progress.StageNumber,
+					StageUnlockedAt: resp.StageUnlockedAt,
+				})
+			}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
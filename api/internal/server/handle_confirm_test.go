@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func confirmScenario() AdminScenarioRequest {
+	return AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "A", Question: "1+1?", CorrectAnswer: "2"},
+			{Location: "B", Question: "2+2?", CorrectAnswer: "4"},
+		},
+	}
+}
+
+func TestConfirmStartsStageTimer(t *testing.T) {
+	r, _, _, team := gameRouter(t, confirmScenario(), AdminGameRequest{WaitForConfirmations: true, TimerEnabled: true, StageTimerMinutes: 5})
+	ana := join(t, r, team.JoinToken, "Ana")
+	luis := join(t, r, team.JoinToken, "Luis")
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	// Nothing to confirm before the supervisor unlocks.
+	if w := postJSON(t, r, "/api/demo/game/confirm", ana.Token, nil); w.Code != http.StatusConflict {
+		t.Errorf("confirm before unlock: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The question is out, but the timer waits for both players.
+	state := gameState(t, r, super.Token)
+	if state.StageUnlockedAt != nil {
+		t.Errorf("stageUnlockedAt = %v, want unset until everyone confirms", *state.StageUnlockedAt)
+	}
+	if c := state.Confirmations; c == nil || c.Confirmed != 0 || c.Required != 2 {
+		t.Errorf("confirmations = %+v, want 0 of 2", c)
+	}
+	if w := postJSON(t, r, "/api/demo/game/confirm", super.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("supervisor confirm: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := postJSON(t, r, "/api/demo/game/confirm", ana.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirm: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ConfirmResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.StageNumber != 1 || resp.Confirmed != 1 || resp.Required != 2 || resp.Started {
+		t.Errorf("first confirm = %+v, want 1 of 2, not started", resp)
+	}
+
+	// Confirming again doesn't count twice.
+	w = postJSON(t, r, "/api/demo/game/confirm", ana.Token, nil)
+	resp = ConfirmResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.Confirmed != 1 {
+		t.Errorf("repeat confirm: %d %+v, want 200 with 1 confirmed", w.Code, resp)
+	}
+	if c := gameState(t, r, ana.Token).Confirmations; c == nil || !c.Self || c.Confirmed != 1 {
+		t.Errorf("ana's confirmations = %+v, want self with 1 confirmed", c)
+	}
+
+	// The last confirmation starts the timer.
+	w = postJSON(t, r, "/api/demo/game/confirm", luis.Token, nil)
+	resp = ConfirmResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.Started || resp.StageUnlockedAt == nil {
+		t.Errorf("last confirm: %d %+v, want started with stageUnlockedAt", w.Code, resp)
+	}
+	state = gameState(t, r, super.Token)
+	if state.StageUnlockedAt == nil || state.Confirmations != nil {
+		t.Errorf("state after confirmations: stageUnlockedAt=%v confirmations=%+v", state.StageUnlockedAt, state.Confirmations)
+	}
+	if w := postJSON(t, r, "/api/demo/game/confirm", luis.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("confirm after start: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The next stage needs fresh confirmations.
+	if w := postJSON(t, r, "/api/demo/game/answer", super.Token, AnswerRequest{Answer: "2"}); w.Code != http.StatusOK {
+		t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock stage 2: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if c := gameState(t, r, ana.Token).Confirmations; c == nil || c.Confirmed != 0 || c.Self {
+		t.Errorf("stage 2 confirmations = %+v, want none yet", c)
+	}
+}
+
+func TestConfirmWithoutWaiting(t *testing.T) {
+	r, _, _, team := gameRouter(t, confirmScenario(), AdminGameRequest{TimerEnabled: true, StageTimerMinutes: 5})
+	ana := join(t, r, team.JoinToken, "Ana")
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	state := gameState(t, r, ana.Token)
+	if state.StageUnlockedAt == nil || state.Confirmations != nil {
+		t.Errorf("state after unlock: stageUnlockedAt=%v confirmations=%+v, want the timer running", state.StageUnlockedAt, state.Confirmations)
+	}
+	if w := postJSON(t, r, "/api/demo/game/confirm", ana.Token, nil); w.Code != http.StatusConflict {
+		t.Errorf("confirm: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -3,16 +3,32 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 )
 
-func handleEvents(broker *Broker) http.HandlerFunc {
+// handleEvents streams team events over SSE. Connections are closed after
+// maxAge with a "reconnect" event so that long-lived streams don't pile up
+// behind proxies; EventSource reconnects on its own and the client refetches
+// state on reopen. A zero maxAge disables the limit. Clients that would
+// rather not miss events can ask for a bigger buffer with ?buffer=N, capped
+// by buffer.Max (see SSEBuffer).
+func handleEvents(broker *Broker, maxAge time.Duration, buffer SSEBuffer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		token := r.URL.Query().Get("token")
 		if token == "" {
 			writeError(w, http.StatusUnauthorized, "token query parameter required")
 			return
 		}
+		var requested int
+		if s := r.URL.Query().Get("buffer"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				writeError(w, http.StatusBadRequest, "buffer must be a positive integer")
+				return
+			}
+			requested = n
+		}
 
 		store := clientStore(r)
 
@@ -28,28 +44,51 @@ func handleEvents(broker *Broker) http.HandlerFunc {
 			return
 		}
 
+		// The stream outlives the per-request read/write deadlines set by
+		// requestTimeouts; lift them and rely on maxAge instead.
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
 		w.Header().Set("X-Accel-Buffering", "no")
 		flusher.Flush()
 
-		ch := broker.Subscribe(sess.TeamID)
+		ch := broker.Subscribe(sess.TeamID, buffer.size(requested))
 		defer broker.Unsubscribe(sess.TeamID, ch)
 
 		ping := time.NewTicker(30 * time.Second)
 		defer ping.Stop()
 
+		// A nil channel blocks forever, which leaves the limit disabled.
+		var expired <-chan time.Time
+		if maxAge > 0 {
+			lifetime := time.NewTimer(maxAge)
+			defer lifetime.Stop()
+			expired = lifetime.C
+		}
+
 		for {
 			select {
 			case <-r.Context().Done():
 				return
 			case data := <-ch:
 				fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+				// Write whatever else is already queued (e.g. a coalesced
+				// batch) before flushing, so a burst goes out in one write.
+				for queued := len(ch); queued > 0; queued-- {
+					fmt.Fprintf(w, "event: state\ndata: %s\n\n", <-ch)
+				}
 				flusher.Flush()
 			case <-ping.C:
 				fmt.Fprintf(w, ": ping\n\n")
 				flusher.Flush()
+			case <-expired:
+				fmt.Fprintf(w, "retry: 1000\nevent: reconnect\ndata: {}\n\n")
+				flusher.Flush()
+				return
 			}
 		}
 	}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// gamePINLength is the number of digits in a game's quick-entry PIN.
+const gamePINLength = 6
+
+type GamePINTeam struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GamePINResponse lists an active game's teams so a player who entered the
+// game PIN can pick one and join with {pin, teamId} instead of a join token.
+type GamePINResponse struct {
+	GameName string        `json:"gameName"`
+	Language string        `json:"language,omitempty"`
+	Teams    []GamePINTeam `json:"teams"`
+	GameID   string        `json:"-"`
+}
+
+func generateGamePIN() string {
+	return generateJoinToken(JoinTokenFormat{Style: tokenStylePIN, PINLength: gamePINLength})
+}
+
+func validGamePIN(pin string) bool {
+	if len(pin) != gamePINLength {
+		return false
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func handleGamePIN() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pin := chi.URLParam(r, "pin")
+		if !validGamePIN(pin) {
+			writeError(w, http.StatusBadRequest, "pin must be 6 digits")
+			return
+		}
+		store := clientStore(r)
+
+		resp, err := store.GameByPIN(r.Context(), pin)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "game not found or not active")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGamePINLookup(t *testing.T) {
+	r, store := playerRouterWithStore(t)
+	ctx := context.Background()
+
+	g, err := store.GetGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	if !validGamePIN(g.PIN) {
+		t.Fatalf("active game should have a 6-digit PIN, got %q", g.PIN)
+	}
+
+	get := func(pin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/demo/games/pin/"+pin, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(g.PIN)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp GamePINResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.GameName != "Lima Centro Historico" {
+		t.Errorf("expected game name 'Lima Centro Historico', got %q", resp.GameName)
+	}
+	if len(resp.Teams) != 2 || resp.Teams[0].Name != "Los Incas" || resp.Teams[1].Name != "Los Condores" {
+		t.Fatalf("unexpected teams: %+v", resp.Teams)
+	}
+
+	other := "000000"
+	if g.PIN == other {
+		other = "000001"
+	}
+	if w := get(other); w.Code != http.StatusNotFound {
+		t.Errorf("unknown PIN: expected 404, got %d", w.Code)
+	}
+	if w := get("12ab56"); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed PIN: expected 400, got %d", w.Code)
+	}
+
+	// Join with the PIN and the picked team instead of a join token.
+	jr := postJSON(t, r, "/api/demo/join", "", JoinRequest{PIN: g.PIN, TeamID: resp.Teams[1].ID, PlayerName: "Ana"})
+	if jr.Code != http.StatusOK {
+		t.Fatalf("join by PIN: expected 200, got %d: %s", jr.Code, jr.Body.String())
+	}
+	var joined JoinResponse
+	json.NewDecoder(jr.Body).Decode(&joined)
+	if joined.TeamID != demoCondores.ID || joined.Role != "player" {
+		t.Errorf("unexpected join response: %+v", joined)
+	}
+	if w := postJSON(t, r, "/api/demo/join", "", JoinRequest{PIN: g.PIN, TeamID: "nope", PlayerName: "Ana"}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown team: expected 404, got %d", w.Code)
+	}
+
+	// Ending the game frees its PIN.
+	if err := store.ExpireGame(ctx, g.ID); err != nil {
+		t.Fatalf("expire game: %v", err)
+	}
+	if w := get(g.PIN); w.Code != http.StatusNotFound {
+		t.Errorf("ended game: expected 404, got %d", w.Code)
+	}
+	g, _ = store.GetGame(ctx, g.ID)
+	if g.PIN != "" {
+		t.Errorf("ended game should have no PIN, got %q", g.PIN)
+	}
+}
+
+func TestGamePINUnique(t *testing.T) {
+	_, store := setupStores(t)
+	ctx := context.Background()
+
+	g, _ := store.GetGame(ctx, demoSeed.GameID)
+	seen := map[string]bool{g.PIN: true}
+	for range 20 {
+		created, err := store.CreateGame(ctx, AdminGameRequest{ScenarioID: "s1", ScenarioName: "S", Mode: "classic", Status: "active"},
+			[]AdminStage{{StageNumber: 1, Location: "A", Question: "Q?", CorrectAnswer: "a"}})
+		if err != nil {
+			t.Fatalf("create game: %v", err)
+		}
+		if !validGamePIN(created.PIN) || seen[created.PIN] {
+			t.Fatalf("PIN %q invalid or already in use", created.PIN)
+		}
+		seen[created.PIN] = true
+	}
+
+	// A game whose PIN collides with another game's is given a new one.
+	clash := game{Status: "active", PIN: "123456"}
+	clash.syncPIN(map[string]bool{"123456": true})
+	if clash.PIN == "123456" || !validGamePIN(clash.PIN) {
+		t.Errorf("expected a fresh PIN, got %q", clash.PIN)
+	}
+
+	// Drafts don't get a PIN until they go active.
+	draft := game{Status: "draft"}
+	draft.syncPIN(map[string]bool{})
+	if draft.PIN != "" {
+		t.Errorf("draft game should have no PIN, got %q", draft.PIN)
+	}
+
+	// Only a lobby or active game without a PIN needs the PINs in use.
+	for _, tc := range []struct {
+		g    game
+		want bool
+	}{
+		{game{Status: "active"}, true},
+		{game{Status: "lobby"}, true},
+		{game{Status: "active", PIN: "123456"}, false},
+		{game{Status: "paused", PIN: "123456"}, false},
+		{game{Status: "ended", PIN: "123456"}, false},
+		{game{Status: "draft"}, false},
+	} {
+		if got := tc.g.needsPIN(); got != tc.want {
+			t.Errorf("needsPIN(%s, %q) = %v, want %v", tc.g.Status, tc.g.PIN, got, tc.want)
+		}
+	}
+
+	// An ended game's PIN is free again.
+	if err := store.ExpireGame(ctx, demoSeed.GameID); err != nil {
+		t.Fatalf("expire game: %v", err)
+	}
+	used, err := gamePINsInUse(ctx, store.db, "")
+	if err != nil {
+		t.Fatalf("PINs in use: %v", err)
+	}
+	if used[g.PIN] || len(used) != 20 {
+		t.Errorf("PINs in use = %d, want the 20 created games' without the ended one's %q", len(used), g.PIN)
+	}
+}
@@ -1,21 +1,42 @@
 package server
 
 import (
-	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 )
 
 type GameInfo struct {
-	Status            string  `json:"status"`
-	Mode              string  `json:"mode"`
-	Language          string  `json:"language,omitempty"`
-	Supervised        bool    `json:"supervised"`
-	TimerEnabled      bool    `json:"timerEnabled"`
-	TimerMinutes      int     `json:"timerMinutes"`
-	StageTimerMinutes int     `json:"stageTimerMinutes"`
-	StartedAt         *string `json:"startedAt"`
-	TotalStages       int     `json:"totalStages"`
+	Status               string  `json:"status"`
+	Phase                string  `json:"phase"` // see gamePhase
+	Mode                 string  `json:"mode"`
+	Language             string  `json:"language,omitempty"`
+	Supervised           bool    `json:"supervised"`
+	TimerEnabled         bool    `json:"timerEnabled"`
+	TimerMinutes         int     `json:"timerMinutes"`
+	StageTimerMinutes    int     `json:"stageTimerMinutes"`
+	StartedAt            *string `json:"startedAt"`
+	TotalStages          int     `json:"totalStages"`
+	RequireAllPlayers    bool    `json:"requireAllPlayers,omitempty"`
+	PlayersCanAnswer     bool    `json:"playersCanAnswer,omitempty"`
+	ManualAdvance        bool    `json:"manualAdvance,omitempty"`
+	WaitForConfirmations bool    `json:"waitForConfirmations,omitempty"`
+	AllowGiveUp          bool    `json:"allowGiveUp,omitempty"`       // POST /game/giveup may skip the current question
+	MaxAttempts          int     `json:"maxAttempts,omitempty"`       // wrong answers allowed per question; 0 = one answer settles it
+	IndividualScoring    bool    `json:"individualScoring,omitempty"` // each player answers for themselves; the state is this player's
+	// Capabilities spells out what the mode implies, so clients don't have
+	// to keep their own copy of the mode rules.
+	Capabilities Capabilities `json:"capabilities"`
+}
+
+// Capabilities are the features a game's mode uses; see modeCapabilities.
+type Capabilities struct {
+	UsesUnlock           bool `json:"usesUnlock"`           // stages start locked
+	UsesQuestions        bool `json:"usesQuestions"`        // stages end with a question to answer
+	SupervisorControlled bool `json:"supervisorControlled"` // the supervisor unlocks stages
+	UsesTeamSecret       bool `json:"usesTeamSecret"`       // unlock codes are worked out from the team secret
 }
 
 type TeamInfo struct {
@@ -31,13 +52,27 @@ type StageInfo struct {
 	QuestionImage  string `json:"questionImage,omitempty"`
 	Location       string `json:"location"`
 	Locked         bool   `json:"locked"`
+	UnlockMethod   string `json:"unlockMethod"` // how a locked stage opens: see unlockMethod
 	LocationNumber int    `json:"locationNumber,omitempty"`
+	// Set with the question: "text", "list" or "number", so the client can
+	// offer a number pad for numeric answers.
+	AnswerType string `json:"answerType,omitempty"`
+	// Files to download with the question; shown when the question is.
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// When the stage timer runs out (RFC 3339, UTC); set while it's ticking
+	// on this stage. The stage is then recorded wrong (stage_timeout).
+	StageDeadline string `json:"stageDeadline,omitempty"`
 }
 
 type CompletedStage struct {
 	StageNumber int    `json:"stageNumber"`
 	IsCorrect   bool   `json:"isCorrect"`
 	AnsweredAt  string `json:"answeredAt"`
+	GaveUp      bool   `json:"gaveUp,omitempty"`
+	Points      int    `json:"points,omitempty"` // what the result scored
+	Explanation string `json:"explanation,omitempty"`
+	Answer      string `json:"-"` // the team's answer, for GET /game/recap
+	PlayerID    string `json:"-"` // who answered, for individualScoring games
 }
 
 type PlayerInfo struct {
@@ -46,36 +81,104 @@ type PlayerInfo struct {
 	Role string `json:"role"`
 }
 
+type ConfirmationInfo struct {
+	Confirmed int  `json:"confirmed"`
+	Required  int  `json:"required"`
+	Self      bool `json:"self"` // this player has confirmed
+}
+
+// TeammatesInfo counts the players on a team that a minPlayersToStart game
+// is still waiting for.
+type TeammatesInfo struct {
+	Joined   int `json:"joined"`
+	Required int `json:"required"`
+}
+
 type LastStageResult struct {
 	StageNumber   int       `json:"stageNumber"`
 	IsCorrect     bool      `json:"isCorrect"`
 	CorrectAnswer string    `json:"correctAnswer"`
+	Explanation   string    `json:"explanation,omitempty"`
 	FunFacts      []FunFact `json:"funFacts,omitempty"`
 }
 
 type GameStateResponse struct {
-	Game            GameInfo         `json:"game"`
-	Team            TeamInfo         `json:"team"`
-	Role            string           `json:"role"`
-	TeamSecret      int              `json:"teamSecret,omitempty"`
-	StageUnlockedAt *string          `json:"stageUnlockedAt,omitempty"`
-	CurrentStage    *StageInfo       `json:"currentStage"`
-	LastResult      *LastStageResult `json:"lastResult,omitempty"`
+	Game            GameInfo `json:"game"`
+	Team            TeamInfo `json:"team"`
+	Role            string   `json:"role"`
+	TeamSecret      int      `json:"teamSecret,omitempty"`
+	StageUnlockedAt *string  `json:"stageUnlockedAt,omitempty"`
+	// StageRemainingSeconds is what's left on the stage timer, counted from
+	// stageUnlockedAt so the whole team shares one countdown.
+	StageRemainingSeconds int `json:"stageRemainingSeconds,omitempty"`
+	// AttemptsRemaining is how many more wrong answers a maxAttempts game
+	// allows on the current question.
+	AttemptsRemaining int              `json:"attemptsRemaining,omitempty"`
+	CurrentStage      *StageInfo       `json:"currentStage"`
+	LastResult        *LastStageResult `json:"lastResult,omitempty"`
+	// PendingAdvance is set while a manualAdvance game holds the team on
+	// LastResult; CanAdvance says whether this session may call next.
+	PendingAdvance bool `json:"pendingAdvance,omitempty"`
+	CanAdvance     bool `json:"canAdvance,omitempty"`
+	// Confirmations is set while a waitForConfirmations game holds the
+	// unlocked stage's timer until every player has confirmed.
+	Confirmations *ConfirmationInfo `json:"confirmations,omitempty"`
+	// Teammates is set while a minPlayersToStart game holds the team until
+	// enough players have joined.
+	Teammates       *TeammatesInfo   `json:"teammates,omitempty"`
 	CompletedStages []CompletedStage `json:"completedStages"`
-	Players         []PlayerInfo     `json:"players"`
+	// TeamScore adds up the points of completedStages: the team's, or the
+	// player's own in individualScoring games.
+	TeamScore int          `json:"teamScore"`
+	Players   []PlayerInfo `json:"players"`
+	// Set once the team has finished every stage, so a player who rejoins
+	// after the end lands on the completion screen rather than a blank one.
+	Outro string       `json:"outro,omitempty"`
+	Recap []RecapStage `json:"recap,omitempty"`
 }
 
 type scenarioStage struct {
-	StageNumber    int       `json:"stageNumber"`
-	Location       string    `json:"location"`
-	Clue           string    `json:"clue"`
-	ClueImage      string    `json:"clueImage,omitempty"`
-	Question       string    `json:"question"`
-	QuestionImage  string    `json:"questionImage,omitempty"`
-	CorrectAnswer  string    `json:"correctAnswer"`
-	UnlockCode     string    `json:"unlockCode,omitempty"`
-	LocationNumber int       `json:"locationNumber,omitempty"`
-	FunFacts       []FunFact `json:"funFacts,omitempty"`
+	StageNumber      int          `json:"stageNumber"`
+	Location         string       `json:"location"`
+	Clue             string       `json:"clue"`
+	ClueImage        string       `json:"clueImage,omitempty"`
+	Question         string       `json:"question"`
+	QuestionImage    string       `json:"questionImage,omitempty"`
+	CorrectAnswer    string       `json:"correctAnswer"`
+	Explanation      string       `json:"explanation,omitempty"` // shown with the result, right or wrong
+	UnlockCode       string       `json:"unlockCode,omitempty"`
+	LocationNumber   int          `json:"locationNumber,omitempty"`
+	CaseSensitive    bool         `json:"caseSensitive,omitempty"` // compare the answer with exact case
+	StrictAnswers    bool         `json:"strictAnswers,omitempty"` // compare the answer as typed
+	AnswerType       string       `json:"answerType,omitempty"`
+	AcceptedAnswers  []string     `json:"acceptedAnswers,omitempty"`
+	ListDelimiter    string       `json:"listDelimiter,omitempty"`
+	ListUnordered    bool         `json:"listUnordered,omitempty"`
+	NumericTolerance float64      `json:"numericTolerance,omitempty"`
+	Attachments      []Attachment `json:"attachments,omitempty"`
+	FunFacts         []FunFact    `json:"funFacts,omitempty"`
+}
+
+// matchesAnswer compares a submitted answer with the stage's answer key,
+// part by part for list stages.
+func (s scenarioStage) matchesAnswer(answer string, rules answerRules) bool {
+	rules = rules.forStage(s.CaseSensitive, s.StrictAnswers)
+	switch s.AnswerType {
+	case answerTypeList:
+		return listAnswerMatches(answer, s.AcceptedAnswers, s.ListDelimiter, s.ListUnordered, rules)
+	case answerTypeNumber:
+		return numericAnswerMatches(answer, s.CorrectAnswer, s.NumericTolerance)
+	}
+	return answerMatches(answer, s.CorrectAnswer, rules)
+}
+
+// inputType is the stage's answer type as shown to players: "text" unless
+// the stage says otherwise.
+func (s scenarioStage) inputType() string {
+	if s.AnswerType == "" {
+		return "text"
+	}
+	return s.AnswerType
 }
 
 // rotatedStageIndex returns the scenario stage index for a team's Nth sequential stage (1-based).
@@ -109,6 +212,60 @@ func modeRequiresUnlock(mode string) bool {
 	}
 }
 
+// modeCapabilities derives a mode's Capabilities from the mode helpers.
+func modeCapabilities(mode string) Capabilities {
+	return Capabilities{
+		UsesUnlock:           modeRequiresUnlock(mode),
+		UsesQuestions:        modeHasQuestion(mode),
+		SupervisorControlled: unlockMethod(mode) == "supervisor",
+		UsesTeamSecret:       mode == "math_puzzle",
+	}
+}
+
+// unlockMethod tells the client which unlock UI a mode's stages need:
+// "scan" a QR code, type a "code" worked out from the location number, wait
+// for the "supervisor", or "none" when stages are never locked.
+func unlockMethod(mode string) string {
+	switch mode {
+	case "qr_quiz", "qr_hunt":
+		return "scan"
+	case "math_puzzle":
+		return "code"
+	case "supervised":
+		return "supervisor"
+	default:
+		return "none"
+	}
+}
+
+// gamePhase tells the client which screen to show, since a nil current stage
+// alone can't distinguish a game that hasn't started from one the team has
+// finished:
+//   - lobby: teams are gathering and the game hasn't started
+//   - waiting: the game is a draft or paused
+//   - playing: the game is active and the team has stages left
+//   - finished: the team has completed every stage
+//   - ended: the game ended (operator or timer) before the team finished
+//   - misconfigured: the game has no stages, so there is nothing to play
+func gamePhase(status string, completed, total int) string {
+	if total == 0 {
+		return "misconfigured"
+	}
+	if completed >= total {
+		return "finished"
+	}
+	switch status {
+	case "lobby":
+		return "lobby"
+	case "active":
+		return "playing"
+	case "ended":
+		return "ended"
+	default:
+		return "waiting"
+	}
+}
+
 // isStageUnlocked checks if a stage number is in the unlocked list.
 func isStageUnlocked(unlockedStages []int, stageNumber int) bool {
 	for _, n := range unlockedStages {
@@ -119,7 +276,19 @@ func isStageUnlocked(unlockedStages []int, stageNumber int) bool {
 	return false
 }
 
-func handleGameState() http.HandlerFunc {
+// writeGameError answers a failed read of the player's game. Corrupt stages
+// are logged with the game ID and get their own message, which tells the
+// team an admin can fix the game rather than leaving it at "internal error".
+func writeGameError(w http.ResponseWriter, gameID string, err error) {
+	if errors.Is(err, ErrCorruptStages) {
+		slog.Error("game stages are corrupt; repair them from the scenario", "game", gameID, "error", err)
+		writeError(w, http.StatusInternalServerError, "game stages are unreadable; an admin can restore them from the scenario")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func handleGameState(broker *Broker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sess, err := playerFromRequest(r)
 		if err != nil {
@@ -129,9 +298,9 @@ func handleGameState() http.HandlerFunc {
 
 		store := clientStore(r)
 
-		data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+		data, err := store.TeamProgress(r.Context(), sess.GameID, sess.TeamID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+			writeGameError(w, sess.GameID, err)
 			return
 		}
 
@@ -143,28 +312,83 @@ func handleGameState() http.HandlerFunc {
 			}
 		}
 
-		var stages []scenarioStage
-		if err := json.Unmarshal([]byte(data.StagesJSON), &stages); err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
 			return
 		}
 
-		completed, err := store.ListCompletedStages(r.Context(), sess.GameID, sess.TeamID)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
-			return
+		// A stage timer that ran out before anyone answered counts as a wrong
+		// answer. Whichever device fetches state first records it and tells
+		// the rest of the team.
+		if remaining, running := data.stageRemaining(time.Now()); running && remaining < 0 && data.Status == "active" && modeHasQuestion(data.Mode) {
+			answered := data.answered()
+			recorded, err := store.TimeOutStage(r.Context(), sess.GameID, sess.TeamID, "", answered+1, "")
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if recorded {
+				logGameEvent(r, store, sess.GameID, GameEvent{
+					Type:        "timed_out",
+					TeamID:      sess.TeamID,
+					StageNumber: answered + 1,
+				})
+				broker.Publish(sess.TeamID, SSEEvent{
+					Type:        "stage_timeout",
+					StageNumber: answered + 1,
+				})
+			}
+			data, err = store.TeamProgress(r.Context(), sess.GameID, sess.TeamID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+		}
+
+		// firstStageUnlocked games open the first stage on the team's first
+		// state fetch, so players start at a known meeting point without a
+		// code. The stage timer runs from here.
+		if data.opensFirstStage() {
+			if err := store.UnlockStage(r.Context(), sess.GameID, sess.TeamID, 1); err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			broker.Publish(sess.TeamID, SSEEvent{
+				Type:        "stage_unlocked",
+				StageNumber: 1,
+			})
+			data, err = store.TeamProgress(r.Context(), sess.GameID, sess.TeamID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
 		}
 
+		data = data.forPlayer(sess.PlayerID)
+		completed := data.Completed
+
+		if len(stages) > 0 {
+			for i := range completed {
+				completed[i].Explanation = stages[rotatedStageIndex(completed[i].StageNumber, data.StartStage, len(stages))].Explanation
+			}
+		}
+
+		// A team held on a result stays on the stage it just answered.
 		currentStageNum := len(completed) + 1
+		if data.PendingAdvance {
+			currentStageNum--
+		}
 		var currentStage *StageInfo
 		if currentStageNum <= len(stages) && data.Status == "active" {
 			idx := rotatedStageIndex(currentStageNum, data.StartStage, len(stages))
 			s := stages[idx]
 			si := StageInfo{
-				StageNumber: currentStageNum,
-				Clue:        s.Clue,
-				ClueImage:   s.ClueImage,
-				Location:    s.Location,
+				StageNumber:  currentStageNum,
+				Clue:         s.Clue,
+				ClueImage:    s.ClueImage,
+				Location:     data.stageLocation(sess.Role, s.Location),
+				UnlockMethod: unlockMethod(data.Mode),
 			}
 
 			if modeRequiresUnlock(data.Mode) {
@@ -173,6 +397,8 @@ func handleGameState() http.HandlerFunc {
 				if unlocked && modeHasQuestion(data.Mode) {
 					si.Question = s.Question
 					si.QuestionImage = s.QuestionImage
+					si.AnswerType = s.inputType()
+					si.Attachments = s.Attachments
 				}
 				if data.Mode == "math_puzzle" {
 					si.LocationNumber = s.LocationNumber
@@ -181,14 +407,21 @@ func handleGameState() http.HandlerFunc {
 				// classic: always show question, never locked
 				si.Question = s.Question
 				si.QuestionImage = s.QuestionImage
+				si.AnswerType = s.inputType()
+				si.Attachments = s.Attachments
+			}
+
+			if deadline, running := data.stageDeadline(); running && currentStageNum == len(completed)+1 {
+				si.StageDeadline = deadline.UTC().Format(time.RFC3339)
 			}
 
 			currentStage = &si
 		}
 
 		// Build last result so all players (not just the submitter) can see results.
+		// A game whose stages were removed mid-play has nothing to show it against.
 		var lastResult *LastStageResult
-		if len(completed) > 0 {
+		if len(completed) > 0 && len(stages) > 0 {
 			last := completed[len(completed)-1]
 			lastIdx := rotatedStageIndex(last.StageNumber, data.StartStage, len(stages))
 			ls := stages[lastIdx]
@@ -196,6 +429,7 @@ func handleGameState() http.HandlerFunc {
 				StageNumber:   last.StageNumber,
 				IsCorrect:     last.IsCorrect,
 				CorrectAnswer: ls.CorrectAnswer,
+				Explanation:   ls.Explanation,
 				FunFacts:      ls.FunFacts,
 			}
 		}
@@ -210,15 +444,24 @@ func handleGameState() http.HandlerFunc {
 			Role:            sess.Role,
 			StageUnlockedAt: data.StageUnlockedAt,
 			Game: GameInfo{
-				Status:            data.Status,
-				Mode:              data.Mode,
-				Language:          data.Language,
-				Supervised:        data.Supervised,
-				TimerEnabled:      data.TimerEnabled,
-				TimerMinutes:      data.TimerMinutes,
-				StageTimerMinutes: data.StageTimerMinutes,
-				StartedAt:         data.StartedAt,
-				TotalStages:       len(stages),
+				Status:               data.Status,
+				Phase:                gamePhase(data.Status, len(completed), len(stages)),
+				Mode:                 data.Mode,
+				Language:             data.Language,
+				Supervised:           data.Supervised,
+				TimerEnabled:         data.TimerEnabled,
+				TimerMinutes:         data.TimerMinutes,
+				StageTimerMinutes:    data.StageTimerMinutes,
+				StartedAt:            data.StartedAt,
+				TotalStages:          len(stages),
+				RequireAllPlayers:    data.RequireAllPlayers,
+				PlayersCanAnswer:     data.PlayersCanAnswer,
+				ManualAdvance:        data.ManualAdvance,
+				WaitForConfirmations: data.WaitForConfirmations && data.Supervised,
+				AllowGiveUp:          data.AllowGiveUp && modeHasQuestion(data.Mode),
+				MaxAttempts:          data.maxAttempts(),
+				IndividualScoring:    data.individualScoring(data.Mode),
+				Capabilities:         modeCapabilities(data.Mode),
 			},
 			Team: TeamInfo{
 				ID:   sess.TeamID,
@@ -226,9 +469,37 @@ func handleGameState() http.HandlerFunc {
 			},
 			CurrentStage:    currentStage,
 			LastResult:      lastResult,
+			PendingAdvance:  data.PendingAdvance,
+			CanAdvance:      data.canAdvance(sess.Role),
 			CompletedStages: completed,
+			TeamScore:       data.score(),
 			Players:         players,
 		}
+		if remaining, running := data.stageRemaining(time.Now()); running && remaining > 0 {
+			resp.StageRemainingSeconds = int((remaining + time.Second - 1) / time.Second)
+		}
+		if data.maxAttempts() > 0 && currentStage != nil && !data.PendingAdvance {
+			attempts, err := store.CountAttempts(r.Context(), sess.GameID, sess.TeamID, currentStageNum)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			resp.AttemptsRemaining = data.maxAttempts() - attempts
+		}
+		if data.awaitingConfirmations(currentStageNum) {
+			resp.Confirmations = &ConfirmationInfo{
+				Confirmed: len(data.ConfirmedPlayerIDs),
+				Required:  data.ConfirmRequired,
+				Self:      slices.Contains(data.ConfirmedPlayerIDs, sess.PlayerID),
+			}
+		}
+		if data.waitingForTeammates() {
+			resp.Teammates = &TeammatesInfo{Joined: data.TeamPlayers, Required: data.MinPlayersToStart}
+		}
+		if resp.Game.Phase == "finished" {
+			resp.Outro = data.Outro
+			resp.Recap = recapStages(data.gameStateData, sess.Role, stages, completed)
+		}
 		if data.Mode == "math_puzzle" {
 			resp.TeamSecret = data.TeamSecret
 		}
@@ -2,23 +2,38 @@ package server
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/playperu/cityquiz/internal/database"
 )
 
+// testMaxAnswerLen is the answer/code length cap the test routers use.
+const testMaxAnswerLen = 50
+
+// The seeded demo teams; see demoSeed.
+var (
+	demoIncas    = demoSeed.Teams[0]
+	demoCondores = demoSeed.Teams[1]
+)
+
 func setupStores(t *testing.T) (*AdminDocStore, *DocStore) {
 	t.Helper()
 	ctx := context.Background()
 
 	// Admin store.
-	adminDB, err := database.Open(ctx, ":memory:")
+	adminDB, err := database.Open(ctx, ":memory:", 1)
 	if err != nil {
 		t.Fatalf("open admin db: %v", err)
 	}
@@ -35,11 +50,11 @@ func setupStores(t *testing.T) (*AdminDocStore, *DocStore) {
 	}
 
 	// Client store.
-	clientDB, err := database.Open(ctx, ":memory:")
+	clientDB, err := database.Open(ctx, ":memory:", 1)
 	if err != nil {
 		t.Fatalf("open client db: %v", err)
 	}
-	store, err := NewDocStore(ctx, clientDB)
+	store, err := NewDocStore(ctx, clientDB, nil)
 	if err != nil {
 		t.Fatalf("init doc store: %v", err)
 	}
@@ -54,6 +69,14 @@ func setupStores(t *testing.T) (*AdminDocStore, *DocStore) {
 }
 
 func playerRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	r, _ := playerRouterWithStore(t)
+	return r
+}
+
+// playerRouterWithStore is playerRouter that also exposes the backing store
+// for assertions on persisted data.
+func playerRouterWithStore(t *testing.T) (*chi.Mux, *DocStore) {
 	t.Helper()
 	_, store := setupStores(t)
 	broker := NewBroker()
@@ -68,17 +91,23 @@ func playerRouter(t *testing.T) *chi.Mux {
 	})
 
 	r.Get("/api/{client}/teams/{joinToken}", handleTeamLookup())
+	r.Get("/api/{client}/games/pin/{pin}", handleGamePIN())
 	r.Post("/api/{client}/join", handleJoin(broker))
-	r.Get("/api/{client}/game/state", handleGameState())
-	r.Post("/api/{client}/game/answer", handleAnswer(broker))
-	r.Post("/api/{client}/game/unlock", handleUnlock(broker))
-	return r
+	r.Get("/api/{client}/game/resume", handleResume())
+	r.With(compressJSON).Get("/api/{client}/game/state", handleGameState(broker))
+	r.Get("/api/{client}/game/recap", handleRecap())
+	r.Post("/api/{client}/game/answer", handleAnswer(broker, testMaxAnswerLen))
+	r.Post("/api/{client}/game/unlock", handleUnlock(broker, testMaxAnswerLen))
+	r.Post("/api/{client}/game/next", handleNext(broker))
+	r.Post("/api/{client}/game/giveup", handleGiveUp(broker))
+	r.Post("/api/{client}/game/confirm", handleConfirm(broker))
+	return r, store
 }
 
 func TestTeamLookup(t *testing.T) {
 	r := playerRouter(t)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/demo/teams/incas-2025", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/demo/teams/"+demoIncas.JoinToken, nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
@@ -109,11 +138,81 @@ func TestTeamLookupNotFound(t *testing.T) {
 	}
 }
 
+func TestPausedGameLookupAndJoin(t *testing.T) {
+	r, store := playerRouterWithStore(t)
+	ctx := context.Background()
+	setStatus := func(status string) {
+		t.Helper()
+		if err := store.modifyGame(ctx, demoSeed.GameID, func(g *game) error { g.Status = status; return nil }); err != nil {
+			t.Fatalf("set status %s: %v", status, err)
+		}
+	}
+	lookup := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/demo/teams/"+demoIncas.JoinToken, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	setStatus("paused")
+	if code := lookup(); code != http.StatusOK {
+		t.Fatalf("lookup in paused game: expected 200, got %d", code)
+	}
+	player := join(t, r, demoIncas.JoinToken, "Ana")
+	if state := gameState(t, r, player.Token); state.Game.Phase != "waiting" {
+		t.Errorf("paused game phase = %q, want waiting", state.Game.Phase)
+	}
+
+	w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "1651"})
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "game is not active") {
+		t.Errorf("answer while paused: expected 409 game is not active, got %d: %s", w.Code, w.Body.String())
+	}
+	if n, _ := store.CountAnsweredStages(ctx, demoSeed.GameID, demoIncas.ID); n != 0 {
+		t.Errorf("expected no recorded answers while paused, got %d", n)
+	}
+
+	// Drafts and ended games still can't be joined.
+	for _, status := range []string{"draft", "ended"} {
+		setStatus(status)
+		if code := lookup(); code != http.StatusNotFound {
+			t.Errorf("lookup in %s game: expected 404, got %d", status, code)
+		}
+	}
+}
+
+func TestLobbyGameJoinAndAnswer(t *testing.T) {
+	r, store := playerRouterWithStore(t)
+	ctx := context.Background()
+	if err := store.modifyGame(ctx, demoSeed.GameID, func(g *game) error { g.Status = "lobby"; return nil }); err != nil {
+		t.Fatalf("set status lobby: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/demo/teams/"+demoIncas.JoinToken, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("lookup in lobby: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	player := join(t, r, demoIncas.JoinToken, "Ana")
+	if state := gameState(t, r, player.Token); state.Game.Phase != "lobby" || state.CurrentStage != nil {
+		t.Errorf("lobby game state: phase %q, current stage %+v, want lobby with no stage", state.Game.Phase, state.CurrentStage)
+	}
+
+	w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "1651"})
+	var errResp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&errResp)
+	if w.Code != http.StatusConflict || errResp != (ErrorResponse{Error: "game has not started yet", Code: "game_not_started"}) {
+		t.Errorf("answer in lobby: got %d %+v, want 409 game_not_started", w.Code, errResp)
+	}
+	if n, _ := store.CountAnsweredStages(ctx, demoSeed.GameID, demoIncas.ID); n != 0 {
+		t.Errorf("expected no recorded answers in the lobby, got %d", n)
+	}
+}
+
 func TestJoinAndGameState(t *testing.T) {
 	r := playerRouter(t)
 
 	// Join the team.
-	body, _ := json.Marshal(JoinRequest{JoinToken: "incas-2025", PlayerName: "Maria"})
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Maria"})
 	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -162,11 +261,147 @@ func TestJoinAndGameState(t *testing.T) {
 	}
 }
 
+func TestResumeSession(t *testing.T) {
+	r, store := playerRouterWithStore(t)
+	ctx := context.Background()
+	resume := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/demo/game/resume?token="+token, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	player := join(t, r, demoIncas.JoinToken, "Ana")
+	w := resume(player.Token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("resume: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ResumeResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Token != player.Token || resp.PlayerID != player.PlayerID || resp.Role != "player" {
+		t.Errorf("resume: expected the joined player's session, got %+v", resp)
+	}
+	if resp.TeamID != demoIncas.ID || resp.TeamName != "Los Incas" || resp.GameName == "" {
+		t.Errorf("resume: expected Los Incas with a game name, got %+v", resp)
+	}
+	if n := len(gameState(t, r, player.Token).Players); n != 1 {
+		t.Errorf("expected resume not to add a player, team has %d", n)
+	}
+
+	for _, token := range []string{"", "not-a-session"} {
+		if w := resume(token); w.Code != http.StatusUnauthorized {
+			t.Errorf("resume %q: expected 401, got %d", token, w.Code)
+		}
+	}
+
+	// A session on a deleted team is dead too.
+	if err := store.modifyGame(ctx, demoSeed.GameID, func(g *game) error {
+		g.Teams = slices.DeleteFunc(g.Teams, func(tm team) bool { return tm.ID == demoIncas.ID })
+		return nil
+	}); err != nil {
+		t.Fatalf("delete team: %v", err)
+	}
+	if w := resume(player.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("resume after team deleted: expected 401, got %d", w.Code)
+	}
+}
+
+func TestGameStateCompressed(t *testing.T) {
+	r := playerRouter(t)
+	token := join(t, r, demoIncas.JoinToken, "Ana").Token
+
+	fetch := func(encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/demo/game/state", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("state: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	plain := fetch("")
+	if enc := plain.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("uncompressed request got Content-Encoding %q", enc)
+	}
+	zipped := fetch("gzip")
+	if enc := zipped.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("expected gzip, got Content-Encoding %q", enc)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(zipped.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	var state GameStateResponse
+	if err := json.NewDecoder(zr).Decode(&state); err != nil {
+		t.Fatalf("decode compressed state: %v", err)
+	}
+	if state.Team.Name != "Los Incas" {
+		t.Errorf("unexpected team in compressed state: %+v", state.Team)
+	}
+	if zipped.Body.Len() >= plain.Body.Len() {
+		t.Errorf("compressed state is %d bytes, plain %d", zipped.Body.Len(), plain.Body.Len())
+	}
+	t.Logf("game state: %d bytes plain, %d gzipped", plain.Body.Len(), zipped.Body.Len())
+}
+
+func TestPlayerJoinedDelta(t *testing.T) {
+	broker := NewBroker()
+	r, _, _, team := brokerRouter(t, giveUpScenario(), AdminGameRequest{}, broker)
+	for i := range 29 {
+		join(t, r, team.JoinToken, fmt.Sprintf("Player %d", i+1))
+	}
+
+	// Earlier joins may still be on their way out of coalescing; leave
+	// room for them all.
+	events := broker.Subscribe(team.ID, 64)
+	defer broker.Unsubscribe(team.ID, events)
+	ana := join(t, r, team.JoinToken, "Ana")
+
+	var raw []byte
+	var ev SSEEvent
+	for ev.Player.Name != "Ana" {
+		select {
+		case raw = <-events:
+			ev = SSEEvent{}
+			json.Unmarshal(raw, &ev)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for Ana's player_joined")
+		}
+	}
+	var fields map[string]any
+	json.Unmarshal(raw, &fields)
+	if len(fields) != 3 || ev.Type != "player_joined" || ev.Player != (PlayerInfo{ID: ana.PlayerID, Name: "Ana", Role: "player"}) {
+		t.Errorf("event = %s, want player_joined with just the new player", raw)
+	}
+
+	// The event is everything a device needs to add Ana to its roster: the
+	// same entry a refetch would list, at a fraction of the size.
+	req := httptest.NewRequest(http.MethodGet, "/api/demo/game/state", nil)
+	req.Header.Set("Authorization", "Bearer "+ana.Token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var state GameStateResponse
+	json.Unmarshal(w.Body.Bytes(), &state)
+	if !slices.Contains(state.Players, ev.Player) {
+		t.Errorf("game state players %+v don't list %+v", state.Players, ev.Player)
+	}
+	if len(raw)*10 > w.Body.Len() {
+		t.Errorf("player_joined is %d bytes, game state %d; want the event under a tenth of it", len(raw), w.Body.Len())
+	}
+	t.Logf("30 players: player_joined %d bytes, game state refetch %d bytes", len(raw), w.Body.Len())
+}
+
 func TestAnswerFlow(t *testing.T) {
 	r := playerRouter(t)
 
 	// Join.
-	body, _ := json.Marshal(JoinRequest{JoinToken: "condores-2025", PlayerName: "Carlos"})
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoCondores.JoinToken, PlayerName: "Carlos"})
 	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -255,11 +490,150 @@ func TestAnswerFlow(t *testing.T) {
 	}
 }
 
+func TestAnswerAndCodeTooLong(t *testing.T) {
+	r, store := playerRouterWithStore(t)
+	token := join(t, r, demoCondores.JoinToken, "Carlos").Token
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	long := strings.Repeat("ñ", testMaxAnswerLen+1)
+	for _, tc := range []struct {
+		path string
+		body any
+		want string
+	}{
+		{"/api/demo/game/answer", AnswerRequest{Answer: long}, "answer is too long"},
+		{"/api/demo/game/unlock", UnlockRequest{Code: long}, "code is too long"},
+	} {
+		w := post(tc.path, tc.body)
+		var resp ErrorResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusBadRequest || resp.Error != tc.want {
+			t.Errorf("%s: expected 400 %q, got %d %+v", tc.path, tc.want, w.Code, resp)
+		}
+	}
+
+	// Nothing was recorded; an answer right at the limit (counted in
+	// characters, not bytes) still goes through.
+	if n, _ := store.CountAnsweredStages(context.Background(), demoSeed.GameID, demoCondores.ID); n != 0 {
+		t.Errorf("expected no recorded answers, got %d", n)
+	}
+	if w := post("/api/demo/game/answer", AnswerRequest{Answer: long[:len(long)-len("ñ")]}); w.Code != http.StatusOK {
+		t.Errorf("answer at the limit: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCaseSensitiveStage(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Codes",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Code on the door?", CorrectAnswer: "AbC", CaseSensitive: true},
+			{Location: "B", Question: "City?", CorrectAnswer: "Lima"},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	for _, tc := range []struct {
+		answer string
+		want   bool
+	}{
+		{"abc", false}, // stage 1 is case-sensitive
+		{"LIMA", true}, // stage 2 isn't
+	} {
+		w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: tc.answer})
+		if w.Code != http.StatusOK {
+			t.Fatalf("answer %q: expected 200, got %d: %s", tc.answer, w.Code, w.Body.String())
+		}
+		var resp AnswerResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.IsCorrect != tc.want {
+			t.Errorf("answer %q: isCorrect = %v, want %v", tc.answer, resp.IsCorrect, tc.want)
+		}
+	}
+}
+
+func TestAccentsFoldedByDefault(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Streets",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Street?", CorrectAnswer: "Jirón de la Unión"},
+			{Location: "B", Question: "Formula?", CorrectAnswer: "Jirón", StrictAnswers: true},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	if resp := answer(t, r, player.Token, "jiron de la  union "); !resp.IsCorrect {
+		t.Error(`"jiron de la  union " should match "Jirón de la Unión"`)
+	}
+	if resp := answer(t, r, player.Token, "Jiron"); resp.IsCorrect {
+		t.Error(`"Jiron" should not match a strict "Jirón"`)
+	}
+}
+
+func TestAnswerExplanation(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Notes",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Year?", CorrectAnswer: "1651", Explanation: "  The cathedral was rebuilt in 1651.  "},
+			{Location: "B", Question: "City?", CorrectAnswer: "Lima"},
+			{Location: "C", Question: "River?", CorrectAnswer: "Rimac", Explanation: "The Rimac runs through the centre."},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	for _, tc := range []struct {
+		answer string
+		want   string
+	}{
+		{"1651", "The cathedral was rebuilt in 1651."}, // correct
+		{"Cusco", ""}, // no explanation on this stage
+		{"Amazon", "The Rimac runs through the centre."}, // wrong answers get it too
+	} {
+		w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: tc.answer})
+		if w.Code != http.StatusOK {
+			t.Fatalf("answer %q: expected 200, got %d: %s", tc.answer, w.Code, w.Body.String())
+		}
+		var resp AnswerResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Explanation != tc.want {
+			t.Errorf("answer %q: explanation = %q, want %q", tc.answer, resp.Explanation, tc.want)
+		}
+	}
+
+	state := gameState(t, r, player.Token)
+	if state.LastResult == nil || state.LastResult.Explanation != "The Rimac runs through the centre." {
+		t.Errorf("lastResult = %+v, want the stage 3 explanation", state.LastResult)
+	}
+	var got []string
+	for _, c := range state.CompletedStages {
+		got = append(got, c.Explanation)
+	}
+	if want := []string{"The cathedral was rebuilt in 1651.", "", "The Rimac runs through the centre."}; !slices.Equal(got, want) {
+		t.Errorf("completed stage explanations = %q, want %q", got, want)
+	}
+}
+
 func TestCompleteAllStages(t *testing.T) {
 	r := playerRouter(t)
 
 	// Join.
-	body, _ := json.Marshal(JoinRequest{JoinToken: "incas-2025", PlayerName: "Ana"})
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Ana"})
 	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -318,12 +692,13 @@ func TestModeHelpers(t *testing.T) {
 		mode         string
 		wantQuestion bool
 		wantUnlock   bool
+		wantMethod   string
 	}{
-		{"classic", true, false},
-		{"qr_quiz", true, true},
-		{"qr_hunt", false, true},
-		{"math_puzzle", false, true},
-		{"supervised", true, true},
+		{"classic", true, false, "none"},
+		{"qr_quiz", true, true, "scan"},
+		{"qr_hunt", false, true, "scan"},
+		{"math_puzzle", false, true, "code"},
+		{"supervised", true, true, "supervisor"},
 	}
 	for _, tt := range tests {
 		if got := modeHasQuestion(tt.mode); got != tt.wantQuestion {
@@ -332,6 +707,95 @@ func TestModeHelpers(t *testing.T) {
 		if got := modeRequiresUnlock(tt.mode); got != tt.wantUnlock {
 			t.Errorf("modeRequiresUnlock(%q) = %v, want %v", tt.mode, got, tt.wantUnlock)
 		}
+		if got := unlockMethod(tt.mode); got != tt.wantMethod {
+			t.Errorf("unlockMethod(%q) = %q, want %q", tt.mode, got, tt.wantMethod)
+		}
+	}
+}
+
+// TestUnlockMethodInResponses plays the first stage of a game in each mode
+// and checks both the current stage in game state and the next stage in the
+// answer or unlock response say how to unlock.
+func TestUnlockMethodInResponses(t *testing.T) {
+	stages := []AdminStage{
+		{Location: "A", Clue: "Go to A", Question: "1+1?", CorrectAnswer: "2", UnlockCode: "CODE-A", LocationNumber: 10},
+		{Location: "B", Clue: "Go to B", Question: "2+2?", CorrectAnswer: "4", UnlockCode: "CODE-B", LocationNumber: 20},
+	}
+	for _, tc := range []struct {
+		mode string
+		want string
+	}{
+		{"classic", "none"},
+		{"qr_quiz", "scan"},
+		{"qr_hunt", "scan"},
+		{"math_puzzle", "code"},
+		{"supervised", "supervisor"},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			sc := AdminScenarioRequest{Name: "Methods", City: "Lima", Mode: tc.mode, Stages: stages}
+			r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+			token := team.JoinToken
+			if tc.mode == "supervised" {
+				token = team.SupervisorToken
+			}
+			player := join(t, r, token, "Ana")
+
+			state := gameState(t, r, player.Token)
+			if state.CurrentStage == nil || state.CurrentStage.UnlockMethod != tc.want {
+				t.Fatalf("current stage = %+v, want unlockMethod %q", state.CurrentStage, tc.want)
+			}
+
+			// Finish stage 1 the way the mode does, and look at stage 2.
+			var next *StageInfo
+			switch tc.mode {
+			case "qr_hunt", "math_puzzle":
+				code := "CODE-A"
+				if tc.mode == "math_puzzle" {
+					code = strconv.Itoa(state.TeamSecret + 10)
+				}
+				w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: code})
+				var resp UnlockResponse
+				json.NewDecoder(w.Body).Decode(&resp)
+				if w.Code != http.StatusOK {
+					t.Fatalf("unlock: expected 200, got %d", w.Code)
+				}
+				next = resp.NextStage
+			default:
+				switch tc.mode {
+				case "qr_quiz":
+					postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "CODE-A"})
+				case "supervised":
+					postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{})
+				}
+				w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "2"})
+				var resp AnswerResponse
+				json.NewDecoder(w.Body).Decode(&resp)
+				if w.Code != http.StatusOK {
+					t.Fatalf("answer: expected 200, got %d", w.Code)
+				}
+				next = resp.NextStage
+			}
+			if next == nil || next.UnlockMethod != tc.want {
+				t.Errorf("next stage = %+v, want unlockMethod %q", next, tc.want)
+			}
+		})
+	}
+}
+
+func TestModeCapabilities(t *testing.T) {
+	for _, tt := range []struct {
+		mode string
+		want Capabilities
+	}{
+		{"classic", Capabilities{UsesQuestions: true}},
+		{"qr_quiz", Capabilities{UsesUnlock: true, UsesQuestions: true}},
+		{"qr_hunt", Capabilities{UsesUnlock: true}},
+		{"math_puzzle", Capabilities{UsesUnlock: true, UsesTeamSecret: true}},
+		{"supervised", Capabilities{UsesUnlock: true, UsesQuestions: true, SupervisorControlled: true}},
+	} {
+		if got := modeCapabilities(tt.mode); got != tt.want {
+			t.Errorf("modeCapabilities(%q) = %+v, want %+v", tt.mode, got, tt.want)
+		}
 	}
 }
 
@@ -355,7 +819,7 @@ func TestGameStateIncludesMode(t *testing.T) {
 	r := playerRouter(t)
 
 	// Join.
-	body, _ := json.Marshal(JoinRequest{JoinToken: "incas-2025", PlayerName: "Test"})
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Test"})
 	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -375,6 +839,9 @@ func TestGameStateIncludesMode(t *testing.T) {
 	if state.Game.Mode != "classic" {
 		t.Errorf("expected mode 'classic', got %q", state.Game.Mode)
 	}
+	if state.Game.Capabilities != modeCapabilities("classic") {
+		t.Errorf("expected classic capabilities, got %+v", state.Game.Capabilities)
+	}
 	// Classic mode: current stage should not be locked.
 	if state.CurrentStage == nil {
 		t.Fatal("expected current stage")
@@ -418,3 +885,542 @@ func TestUnauthorizedAccess(t *testing.T) {
 		t.Errorf("expected 401, got %d", w.Code)
 	}
 }
+
+func TestEventsMaxDurationReconnect(t *testing.T) {
+	_, store := setupStores(t)
+	broker := NewBroker()
+
+	_, token, err := store.JoinTeam(context.Background(), demoSeed.GameID, demoIncas.ID, "Eve", "player")
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/demo/game/events?token="+token, nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyStore, Store(store)))
+	w := httptest.NewRecorder()
+
+	// Handler must return on its own once the lifetime elapses.
+	handleEvents(broker, 50*time.Millisecond, SSEBuffer{})(w, req)
+
+	if !strings.Contains(w.Body.String(), "event: reconnect") {
+		t.Errorf("expected reconnect event, got %q", w.Body.String())
+	}
+	broker.mu.RLock()
+	subs := len(broker.subs)
+	broker.mu.RUnlock()
+	if subs != 0 {
+		t.Errorf("expected subscriber to be removed, got %d teams subscribed", subs)
+	}
+}
+
+func TestAnswerRecordsPlayer(t *testing.T) {
+	r, store := playerRouterWithStore(t)
+
+	// Two players on the same team; only the second one answers.
+	body, _ := json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Maria"})
+	req := httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	body, _ = json.Marshal(JoinRequest{JoinToken: demoIncas.JoinToken, PlayerName: "Pedro"})
+	req = httptest.NewRequest(http.MethodPost, "/api/demo/join", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var joinResp JoinResponse
+	json.NewDecoder(w.Body).Decode(&joinResp)
+
+	body, _ = json.Marshal(AnswerRequest{Answer: "1651"})
+	req = httptest.NewRequest(http.MethodPost, "/api/demo/game/answer", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+joinResp.Token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	status, err := store.GameStatus(context.Background(), demoSeed.GameID)
+	if err != nil {
+		t.Fatalf("game status: %v", err)
+	}
+	for _, team := range status.Teams {
+		if team.ID != joinResp.TeamID {
+			continue
+		}
+		if len(team.Results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(team.Results))
+		}
+		if team.Results[0].PlayerName != "Pedro" {
+			t.Errorf("expected answer attributed to Pedro, got %q", team.Results[0].PlayerName)
+		}
+		return
+	}
+	t.Fatal("team not found in game status")
+}
+
+func TestRequireAllPlayersMajority(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Everyone Answers",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "Plaza", Question: "Q1?", CorrectAnswer: "a1"},
+			{Location: "Park", Question: "Q2?", CorrectAnswer: "a2"},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{RequireAllPlayers: true})
+	p1 := join(t, r, team.JoinToken, "Ana")
+	p2 := join(t, r, team.JoinToken, "Luis")
+	p3 := join(t, r, team.JoinToken, "Rosa")
+
+	w := postJSON(t, r, "/api/demo/game/answer", p1.Token, AnswerRequest{Answer: "a1"})
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Waiting || resp.AnsweredPlayers != 1 || resp.RequiredPlayers != 3 {
+		t.Fatalf("expected waiting 1/3, got %+v", resp)
+	}
+	if resp.CorrectAnswer != "" {
+		t.Errorf("expected correct answer hidden while waiting, got %q", resp.CorrectAnswer)
+	}
+
+	w = postJSON(t, r, "/api/demo/game/answer", p1.Token, AnswerRequest{Answer: "a1"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("duplicate answer: expected 409, got %d", w.Code)
+	}
+
+	state := gameState(t, r, p2.Token)
+	if state.CurrentStage.StageNumber != 1 {
+		t.Fatalf("expected team still on stage 1, got %d", state.CurrentStage.StageNumber)
+	}
+
+	postJSON(t, r, "/api/demo/game/answer", p2.Token, AnswerRequest{Answer: "a1"})
+	w = postJSON(t, r, "/api/demo/game/answer", p3.Token, AnswerRequest{Answer: "wrong"})
+	resp = AnswerResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Waiting || !resp.IsCorrect {
+		t.Fatalf("expected 2/3 correct to grade correct, got %+v", resp)
+	}
+
+	// Stage 2: one of three correct is not a majority.
+	postJSON(t, r, "/api/demo/game/answer", p1.Token, AnswerRequest{Answer: "a2"})
+	postJSON(t, r, "/api/demo/game/answer", p2.Token, AnswerRequest{Answer: "nope"})
+	w = postJSON(t, r, "/api/demo/game/answer", p3.Token, AnswerRequest{Answer: "nope"})
+	resp = AnswerResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.IsCorrect || !resp.GameComplete {
+		t.Errorf("expected wrong result and game complete, got %+v", resp)
+	}
+}
+
+func TestRequireAllPlayersFirstCorrect(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Anyone Right",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "Plaza", Question: "Q1?", CorrectAnswer: "a1"},
+		},
+	}
+	r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{RequireAllPlayers: true, AllPlayersGrading: "first_correct"})
+	p1 := join(t, r, team.JoinToken, "Ana")
+	p2 := join(t, r, team.JoinToken, "Luis")
+
+	postJSON(t, r, "/api/demo/game/answer", p1.Token, AnswerRequest{Answer: "wrong"})
+	w := postJSON(t, r, "/api/demo/game/answer", p2.Token, AnswerRequest{Answer: "A1"})
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.IsCorrect {
+		t.Fatalf("expected first_correct to grade correct, got %+v", resp)
+	}
+
+	status, err := store.GameStatus(context.Background(), gameID)
+	if err != nil {
+		t.Fatalf("game status: %v", err)
+	}
+	results := status.Teams[0].Results
+	if len(results) != 1 || results[0].Answer != "A1" || results[0].PlayerName != "Luis" {
+		t.Errorf("expected Luis's correct answer recorded, got %+v", results)
+	}
+}
+
+func TestSpectatorReadOnly(t *testing.T) {
+	r, store, _, team := modeRouter(t, AdminScenarioRequest{
+		Name: "Big Screen",
+		City: "Lima",
+		Stages: []AdminStage{
+			{Location: "Plaza", Question: "Q1?", CorrectAnswer: "a1"},
+		},
+	})
+	if team.SpectatorToken == "" {
+		t.Fatal("expected team to have a spectator token")
+	}
+	join(t, r, team.JoinToken, "Ana")
+	spectator := join(t, r, team.SpectatorToken, "Screen")
+	if spectator.Role != "spectator" {
+		t.Fatalf("expected role spectator, got %q", spectator.Role)
+	}
+
+	state := gameState(t, r, spectator.Token)
+	if state.Role != "spectator" || state.CurrentStage == nil {
+		t.Fatalf("expected readable state for spectator, got %+v", state)
+	}
+	if len(state.Players) != 1 {
+		t.Errorf("expected spectator not listed as a player, got %d players", len(state.Players))
+	}
+
+	w := postJSON(t, r, "/api/demo/game/answer", spectator.Token, AnswerRequest{Answer: "a1"})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("answer: expected 403, got %d", w.Code)
+	}
+	w = postJSON(t, r, "/api/demo/game/unlock", spectator.Token, UnlockRequest{Code: "X"})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("unlock: expected 403, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/demo/game/events?token="+spectator.Token, nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyStore, Store(store)))
+	ew := httptest.NewRecorder()
+	handleEvents(NewBroker(), 20*time.Millisecond, SSEBuffer{})(ew, req)
+	if ew.Code != http.StatusOK || ew.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("events: expected event stream, got %d %q", ew.Code, ew.Body.String())
+	}
+}
+
+func TestSelectStagePool(t *testing.T) {
+	stages := make([]AdminStage, 10)
+	for i := range stages {
+		stages[i].StageNumber = i + 1
+	}
+
+	a := selectStagePool(stages, 4, "team-a")
+	if len(a) != 4 {
+		t.Fatalf("expected 4 stages, got %v", a)
+	}
+	if !slices.IsSorted(a) {
+		t.Errorf("expected pool in scenario order, got %v", a)
+	}
+	if again := selectStagePool(stages, 4, "team-a"); !slices.Equal(a, again) {
+		t.Errorf("expected reproducible pool, got %v then %v", a, again)
+	}
+	if b := selectStagePool(stages, 4, "team-b"); slices.Equal(a, b) {
+		t.Errorf("expected different teams to get different pools, both got %v", a)
+	}
+
+	if pool := selectStagePool(stages, 0, "team-a"); pool != nil {
+		t.Errorf("expected nil pool for playCount 0, got %v", pool)
+	}
+	if pool := selectStagePool(stages, 10, "team-a"); pool != nil {
+		t.Errorf("expected nil pool when playCount covers every stage, got %v", pool)
+	}
+}
+
+func TestStagePoolGameState(t *testing.T) {
+	r, store, gameID, team := gameRouter(t, AdminScenarioRequest{
+		Name:      "Pool",
+		City:      "Lima",
+		Mode:      "classic",
+		PlayCount: 2,
+		Stages: []AdminStage{
+			{Location: "A", Question: "Q1?", CorrectAnswer: "a1"},
+			{Location: "B", Question: "Q2?", CorrectAnswer: "a2"},
+			{Location: "C", Question: "Q3?", CorrectAnswer: "a3"},
+			{Location: "D", Question: "Q4?", CorrectAnswer: "a4"},
+			{Location: "E", Question: "Q5?", CorrectAnswer: "a5"},
+		},
+	}, AdminGameRequest{})
+
+	g, err := store.GetGame(context.Background(), gameID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	if g.PlayCount != 2 {
+		t.Errorf("expected playCount 2, got %d", g.PlayCount)
+	}
+	pool := selectStagePool(g.Stages, 2, team.ID)
+
+	player := join(t, r, team.JoinToken, "Ana")
+	state := gameState(t, r, player.Token)
+	if state.Game.TotalStages != 2 {
+		t.Fatalf("expected 2 total stages, got %d", state.Game.TotalStages)
+	}
+	want := g.Stages[pool[0]-1].Question
+	if state.CurrentStage.Question != want {
+		t.Errorf("expected first pooled question %q, got %q", want, state.CurrentStage.Question)
+	}
+
+	answer := g.Stages[pool[0]-1].CorrectAnswer
+	w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: answer})
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	state = gameState(t, r, player.Token)
+	if want := g.Stages[pool[1]-1].Question; state.CurrentStage.Question != want {
+		t.Errorf("expected second pooled question %q, got %q", want, state.CurrentStage.Question)
+	}
+}
+
+func TestStaggeredStart(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Staggered",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Q1?", CorrectAnswer: "a1"},
+			{Location: "B", Question: "Q2?", CorrectAnswer: "a2"},
+			{Location: "C", Question: "Q3?", CorrectAnswer: "a3"},
+		},
+	}
+	// Each team plays every stage once, wrapping around to stage 1 after
+	// the last, and finishes after its third answer whatever its start.
+	for start, order := range map[int][]int{1: {1, 2, 3}, 2: {2, 3, 1}, 3: {3, 1, 2}} {
+		t.Run(strconv.Itoa(start), func(t *testing.T) {
+			r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{})
+			if _, err := store.UpdateTeam(context.Background(), gameID, team.ID, AdminTeamRequest{Name: team.Name, StartStage: start, Version: team.Version}); err != nil {
+				t.Fatalf("update team: %v", err)
+			}
+			player := join(t, r, team.JoinToken, "Ana")
+
+			for i, n := range order {
+				state := gameState(t, r, player.Token)
+				if state.CurrentStage == nil || state.CurrentStage.StageNumber != i+1 {
+					t.Fatalf("step %d: current stage = %+v, want team stage %d", i+1, state.CurrentStage, i+1)
+				}
+				if want := fmt.Sprintf("Q%d?", n); state.CurrentStage.Question != want {
+					t.Errorf("step %d: question = %q, want %q", i+1, state.CurrentStage.Question, want)
+				}
+				resp := answer(t, r, player.Token, fmt.Sprintf("a%d", n))
+				if !resp.IsCorrect {
+					t.Fatalf("step %d: answer a%d scored wrong", i+1, n)
+				}
+				if last := i == len(order)-1; resp.GameComplete != last {
+					t.Errorf("step %d: gameComplete = %v, want %v", i+1, resp.GameComplete, last)
+				}
+			}
+
+			state := gameState(t, r, player.Token)
+			if state.CurrentStage != nil || len(state.CompletedStages) != len(order) {
+				t.Errorf("after the last answer: current stage %+v, %d completed, want none current and %d completed", state.CurrentStage, len(state.CompletedStages), len(order))
+			}
+			if state.Game.Phase != "finished" {
+				t.Errorf("phase = %q, want finished", state.Game.Phase)
+			}
+		})
+	}
+}
+
+func TestGamePhase(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Phases",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Q1?", CorrectAnswer: "a1"},
+			{Location: "B", Question: "Q2?", CorrectAnswer: "a2"},
+		},
+	}
+	ctx := context.Background()
+	setGame := func(t *testing.T, store *DocStore, gameID string, fn func(g *game)) {
+		t.Helper()
+		if err := store.modifyGame(ctx, gameID, func(g *game) error { fn(g); return nil }); err != nil {
+			t.Fatalf("modify game: %v", err)
+		}
+	}
+
+	t.Run("draft is waiting", func(t *testing.T) {
+		r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{})
+		player := join(t, r, team.JoinToken, "Ana")
+		setGame(t, store, gameID, func(g *game) { g.Status = "draft" })
+
+		state := gameState(t, r, player.Token)
+		if state.Game.Phase != "waiting" || state.CurrentStage != nil {
+			t.Errorf("expected waiting with no stage, got %q / %+v", state.Game.Phase, state.CurrentStage)
+		}
+	})
+
+	t.Run("mid-game is playing", func(t *testing.T) {
+		r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+		player := join(t, r, team.JoinToken, "Ana")
+		postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a1"})
+
+		state := gameState(t, r, player.Token)
+		if state.Game.Phase != "playing" || state.CurrentStage == nil {
+			t.Errorf("expected playing with a stage, got %q / %+v", state.Game.Phase, state.CurrentStage)
+		}
+	})
+
+	t.Run("all stages done is finished", func(t *testing.T) {
+		r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+		player := join(t, r, team.JoinToken, "Ana")
+		postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a1"})
+		postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "wrong"})
+
+		state := gameState(t, r, player.Token)
+		if state.Game.Phase != "finished" || state.CurrentStage != nil {
+			t.Errorf("expected finished with no stage, got %q / %+v", state.Game.Phase, state.CurrentStage)
+		}
+	})
+
+	t.Run("no stages is misconfigured", func(t *testing.T) {
+		r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{})
+		player := join(t, r, team.JoinToken, "Ana")
+		setGame(t, store, gameID, func(g *game) { g.Stages = nil })
+
+		state := gameState(t, r, player.Token)
+		if state.Game.Phase != "misconfigured" || state.CurrentStage != nil || state.Game.TotalStages != 0 {
+			t.Errorf("expected misconfigured with no stage, got %q / %+v", state.Game.Phase, state.CurrentStage)
+		}
+		w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a1"})
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "game has no stages") {
+			t.Errorf("answer: expected 409 game has no stages, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("timed out is ended", func(t *testing.T) {
+		r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{TimerEnabled: true, TimerMinutes: 30, StageTimerMinutes: 10})
+		player := join(t, r, team.JoinToken, "Ana")
+		started := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+		setGame(t, store, gameID, func(g *game) { g.StartedAt = &started })
+
+		state := gameState(t, r, player.Token)
+		if state.Game.Phase != "ended" || state.Game.Status != "ended" {
+			t.Errorf("expected ended, got phase %q status %q", state.Game.Phase, state.Game.Status)
+		}
+	})
+}
+
+func TestStageRemaining(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *string {
+		s := now.Add(d).Format(time.RFC3339Nano)
+		return &s
+	}
+	for _, tc := range []struct {
+		name        string
+		minutes     int
+		unlockedAt  *string
+		wantRunning bool
+		want        time.Duration
+	}{
+		{"no stage timer", 0, at(-time.Minute), false, 0},
+		{"not unlocked yet", 5, nil, false, 0},
+		{"just unlocked", 5, at(0), true, 5 * time.Minute},
+		{"part way", 5, at(-90 * time.Second), true, 210 * time.Second},
+		{"ran out", 5, at(-6 * time.Minute), true, -time.Minute},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := gameStateData{StageTimerMinutes: tc.minutes, StageUnlockedAt: tc.unlockedAt}
+			got, running := d.stageRemaining(now)
+			if running != tc.wantRunning || got != tc.want {
+				t.Errorf("stageRemaining = %v, %v; want %v, %v", got, running, tc.want, tc.wantRunning)
+			}
+		})
+	}
+}
+
+func TestStageTimerSharedAndTimesOut(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "A", Clue: "Go to A", Question: "1+1?", CorrectAnswer: "2"},
+			{Location: "B", Clue: "Go to B", Question: "2+2?", CorrectAnswer: "4"},
+		},
+	}
+	broker := NewBroker()
+	r, store, gameID, team := brokerRouter(t, sc, AdminGameRequest{TimerEnabled: true, StageTimerMinutes: 5}, broker)
+	ana := join(t, r, team.JoinToken, "Ana")
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	if state := gameState(t, r, ana.Token); state.StageRemainingSeconds != 0 {
+		t.Errorf("remaining before unlock = %d, want none", state.StageRemainingSeconds)
+	}
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Pretend the stage was unlocked two minutes ago: everyone sees what's
+	// left of the same five minutes.
+	backdate := func(d time.Duration) {
+		t.Helper()
+		err := store.modifyGame(context.Background(), gameID, func(g *game) error {
+			at := time.Now().Add(-d).UTC().Format(time.RFC3339Nano)
+			g.Teams[0].StageUnlockedAt = &at
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("backdate unlock: %v", err)
+		}
+	}
+	backdate(2 * time.Minute)
+	for _, token := range []string{ana.Token, super.Token} {
+		state := gameState(t, r, token)
+		if got := state.StageRemainingSeconds; got < 175 || got > 180 {
+			t.Errorf("remaining = %d, want about 180", got)
+		}
+		deadline, err := time.Parse(time.RFC3339, state.CurrentStage.StageDeadline)
+		if want := time.Now().Add(3 * time.Minute); err != nil || deadline.Before(want.Add(-2*time.Second)) || deadline.After(want.Add(time.Second)) {
+			t.Errorf("stageDeadline = %q, want about %s", state.CurrentStage.StageDeadline, want.UTC().Format(time.RFC3339))
+		}
+	}
+
+	// Once it runs out the stage counts as wrong and the team moves on.
+	ch := broker.Subscribe(team.ID, 0)
+	defer broker.Unsubscribe(team.ID, ch)
+	backdate(6 * time.Minute)
+	state := gameState(t, r, ana.Token)
+	if len(state.CompletedStages) != 1 || state.CompletedStages[0].IsCorrect {
+		t.Errorf("completed = %+v, want stage 1 wrong", state.CompletedStages)
+	}
+	if state.CurrentStage == nil || state.CurrentStage.StageNumber != 2 || !state.CurrentStage.Locked || state.StageRemainingSeconds != 0 || state.CurrentStage.StageDeadline != "" {
+		t.Errorf("after timeout: currentStage=%+v remaining=%d, want locked stage 2", state.CurrentStage, state.StageRemainingSeconds)
+	}
+	gameState(t, r, super.Token)
+	if len(ch) != 1 {
+		t.Fatalf("expected one stage_timeout event, got %d", len(ch))
+	}
+	var e SSEEvent
+	json.Unmarshal(<-ch, &e)
+	if e.Type != "stage_timeout" || e.StageNumber != 1 {
+		t.Errorf("event = %+v, want stage_timeout for stage 1", e)
+	}
+}
+
+func TestRejoinAfterFinishing(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Short",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Year?", CorrectAnswer: "1651"},
+			{Location: "B", Question: "City?", CorrectAnswer: "Lima"},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{Outro: "  Thanks for playing!  "})
+	ana := join(t, r, team.JoinToken, "Ana")
+
+	if state := gameState(t, r, ana.Token); state.Outro != "" || state.Recap != nil {
+		t.Errorf("outro %q and recap %+v before finishing, want neither", state.Outro, state.Recap)
+	}
+	postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "1651"})
+	postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "Cusco"})
+
+	// The game is still active, so the team can still be looked up and joined.
+	req := httptest.NewRequest(http.MethodGet, "/api/demo/teams/"+team.JoinToken, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("team lookup: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	luis := join(t, r, team.JoinToken, "Luis")
+
+	state := gameState(t, r, luis.Token)
+	if state.Game.Phase != "finished" || state.CurrentStage != nil {
+		t.Errorf("phase %q with currentStage %+v, want finished", state.Game.Phase, state.CurrentStage)
+	}
+	if state.Outro != "Thanks for playing!" {
+		t.Errorf("outro = %q, want the trimmed game outro", state.Outro)
+	}
+	if len(state.Recap) != 2 || state.Recap[0].Answer != "1651" || !state.Recap[0].IsCorrect || state.Recap[1].Answer != "Cusco" || state.Recap[1].IsCorrect {
+		t.Errorf("recap = %+v, want both stages with the team's answers", state.Recap)
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleGiveUp lets a team stuck on a question skip it in games with
+// allowGiveUp. The stage is recorded wrong with no answer, and the response
+// is the same result an answer gets, so the team sees the correct answer and
+// explanation and moves on (or waits on the result in manualAdvance games).
+// The same players who may answer may give up.
+func handleGiveUp(broker *Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, err := playerFromRequest(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+		if sess.Role == "spectator" {
+			writeError(w, http.StatusForbidden, "spectators cannot give up")
+			return
+		}
+
+		store := clientStore(r)
+
+		progress, err := store.TeamProgress(r.Context(), sess.GameID, sess.TeamID)
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+		data := progress.forPlayer(sess.PlayerID)
+
+		if data.TimerEnabled && data.Status == "active" && data.StartedAt != nil {
+			start, _ := time.Parse(time.RFC3339Nano, *data.StartedAt)
+			if time.Since(start) > time.Duration(data.TimerMinutes)*time.Minute {
+				store.ExpireGame(r.Context(), sess.GameID)
+				writeError(w, http.StatusConflict, "game has ended")
+				return
+			}
+		}
+		if data.Status != "active" {
+			writeError(w, http.StatusConflict, "game is not active")
+			return
+		}
+		if !modeHasQuestion(data.Mode) {
+			writeError(w, http.StatusConflict, "this mode does not use questions")
+			return
+		}
+		if !data.AllowGiveUp {
+			writeError(w, http.StatusForbidden, "this game does not allow giving up")
+			return
+		}
+		if data.Supervised && !data.PlayersCanAnswer && sess.Role != "supervisor" {
+			writeError(w, http.StatusForbidden, "only the supervisor can give up")
+			return
+		}
+
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+		if len(stages) == 0 {
+			writeError(w, http.StatusConflict, "game has no stages")
+			return
+		}
+
+		answeredCount := data.answered()
+		if data.PendingAdvance {
+			writeError(w, http.StatusConflict, "move on to the next stage first")
+			return
+		}
+		if data.waitingForTeammates() {
+			writeError(w, http.StatusConflict, "waiting for teammates")
+			return
+		}
+		currentStageNum := answeredCount + 1
+		if currentStageNum > len(stages) {
+			writeError(w, http.StatusConflict, "all stages completed")
+			return
+		}
+		if modeRequiresUnlock(data.Mode) && !isStageUnlocked(data.UnlockedStages, currentStageNum) {
+			writeError(w, http.StatusConflict, "stage not unlocked")
+			return
+		}
+
+		recorded, err := store.GiveUpStage(r.Context(), sess.GameID, sess.TeamID, sess.PlayerID, currentStageNum)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if !recorded {
+			// A teammate's answer or give-up landed first.
+			writeError(w, http.StatusConflict, "stage already answered")
+			return
+		}
+
+		logGameEvent(r, store, sess.GameID, GameEvent{
+			Type:        "gave_up",
+			TeamID:      sess.TeamID,
+			PlayerID:    sess.PlayerID,
+			StageNumber: currentStageNum,
+		})
+
+		stage := stages[rotatedStageIndex(currentStageNum, data.StartStage, len(stages))]
+		resp := AnswerResponse{
+			StageNumber: currentStageNum,
+			CanAdvance:  data.canAdvance(sess.Role),
+		}
+		resp.setResult(data.gameStateData, stages, stage, sess.Role)
+
+		// With individualScoring the stage is only this player's.
+		if !data.individualScoring(data.Mode) {
+			broker.Publish(sess.TeamID, SSEEvent{
+				Type:        "stage_gaveup",
+				StageNumber: currentStageNum,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func giveUpScenario() AdminScenarioRequest {
+	return AdminScenarioRequest{
+		Name: "Give Up",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Clue: "Go to A", Question: "Year?", CorrectAnswer: "1651", Explanation: "The fountain's date."},
+			{Location: "B", Clue: "Go to B", Question: "Saint?", CorrectAnswer: "Rosa"},
+		},
+	}
+}
+
+func TestGiveUpDisabled(t *testing.T) {
+	r, store, gameID, team := gameRouter(t, giveUpScenario(), AdminGameRequest{})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	if gameState(t, r, player.Token).Game.AllowGiveUp {
+		t.Error("game state reports allowGiveUp on a game without it")
+	}
+	if w := postJSON(t, r, "/api/demo/game/giveup", player.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("give up: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if n, _ := store.CountAnsweredStages(context.Background(), gameID, team.ID); n != 0 {
+		t.Errorf("answered stages = %d after a refused give-up, want 0", n)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	broker := NewBroker()
+	r, _, _, team := brokerRouter(t, giveUpScenario(), AdminGameRequest{AllowGiveUp: true}, broker)
+	player := join(t, r, team.JoinToken, "Ana")
+
+	if !gameState(t, r, player.Token).Game.AllowGiveUp {
+		t.Error("game state should report allowGiveUp")
+	}
+
+	events := broker.Subscribe(team.ID, 0)
+	defer broker.Unsubscribe(team.ID, events)
+
+	w := postJSON(t, r, "/api/demo/game/giveup", player.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("give up: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.IsCorrect || resp.StageNumber != 1 || resp.CorrectAnswer != "1651" || resp.Explanation != "The fountain's date." {
+		t.Errorf("give up response = %+v, want stage 1 wrong with its answer and explanation", resp)
+	}
+	if resp.NextStage == nil || resp.NextStage.StageNumber != 2 || resp.GameComplete {
+		t.Errorf("give up response next stage = %+v, want stage 2", resp.NextStage)
+	}
+
+	// Ana's player_joined is still held back by coalescing and goes out first.
+	var got SSEEvent
+	for got.Type == "" || got.Type == "player_joined" {
+		select {
+		case data := <-events:
+			got = SSEEvent{}
+			json.Unmarshal(data, &got)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for stage_gaveup")
+		}
+	}
+	if want := (SSEEvent{Type: "stage_gaveup", StageNumber: 1}); got != want {
+		t.Errorf("event = %+v, want %+v", got, want)
+	}
+
+	state := gameState(t, r, player.Token)
+	if state.CurrentStage == nil || state.CurrentStage.StageNumber != 2 {
+		t.Fatalf("current stage = %+v, want stage 2", state.CurrentStage)
+	}
+	if len(state.CompletedStages) != 1 || state.CompletedStages[0].IsCorrect || !state.CompletedStages[0].GaveUp {
+		t.Errorf("completed stages = %+v, want stage 1 given up and wrong", state.CompletedStages)
+	}
+
+	// Giving up the last stage ends the game for the team; there's nothing
+	// left to give up after that.
+	w = postJSON(t, r, "/api/demo/game/giveup", player.Token, nil)
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.GameComplete || resp.CorrectAnswer != "Rosa" {
+		t.Errorf("give up last stage: got %d %+v, want the game complete", w.Code, resp)
+	}
+	if w := postJSON(t, r, "/api/demo/game/giveup", player.Token, nil); w.Code != http.StatusConflict {
+		t.Errorf("give up after the last stage: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGiveUpSupervisorOnly(t *testing.T) {
+	sc := giveUpScenario()
+	sc.Mode = "supervised"
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{AllowGiveUp: true})
+	player := join(t, r, team.JoinToken, "Ana")
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	if w := postJSON(t, r, "/api/demo/game/giveup", player.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("player give up in supervised game: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{})
+	if w := postJSON(t, r, "/api/demo/game/giveup", super.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("supervisor give up: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"strings"
 )
 
+// JoinRequest identifies the team either by JoinToken or, for quick entry,
+// by the game's PIN plus the ID of the team the player picked.
 type JoinRequest struct {
 	JoinToken  string `json:"joinToken"`
+	PIN        string `json:"pin,omitempty"`
+	TeamID     string `json:"teamId,omitempty"`
 	PlayerName string `json:"playerName"`
 }
 
@@ -28,14 +32,20 @@ func handleJoin(broker *Broker) http.HandlerFunc {
 		}
 
 		req.PlayerName = strings.TrimSpace(req.PlayerName)
-		if req.PlayerName == "" || req.JoinToken == "" {
-			writeError(w, http.StatusBadRequest, "joinToken and playerName are required")
+		if req.PlayerName == "" || (req.JoinToken == "" && (req.PIN == "" || req.TeamID == "")) {
+			writeError(w, http.StatusBadRequest, "joinToken (or pin and teamId) and playerName are required")
 			return
 		}
 
 		store := clientStore(r)
 
-		team, err := store.TeamLookup(r.Context(), req.JoinToken)
+		var team TeamLookupResponse
+		var err error
+		if req.JoinToken != "" {
+			team, err = store.TeamLookup(r.Context(), req.JoinToken)
+		} else {
+			team, err = teamByPIN(r, store, req.PIN, req.TeamID)
+		}
 		if errors.Is(err, ErrNotFound) {
 			writeError(w, http.StatusNotFound, "team not found or game not active")
 			return
@@ -51,11 +61,21 @@ func handleJoin(broker *Broker) http.HandlerFunc {
 			return
 		}
 
-		broker.Publish(team.ID, SSEEvent{
-			Type:       "player_joined",
-			PlayerName: req.PlayerName,
+		logGameEvent(r, store, team.GameID, GameEvent{
+			Type:     "joined",
+			TeamID:   team.ID,
+			PlayerID: playerID,
+			Role:     team.Role,
 		})
 
+		if team.Role != "spectator" {
+			broker.PublishCoalesced(team.ID, SSEEvent{
+				Type:       "player_joined",
+				PlayerName: req.PlayerName,
+				Player:     PlayerInfo{ID: playerID, Name: req.PlayerName, Role: team.Role},
+			})
+		}
+
 		writeJSON(w, http.StatusOK, JoinResponse{
 			Token:    sessionID,
 			PlayerID: playerID,
@@ -65,3 +85,25 @@ func handleJoin(broker *Broker) http.HandlerFunc {
 		})
 	}
 }
+
+// teamByPIN resolves a team picked from the game-PIN team list. PIN entry
+// only ever joins as a player; supervisors and spectators use their tokens.
+func teamByPIN(r *http.Request, store Store, pin, teamID string) (TeamLookupResponse, error) {
+	g, err := store.GameByPIN(r.Context(), pin)
+	if err != nil {
+		return TeamLookupResponse{}, err
+	}
+	for _, t := range g.Teams {
+		if t.ID == teamID {
+			return TeamLookupResponse{
+				ID:       t.ID,
+				Name:     t.Name,
+				GameName: g.GameName,
+				GameID:   g.GameID,
+				Language: g.Language,
+				Role:     "player",
+			}, nil
+		}
+	}
+	return TeamLookupResponse{}, ErrNotFound
+}
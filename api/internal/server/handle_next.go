@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+)
+
+// NextResponse reports where the team is after moving on from a result.
+type NextResponse struct {
+	StageNumber  int  `json:"stageNumber"` // the team's new current stage
+	GameComplete bool `json:"gameComplete"`
+}
+
+// handleNext moves the team on from the result of its last answer in a
+// manualAdvance game, so "submit answer" and "move on" are separate steps.
+// In supervised games only the supervisor can do it. Everyone on the team
+// gets a stage_advanced event with the next clue and leaves the result screen
+// together.
+func handleNext(broker *Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, err := playerFromRequest(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+
+		if sess.Role == "spectator" {
+			writeError(w, http.StatusForbidden, "spectators cannot move the team on")
+			return
+		}
+
+		store := clientStore(r)
+
+		data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+
+		if data.Status != "active" {
+			writeError(w, http.StatusConflict, "game is not active")
+			return
+		}
+		if !data.ManualAdvance || !modeHasQuestion(data.Mode) {
+			writeError(w, http.StatusConflict, "this game advances automatically")
+			return
+		}
+		if !data.canAdvance(sess.Role) {
+			writeError(w, http.StatusForbidden, "only the supervisor can move the team on")
+			return
+		}
+
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+
+		answered, err := store.AdvanceTeam(r.Context(), sess.GameID, sess.TeamID)
+		if errors.Is(err, ErrNothingToAdvance) {
+			writeError(w, http.StatusConflict, "no result to move on from")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		logGameEvent(r, store, sess.GameID, GameEvent{
+			Type:        "advanced",
+			TeamID:      sess.TeamID,
+			PlayerID:    sess.PlayerID,
+			StageNumber: answered + 1,
+		})
+
+		// Every screen on the team moves to the next clue together.
+		event := SSEEvent{
+			Type:        "stage_advanced",
+			StageNumber: answered + 1,
+		}
+		if event.StageNumber <= len(stages) {
+			s := stages[rotatedStageIndex(event.StageNumber, data.StartStage, len(stages))]
+			event.Clue = s.Clue
+			event.Locked = modeRequiresUnlock(data.Mode) && !isStageUnlocked(data.UnlockedStages, event.StageNumber)
+		}
+		broker.Publish(sess.TeamID, event)
+
+		writeJSON(w, http.StatusOK, NextResponse{
+			StageNumber:  answered + 1,
+			GameComplete: answered >= len(stages),
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestManualAdvance(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Steps",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "A", Question: "Year?", CorrectAnswer: "1651", Explanation: "Rebuilt after the earthquake."},
+			{Location: "B", Question: "City?", CorrectAnswer: "Lima"},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{ManualAdvance: true})
+	ana := join(t, r, team.JoinToken, "Ana")
+	luis := join(t, r, team.JoinToken, "Luis")
+
+	// Step one: the answer is graded and the team stays on the result.
+	w := postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "1700"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.IsCorrect || resp.CorrectAnswer != "1651" || resp.Explanation != "Rebuilt after the earthquake." || !resp.CanAdvance {
+		t.Errorf("answer response = %+v, want a wrong result with answer, explanation and canAdvance", resp)
+	}
+
+	// Until someone calls next, state stays on the answered stage.
+	state := gameState(t, r, luis.Token)
+	if !state.PendingAdvance || !state.CanAdvance || state.LastResult == nil || state.LastResult.StageNumber != 1 {
+		t.Errorf("state after answer: pendingAdvance=%v canAdvance=%v lastResult=%+v", state.PendingAdvance, state.CanAdvance, state.LastResult)
+	}
+	if state.CurrentStage == nil || state.CurrentStage.StageNumber != 1 || len(state.CompletedStages) != 1 {
+		t.Errorf("state after answer: currentStage=%+v with %d completed, want stage 1 answered", state.CurrentStage, len(state.CompletedStages))
+	}
+	if w := postJSON(t, r, "/api/demo/game/answer", luis.Token, AnswerRequest{Answer: "Lima"}); w.Code != http.StatusConflict {
+		t.Errorf("answer before next: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Step two: any player moves the team on, once.
+	w = postJSON(t, r, "/api/demo/game/next", luis.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("next: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var next NextResponse
+	json.NewDecoder(w.Body).Decode(&next)
+	if next.StageNumber != 2 || next.GameComplete {
+		t.Errorf("next = %+v, want stage 2", next)
+	}
+	if w := postJSON(t, r, "/api/demo/game/next", ana.Token, nil); w.Code != http.StatusConflict {
+		t.Errorf("second next: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if state := gameState(t, r, ana.Token); state.PendingAdvance || state.CurrentStage == nil || state.CurrentStage.StageNumber != 2 {
+		t.Errorf("state after next: pendingAdvance=%v currentStage=%+v", state.PendingAdvance, state.CurrentStage)
+	}
+
+	// The last stage also waits for next, which then completes the game.
+	if w := postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "Lima"}); w.Code != http.StatusOK {
+		t.Fatalf("answer stage 2: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postJSON(t, r, "/api/demo/game/next", ana.Token, nil)
+	next = NextResponse{}
+	json.NewDecoder(w.Body).Decode(&next)
+	if w.Code != http.StatusOK || !next.GameComplete {
+		t.Errorf("next after last stage: %d %+v, want gameComplete", w.Code, next)
+	}
+}
+
+func TestNextWithoutManualAdvance(t *testing.T) {
+	r := playerRouter(t)
+	player := join(t, r, demoIncas.JoinToken, "Ana")
+
+	w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "1651"})
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.CanAdvance {
+		t.Error("expected canAdvance unset when the game advances on its own")
+	}
+	if w := postJSON(t, r, "/api/demo/game/next", player.Token, nil); w.Code != http.StatusConflict {
+		t.Errorf("next: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if state := gameState(t, r, player.Token); state.PendingAdvance || state.CurrentStage == nil || state.CurrentStage.StageNumber != 2 {
+		t.Errorf("expected the team on stage 2 right away, pendingAdvance=%v currentStage=%+v", state.PendingAdvance, state.CurrentStage)
+	}
+}
+
+func TestManualAdvanceSupervised(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "A", Question: "1+1?", CorrectAnswer: "2"},
+			{Location: "B", Question: "2+2?", CorrectAnswer: "4"},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{ManualAdvance: true, PlayersCanAnswer: true})
+	player := join(t, r, team.JoinToken, "Ana")
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "2"})
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.CanAdvance {
+		t.Errorf("player answer: %d %+v, want 200 without canAdvance", w.Code, resp)
+	}
+
+	// Only the supervisor moves the team on, and can't unlock the next
+	// stage before doing so.
+	if w := postJSON(t, r, "/api/demo/game/next", player.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("player next: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusConflict {
+		t.Errorf("unlock before next: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if state := gameState(t, r, super.Token); !state.PendingAdvance || !state.CanAdvance {
+		t.Errorf("supervisor state: pendingAdvance=%v canAdvance=%v, want both", state.PendingAdvance, state.CanAdvance)
+	}
+	if w := postJSON(t, r, "/api/demo/game/next", super.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("supervisor next: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Errorf("unlock after next: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestNextPublishesNextClue(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "A", Clue: "Go to A", Question: "1+1?", CorrectAnswer: "2"},
+			{Location: "B", Clue: "Go to B", Question: "2+2?", CorrectAnswer: "4"},
+		},
+	}
+	broker := NewBroker()
+	r, _, _, team := brokerRouter(t, sc, AdminGameRequest{ManualAdvance: true}, broker)
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(t, r, "/api/demo/game/answer", super.Token, AnswerRequest{Answer: "2"}); w.Code != http.StatusOK {
+		t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Every device on the team, subscribed before next.
+	subs := make([]chan []byte, 3)
+	for i := range subs {
+		subs[i] = broker.Subscribe(team.ID, 0)
+		defer broker.Unsubscribe(team.ID, subs[i])
+	}
+	if w := postJSON(t, r, "/api/demo/game/next", super.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("next: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	want := SSEEvent{Type: "stage_advanced", StageNumber: 2, Clue: "Go to B", Locked: true}
+	for i, ch := range subs {
+		var got SSEEvent
+		for got.Type != "stage_advanced" {
+			select {
+			case data := <-ch:
+				got = SSEEvent{}
+				json.Unmarshal(data, &got)
+			case <-time.After(time.Second):
+				t.Fatalf("subscriber %d: timed out waiting for stage_advanced", i)
+			}
+		}
+		if got != want {
+			t.Errorf("subscriber %d got %+v, want %+v", i, got, want)
+		}
+	}
+}
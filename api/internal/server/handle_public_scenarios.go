@@ -0,0 +1,28 @@
+package server
+
+import "net/http"
+
+// PublicScenario is what GET /api/public/scenarios shows about a scenario:
+// enough for an "upcoming tours" page, nothing that helps anyone play it.
+// It has no stages, so answers and unlock codes can't leak through it.
+type PublicScenario struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	City        string `json:"city"`
+	Description string `json:"description"`
+	Mode        string `json:"mode"`
+	StageCount  int    `json:"stageCount"`
+}
+
+// handlePublicScenarios lists the scenarios admins flagged public. It needs
+// no auth; publicCORS decides which other sites may read it.
+func handlePublicScenarios(admin AdminStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scenarios, err := admin.ListPublicScenarios(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, scenarios)
+	}
+}
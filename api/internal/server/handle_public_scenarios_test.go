@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestPublicScenarios(t *testing.T) {
+	admin, _ := setupStores(t)
+	ctx := context.Background()
+
+	// The demo scenario stays private; this one is listed.
+	pub, err := admin.CreateScenario(ctx, AdminScenarioRequest{
+		Name: "Old Town", City: "Cusco", Description: "A walk round the plaza", Mode: "qr_quiz", Public: true,
+		Stages: []AdminStage{
+			{StageNumber: 1, Location: "Plaza", Clue: "Find the fountain", Question: "Year?", CorrectAnswer: "secret-answer", UnlockCode: "UNLOCK-42"},
+			{StageNumber: 2, Location: "Church", Clue: "Look up", Question: "Bells?", CorrectAnswer: "seven", UnlockCode: "UNLOCK-43"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create scenario: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.With(publicCORS([]string{"https://tours.example"})).Get("/api/public/scenarios", handlePublicScenarios(admin))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/public/scenarios", nil)
+	req.Header.Set("Origin", "https://tours.example")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://tours.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the allowed origin", got)
+	}
+
+	body := rec.Body.String()
+	for _, leak := range []string{"secret-answer", "UNLOCK-42", "seven", "stages", "correctAnswer", demoSeed.ScenarioID} {
+		if strings.Contains(body, leak) {
+			t.Errorf("response contains %q: %s", leak, body)
+		}
+	}
+
+	var got []PublicScenario
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := PublicScenario{ID: pub.ID, Name: "Old Town", City: "Cusco", Description: "A walk round the plaza", Mode: "qr_quiz", StageCount: 2}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("scenarios = %+v, want [%+v]", got, want)
+	}
+
+	// Unpublishing takes it off the list.
+	update := AdminScenarioRequest{Name: pub.Name, City: pub.City, Mode: pub.Mode, Stages: pub.Stages, Version: pub.Version}
+	if _, err := admin.UpdateScenario(ctx, pub.ID, update); err != nil {
+		t.Fatalf("update scenario: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/public/scenarios", nil))
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("after unpublishing, body = %s, want []", body)
+	}
+}
+
+func TestPublicCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    string
+	}{
+		{"no origins configured", nil, "https://tours.example", ""},
+		{"allowed origin", []string{"https://tours.example"}, "https://tours.example", "https://tours.example"},
+		{"other origin", []string{"https://tours.example"}, "https://evil.example", ""},
+		{"any origin", []string{"*"}, "https://evil.example", "*"},
+		{"same-origin request", []string{"*"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := publicCORS(tt.origins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/api/public/scenarios", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+)
+
+// RecapStage is one answered stage on a player's recap screen.
+type RecapStage struct {
+	StageNumber int    `json:"stageNumber"`
+	Location    string `json:"location,omitempty"`
+	Question    string `json:"question"`
+	Answer      string `json:"answer"` // empty when the stage timed out
+	IsCorrect   bool   `json:"isCorrect"`
+	Explanation string `json:"explanation,omitempty"`
+	AnsweredAt  string `json:"answeredAt"`
+}
+
+type RecapResponse struct {
+	Stages []RecapStage `json:"stages"`
+}
+
+// handleRecap lists the stages the session's team has answered, with each
+// question and the team's answer, for the player recap screen. It works in
+// any game status so the recap stays available once the game ends.
+func handleRecap() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, err := playerFromRequest(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+
+		store := clientStore(r)
+
+		data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
+			return
+		}
+
+		completed, err := store.ListCompletedStages(r.Context(), sess.GameID, sess.TeamID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		completed = data.ownStages(completed, sess.PlayerID)
+
+		writeJSON(w, http.StatusOK, RecapResponse{Stages: recapStages(data, sess.Role, stages, completed)})
+	}
+}
+
+// recapStages pairs the team's completed stages with the stage snapshot, as
+// a session with role sees them.
+func recapStages(data gameStateData, role string, stages []scenarioStage, completed []CompletedStage) []RecapStage {
+	recap := []RecapStage{}
+	// A game whose stages were removed mid-play has nothing to show.
+	if len(stages) == 0 {
+		return recap
+	}
+	for _, c := range completed {
+		s := stages[rotatedStageIndex(c.StageNumber, data.StartStage, len(stages))]
+		recap = append(recap, RecapStage{
+			StageNumber: c.StageNumber,
+			Location:    data.stageLocation(role, s.Location),
+			Question:    s.Question,
+			Answer:      c.Answer,
+			IsCorrect:   c.IsCorrect,
+			Explanation: s.Explanation,
+			AnsweredAt:  c.AnsweredAt,
+		})
+	}
+	return recap
+}
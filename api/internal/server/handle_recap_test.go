@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecap(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Notes",
+		City: "Lima",
+		Mode: "classic",
+		Stages: []AdminStage{
+			{Location: "Cathedral", Question: "Year?", CorrectAnswer: "1651", Explanation: "Rebuilt after the earthquake."},
+			{Location: "Plaza", Question: "City?", CorrectAnswer: "Lima"},
+			{Location: "Bridge", Question: "River?", CorrectAnswer: "Rimac"},
+		},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{})
+	ana := join(t, r, team.JoinToken, "Ana")
+	luis := join(t, r, team.JoinToken, "Luis")
+
+	recap := func(token string) []RecapStage {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/demo/game/recap", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("recap: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp RecapResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Stages
+	}
+
+	if got := recap(ana.Token); len(got) != 0 {
+		t.Errorf("recap before answering = %+v, want empty", got)
+	}
+
+	postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "1651"})
+	postJSON(t, r, "/api/demo/game/answer", luis.Token, AnswerRequest{Answer: "Cusco"})
+
+	// Either player sees the team's answers, in order.
+	want := []RecapStage{
+		{StageNumber: 1, Location: "Cathedral", Question: "Year?", Answer: "1651", IsCorrect: true, Explanation: "Rebuilt after the earthquake."},
+		{StageNumber: 2, Location: "Plaza", Question: "City?", Answer: "Cusco"},
+	}
+	got := recap(ana.Token)
+	if len(got) != len(want) {
+		t.Fatalf("recap = %+v, want %d stages", got, len(want))
+	}
+	for i := range want {
+		want[i].AnsweredAt = got[i].AnsweredAt
+		if got[i] != want[i] || got[i].AnsweredAt == "" {
+			t.Errorf("stage %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/demo/game/recap", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("recap without token: expected 401, got %d", w.Code)
+	}
+}
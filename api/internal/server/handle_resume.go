@@ -0,0 +1,63 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ResumeResponse is what the app needs to go straight to the game screen
+// with a session token it already holds.
+type ResumeResponse struct {
+	Token    string `json:"token"`
+	PlayerID string `json:"playerId"`
+	TeamID   string `json:"teamId"`
+	TeamName string `json:"teamName"`
+	GameName string `json:"gameName"`
+	Role     string `json:"role"`
+	Language string `json:"language,omitempty"`
+}
+
+// handleResume validates a stored session token so a returning player skips
+// the join screen instead of joining again as a new player. A token whose
+// session, game or team no longer exists is dead and gets 401.
+func handleResume() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+
+		store := clientStore(r)
+
+		sess, err := store.PlayerFromToken(r.Context(), token)
+		if errors.Is(err, errNoSession) {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		team, err := store.SessionTeam(r.Context(), sess.GameID, sess.TeamID)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusUnauthorized, "invalid or missing session token")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, ResumeResponse{
+			Token:    token,
+			PlayerID: sess.PlayerID,
+			TeamID:   team.ID,
+			TeamName: team.Name,
+			GameName: team.GameName,
+			Role:     sess.Role,
+			Language: team.Language,
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -19,7 +20,7 @@ func supervisedRouter(t *testing.T) (*chi.Mux, string, string) {
 	t.Helper()
 	ctx := context.Background()
 
-	adminDB, err := database.Open(ctx, ":memory:")
+	adminDB, err := database.Open(ctx, ":memory:", 1)
 	if err != nil {
 		t.Fatalf("open admin db: %v", err)
 	}
@@ -29,11 +30,11 @@ func supervisedRouter(t *testing.T) (*chi.Mux, string, string) {
 	}
 	t.Cleanup(func() { adminDB.Close() })
 
-	clientDB, err := database.Open(ctx, ":memory:")
+	clientDB, err := database.Open(ctx, ":memory:", 1)
 	if err != nil {
 		t.Fatalf("open client db: %v", err)
 	}
-	store, err := NewDocStore(ctx, clientDB)
+	store, err := NewDocStore(ctx, clientDB, nil)
 	if err != nil {
 		t.Fatalf("init doc store: %v", err)
 	}
@@ -80,9 +81,11 @@ func supervisedRouter(t *testing.T) (*chi.Mux, string, string) {
 		})
 	})
 	r.Post("/api/{client}/join", handleJoin(broker))
-	r.Get("/api/{client}/game/state", handleGameState())
-	r.Post("/api/{client}/game/answer", handleAnswer(broker))
-	r.Post("/api/{client}/game/unlock", handleUnlock(broker))
+	r.Get("/api/{client}/game/state", handleGameState(broker))
+	r.Post("/api/{client}/game/answer", handleAnswer(broker, testMaxAnswerLen))
+	r.Post("/api/{client}/game/unlock", handleUnlock(broker, testMaxAnswerLen))
+	r.Post("/api/{client}/game/next", handleNext(broker))
+	r.Post("/api/{client}/game/confirm", handleConfirm(broker))
 
 	return r, team.JoinToken, team.SupervisorToken
 }
@@ -262,3 +265,213 @@ func TestSupervisedFlowFull(t *testing.T) {
 		t.Errorf("expected 2 completed stages, got %d", len(state.CompletedStages))
 	}
 }
+
+func TestSupervisedPlayersCanAnswer(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "Plaza A", Clue: "Go to A", Question: "What is 1+1?", CorrectAnswer: "2"},
+			{Location: "Plaza B", Clue: "Go to B", Question: "What is 2+2?", CorrectAnswer: "4"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		canAnswer  bool
+		wantAnswer int
+	}{
+		{"supervisor only (default)", false, http.StatusForbidden},
+		{"players can answer", true, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _, team := gameRouter(t, sc, AdminGameRequest{PlayersCanAnswer: tc.canAnswer})
+			player := join(t, r, team.JoinToken, "Player")
+			super := join(t, r, team.SupervisorToken, "Guide")
+
+			if state := gameState(t, r, player.Token); state.Game.PlayersCanAnswer != tc.canAnswer {
+				t.Errorf("expected playersCanAnswer %v in game state, got %v", tc.canAnswer, state.Game.PlayersCanAnswer)
+			}
+
+			// Unlocking stays with the supervisor either way.
+			w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{})
+			if w.Code != http.StatusForbidden {
+				t.Errorf("player unlock: expected 403, got %d: %s", w.Code, w.Body.String())
+			}
+			w = postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{})
+			if w.Code != http.StatusOK {
+				t.Fatalf("supervisor unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "2"})
+			if w.Code != tc.wantAnswer {
+				t.Fatalf("player answer: expected %d, got %d: %s", tc.wantAnswer, w.Code, w.Body.String())
+			}
+			if tc.canAnswer {
+				var resp AnswerResponse
+				json.NewDecoder(w.Body).Decode(&resp)
+				if !resp.IsCorrect || resp.StageNumber != 1 {
+					t.Errorf("expected correct stage 1 answer, got %+v", resp)
+				}
+
+				// Stage 2 is locked again until the supervisor opens it.
+				w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "4"})
+				if w.Code != http.StatusConflict {
+					t.Errorf("answer on locked stage 2: expected 409, got %d: %s", w.Code, w.Body.String())
+				}
+			}
+
+			// The supervisor can always answer.
+			if !tc.canAnswer {
+				w = postJSON(t, r, "/api/demo/game/answer", super.Token, AnswerRequest{Answer: "2"})
+				if w.Code != http.StatusOK {
+					t.Errorf("supervisor answer: expected 200, got %d: %s", w.Code, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHideLocationFromPlayers(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "Plaza A", Clue: "Go to A", Question: "What is 1+1?", CorrectAnswer: "2"},
+			{Location: "Plaza B", Clue: "Go to B", Question: "What is 2+2?", CorrectAnswer: "4"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		hide bool
+	}{
+		{"shown to everyone (default)", false},
+		{"supervisor only", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _, team := gameRouter(t, sc, AdminGameRequest{HideLocationFromPlayers: tc.hide, PlayersCanAnswer: true})
+			player := join(t, r, team.JoinToken, "Player")
+			super := join(t, r, team.SupervisorToken, "Guide")
+
+			wantPlayer := "Plaza A"
+			if tc.hide {
+				wantPlayer = ""
+			}
+			state := gameState(t, r, player.Token)
+			if state.CurrentStage == nil || state.CurrentStage.Location != wantPlayer || state.CurrentStage.Clue != "Go to A" {
+				t.Errorf("player stage = %+v, want location %q and the clue", state.CurrentStage, wantPlayer)
+			}
+			state = gameState(t, r, super.Token)
+			if state.CurrentStage == nil || state.CurrentStage.Location != "Plaza A" {
+				t.Errorf("supervisor stage = %+v, want location %q", state.CurrentStage, "Plaza A")
+			}
+
+			// The next stage in the answer response follows the same rule.
+			if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+				t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "2"})
+			var resp AnswerResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			wantNext := "Plaza B"
+			if tc.hide {
+				wantNext = ""
+			}
+			if resp.NextStage == nil || resp.NextStage.Location != wantNext || resp.NextStage.Clue != "Go to B" {
+				t.Errorf("player next stage = %+v, want location %q and the clue", resp.NextStage, wantNext)
+			}
+		})
+	}
+}
+
+func TestMinPlayersToStart(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name:   "Crowd",
+		City:   "Lima",
+		Mode:   "supervised",
+		Stages: []AdminStage{{Location: "A", Question: "1+1?", CorrectAnswer: "2"}},
+	}
+	r, _, _, team := gameRouter(t, sc, AdminGameRequest{PlayersCanAnswer: true, MinPlayersToStart: 2})
+	super := join(t, r, team.SupervisorToken, "Guide")
+	ana := join(t, r, team.JoinToken, "Ana")
+
+	// The supervisor doesn't count, so one player is below the threshold.
+	state := gameState(t, r, ana.Token)
+	if state.Teammates == nil || *state.Teammates != (TeammatesInfo{Joined: 1, Required: 2}) {
+		t.Errorf("teammates = %+v, want 1 of 2", state.Teammates)
+	}
+	for _, tc := range []struct {
+		path, token string
+		body        any
+	}{
+		{"/api/demo/game/unlock", super.Token, UnlockRequest{}},
+		{"/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "2"}},
+	} {
+		w := postJSON(t, r, tc.path, tc.token, tc.body)
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "waiting for teammates") {
+			t.Errorf("%s below threshold: expected 409 waiting for teammates, got %d: %s", tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	// At the threshold the gate lifts.
+	join(t, r, team.JoinToken, "Luis")
+	if state := gameState(t, r, ana.Token); state.Teammates != nil {
+		t.Errorf("teammates = %+v at the threshold, want unset", state.Teammates)
+	}
+	if w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{}); w.Code != http.StatusOK {
+		t.Fatalf("unlock at threshold: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(t, r, "/api/demo/game/answer", ana.Token, AnswerRequest{Answer: "2"}); w.Code != http.StatusOK {
+		t.Errorf("answer at threshold: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	bad := AdminGameRequest{ScenarioID: "s1", MinPlayersToStart: -1}
+	if msg := bad.validate(TimerLimits{}); msg == "" {
+		t.Error("expected negative minPlayersToStart to be rejected")
+	}
+}
+
+func TestSupervisorTokenLookup(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Guided",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "Plaza A", Clue: "Go to A", Question: "What is 1+1?", CorrectAnswer: "2"},
+		},
+	}
+	r, store, gameID, team := gameRouter(t, sc, AdminGameRequest{})
+	lookup := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/demo/teams/"+token, nil))
+		return w
+	}
+
+	for token, role := range map[string]string{team.JoinToken: "player", team.SupervisorToken: "supervisor", team.SpectatorToken: "spectator"} {
+		w := lookup(token)
+		var resp TeamLookupResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusOK || resp.Role != role || resp.Mode != "supervised" || !resp.Supervised {
+			t.Errorf("lookup %s: %d %+v, want role %s in a supervised game", token, w.Code, resp, role)
+		}
+	}
+
+	// A game switched away from supervised keeps the token, but it no
+	// longer makes anyone a supervisor.
+	if err := store.modifyGame(context.Background(), gameID, func(g *game) error { g.Supervised = false; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if w := lookup(team.SupervisorToken); w.Code != http.StatusNotFound {
+		t.Errorf("supervisor token in a non-supervised game: expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	w := postJSON(t, r, "/api/demo/join", "", JoinRequest{JoinToken: team.SupervisorToken, PlayerName: "Guide"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("joining with it: expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := lookup(team.JoinToken); w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"supervised":true`) {
+		t.Errorf("player token after the switch: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -7,13 +7,19 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// TeamLookupResponse tells the join screen who a token joins as before the
+// player picks a name. Role is "player", "supervisor" (supervised games
+// only) or "spectator"; Mode and Supervised let the screen explain what the
+// role does in this game.
 type TeamLookupResponse struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	GameName string `json:"gameName"`
-	Role     string `json:"role"`
-	Language string `json:"language,omitempty"`
-	GameID   string `json:"-"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	GameName   string `json:"gameName"`
+	Role       string `json:"role" enum:"player,supervisor,spectator"`
+	Language   string `json:"language,omitempty"`
+	Mode       string `json:"mode"`
+	Supervised bool   `json:"supervised"`
+	GameID     string `json:"-"`
 }
 
 func handleTeamLookup() http.HandlerFunc {
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,15 +12,62 @@ type UnlockRequest struct {
 }
 
 type UnlockResponse struct {
-	StageNumber   int        `json:"stageNumber"`
-	Unlocked      bool       `json:"unlocked"`
-	StageComplete bool       `json:"stageComplete,omitempty"`
-	NextStage     *StageInfo `json:"nextStage,omitempty"`
-	GameComplete  bool       `json:"gameComplete,omitempty"`
-	Question      string     `json:"question,omitempty"`
+	StageNumber    int        `json:"stageNumber"`
+	Unlocked       bool       `json:"unlocked"`
+	UnlockedStages []int      `json:"unlockedStages,omitempty"`
+	StageComplete  bool       `json:"stageComplete,omitempty"`
+	NextStage      *StageInfo `json:"nextStage,omitempty"`
+	GameComplete   bool       `json:"gameComplete,omitempty"`
+	StageQuestion
 }
 
-func handleUnlock(broker *Broker) http.HandlerFunc {
+// StageQuestion is an unlocked stage's question as players get it from the
+// unlock response or the stage_unlocked event.
+type StageQuestion struct {
+	Question      string       `json:"question,omitempty"`
+	QuestionImage string       `json:"questionImage,omitempty"`
+	AnswerType    string       `json:"answerType,omitempty"` // see StageInfo.AnswerType
+	Attachments   []Attachment `json:"attachments,omitempty"`
+}
+
+// unlockedQuestion is stage's question with everything shown alongside it,
+// for unlock responses and stage_unlocked events alike.
+func unlockedQuestion(stage scenarioStage) StageQuestion {
+	return StageQuestion{
+		Question:      stage.Question,
+		QuestionImage: stage.QuestionImage,
+		AnswerType:    stage.inputType(),
+		Attachments:   stage.Attachments,
+	}
+}
+
+// sharedCodeRun returns the team stage numbers opened by scanning the QR code
+// of currentStageNum: the current stage plus the directly following stages (in
+// the team's order) whose UnlockCode matches it. The run stops at the
+// first stage with a different code and never wraps past the team's last
+// stage. In qr_quiz the extra stages are only unlocked — each still needs its
+// own answer; in qr_hunt they all complete together.
+func sharedCodeRun(stages []scenarioStage, currentStageNum, startStage int) []int {
+	code := stages[rotatedStageIndex(currentStageNum, startStage, len(stages))].UnlockCode
+	run := []int{currentStageNum}
+	for n := currentStageNum + 1; n <= len(stages); n++ {
+		if code == "" || !unlockCodeMatches(stages[rotatedStageIndex(n, startStage, len(stages))].UnlockCode, code) {
+			break
+		}
+		run = append(run, n)
+	}
+	return run
+}
+
+// unlockCodeMatches reports whether a scanned code opens a stage with
+// unlockCode. QR codes are compared without regard to case.
+func unlockCodeMatches(code, unlockCode string) bool {
+	return strings.EqualFold(code, unlockCode)
+}
+
+// handleUnlock opens the team's current stage. Codes over maxLen characters
+// are rejected like overlong answers (0 = no cap).
+func handleUnlock(broker *Broker, maxLen int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sess, err := playerFromRequest(r)
 		if err != nil {
@@ -29,18 +75,27 @@ func handleUnlock(broker *Broker) http.HandlerFunc {
 			return
 		}
 
+		if sess.Role == "spectator" {
+			writeError(w, http.StatusForbidden, "spectators cannot unlock stages")
+			return
+		}
+
 		var req UnlockRequest
 		if err := readJSON(r, &req); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
 		req.Code = strings.TrimSpace(req.Code)
+		if tooLong(req.Code, maxLen) {
+			writeError(w, http.StatusBadRequest, "code is too long")
+			return
+		}
 
 		store := clientStore(r)
 
-		data, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+		data, err := store.TeamProgress(r.Context(), sess.GameID, sess.TeamID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+			writeGameError(w, sess.GameID, err)
 			return
 		}
 
@@ -63,91 +118,110 @@ func handleUnlock(broker *Broker) http.HandlerFunc {
 			return
 		}
 
-		var stages []scenarioStage
-		if err := json.Unmarshal([]byte(data.StagesJSON), &stages); err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+		stages, err := data.scenarioStages()
+		if err != nil {
+			writeGameError(w, sess.GameID, err)
 			return
 		}
 
-		answeredCount, err := store.CountAnsweredStages(r.Context(), sess.GameID, sess.TeamID)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "internal error")
+		answeredCount := data.answered()
+
+		if len(stages) == 0 {
+			writeError(w, http.StatusConflict, "game has no stages")
+			return
+		}
+		if data.PendingAdvance {
+			writeError(w, http.StatusConflict, "move on to the next stage first")
+			return
+		}
+		if data.waitingForTeammates() {
+			writeError(w, http.StatusConflict, "waiting for teammates")
 			return
 		}
-
 		currentStageNum := answeredCount + 1
 		if currentStageNum > len(stages) {
 			writeError(w, http.StatusConflict, "all stages completed")
 			return
 		}
 
+		idx := rotatedStageIndex(currentStageNum, data.StartStage, len(stages))
+		stage := stages[idx]
+
 		if isStageUnlocked(data.UnlockedStages, currentStageNum) {
+			// A retried unlock, say after a lost response, gets the same
+			// success again without unlocking or publishing anything.
+			// Anything else against an open stage is a conflict.
+			if resp, ok := repeatedUnlock(data.Mode, stages, stage, currentStageNum, data.StartStage, req.Code, sess.Role); ok {
+				writeJSON(w, http.StatusOK, resp)
+				return
+			}
 			writeError(w, http.StatusConflict, "stage already unlocked")
 			return
 		}
 
-		idx := rotatedStageIndex(currentStageNum, data.StartStage, len(stages))
-		stage := stages[idx]
-
 		switch data.Mode {
 		case "qr_quiz":
 			if req.Code == "" {
 				writeError(w, http.StatusBadRequest, "code is required")
 				return
 			}
-			if !strings.EqualFold(req.Code, stage.UnlockCode) {
+			if !unlockCodeMatches(req.Code, stage.UnlockCode) {
 				writeError(w, http.StatusUnprocessableEntity, "invalid code")
 				return
 			}
-			if err := store.UnlockStage(r.Context(), sess.GameID, sess.TeamID, currentStageNum); err != nil {
+			run := sharedCodeRun(stages, currentStageNum, data.StartStage)
+			if err := store.UnlockStage(r.Context(), sess.GameID, sess.TeamID, run...); err != nil {
 				writeError(w, http.StatusInternalServerError, "internal error")
 				return
 			}
+			logUnlocks(r, store, sess, run, false)
 			broker.Publish(sess.TeamID, SSEEvent{
 				Type:        "stage_unlocked",
 				StageNumber: currentStageNum,
 			})
-			writeJSON(w, http.StatusOK, UnlockResponse{
-				StageNumber: currentStageNum,
-				Unlocked:    true,
-				Question:    stage.Question,
-			})
+			writeJSON(w, http.StatusOK, qrQuizUnlockResponse(stage, currentStageNum, run))
 
 		case "qr_hunt":
 			if req.Code == "" {
 				writeError(w, http.StatusBadRequest, "code is required")
 				return
 			}
-			if !strings.EqualFold(req.Code, stage.UnlockCode) {
+			if !unlockCodeMatches(req.Code, stage.UnlockCode) {
 				writeError(w, http.StatusUnprocessableEntity, "invalid code")
 				return
 			}
-			if err := store.UnlockAndCompleteStage(r.Context(), sess.GameID, sess.TeamID, currentStageNum); err != nil {
+			// Every stage behind a shared code completes with the one scan.
+			run := sharedCodeRun(stages, currentStageNum, data.StartStage)
+			lastStageNum := run[len(run)-1]
+			if err := store.UnlockAndCompleteStage(r.Context(), sess.GameID, sess.TeamID, run...); err != nil {
 				writeError(w, http.StatusInternalServerError, "internal error")
 				return
 			}
+			logUnlocks(r, store, sess, run, true)
 			resp := UnlockResponse{
-				StageNumber:   currentStageNum,
-				Unlocked:      true,
-				StageComplete: true,
+				StageNumber:    currentStageNum,
+				Unlocked:       true,
+				UnlockedStages: run,
+				StageComplete:  true,
 			}
-			nextStageNum := currentStageNum + 1
+			nextStageNum := lastStageNum + 1
 			if nextStageNum <= len(stages) {
 				nextIdx := rotatedStageIndex(nextStageNum, data.StartStage, len(stages))
 				s := stages[nextIdx]
 				resp.NextStage = &StageInfo{
-					StageNumber: nextStageNum,
-					Clue:        s.Clue,
-					ClueImage:   s.ClueImage,
-					Location:    s.Location,
-					Locked:      true,
+					StageNumber:  nextStageNum,
+					Clue:         s.Clue,
+					ClueImage:    s.ClueImage,
+					Location:     data.stageLocation(sess.Role, s.Location),
+					Locked:       true,
+					UnlockMethod: unlockMethod(data.Mode),
 				}
 			} else {
 				resp.GameComplete = true
 			}
 			broker.Publish(sess.TeamID, SSEEvent{
 				Type:        "stage_completed",
-				StageNumber: currentStageNum,
+				StageNumber: lastStageNum,
 			})
 			writeJSON(w, http.StatusOK, resp)
 
@@ -165,6 +239,7 @@ func handleUnlock(broker *Broker) http.HandlerFunc {
 				writeError(w, http.StatusInternalServerError, "internal error")
 				return
 			}
+			logUnlocks(r, store, sess, []int{currentStageNum}, true)
 			resp := UnlockResponse{
 				StageNumber:   currentStageNum,
 				Unlocked:      true,
@@ -175,11 +250,12 @@ func handleUnlock(broker *Broker) http.HandlerFunc {
 				nextIdx := rotatedStageIndex(nextStageNum, data.StartStage, len(stages))
 				s := stages[nextIdx]
 				resp.NextStage = &StageInfo{
-					StageNumber: nextStageNum,
-					Clue:        s.Clue,
-					ClueImage:   s.ClueImage,
-					Location:    s.Location,
-					Locked:      true,
+					StageNumber:  nextStageNum,
+					Clue:         s.Clue,
+					ClueImage:    s.ClueImage,
+					Location:     data.stageLocation(sess.Role, s.Location),
+					Locked:       true,
+					UnlockMethod: unlockMethod(data.Mode),
 				}
 			} else {
 				resp.GameComplete = true
@@ -199,18 +275,78 @@ func handleUnlock(broker *Broker) http.HandlerFunc {
 				writeError(w, http.StatusInternalServerError, "internal error")
 				return
 			}
+			logUnlocks(r, store, sess, []int{currentStageNum}, false)
+			// Players get the question straight from the event, with the
+			// timer's start unless the game waits for their confirmations.
+			unlocked, err := store.GameState(r.Context(), sess.GameID, sess.TeamID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			question := unlockedQuestion(stage)
 			broker.Publish(sess.TeamID, SSEEvent{
-				Type:        "stage_unlocked",
-				StageNumber: currentStageNum,
-			})
-			writeJSON(w, http.StatusOK, UnlockResponse{
-				StageNumber: currentStageNum,
-				Unlocked:    true,
-				Question:    stage.Question,
+				Type:            "stage_unlocked",
+				StageNumber:     currentStageNum,
+				StageQuestion:   &question,
+				StageUnlockedAt: unlocked.StageUnlockedAt,
 			})
+			writeJSON(w, http.StatusOK, supervisedUnlockResponse(stage, currentStageNum))
 
 		default:
 			writeError(w, http.StatusConflict, "unknown mode")
 		}
 	}
 }
+
+// qrQuizUnlockResponse is the answer to scanning a qr_quiz stage's code: the
+// stages it opened and the current stage's question.
+func qrQuizUnlockResponse(stage scenarioStage, stageNumber int, run []int) UnlockResponse {
+	return UnlockResponse{
+		StageNumber:    stageNumber,
+		Unlocked:       true,
+		UnlockedStages: run,
+		StageQuestion:  unlockedQuestion(stage),
+	}
+}
+
+// supervisedUnlockResponse is the answer to a supervisor unlocking a stage.
+func supervisedUnlockResponse(stage scenarioStage, stageNumber int) UnlockResponse {
+	return UnlockResponse{
+		StageNumber:   stageNumber,
+		Unlocked:      true,
+		StageQuestion: unlockedQuestion(stage),
+	}
+}
+
+// repeatedUnlock returns the response an unlock of the already open current
+// stage got the first time, if this request would have made it: the stage's
+// code again in qr_quiz, or the supervisor again in supervised games. qr_hunt
+// and math_puzzle unlocks complete the stage, so there a retry meets the next
+// stage and is checked against it like any other unlock.
+func repeatedUnlock(mode string, stages []scenarioStage, stage scenarioStage, stageNumber, startStage int, code, role string) (UnlockResponse, bool) {
+	switch mode {
+	case "qr_quiz":
+		if code != "" && unlockCodeMatches(code, stage.UnlockCode) {
+			return qrQuizUnlockResponse(stage, stageNumber, sharedCodeRun(stages, stageNumber, startStage)), true
+		}
+	case "supervised":
+		if role == "supervisor" {
+			return supervisedUnlockResponse(stage, stageNumber), true
+		}
+	}
+	return UnlockResponse{}, false
+}
+
+// logUnlocks records the stages a player unlocked in the game's event log;
+// completed is set in modes where the unlock also completes them.
+func logUnlocks(r *http.Request, store Store, sess sessionInfo, stageNumbers []int, completed bool) {
+	for _, n := range stageNumbers {
+		logGameEvent(r, store, sess.GameID, GameEvent{
+			Type:        "unlocked",
+			TeamID:      sess.TeamID,
+			PlayerID:    sess.PlayerID,
+			StageNumber: n,
+			Completed:   completed,
+		})
+	}
+}
@@ -0,0 +1,537 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/playperu/cityquiz/internal/database"
+)
+
+// modeRouter sets up a chi router with an active game built from the given
+// scenario and a single team. The scenario is validated first so defaults
+// (mode, stage numbers, unlock codes) are filled in like the admin API does.
+// Returns the router, the store, the game ID, and the team.
+func modeRouter(t *testing.T, sc AdminScenarioRequest) (*chi.Mux, *DocStore, string, AdminTeamItem) {
+	t.Helper()
+	return gameRouter(t, sc, AdminGameRequest{})
+}
+
+// gameRouter is modeRouter with extra game settings; scenario, mode and
+// status fields of req are filled in from sc.
+func gameRouter(t *testing.T, sc AdminScenarioRequest, req AdminGameRequest) (*chi.Mux, *DocStore, string, AdminTeamItem) {
+	t.Helper()
+	return brokerRouter(t, sc, req, NewBroker())
+}
+
+// brokerRouter is gameRouter publishing to broker, for tests that subscribe
+// to the team's events.
+func brokerRouter(t *testing.T, sc AdminScenarioRequest, req AdminGameRequest, broker *Broker) (*chi.Mux, *DocStore, string, AdminTeamItem) {
+	t.Helper()
+	ctx := context.Background()
+
+	if msg := sc.validate(); msg != "" {
+		t.Fatalf("invalid scenario: %s", msg)
+	}
+
+	clientDB, err := database.Open(ctx, ":memory:", 1)
+	if err != nil {
+		t.Fatalf("open client db: %v", err)
+	}
+	store, err := NewDocStore(ctx, clientDB, nil)
+	if err != nil {
+		t.Fatalf("init doc store: %v", err)
+	}
+	t.Cleanup(func() { clientDB.Close() })
+
+	req.ScenarioID = "s-test"
+	req.ScenarioName = sc.Name
+	req.Mode = sc.Mode
+	req.PlayCount = sc.PlayCount
+	req.Status = "active"
+	req.Supervised = sc.Mode == "supervised"
+	if msg := req.validate(TimerLimits{}); msg != "" {
+		t.Fatalf("invalid game: %s", msg)
+	}
+	g, err := store.CreateGame(ctx, req, sc.Stages)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	team, err := store.CreateTeam(ctx, g.ID, AdminTeamRequest{Name: "Team Test"}, "join-test")
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ctxKeyStore, Store(store))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Get("/api/{client}/teams/{joinToken}", handleTeamLookup())
+	r.Post("/api/{client}/join", handleJoin(broker))
+	r.Get("/api/{client}/game/state", handleGameState(broker))
+	r.Get("/api/{client}/game/recap", handleRecap())
+	r.Post("/api/{client}/game/answer", handleAnswer(broker, testMaxAnswerLen))
+	r.Post("/api/{client}/game/unlock", handleUnlock(broker, testMaxAnswerLen))
+	r.Post("/api/{client}/game/next", handleNext(broker))
+	r.Post("/api/{client}/game/giveup", handleGiveUp(broker))
+	r.Post("/api/{client}/game/confirm", handleConfirm(broker))
+
+	return r, store, g.ID, team
+}
+
+func TestQRQuizSharedUnlockCode(t *testing.T) {
+	r, _, _, team := modeRouter(t, AdminScenarioRequest{
+		Name: "Shared Codes",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "Museum hall", Question: "Q1?", CorrectAnswer: "a1", UnlockCode: "MUSEUM"},
+			{Location: "Museum roof", Question: "Q2?", CorrectAnswer: "a2", UnlockCode: "MUSEUM"},
+			{Location: "Park", Question: "Q3?", CorrectAnswer: "a3", UnlockCode: "PARK"},
+		},
+	})
+	player := join(t, r, team.JoinToken, "Ana")
+
+	// One scan unlocks both museum stages but not the park.
+	w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "MUSEUM"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var unlockResp UnlockResponse
+	json.NewDecoder(w.Body).Decode(&unlockResp)
+	if len(unlockResp.UnlockedStages) != 2 || unlockResp.UnlockedStages[0] != 1 || unlockResp.UnlockedStages[1] != 2 {
+		t.Errorf("expected stages [1 2] unlocked, got %v", unlockResp.UnlockedStages)
+	}
+
+	// Stage 1 still needs its own answer.
+	state := gameState(t, r, player.Token)
+	if state.CurrentStage.StageNumber != 1 || state.CurrentStage.Locked {
+		t.Fatalf("expected unlocked stage 1, got %+v", state.CurrentStage)
+	}
+	w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer stage 1: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Stage 2 is already open — question visible, answerable without a scan.
+	state = gameState(t, r, player.Token)
+	if state.CurrentStage.StageNumber != 2 || state.CurrentStage.Locked {
+		t.Fatalf("expected unlocked stage 2, got %+v", state.CurrentStage)
+	}
+	if state.CurrentStage.Question != "Q2?" {
+		t.Errorf("expected stage 2 question visible, got %q", state.CurrentStage.Question)
+	}
+	// Rescanning the code is a retry: it succeeds again without changing
+	// anything.
+	w = postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "MUSEUM"})
+	unlockResp = UnlockResponse{}
+	json.NewDecoder(w.Body).Decode(&unlockResp)
+	if w.Code != http.StatusOK || unlockResp.StageNumber != 2 || unlockResp.Question != "Q2?" {
+		t.Errorf("rescan on stage 2: %d %+v, want 200 with stage 2", w.Code, unlockResp)
+	}
+	w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a2"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer stage 2: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Stage 3 has its own code and stays locked.
+	state = gameState(t, r, player.Token)
+	if state.CurrentStage.StageNumber != 3 || !state.CurrentStage.Locked {
+		t.Fatalf("expected locked stage 3, got %+v", state.CurrentStage)
+	}
+}
+
+func TestQRHuntSharedUnlockCode(t *testing.T) {
+	r, _, _, team := modeRouter(t, AdminScenarioRequest{
+		Name: "Shared Hunt",
+		City: "Lima",
+		Mode: "qr_hunt",
+		Stages: []AdminStage{
+			{Location: "Gate", UnlockCode: "GATE"},
+			{Location: "Hall A", UnlockCode: "HALL"},
+			{Location: "Hall B", UnlockCode: "HALL"},
+		},
+	})
+	player := join(t, r, team.JoinToken, "Luis")
+
+	w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "GATE"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock gate: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The hall code completes both hall stages and the game.
+	w = postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "HALL"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock hall: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var unlockResp UnlockResponse
+	json.NewDecoder(w.Body).Decode(&unlockResp)
+	if !unlockResp.GameComplete {
+		t.Error("expected game complete after shared hall code")
+	}
+
+	state := gameState(t, r, player.Token)
+	if len(state.CompletedStages) != 3 {
+		t.Errorf("expected 3 completed stages, got %d", len(state.CompletedStages))
+	}
+}
+
+func TestSharedCodeRun(t *testing.T) {
+	stages := []scenarioStage{
+		{UnlockCode: "A"},
+		{UnlockCode: "a"}, // codes match without regard to case
+		{UnlockCode: "B"},
+		{UnlockCode: "A"},
+	}
+	tests := []struct {
+		current, start int
+		want           []int
+	}{
+		{1, 0, []int{1, 2}},
+		{2, 0, []int{2}},
+		{3, 0, []int{3}},
+		{4, 0, []int{4}},
+		// Rotated start at stage 4: team order is A(4),A(1),A(2),B(3).
+		{1, 4, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		got := sharedCodeRun(stages, tt.current, tt.start)
+		if len(got) != len(tt.want) {
+			t.Errorf("sharedCodeRun(%d, start %d) = %v, want %v", tt.current, tt.start, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("sharedCodeRun(%d, start %d) = %v, want %v", tt.current, tt.start, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAnswerNextClueVisibility(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Clues",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "Plaza", Clue: "Find the fountain", Question: "Q1?", CorrectAnswer: "a1", UnlockCode: "PLAZA"},
+			{Location: "Church", Clue: "Look for the bell", ClueImage: "/uploads/bell.jpg", Question: "Q2?", CorrectAnswer: "a2", UnlockCode: "CHURCH"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		hide     bool
+		wantClue string
+	}{
+		{"shown by default", false, "Look for the bell"},
+		{"hidden", true, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _, team := gameRouter(t, sc, AdminGameRequest{HideLockedClue: tc.hide})
+			player := join(t, r, team.JoinToken, "Ana")
+
+			w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "PLAZA"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a1"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp AnswerResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if resp.NextStage == nil || !resp.NextStage.Locked {
+				t.Fatalf("expected locked next stage, got %+v", resp.NextStage)
+			}
+			if resp.NextStage.Clue != tc.wantClue {
+				t.Errorf("expected clue %q, got %q", tc.wantClue, resp.NextStage.Clue)
+			}
+			if tc.hide && resp.NextStage.ClueImage != "" {
+				t.Errorf("expected clue image hidden, got %q", resp.NextStage.ClueImage)
+			}
+			if resp.NextStage.Location != "Church" {
+				t.Errorf("expected location still sent, got %q", resp.NextStage.Location)
+			}
+
+			// Game state still serves the clue so the team can find the stage.
+			state := gameState(t, r, player.Token)
+			if state.CurrentStage.Clue != "Look for the bell" {
+				t.Errorf("expected clue in game state, got %q", state.CurrentStage.Clue)
+			}
+		})
+	}
+}
+
+func TestQuestionImageVisibility(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Pictures",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "Plaza", Clue: "Go to the plaza", Question: "What is this building?", QuestionImage: "/uploads/cathedral.jpg", CorrectAnswer: "Cathedral", UnlockCode: "CODE-A"},
+			{Location: "Park", Clue: "Go to the park", Question: "Who is this?", QuestionImage: "https://example.com/statue.jpg", CorrectAnswer: "Grau", UnlockCode: "CODE-B"},
+		},
+	}
+	r, _, _, team := modeRouter(t, sc)
+	player := join(t, r, team.JoinToken, "Ana")
+
+	// Locked: neither the question nor its image.
+	state := gameState(t, r, player.Token)
+	if cs := state.CurrentStage; cs == nil || !cs.Locked || cs.Question != "" || cs.QuestionImage != "" {
+		t.Fatalf("locked stage = %+v, want no question or image", state.CurrentStage)
+	}
+
+	w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "CODE-A"})
+	var unlock UnlockResponse
+	json.NewDecoder(w.Body).Decode(&unlock)
+	if w.Code != http.StatusOK || unlock.QuestionImage != "/uploads/cathedral.jpg" {
+		t.Errorf("unlock: got %d %+v, want the question image", w.Code, unlock)
+	}
+
+	state = gameState(t, r, player.Token)
+	if cs := state.CurrentStage; cs == nil || cs.Locked || cs.Question != "What is this building?" || cs.QuestionImage != "/uploads/cathedral.jpg" {
+		t.Errorf("unlocked stage = %+v, want the question and its image", state.CurrentStage)
+	}
+
+	// The answer previews the next stage, still locked, without its image.
+	w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "Cathedral"})
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if ns := resp.NextStage; ns == nil || !ns.Locked || ns.QuestionImage != "" {
+		t.Errorf("next stage = %+v, want it locked without an image", resp.NextStage)
+	}
+}
+
+func TestSupervisedUnlockQuestionImage(t *testing.T) {
+	maps := []Attachment{{Label: "Site map", URL: "https://example.com/map.pdf"}}
+	sc := AdminScenarioRequest{
+		Name: "Guided pictures",
+		City: "Lima",
+		Mode: "supervised",
+		Stages: []AdminStage{
+			{Location: "Plaza", Clue: "Go to the plaza", Question: "What is this building?", QuestionImage: "/uploads/cathedral.jpg", CorrectAnswer: "Cathedral", Attachments: maps},
+			{Location: "Park", Clue: "Go to the park", Question: "Who is this?", CorrectAnswer: "Grau"},
+		},
+	}
+	broker := NewBroker()
+	r, _, _, team := brokerRouter(t, sc, AdminGameRequest{}, broker)
+	player := join(t, r, team.JoinToken, "Ana")
+	super := join(t, r, team.SupervisorToken, "Guide")
+
+	events := broker.Subscribe(team.ID, 0)
+	defer broker.Unsubscribe(team.ID, events)
+
+	want := StageQuestion{Question: "What is this building?", QuestionImage: "/uploads/cathedral.jpg", AnswerType: "text", Attachments: maps}
+	w := postJSON(t, r, "/api/demo/game/unlock", super.Token, UnlockRequest{})
+	var unlock UnlockResponse
+	json.NewDecoder(w.Body).Decode(&unlock)
+	if w.Code != http.StatusOK || !reflect.DeepEqual(unlock.StageQuestion, want) {
+		t.Errorf("supervisor unlock: got %d %+v, want %+v", w.Code, unlock.StageQuestion, want)
+	}
+
+	// Players who only follow the event get the same question.
+	ev := nextEvent(t, events)
+	if ev.Type != "stage_unlocked" || ev.StageQuestion == nil || !reflect.DeepEqual(*ev.StageQuestion, want) {
+		t.Errorf("event = %+v (question %+v), want stage_unlocked with %+v", ev, ev.StageQuestion, want)
+	}
+
+	state := gameState(t, r, player.Token)
+	if cs := state.CurrentStage; cs == nil || cs.Locked || cs.QuestionImage != want.QuestionImage {
+		t.Errorf("unlocked stage = %+v, want the question image", state.CurrentStage)
+	}
+}
+
+func TestAttachmentVisibility(t *testing.T) {
+	maps := []Attachment{{Label: "Site map", URL: "https://example.com/map.pdf"}}
+	sc := AdminScenarioRequest{
+		Name: "Handouts",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "Plaza", Question: "Which gate is marked?", CorrectAnswer: "North", UnlockCode: "CODE-A", Attachments: maps},
+			{Location: "Park", Question: "Which path is shortest?", CorrectAnswer: "Left", UnlockCode: "CODE-B", Attachments: []Attachment{{Label: " Worksheet ", URL: " /uploads/sheet.png "}}},
+		},
+	}
+	r, _, _, team := modeRouter(t, sc)
+	player := join(t, r, team.JoinToken, "Ana")
+
+	// Locked: the attachments stay hidden with the question.
+	state := gameState(t, r, player.Token)
+	if cs := state.CurrentStage; cs == nil || !cs.Locked || cs.Attachments != nil {
+		t.Fatalf("locked stage = %+v, want no attachments", state.CurrentStage)
+	}
+
+	w := postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "CODE-A"})
+	var unlock UnlockResponse
+	json.NewDecoder(w.Body).Decode(&unlock)
+	if w.Code != http.StatusOK || !reflect.DeepEqual(unlock.Attachments, maps) {
+		t.Errorf("unlock: got %d %+v, want the attachments", w.Code, unlock)
+	}
+	state = gameState(t, r, player.Token)
+	if cs := state.CurrentStage; cs == nil || !reflect.DeepEqual(cs.Attachments, maps) {
+		t.Errorf("unlocked stage = %+v, want its attachments", state.CurrentStage)
+	}
+
+	// The locked next stage doesn't preview them.
+	w = postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "North"})
+	var resp AnswerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if ns := resp.NextStage; ns == nil || !ns.Locked || ns.Attachments != nil {
+		t.Errorf("next stage = %+v, want it locked without attachments", resp.NextStage)
+	}
+
+	// Validation trimmed the second stage's attachment.
+	w = postJSON(t, r, "/api/demo/game/unlock", player.Token, UnlockRequest{Code: "CODE-B"})
+	unlock = UnlockResponse{}
+	json.NewDecoder(w.Body).Decode(&unlock)
+	if want := []Attachment{{Label: "Worksheet", URL: "/uploads/sheet.png"}}; !reflect.DeepEqual(unlock.Attachments, want) {
+		t.Errorf("second unlock attachments = %+v, want %+v", unlock.Attachments, want)
+	}
+
+	for _, bad := range [][]Attachment{
+		{{Label: "", URL: "https://example.com/a.pdf"}},
+		{{Label: "Map", URL: ""}},
+		{{Label: "Map", URL: "javascript:alert(1)"}},
+		make([]Attachment, maxAttachments+1),
+	} {
+		sc := AdminScenarioRequest{
+			Name: "Test", City: "Lima", Mode: "classic",
+			Stages: []AdminStage{{Location: "A", Question: "Q?", CorrectAnswer: "Answer", Attachments: bad}},
+		}
+		if msg := sc.validate(); !strings.Contains(msg, "attachment") {
+			t.Errorf("validate with %+v = %q, want an attachment error", bad, msg)
+		}
+	}
+	hunt := AdminScenarioRequest{
+		Name: "Test", City: "Lima", Mode: "qr_hunt",
+		Stages: []AdminStage{{Location: "A", Attachments: maps}},
+	}
+	if msg := hunt.validate(); !strings.Contains(msg, "attachments only apply") {
+		t.Errorf("validate qr_hunt with attachments = %q, want an error", msg)
+	}
+}
+
+func TestFirstStageUnlocked(t *testing.T) {
+	sc := AdminScenarioRequest{
+		Name: "Meeting point",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "Plaza", Question: "Q1?", CorrectAnswer: "a1", UnlockCode: "PLAZA"},
+			{Location: "Park", Question: "Q2?", CorrectAnswer: "a2", UnlockCode: "PARK"},
+		},
+	}
+
+	// Off by default: stage 1 needs its code.
+	r, _, _, team := modeRouter(t, sc)
+	player := join(t, r, team.JoinToken, "Ana")
+	if cs := gameState(t, r, player.Token).CurrentStage; cs == nil || !cs.Locked {
+		t.Fatalf("default first stage = %+v, want it locked", cs)
+	}
+
+	broker := NewBroker()
+	r, _, _, team = brokerRouter(t, sc, AdminGameRequest{Settings: map[string]bool{"firstStageUnlocked": true}}, broker)
+	events := broker.Subscribe(team.ID, 0)
+	defer broker.Unsubscribe(team.ID, events)
+	player = join(t, r, team.JoinToken, "Ana")
+	<-events // player_joined
+
+	state := gameState(t, r, player.Token)
+	if cs := state.CurrentStage; cs == nil || cs.StageNumber != 1 || cs.Locked || cs.Question != "Q1?" {
+		t.Fatalf("first stage = %+v, want it unlocked with its question", cs)
+	}
+	select {
+	case data := <-events:
+		if !strings.Contains(string(data), `"stage_unlocked"`) {
+			t.Errorf("event = %s, want stage_unlocked", data)
+		}
+	default:
+		t.Error("no stage_unlocked event")
+	}
+
+	// Only the first stage: the next one still needs its code.
+	w := postJSON(t, r, "/api/demo/game/answer", player.Token, AnswerRequest{Answer: "a1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("answer: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cs := gameState(t, r, player.Token).CurrentStage; cs == nil || cs.StageNumber != 2 || !cs.Locked {
+		t.Errorf("second stage = %+v, want it locked", cs)
+	}
+
+	// qr_hunt unlocks complete stages, so the setting doesn't apply there.
+	hunt := AdminScenarioRequest{
+		Name: "Hunt",
+		City: "Lima",
+		Mode: "qr_hunt",
+		Stages: []AdminStage{
+			{Location: "Plaza", UnlockCode: "PLAZA"},
+			{Location: "Park", UnlockCode: "PARK"},
+		},
+	}
+	r, _, _, team = brokerRouter(t, hunt, AdminGameRequest{FirstStageUnlocked: true}, NewBroker())
+	player = join(t, r, team.JoinToken, "Ana")
+	if cs := gameState(t, r, player.Token).CurrentStage; cs == nil || cs.StageNumber != 1 || !cs.Locked {
+		t.Errorf("qr_hunt first stage = %+v, want it locked", cs)
+	}
+}
+
+func TestUnlockRetry(t *testing.T) {
+	broker := NewBroker()
+	r, _, _, team := brokerRouter(t, AdminScenarioRequest{
+		Name: "Retry",
+		City: "Lima",
+		Mode: "qr_quiz",
+		Stages: []AdminStage{
+			{Location: "Museum", Question: "Q1?", CorrectAnswer: "a1", UnlockCode: "MUSEUM"},
+			{Location: "Park", Question: "Q2?", CorrectAnswer: "a2", UnlockCode: "PARK"},
+		},
+	}, AdminGameRequest{}, broker)
+	ana := join(t, r, team.JoinToken, "Ana")
+	luis := join(t, r, team.JoinToken, "Luis")
+
+	unlock := func(token, code string) (*httptest.ResponseRecorder, UnlockResponse) {
+		t.Helper()
+		w := postJSON(t, r, "/api/demo/game/unlock", token, UnlockRequest{Code: code})
+		var resp UnlockResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, first := unlock(ana.Token, "MUSEUM")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	events := broker.Subscribe(team.ID, 0)
+	defer broker.Unsubscribe(team.ID, events)
+
+	// Retrying, from this or another device, gets the same success.
+	for _, token := range []string{ana.Token, luis.Token} {
+		w, again := unlock(token, "museum")
+		if w.Code != http.StatusOK || !reflect.DeepEqual(again, first) {
+			t.Errorf("retried unlock: %d %+v, want 200 %+v", w.Code, again, first)
+		}
+	}
+	select {
+	case data := <-events:
+		t.Errorf("retry published %s", data)
+	default:
+	}
+
+	// Another code against the open stage is still a conflict.
+	if w, _ := unlock(ana.Token, "PARK"); w.Code != http.StatusConflict {
+		t.Errorf("other code on an open stage: expected 409, got %d", w.Code)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
@@ -14,24 +16,75 @@ type HealthCheckResult struct {
 	Status string `json:"status" enum:"ok,error"`
 }
 
+// DiskHealthResult reports free space in the directory holding the client
+// DBs. Status is "error" once FreeBytes drops below MinFreeBytes.
+type DiskHealthResult struct {
+	Status       string `json:"status" enum:"ok,error"`
+	FreeBytes    uint64 `json:"freeBytes,omitempty"`
+	MinFreeBytes uint64 `json:"minFreeBytes,omitempty"`
+}
+
 // HealthResponse is the top-level response from GET /healthz.
 type HealthResponse struct {
 	SQLite HealthCheckResult `json:"sqlite"`
+	Disk   DiskHealthResult  `json:"disk"`
+	Build  BuildInfo         `json:"build"`
+}
+
+// diskCheck watches free space where the per-client SQLite files live. Each
+// client adds a file there and they only grow; a full disk shows up as
+// cryptic write errors, so /healthz reports it before that happens.
+type diskCheck struct {
+	dir     string
+	minFree uint64                           // bytes; 0 disables the check
+	free    func(dir string) (uint64, error) // freeDiskBytes outside tests
 }
 
-func handleHealth(logger *slog.Logger, db *sql.DB) http.HandlerFunc {
+func newDiskCheck(dir string, minFreeMB int) diskCheck {
+	return diskCheck{dir: dir, minFree: uint64(max(minFreeMB, 0)) << 20, free: freeDiskBytes}
+}
+
+func (d diskCheck) check() (DiskHealthResult, error) {
+	res := DiskHealthResult{Status: "ok", MinFreeBytes: d.minFree}
+	if d.minFree == 0 {
+		return res, nil
+	}
+	free, err := d.free(d.dir)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return res, nil
+	}
+	if err != nil {
+		res.Status = "error"
+		return res, err
+	}
+	res.FreeBytes = free
+	if free < d.minFree {
+		res.Status = "error"
+		return res, fmt.Errorf("%d bytes free in %s, want at least %d", free, d.dir, d.minFree)
+	}
+	return res, nil
+}
+
+func handleHealth(logger *slog.Logger, db *sql.DB, disk diskCheck, build BuildInfo) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 		defer cancel()
 
 		resp := HealthResponse{
 			SQLite: HealthCheckResult{Status: "ok"},
+			Build:  build,
 		}
 		status := http.StatusOK
 
 		if err := db.PingContext(ctx); err != nil {
 			logger.Error("health check failed", "name", "sqlite", "error", err)
-			resp.SQLite = HealthCheckResult{Status: "error"}
+			resp.SQLite.Status = "error"
+			status = http.StatusServiceUnavailable
+		}
+
+		var err error
+		if resp.Disk, err = disk.check(); err != nil {
+			logger.Error("health check failed", "name", "disk", "error", err)
 			status = http.StatusServiceUnavailable
 		}
 
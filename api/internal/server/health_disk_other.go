@@ -0,0 +1,10 @@
+//go:build !unix
+
+package server
+
+import "errors"
+
+// freeDiskBytes is not implemented here; the disk health check is skipped.
+func freeDiskBytes(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/playperu/cityquiz/internal/database"
+)
+
+func TestHealthDiskSpace(t *testing.T) {
+	db, err := database.Open(context.Background(), ":memory:", 1)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	const mb = 1 << 20
+	tests := []struct {
+		name       string
+		minFreeMB  int
+		free       uint64
+		err        error
+		wantCode   int
+		wantStatus string
+	}{
+		{"plenty free", 512, 2048 * mb, nil, http.StatusOK, "ok"},
+		{"below threshold", 512, 100 * mb, nil, http.StatusServiceUnavailable, "error"},
+		{"stat fails", 512, 0, errors.New("no such directory"), http.StatusServiceUnavailable, "error"},
+		{"unsupported platform", 512, 0, errors.ErrUnsupported, http.StatusOK, "ok"},
+		{"check disabled", 0, 0, errors.New("not called"), http.StatusOK, "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statted string
+			disk := newDiskCheck("/data/db", tt.minFreeMB)
+			disk.free = func(dir string) (uint64, error) {
+				statted = dir
+				return tt.free, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			handleHealth(logger, db, disk, BuildInfo{})(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			var resp HealthResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if resp.Disk.Status != tt.wantStatus {
+				t.Errorf("disk status = %q, want %q", resp.Disk.Status, tt.wantStatus)
+			}
+			if resp.SQLite.Status != "ok" {
+				t.Errorf("sqlite status = %q, want ok", resp.SQLite.Status)
+			}
+			if tt.minFreeMB > 0 && statted != "/data/db" {
+				t.Errorf("statted %q, want the client DB directory", statted)
+			}
+			if tt.err == nil && resp.Disk.FreeBytes != tt.free {
+				t.Errorf("freeBytes = %d, want %d", resp.Disk.FreeBytes, tt.free)
+			}
+		})
+	}
+}
+
+func TestFreeDiskBytes(t *testing.T) {
+	free, err := freeDiskBytes(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("disk stats not supported on this platform")
+	}
+	if err != nil {
+		t.Fatalf("freeDiskBytes: %v", err)
+	}
+	if free == 0 {
+		t.Error("expected some free space in the temp dir")
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyTTL is how long a create's response is replayed for a retried
+// Idempotency-Key. It only needs to outlast a client's retry loop.
+const idempotencyTTL = 10 * time.Minute
+
+type idempotentResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	done    bool // false while the first request is still being handled
+	expires time.Time
+}
+
+// IdempotencyCache remembers responses to admin create requests by their
+// Idempotency-Key, so a retried create returns the original result instead
+// of creating a duplicate. It is in-process: keys don't survive a restart.
+type IdempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+func NewIdempotencyCache() *IdempotencyCache {
+	return &IdempotencyCache{
+		entries: make(map[string]*idempotentResponse),
+	}
+}
+
+// begin reserves key. It returns the stored response for a replay, or
+// ok=true when the caller should handle the request and then call finish.
+func (c *IdempotencyCache) begin(key string) (resp *idempotentResponse, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range c.entries {
+		if e.done && now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	if e, found := c.entries[key]; found {
+		return e, false
+	}
+	c.entries[key] = &idempotentResponse{}
+	return nil, true
+}
+
+// finish stores a successful response for key, or releases the key so a
+// failed create can be retried.
+func (c *IdempotencyCache) finish(key string, status int, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if status < 200 || status >= 300 {
+		delete(c.entries, key)
+		return
+	}
+	c.entries[key] = &idempotentResponse{
+		status:  status,
+		header:  header,
+		body:    body,
+		done:    true,
+		expires: time.Now().Add(idempotencyTTL),
+	}
+}
+
+// idempotencyRecorder captures the response so it can be stored for replay.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter { return rec.ResponseWriter }
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotent replays the stored response when a create is retried with the
+// same Idempotency-Key. Keys are scoped to the admin and the request path, so
+// the same key on a different endpoint or client is a new request. A retry
+// that arrives while the first request is still running gets 409. Requests
+// without the header are handled as usual. Must run after adminAuthMiddleware.
+func idempotent(cache *IdempotencyCache) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key = adminFrom(r).AdminID + " " + r.Method + " " + r.URL.Path + " " + key
+
+			stored, ok := cache.begin(key)
+			if !ok {
+				if !stored.done {
+					writeError(w, http.StatusConflict, "a request with this Idempotency-Key is in progress")
+					return
+				}
+				for k, v := range stored.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.status)
+				w.Write(stored.body)
+				return
+			}
+
+			rec := &idempotencyRecorder{ResponseWriter: w}
+			defer func() {
+				cache.finish(key, rec.status, w.Header().Clone(), rec.body.Bytes())
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
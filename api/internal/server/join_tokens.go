@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Join-token styles a client can choose for auto-generated team tokens.
+const (
+	tokenStyleHex   = "hex"   // team-ab12cd34
+	tokenStyleWords = "words" // lima-lions-01
+	tokenStylePIN   = "pin"   // 482913
+)
+
+// minPINLength keeps numeric tokens out of guessing range: with fewer digits a
+// busy client runs out of free PINs and players can join teams by trying
+// neighbouring numbers.
+const (
+	minPINLength = 6
+	maxPINLength = 12
+)
+
+// joinTokenAttempts bounds how often a generated token is redrawn after
+// colliding with an existing one before giving up.
+const joinTokenAttempts = 10
+
+// JoinTokenFormat is how a client's auto-generated join tokens look.
+type JoinTokenFormat struct {
+	Style     string
+	PINLength int
+}
+
+// validate normalizes the format and returns an error message, or "" if valid.
+func (f *JoinTokenFormat) validate() string {
+	if f.Style == "" {
+		f.Style = tokenStyleHex
+	}
+	switch f.Style {
+	case tokenStyleHex, tokenStyleWords:
+		f.PINLength = 0
+	case tokenStylePIN:
+		if f.PINLength == 0 {
+			f.PINLength = minPINLength
+		}
+		if f.PINLength < minPINLength || f.PINLength > maxPINLength {
+			return fmt.Sprintf("pinLength must be between %d and %d", minPINLength, maxPINLength)
+		}
+	default:
+		return "tokenStyle must be hex, words, or pin"
+	}
+	return ""
+}
+
+var tokenAdjectives = []string{
+	"amber", "bold", "brave", "bright", "calm", "clever", "coral", "crimson",
+	"eager", "fast", "golden", "green", "happy", "jolly", "lima", "lucky",
+	"mighty", "misty", "noble", "quick", "royal", "silver", "sunny", "swift",
+	"tidal", "wild", "windy", "wise",
+}
+
+var tokenAnimals = []string{
+	"alpacas", "bears", "condors", "dolphins", "eagles", "falcons", "foxes",
+	"herons", "jaguars", "llamas", "lions", "lynxes", "otters", "owls",
+	"pumas", "ravens", "seals", "sharks", "tigers", "toucans", "vicunas",
+	"wolves",
+}
+
+// generateJoinToken returns a fresh join token in the given format. Tokens are
+// random, not unique by construction; callers check them against existing
+// tokens and redraw on collision.
+func generateJoinToken(f JoinTokenFormat) string {
+	switch f.Style {
+	case tokenStyleWords:
+		return fmt.Sprintf("%s-%s-%02d",
+			tokenAdjectives[randIntn(len(tokenAdjectives))],
+			tokenAnimals[randIntn(len(tokenAnimals))],
+			randIntn(100))
+	case tokenStylePIN:
+		n := max(f.PINLength, minPINLength)
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = '0' + byte(randIntn(10))
+		}
+		return string(b)
+	default:
+		b := make([]byte, 4)
+		randRead(b)
+		return "team-" + hex.EncodeToString(b)
+	}
+}
+
+// clientTokenFormat looks up the join-token format configured for a client.
+// Clients missing from the admin DB get the default hex tokens.
+func clientTokenFormat(ctx context.Context, admin AdminStore, slug string) (JoinTokenFormat, error) {
+	c, err := admin.GetClient(ctx, slug)
+	if errors.Is(err, ErrNotFound) {
+		return JoinTokenFormat{Style: tokenStyleHex}, nil
+	}
+	if err != nil {
+		return JoinTokenFormat{}, err
+	}
+	return c.tokenFormat(), nil
+}
+
+// createTeamWithGeneratedToken creates a team with a generated join token,
+// redrawing the token while it collides with one already handed out.
+func createTeamWithGeneratedToken(ctx context.Context, store Store, gameID string, req AdminTeamRequest, f JoinTokenFormat) (AdminTeamItem, error) {
+	var err error
+	for range joinTokenAttempts {
+		var team AdminTeamItem
+		team, err = store.CreateTeam(ctx, gameID, req, generateJoinToken(f))
+		if err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+			return team, err
+		}
+	}
+	return AdminTeamItem{}, err
+}
+
+func randIntn(n int) int {
+	v, err := rand.Int(random, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestGenerateJoinToken(t *testing.T) {
+	for _, tc := range []struct {
+		format JoinTokenFormat
+		want   *regexp.Regexp
+	}{
+		{JoinTokenFormat{Style: tokenStyleHex}, regexp.MustCompile(`^team-[0-9a-f]{8}$`)},
+		{JoinTokenFormat{Style: tokenStyleWords}, regexp.MustCompile(`^[a-z]+-[a-z]+-[0-9]{2}$`)},
+		{JoinTokenFormat{Style: tokenStylePIN, PINLength: 6}, regexp.MustCompile(`^[0-9]{6}$`)},
+		{JoinTokenFormat{Style: tokenStylePIN, PINLength: 8}, regexp.MustCompile(`^[0-9]{8}$`)},
+		// Too-short PINs are padded up to the minimum.
+		{JoinTokenFormat{Style: tokenStylePIN, PINLength: 4}, regexp.MustCompile(`^[0-9]{6}$`)},
+	} {
+		for range 50 {
+			if tok := generateJoinToken(tc.format); !tc.want.MatchString(tok) {
+				t.Fatalf("%+v: token %q does not match %s", tc.format, tok, tc.want)
+			}
+		}
+	}
+}
+
+func TestJoinTokenFormatValidate(t *testing.T) {
+	for _, tc := range []struct {
+		format JoinTokenFormat
+		want   JoinTokenFormat
+		valid  bool
+	}{
+		{JoinTokenFormat{}, JoinTokenFormat{Style: tokenStyleHex}, true},
+		{JoinTokenFormat{Style: tokenStyleWords, PINLength: 9}, JoinTokenFormat{Style: tokenStyleWords}, true},
+		{JoinTokenFormat{Style: tokenStylePIN}, JoinTokenFormat{Style: tokenStylePIN, PINLength: minPINLength}, true},
+		{JoinTokenFormat{Style: tokenStylePIN, PINLength: 8}, JoinTokenFormat{Style: tokenStylePIN, PINLength: 8}, true},
+		{JoinTokenFormat{Style: tokenStylePIN, PINLength: 4}, JoinTokenFormat{}, false},
+		{JoinTokenFormat{Style: tokenStylePIN, PINLength: 20}, JoinTokenFormat{}, false},
+		{JoinTokenFormat{Style: "emoji"}, JoinTokenFormat{}, false},
+	} {
+		f := tc.format
+		msg := f.validate()
+		if (msg == "") != tc.valid {
+			t.Errorf("%+v: valid = %v, want %v (%q)", tc.format, msg == "", tc.valid, msg)
+			continue
+		}
+		if tc.valid && f != tc.want {
+			t.Errorf("%+v: normalized to %+v, want %+v", tc.format, f, tc.want)
+		}
+	}
+}
+
+func TestCreateTeamGeneratedTokensUnique(t *testing.T) {
+	for _, f := range []JoinTokenFormat{
+		{Style: tokenStyleHex},
+		{Style: tokenStyleWords},
+		{Style: tokenStylePIN, PINLength: minPINLength},
+	} {
+		t.Run(f.Style, func(t *testing.T) {
+			_, store := setupStores(t)
+			ctx := context.Background()
+
+			seen := map[string]bool{}
+			for i := range 30 {
+				team, err := createTeamWithGeneratedToken(ctx, store, demoSeed.GameID, AdminTeamRequest{Name: fmt.Sprintf("Team %d", i)}, f)
+				if err != nil {
+					t.Fatalf("create team: %v", err)
+				}
+				if seen[team.JoinToken] {
+					t.Fatalf("join token %q handed out twice", team.JoinToken)
+				}
+				seen[team.JoinToken] = true
+			}
+		})
+	}
+}
+
+func TestAdminCreateTeamClientTokenStyle(t *testing.T) {
+	r, login := adminRouter(t)
+	cookies := login()
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/admin/clients", CreateClientRequest{Slug: "lima", Name: "Lima", TokenStyle: "words"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create client: %d %s", w.Code, w.Body.String())
+	}
+	w = do(http.MethodPost, "/api/admin/clients", CreateClientRequest{Slug: "short", Name: "Short", TokenStyle: "pin", PINLength: 4})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a 4-digit PIN, got %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/api/admin/clients/lima/games/"+demoSeed.GameID+"/teams", AdminTeamRequest{Name: "Lions"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create team: %d %s", w.Code, w.Body.String())
+	}
+	var team AdminTeamItem
+	json.NewDecoder(w.Body).Decode(&team)
+	if !regexp.MustCompile(`^[a-z]+-[a-z]+-[0-9]{2}$`).MatchString(team.JoinToken) {
+		t.Errorf("expected a word token, got %q", team.JoinToken)
+	}
+
+	// Clients without a record fall back to hex tokens.
+	w = do(http.MethodPost, "/api/admin/clients/demo/games/"+demoSeed.GameID+"/teams", AdminTeamRequest{Name: "Pumas"})
+	json.NewDecoder(w.Body).Decode(&team)
+	if !regexp.MustCompile(`^team-[0-9a-f]{8}$`).MatchString(team.JoinToken) {
+		t.Errorf("expected a hex token, got %q", team.JoinToken)
+	}
+}
@@ -16,6 +16,10 @@ func readJSON(r *http.Request, v any) error {
 	return json.NewDecoder(r.Body).Decode(v)
 }
 
+// writeError writes an ErrorResponse. msg is the English message; it is
+// translated into the language localizeErrors negotiated, if any, and its
+// catalog code is sent alongside.
 func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
+	code, text := localizeMessage(status, msg, responseLang(w))
+	writeJSON(w, status, ErrorResponse{Error: text, Code: code})
 }
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error responses carry a stable machine-readable code next to the message.
+// Handlers keep calling writeError with the English text; messageCatalog maps
+// that text to its code and translations. Messages missing from the catalog
+// (mostly admin-only ones) stay English and get a code derived from the HTTP
+// status.
+
+const defaultLang = "en"
+
+// supportedLangs are the languages with translations besides English.
+var supportedLangs = []string{"es"}
+
+type catalogEntry struct {
+	Code string
+	En   string
+	Es   string
+}
+
+var messageCatalog = []catalogEntry{
+	// Generic.
+	{"internal_error", "internal error", "error interno"},
+	{"game_stages_corrupt", "game stages are unreadable; an admin can restore them from the scenario", "las etapas del juego son ilegibles; un administrador puede restaurarlas desde el escenario"},
+	{"invalid_request_body", "invalid request body", "cuerpo de la solicitud no válido"},
+	{"unsupported_content_type", "Content-Type must be application/json", "Content-Type debe ser application/json"},
+	{"client_not_found", "client not found", "cliente no encontrado"},
+	{"not_authenticated", "not authenticated", "no autenticado"},
+
+	// Player sessions.
+	{"session_invalid", "invalid or missing session token", "token de sesión no válido o ausente"},
+	{"session_token_invalid", "invalid session token", "token de sesión no válido"},
+	{"token_param_required", "token query parameter required", "se requiere el parámetro token"},
+	{"sse_buffer_invalid", "buffer must be a positive integer", "buffer debe ser un número entero positivo"},
+	{"streaming_unsupported", "streaming not supported", "streaming no soportado"},
+
+	// Joining.
+	{"team_not_found", "team not found or game not active", "equipo no encontrado o juego no activo"},
+	{"join_fields_required", "joinToken (or pin and teamId) and playerName are required", "se requieren joinToken (o pin y teamId) y playerName"},
+	{"pin_invalid", "pin must be 6 digits", "el PIN debe tener 6 dígitos"},
+	{"pin_not_found", "game not found or not active", "juego no encontrado o no activo"},
+
+	// Playing.
+	{"game_not_active", "game is not active", "el juego no está activo"},
+	{"game_not_started", "game has not started yet", "el juego aún no ha comenzado"},
+	{"game_ended", "game has ended", "el juego ha terminado"},
+	{"game_no_stages", "game has no stages", "el juego no tiene etapas"},
+	{"all_stages_completed", "all stages completed", "todas las etapas completadas"},
+	{"answer_required", "answer is required", "se requiere una respuesta"},
+	{"answer_too_long", "answer is too long", "la respuesta es demasiado larga"},
+	{"already_answered", "you already answered this stage", "ya respondiste esta etapa"},
+	{"code_required", "code is required", "se requiere un código"},
+	{"code_too_long", "code is too long", "el código es demasiado largo"},
+	{"invalid_code", "invalid code", "código no válido"},
+	{"stage_locked", "stage not unlocked", "etapa no desbloqueada"},
+	{"stage_already_unlocked", "stage already unlocked", "etapa ya desbloqueada"},
+	{"mode_no_unlock", "classic mode does not use unlock", "el modo clásico no usa desbloqueo"},
+	{"mode_no_questions", "this mode does not use questions", "este modo no usa preguntas"},
+	{"unknown_mode", "unknown mode", "modo desconocido"},
+	{"supervisor_answers_only", "only the supervisor can submit answers", "solo el supervisor puede enviar respuestas"},
+	{"supervisor_unlocks_only", "only the supervisor can unlock stages", "solo el supervisor puede desbloquear etapas"},
+	{"spectator_cannot_answer", "spectators cannot submit answers", "los espectadores no pueden enviar respuestas"},
+	{"spectator_cannot_unlock", "spectators cannot unlock stages", "los espectadores no pueden desbloquear etapas"},
+	{"awaiting_next", "move on to the next stage first", "primero pasa a la siguiente etapa"},
+	{"advances_automatically", "this game advances automatically", "este juego avanza automáticamente"},
+	{"nothing_to_advance", "no result to move on from", "no hay ningún resultado del que avanzar"},
+	{"supervisor_advances_only", "only the supervisor can move the team on", "solo el supervisor puede hacer avanzar al equipo"},
+	{"spectator_cannot_advance", "spectators cannot move the team on", "los espectadores no pueden hacer avanzar al equipo"},
+	{"players_confirm_only", "only players confirm a stage", "solo los jugadores confirman una etapa"},
+	{"starts_without_confirmation", "this game starts stages without confirmation", "este juego inicia las etapas sin confirmación"},
+	{"nothing_to_confirm", "no unlocked stage to confirm", "no hay ninguna etapa desbloqueada que confirmar"},
+	{"give_up_not_allowed", "this game does not allow giving up", "este juego no permite rendirse"},
+	{"supervisor_gives_up_only", "only the supervisor can give up", "solo el supervisor puede rendirse"},
+	{"spectator_cannot_give_up", "spectators cannot give up", "los espectadores no pueden rendirse"},
+	{"stage_already_answered", "stage already answered", "la etapa ya fue respondida"},
+	{"waiting_for_teammates", "waiting for teammates", "esperando a los compañeros de equipo"},
+}
+
+var messagesByText = func() map[string]catalogEntry {
+	m := make(map[string]catalogEntry, len(messageCatalog))
+	for _, e := range messageCatalog {
+		m[e.En] = e
+	}
+	return m
+}()
+
+// localizeMessage returns the error code for an English message and its text
+// in lang.
+func localizeMessage(status int, msg, lang string) (code, text string) {
+	e, ok := messagesByText[msg]
+	if !ok {
+		return statusCode(status), msg
+	}
+	if lang == "es" && e.Es != "" {
+		return e.Code, e.Es
+	}
+	return e.Code, e.En
+}
+
+// statusCode derives a code for messages without a catalog entry, e.g.
+// "not_found" for 404.
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.ReplaceAll(text, " ", "_"))
+}
+
+// negotiateLanguage picks the best supported language from an Accept-Language
+// header ("es-PE,es;q=0.9,en;q=0.8"), matching on the primary subtag. Anything
+// unsupported or unparseable falls back to English.
+func negotiateLanguage(header string) string {
+	type pref struct {
+		lang string
+		q    float64
+	}
+	var prefs []pref
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if primary != "" && q > 0 {
+			prefs = append(prefs, pref{primary, q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	for _, p := range prefs {
+		if p.lang == defaultLang {
+			return defaultLang
+		}
+		for _, l := range supportedLangs {
+			if p.lang == l {
+				return l
+			}
+		}
+	}
+	return defaultLang
+}
+
+// localizedWriter carries the negotiated language down to writeError.
+type localizedWriter struct {
+	http.ResponseWriter
+	lang string
+}
+
+func (lw *localizedWriter) Unwrap() http.ResponseWriter { return lw.ResponseWriter }
+
+// Flush keeps SSE working through the wrapper.
+func (lw *localizedWriter) Flush() {
+	_ = http.NewResponseController(lw.ResponseWriter).Flush()
+}
+
+// localizeErrors negotiates the response language from Accept-Language so
+// writeError can translate its message. Only error messages are translated.
+func localizeErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := negotiateLanguage(r.Header.Get("Accept-Language"))
+		if lang == defaultLang {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&localizedWriter{ResponseWriter: w, lang: lang}, r)
+	})
+}
+
+// responseLang finds the language localizeErrors attached to w, looking
+// through wrappers added by later middleware (e.g. idempotencyRecorder).
+func responseLang(w http.ResponseWriter) string {
+	for {
+		if lw, ok := w.(*localizedWriter); ok {
+			return lw.lang
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return defaultLang
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		header, want string
+	}{
+		{"", "en"},
+		{"es", "es"},
+		{"es-PE,es;q=0.9,en;q=0.8", "es"},
+		{"en-US,en;q=0.9,es;q=0.8", "en"},
+		{"fr-FR,fr;q=0.9,es;q=0.5", "es"},
+		{"en;q=0.3, es;q=0.7", "es"},
+		{"es;q=0", "en"},
+		{"de", "en"},
+		{"es;q=abc", "en"},
+	} {
+		if got := negotiateLanguage(tc.header); got != tc.want {
+			t.Errorf("negotiateLanguage(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestWriteErrorLocalized(t *testing.T) {
+	r := localizeErrors(playerRouter(t))
+
+	get := func(path, lang string) ErrorResponse {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp ErrorResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	for _, tc := range []struct {
+		path, lang string
+		want       ErrorResponse
+	}{
+		{"/api/demo/teams/nope-1234", "es-PE,es;q=0.9", ErrorResponse{Error: "equipo no encontrado o juego no activo", Code: "team_not_found"}},
+		{"/api/demo/teams/nope-1234", "", ErrorResponse{Error: "team not found or game not active", Code: "team_not_found"}},
+		{"/api/demo/teams/nope-1234", "de", ErrorResponse{Error: "team not found or game not active", Code: "team_not_found"}},
+		{"/api/demo/game/state", "es", ErrorResponse{Error: "token de sesión no válido o ausente", Code: "session_invalid"}},
+		{"/api/demo/games/pin/12ab56", "es", ErrorResponse{Error: "el PIN debe tener 6 dígitos", Code: "pin_invalid"}},
+	} {
+		if got := get(tc.path, tc.lang); got != tc.want {
+			t.Errorf("GET %s (%q): got %+v, want %+v", tc.path, tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestSSEBufferErrorLocalized(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/demo/game/events?token=x&buffer=0", nil)
+	req.Header.Set("Accept-Language", "es")
+	w := httptest.NewRecorder()
+	localizeErrors(handleEvents(NewBroker(), time.Second, SSEBuffer{})).ServeHTTP(w, req)
+
+	var resp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if want := (ErrorResponse{Error: "buffer debe ser un número entero positivo", Code: "sse_buffer_invalid"}); w.Code != http.StatusBadRequest || resp != want {
+		t.Errorf("buffer=0: got %d %+v, want 400 %+v", w.Code, resp, want)
+	}
+}
+
+func TestWriteErrorUncatalogued(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(&localizedWriter{ResponseWriter: w, lang: "es"}, http.StatusConflict, `join token "x" already exists`)
+
+	var resp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Error != `join token "x" already exists` || resp.Code != "conflict" {
+		t.Errorf("got %+v, want the English message with code conflict", resp)
+	}
+}
@@ -2,9 +2,13 @@ package server
 
 import (
 	"context"
+	"mime"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type ctxKey int
@@ -14,6 +18,73 @@ const (
 	ctxKeyAdmin
 )
 
+// compressJSON gzips (or deflates) JSON responses for clients that accept it.
+// Players poll game state over mobile data, and the state of a game with big
+// teams compresses several-fold. It is applied per route rather than to the
+// whole /api/{client} group so the SSE stream, whose events are already a few
+// bytes each, is never buffered by a compressor.
+var compressJSON = middleware.Compress(5, "application/json")
+
+// requestTimeouts bounds how long a request may spend sending its body and
+// receiving its response, so slow clients can't pin connections open. These
+// are per-request deadlines rather than http.Server's ReadTimeout and
+// WriteTimeout because the server-wide ones can't be lifted: handleEvents
+// clears both deadlines, since an SSE response is written for as long as the
+// stream is open. A zero duration leaves that deadline unset.
+func requestTimeouts(read, write time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rc := http.NewResponseController(w)
+			now := time.Now()
+			// Errors mean the writer doesn't support deadlines (e.g. HTTP/3).
+			if read > 0 {
+				_ = rc.SetReadDeadline(now.Add(read))
+			}
+			if write > 0 {
+				_ = rc.SetWriteDeadline(now.Add(write))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireJSON rejects request bodies that aren't declared as JSON with 415, so
+// form posts and misconfigured clients don't get half-parsed by readJSON.
+// Requests without a body (GET, DELETE, bodiless POSTs like logout) pass.
+// Multipart endpoints (uploads, scenario import) must not use it.
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != 0 {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publicCORS lets pages on the given origins read a public endpoint from the
+// browser. "*" allows any origin. Without origins it adds nothing, so only
+// same-origin pages can read the response. The public endpoints are plain
+// GETs without credentials, so browsers don't send a preflight.
+func publicCORS(origins []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" {
+				if slices.Contains(origins, "*") {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else if slices.Contains(origins, origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func clientMiddleware(clients *Registry) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -23,7 +94,7 @@ func clientMiddleware(clients *Registry) func(http.Handler) http.Handler {
 				return
 			}
 
-			store, err := clients.Get(r.Context(), slug)
+			store, err := clients.Get(slug)
 			if err != nil {
 				writeError(w, http.StatusNotFound, "client not found")
 				return
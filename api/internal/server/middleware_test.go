@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestTimeoutsSlowBody(t *testing.T) {
+	h := requestTimeouts(50*time.Millisecond, time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			writeError(w, http.StatusRequestTimeout, "request body too slow")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Promise a 10-byte body but only send part of it.
+	fmt.Fprint(conn, "POST / HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: 10\r\n\r\n{\"a\"")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("expected 408 for a stalled body, got %d", resp.StatusCode)
+	}
+}
+
+func TestRequestTimeoutsExemptSSE(t *testing.T) {
+	_, store := setupStores(t)
+	_, token, err := store.JoinTeam(context.Background(), demoSeed.GameID, demoIncas.ID, "Eve", "player")
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	events := handleEvents(NewBroker(), 300*time.Millisecond, SSEBuffer{})
+	h := requestTimeouts(50*time.Millisecond, 50*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events(w, r.WithContext(context.WithValue(r.Context(), ctxKeyStore, Store(store))))
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/demo/game/events?token=" + token)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The reconnect event is written well after the write deadline would
+	// have expired; it only arrives if the stream lifted it.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if !strings.Contains(string(body), "event: reconnect") {
+		t.Errorf("expected stream to outlive the write timeout, got %q", body)
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	h := requireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json", "application/json", `{"a":1}`, http.StatusOK},
+		{"json with charset", "application/json; charset=utf-8", `{"a":1}`, http.StatusOK},
+		{"form post", "application/x-www-form-urlencoded", "a=1", http.StatusUnsupportedMediaType},
+		{"text", "text/plain", `{"a":1}`, http.StatusUnsupportedMediaType},
+		{"missing header", "", `{"a":1}`, http.StatusUnsupportedMediaType},
+		{"empty body", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/demo/join", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	openapi "github.com/swaggest/openapi-go"
 	"github.com/swaggest/openapi-go/openapi3"
@@ -11,6 +12,7 @@ import (
 // ErrorResponse is returned for all error responses.
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code"`
 }
 
 func newOpenAPISpec() *openapi3.Spec {
@@ -22,29 +24,68 @@ func newOpenAPISpec() *openapi3.Spec {
 	// GET /healthz
 	getHealthz, _ := r.NewOperationContext(http.MethodGet, "/healthz")
 	getHealthz.SetSummary("Health check")
-	getHealthz.SetDescription("Returns the health status of backend dependencies.")
+	getHealthz.SetDescription("Returns the health status of backend dependencies: the admin SQLite database and free disk space in the client DB directory (503 below MIN_FREE_DISK_MB).")
 	getHealthz.AddRespStructure(HealthResponse{}, openapi.WithHTTPStatus(http.StatusOK))
 	getHealthz.AddRespStructure(HealthResponse{}, openapi.WithHTTPStatus(http.StatusServiceUnavailable))
 	_ = r.AddOperation(getHealthz)
 
+	// GET /api/version
+	getVersion, _ := r.NewOperationContext(http.MethodGet, "/api/version")
+	getVersion.SetSummary("Build info")
+	getVersion.SetDescription("Returns the version, git commit and Go version of the running server.")
+	getVersion.AddRespStructure(BuildInfo{}, openapi.WithHTTPStatus(http.StatusOK))
+	_ = r.AddOperation(getVersion)
+
+	// GET /api/public/scenarios
+	getPublicScenarios, _ := r.NewOperationContext(http.MethodGet, "/api/public/scenarios")
+	getPublicScenarios.SetSummary("List public scenarios")
+	getPublicScenarios.SetDescription("Lists the scenarios flagged public, newest first, for an \"upcoming tours\" page: name, city, description, mode and stage count, never stages or answers. No auth. Pages on PUBLIC_CORS_ORIGINS may read it cross-origin.")
+	getPublicScenarios.AddRespStructure([]PublicScenario{}, openapi.WithHTTPStatus(http.StatusOK))
+	_ = r.AddOperation(getPublicScenarios)
+
 	// GET /api/teams/{joinToken}
 	getTeam, _ := r.NewOperationContext(http.MethodGet, "/api/teams/{joinToken}")
 	getTeam.SetSummary("Look up team")
-	getTeam.SetDescription("Look up a team by its join token before joining.")
+	getTeam.SetDescription("Look up a team by its join, supervisor or spectator token before joining. Returns the role the token joins as, with the game's mode and whether it is supervised so the join screen can explain the role. Supervisor tokens only resolve in supervised games. Works for lobby, active and paused games; 404 for drafts and ended games.")
 	getTeam.AddRespStructure(TeamLookupResponse{}, openapi.WithHTTPStatus(http.StatusOK))
 	getTeam.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
 	_ = r.AddOperation(getTeam)
 
+	// GET /api/games/pin/{pin}
+	getGamePIN, _ := r.NewOperationContext(http.MethodGet, "/api/games/pin/{pin}")
+	getGamePIN.SetSummary("Look up game by PIN")
+	getGamePIN.SetDescription("Look up a lobby, active or paused game by its 6-digit PIN and list its team names, so a player can pick a team and join with pin and teamId.")
+	getGamePIN.AddRespStructure(GamePINResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	getGamePIN.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	getGamePIN.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	_ = r.AddOperation(getGamePIN)
+
+	// GET /api/branding
+	getBranding, _ := r.NewOperationContext(http.MethodGet, "/api/branding")
+	getBranding.SetSummary("Get client branding")
+	getBranding.SetDescription("The client's name, logo and primary color for theming the join screen. No auth. The name falls back to the client's own; other empty fields mean the default look.")
+	getBranding.AddRespStructure(Branding{}, openapi.WithHTTPStatus(http.StatusOK))
+	getBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	_ = r.AddOperation(getBranding)
+
 	// POST /api/join
 	postJoin, _ := r.NewOperationContext(http.MethodPost, "/api/join")
 	postJoin.SetSummary("Join a team")
-	postJoin.SetDescription("Player joins a team using the join token. Returns a session token.")
+	postJoin.SetDescription("Player joins a team using the join token, or the game PIN plus a team ID from the PIN lookup. Returns a session token.")
 	postJoin.AddReqStructure(JoinRequest{})
 	postJoin.AddRespStructure(JoinResponse{}, openapi.WithHTTPStatus(http.StatusOK))
 	postJoin.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
 	postJoin.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
 	_ = r.AddOperation(postJoin)
 
+	// GET /api/game/resume
+	getResume, _ := r.NewOperationContext(http.MethodGet, "/api/game/resume")
+	getResume.SetSummary("Resume session")
+	getResume.SetDescription("Validate a session token the app already holds (pass it as the token query parameter) and return its team and game, so a returning player skips the join screen instead of joining again. 401 if the session, its game or its team no longer exists.")
+	getResume.AddRespStructure(ResumeResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	getResume.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(getResume)
+
 	// GET /api/game/state
 	getState, _ := r.NewOperationContext(http.MethodGet, "/api/game/state")
 	getState.SetSummary("Get game state")
@@ -53,12 +94,21 @@ func newOpenAPISpec() *openapi3.Spec {
 	getState.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(getState)
 
+	// GET /api/game/recap
+	getRecap, _ := r.NewOperationContext(http.MethodGet, "/api/game/recap")
+	getRecap.SetSummary("Recap answered stages")
+	getRecap.SetDescription("Lists the stages the player's team has answered, in order, with each stage's location, question and explanation and the team's answer and result. Available in any game status. Requires Bearer token.")
+	getRecap.AddRespStructure(RecapResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	getRecap.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(getRecap)
+
 	// POST /api/game/answer
 	postAnswer, _ := r.NewOperationContext(http.MethodPost, "/api/game/answer")
 	postAnswer.SetSummary("Submit answer")
-	postAnswer.SetDescription("Submit an answer for the current stage. Requires Bearer token.")
+	postAnswer.SetDescription("Submit an answer for the current stage. The response always carries the stage's correctAnswer and explanation for the result screen. In manualAdvance games the team stays on that result (pendingAdvance, with currentStage on the answered stage) until POST /api/game/next; answering before then is 409, and canAdvance says whether this player may call next. Answers longer than MAX_ANSWER_LENGTH characters are rejected with 400. Requires Bearer token.")
 	postAnswer.AddReqStructure(AnswerRequest{})
 	postAnswer.AddRespStructure(AnswerResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	postAnswer.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
 	postAnswer.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	postAnswer.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	_ = r.AddOperation(postAnswer)
@@ -66,7 +116,7 @@ func newOpenAPISpec() *openapi3.Spec {
 	// POST /api/game/unlock
 	postUnlock, _ := r.NewOperationContext(http.MethodPost, "/api/game/unlock")
 	postUnlock.SetSummary("Unlock stage")
-	postUnlock.SetDescription("Unlock the current stage using a code (QR, math, or supervised). Requires Bearer token. Not used in classic mode.")
+	postUnlock.SetDescription("Unlock the current stage using a code (QR, math, or supervised). Codes longer than MAX_ANSWER_LENGTH characters are rejected with 400. Retrying an unlock of the already open current stage (its code again in qr_quiz, the supervisor again in supervised games) returns the same 200 response without publishing anything; any other unlock of an open stage is 409. Requires Bearer token. Not used in classic mode.")
 	postUnlock.AddReqStructure(UnlockRequest{})
 	postUnlock.AddRespStructure(UnlockResponse{}, openapi.WithHTTPStatus(http.StatusOK))
 	postUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
@@ -76,10 +126,40 @@ func newOpenAPISpec() *openapi3.Spec {
 	postUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
 	_ = r.AddOperation(postUnlock)
 
+	// POST /api/game/next
+	postNext, _ := r.NewOperationContext(http.MethodPost, "/api/game/next")
+	postNext.SetSummary("Move on from a result")
+	postNext.SetDescription("In manualAdvance games, move the team on from the result of its last answer; every device gets a stage_advanced event with the new stage number, its clue and whether it is still locked. Only the supervisor may call it in supervised games (403 otherwise). 409 if the game advances automatically or the team already moved on. Requires Bearer token.")
+	postNext.AddRespStructure(NextResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	postNext.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	postNext.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusForbidden))
+	postNext.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	_ = r.AddOperation(postNext)
+
+	// POST /api/game/giveup
+	postGiveUp, _ := r.NewOperationContext(http.MethodPost, "/api/game/giveup")
+	postGiveUp.SetSummary("Give up the current question")
+	postGiveUp.SetDescription("In games with allowGiveUp, skip the current question: the stage is recorded wrong with no answer (gaveUp in completedStages) and the response is the same result an answer gets, with correctAnswer, explanation and the next stage. Every device gets a stage_gaveup event. The players who may answer may give up; 403 if the game doesn't allow it, 409 when there is no open question (locked, already answered, waiting on a result or on teammates). Requires Bearer token.")
+	postGiveUp.AddRespStructure(AnswerResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	postGiveUp.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	postGiveUp.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusForbidden))
+	postGiveUp.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	_ = r.AddOperation(postGiveUp)
+
+	// POST /api/game/confirm
+	postConfirm, _ := r.NewOperationContext(http.MethodPost, "/api/game/confirm")
+	postConfirm.SetSummary("Confirm an unlocked stage")
+	postConfirm.SetDescription("In supervised games with waitForConfirmations, acknowledge the stage the supervisor just unlocked. The stage timer starts once every player (supervisor aside) has confirmed; the supervisor gets a player_confirmed event per player and everyone gets stage_started with stageUnlockedAt. Confirming twice is a no-op. 403 for supervisors and spectators; 409 if the game doesn't wait for confirmations, the stage isn't unlocked or its timer already started. Requires Bearer token.")
+	postConfirm.AddRespStructure(ConfirmResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	postConfirm.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	postConfirm.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusForbidden))
+	postConfirm.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	_ = r.AddOperation(postConfirm)
+
 	// GET /api/game/events
 	getEvents, _ := r.NewOperationContext(http.MethodGet, "/api/game/events")
 	getEvents.SetSummary("SSE event stream")
-	getEvents.SetDescription("Server-Sent Events stream for real-time game updates. Pass token as query parameter.")
+	getEvents.SetDescription("Server-Sent Events stream for real-time game updates. Pass token as query parameter. Optional buffer=N asks for a bigger per-connection event buffer (capped by SSE_MAX_BUFFER); events that find the buffer full are dropped for that connection. 400 if buffer isn't a positive integer.")
 	getEvents.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusOK),
 		openapi.WithContentType("text/event-stream"))
 	_ = r.AddOperation(getEvents)
@@ -119,13 +199,55 @@ func newOpenAPISpec() *openapi3.Spec {
 	// POST /api/admin/scenarios
 	createScenario, _ := r.NewOperationContext(http.MethodPost, "/api/admin/scenarios")
 	createScenario.SetSummary("Create scenario")
-	createScenario.SetDescription("Creates a new scenario with stages. Requires admin_session cookie.")
+	createScenario.SetDescription("Creates a new scenario with stages. Requires admin_session cookie. An Idempotency-Key header makes retries return the original response instead of creating a duplicate.")
 	createScenario.AddReqStructure(AdminScenarioRequest{})
 	createScenario.AddRespStructure(AdminScenarioDetail{}, openapi.WithHTTPStatus(http.StatusCreated))
 	createScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	createScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	createScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(createScenario)
 
+	// POST /api/admin/scenarios/validate
+	validateScenario, _ := r.NewOperationContext(http.MethodPost, "/api/admin/scenarios/validate")
+	validateScenario.SetSummary("Validate scenario")
+	validateScenario.SetDescription("Checks a scenario without saving it. Returns the blocking error, if any, and non-blocking warnings (stages without a clue, repeated locations, answers that are easy to guess). Create and update return the same warnings. Requires admin_session cookie.")
+	validateScenario.AddReqStructure(AdminScenarioRequest{})
+	validateScenario.AddRespStructure(ScenarioValidation{}, openapi.WithHTTPStatus(http.StatusOK))
+	validateScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	validateScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(validateScenario)
+
+	// POST /api/admin/scenarios/{id}/mode
+	changeScenarioMode, _ := r.NewOperationContext(http.MethodPost, "/api/admin/scenarios/{id}/mode")
+	changeScenarioMode.SetSummary("Change scenario mode")
+	changeScenarioMode.SetDescription("Moves a scenario to another mode and re-validates it. QR modes get unlock codes for stages without one; leaving a question mode clears explanations, caseSensitive and strictAnswers. Unlock codes and location numbers are kept. A 400 names every stage that still needs a question or a locationNumber. Games already created keep their mode. Requires admin_session cookie.")
+	changeScenarioMode.AddReqStructure(ScenarioModeRequest{})
+	changeScenarioMode.AddRespStructure(AdminScenarioDetail{}, openapi.WithHTTPStatus(http.StatusOK))
+	changeScenarioMode.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	changeScenarioMode.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	changeScenarioMode.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	changeScenarioMode.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(changeScenarioMode)
+
+	testUnlock, _ := r.NewOperationContext(http.MethodPost, "/api/admin/scenarios/{id}/test-unlock")
+	testUnlock.SetSummary("Test a QR unlock code")
+	testUnlock.SetDescription("Checks a scanned code against a qr_quiz or qr_hunt scenario for a team that has done progress stages, without a game. Returns whether it matches the stage the team would be on, the stages it would open (several when they share the code), and for a code that doesn't match, the stage it belongs to. Nothing is saved. 400 without a code or with progress out of range; 409 for modes that don't scan. Requires admin_session cookie.")
+	testUnlock.AddReqStructure(TestUnlockRequest{})
+	testUnlock.AddRespStructure(TestUnlockResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	testUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	testUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	testUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	testUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(testUnlock)
+
+	// GET /api/admin/scenarios/export-all
+	exportAllScenarios, _ := r.NewOperationContext(http.MethodGet, "/api/admin/scenarios/export-all")
+	exportAllScenarios.SetSummary("Export all scenarios")
+	exportAllScenarios.SetDescription("Streams a zip (scenarios.zip) with one markdown file per scenario in the single-scenario export format. POST the zip to /api/admin/scenarios/import to restore it; the response then lists a ScenarioImportResult per file. Requires admin_session cookie.")
+	exportAllScenarios.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusOK), openapi.WithContentType("application/zip"))
+	exportAllScenarios.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(exportAllScenarios)
+
 	// GET /api/admin/scenarios/{id}
 	getScenario, _ := r.NewOperationContext(http.MethodGet, "/api/admin/scenarios/{id}")
 	getScenario.SetSummary("Get scenario")
@@ -138,18 +260,28 @@ func newOpenAPISpec() *openapi3.Spec {
 	// PUT /api/admin/scenarios/{id}
 	updateScenario, _ := r.NewOperationContext(http.MethodPut, "/api/admin/scenarios/{id}")
 	updateScenario.SetSummary("Update scenario")
-	updateScenario.SetDescription("Updates a scenario and its stages. Requires admin_session cookie.")
+	updateScenario.SetDescription("Updates a scenario and its stages. The body must carry the version the edit started from; if the scenario was saved since, returns 409 and changes nothing. The response has the bumped version. Requires admin_session cookie.")
 	updateScenario.AddReqStructure(AdminScenarioRequest{})
 	updateScenario.AddRespStructure(AdminScenarioDetail{}, openapi.WithHTTPStatus(http.StatusOK))
 	updateScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	updateScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	updateScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
 	updateScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(updateScenario)
 
+	// GET /api/admin/scenarios/{id}/usage
+	scenarioUsage, _ := r.NewOperationContext(http.MethodGet, "/api/admin/scenarios/{id}/usage")
+	scenarioUsage.SetSummary("Scenario usage")
+	scenarioUsage.SetDescription("Lists the games, across all clients, created from the scenario (client slug, game ID, status). These are what make DELETE return 409. Requires admin_session cookie.")
+	scenarioUsage.AddRespStructure([]ScenarioUsage{}, openapi.WithHTTPStatus(http.StatusOK))
+	scenarioUsage.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	scenarioUsage.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(scenarioUsage)
+
 	// DELETE /api/admin/scenarios/{id}
 	deleteScenario, _ := r.NewOperationContext(http.MethodDelete, "/api/admin/scenarios/{id}")
 	deleteScenario.SetSummary("Delete scenario")
-	deleteScenario.SetDescription("Deletes a scenario. Blocked if games reference it. Requires admin_session cookie.")
+	deleteScenario.SetDescription("Deletes a scenario. Blocked with 409 if games reference it; GET /api/admin/scenarios/{id}/usage lists them. Requires admin_session cookie.")
 	deleteScenario.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusOK))
 	deleteScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	deleteScenario.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
@@ -167,10 +299,11 @@ func newOpenAPISpec() *openapi3.Spec {
 	// POST /api/admin/games
 	createGame, _ := r.NewOperationContext(http.MethodPost, "/api/admin/games")
 	createGame.SetSummary("Create game")
-	createGame.SetDescription("Creates a new game for the demo client. Requires admin_session cookie.")
+	createGame.SetDescription("Creates a new game for the demo client. maxAttempts is how many wrong answers a team may give a question before failing it; 0, the default, means the first answer settles the question, not unlimited answers. Requires admin_session cookie. An Idempotency-Key header makes retries return the original response instead of creating a duplicate.")
 	createGame.AddReqStructure(AdminGameRequest{})
 	createGame.AddRespStructure(AdminGameDetail{}, openapi.WithHTTPStatus(http.StatusCreated))
 	createGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	createGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	createGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(createGame)
 
@@ -186,10 +319,11 @@ func newOpenAPISpec() *openapi3.Spec {
 	// PUT /api/admin/games/{gameID}
 	updateGame, _ := r.NewOperationContext(http.MethodPut, "/api/admin/games/{gameID}")
 	updateGame.SetSummary("Update game")
-	updateGame.SetDescription("Updates a game's scenario, status, and timer. Requires admin_session cookie.")
+	updateGame.SetDescription("Updates a game's scenario, status, and timer. The body must carry the game version the edit started from; returns 409 if the game was saved, extended or expired since. Requires admin_session cookie.")
 	updateGame.AddReqStructure(AdminGameRequest{})
 	updateGame.AddRespStructure(AdminGameDetail{}, openapi.WithHTTPStatus(http.StatusOK))
 	updateGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	updateGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	updateGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
 	updateGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(updateGame)
@@ -216,7 +350,7 @@ func newOpenAPISpec() *openapi3.Spec {
 	// POST /api/admin/games/{gameID}/teams
 	createTeam, _ := r.NewOperationContext(http.MethodPost, "/api/admin/games/{gameID}/teams")
 	createTeam.SetSummary("Create team")
-	createTeam.SetDescription("Creates a team in a game. Auto-generates join token if blank. Requires admin_session cookie.")
+	createTeam.SetDescription("Creates a team in a game. Auto-generates join token if blank. Requires admin_session cookie. An Idempotency-Key header makes retries return the original response instead of creating a duplicate.")
 	createTeam.AddReqStructure(AdminTeamRequest{})
 	createTeam.AddRespStructure(AdminTeamItem{}, openapi.WithHTTPStatus(http.StatusCreated))
 	createTeam.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
@@ -227,10 +361,11 @@ func newOpenAPISpec() *openapi3.Spec {
 	// PUT /api/admin/games/{gameID}/teams/{teamID}
 	updateTeam, _ := r.NewOperationContext(http.MethodPut, "/api/admin/games/{gameID}/teams/{teamID}")
 	updateTeam.SetSummary("Update team")
-	updateTeam.SetDescription("Updates a team's name and guide name. Token is immutable. Requires admin_session cookie.")
+	updateTeam.SetDescription("Updates a team's name and guide name. Token is immutable. The body must carry the team version the edit started from; returns 409 if the team was edited since (player progress doesn't count). Requires admin_session cookie.")
 	updateTeam.AddReqStructure(AdminTeamRequest{})
 	updateTeam.AddRespStructure(AdminTeamItem{}, openapi.WithHTTPStatus(http.StatusOK))
 	updateTeam.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	updateTeam.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
 	updateTeam.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
 	updateTeam.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(updateTeam)
@@ -245,16 +380,194 @@ func newOpenAPISpec() *openapi3.Spec {
 	deleteTeamOp.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
 	_ = r.AddOperation(deleteTeamOp)
 
+	// POST /api/admin/clients/{client}/games/{gameID}/regrade
+	regradeGame, _ := r.NewOperationContext(http.MethodPost, "/api/admin/clients/{client}/games/{gameID}/regrade")
+	regradeGame.SetSummary("Regrade game")
+	regradeGame.SetDescription("Re-evaluates all recorded answers against the (optionally corrected) answer key. Requires confirm=true and admin_session cookie.")
+	regradeGame.AddReqStructure(RegradeRequest{})
+	regradeGame.AddRespStructure(RegradeResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	regradeGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	regradeGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	regradeGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(regradeGame)
+
+	// POST /api/admin/clients/{client}/games/{gameID}/repair-stages
+	repairStages, _ := r.NewOperationContext(http.MethodPost, "/api/admin/clients/{client}/games/{gameID}/repair-stages")
+	repairStages.SetSummary("Repair game stages")
+	repairStages.SetDescription("Replaces the game's stage snapshot with its scenario's current stages, in any status, keeping team progress. Repairs games whose stored stages are missing or can't be read (players get code game_stages_corrupt). Active games need confirm=true (409 without). 409 if the scenario is gone or has no stages. Returns the new stage count and game version. Requires admin_session cookie.")
+	repairStages.AddReqStructure(RepairStagesRequest{})
+	repairStages.AddRespStructure(RepairStagesResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	repairStages.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	repairStages.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	repairStages.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	repairStages.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(repairStages)
+
+	// POST /api/admin/clients/{client}/games/{gameID}/clone
+	cloneGame, _ := r.NewOperationContext(http.MethodPost, "/api/admin/clients/{client}/games/{gameID}/clone")
+	cloneGame.SetSummary("Clone game")
+	cloneGame.SetDescription("Creates a draft copy of the game with the same scenario snapshot, settings and teams. Teams get new join tokens; players and results are not copied. Requires admin_session cookie.")
+	cloneGame.AddRespStructure(AdminGameDetail{}, openapi.WithHTTPStatus(http.StatusCreated))
+	cloneGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	cloneGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(cloneGame)
+
+	// GET /api/admin/clients/{client}/games/{gameID}/stage-stats
+	stageStats, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/games/{gameID}/stage-stats")
+	stageStats.SetSummary("Stage answer statistics")
+	stageStats.SetDescription("Per scenario stage: answers recorded across all teams, how many were correct or wrong, and attempts per correct answer. Stages no team has answered have zeros. Requires admin_session cookie.")
+	stageStats.AddRespStructure([]AdminStageStats{}, openapi.WithHTTPStatus(http.StatusOK))
+	stageStats.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	stageStats.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(stageStats)
+
+	// GET /api/admin/clients/{client}/games/{gameID}/answers
+	answerLog, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/games/{gameID}/answers")
+	answerLog.SetSummary("Answer log for moderation")
+	answerLog.SetDescription("Per scenario stage: the distinct answers teams gave (grouped ignoring case, spacing and accents, and trailing punctuation when the game ignores it), whether each is correct, and which teams gave it, most-shared first. Query parameters: wrongOnly=true drops correct answers; minTeams=N keeps answers shared by at least N teams. Requires admin_session cookie.")
+	answerLog.AddRespStructure([]AdminStageAnswers{}, openapi.WithHTTPStatus(http.StatusOK))
+	answerLog.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	answerLog.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	answerLog.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(answerLog)
+
+	// GET /api/admin/clients/{client}/games/{gameID}/results.csv
+	resultsCSV, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/games/{gameID}/results.csv")
+	resultsCSV.SetSummary("Export game results as CSV")
+	resultsCSV.SetDescription("Downloads every team's results as CSV with columns team, stageNumber (the scenario stage), answer, isCorrect, answeredAt, named after the scenario. A game without results is just the header row. Requires admin_session cookie.")
+	resultsCSV.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusOK), openapi.WithContentType("text/csv"))
+	resultsCSV.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	resultsCSV.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(resultsCSV)
+
+	// GET /api/admin/clients/{client}/games/{gameID}/event-log
+	eventLog, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/games/{gameID}/event-log")
+	eventLog.SetSummary("Game event log")
+	eventLog.SetDescription("Every join, unlock, answer, advance, stage timeout and give-up of the game's teams, in the order they happened, with the player (or the admin, for admin unlocks) who did it. Kept until the game is deleted. Requires admin_session cookie.")
+	eventLog.AddRespStructure([]GameEvent{}, openapi.WithHTTPStatus(http.StatusOK))
+	eventLog.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	eventLog.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(eventLog)
+
+	// GET /api/admin/clients/{client}/games/{gameID}/timeline
+	timeline, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/games/{gameID}/timeline")
+	timeline.SetSummary("Game timeline")
+	timeline.SetDescription("The game's event log as a readable narrative, every team's merged in order (\"Incas unlocked stage 2 (Ana)\"), for debriefs and disputes. Times are UTC. Query parameter: format=text returns plain text, one \"HH:MM text\" line per entry. Requires admin_session cookie.")
+	timeline.AddRespStructure([]TimelineEntry{}, openapi.WithHTTPStatus(http.StatusOK))
+	timeline.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	timeline.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(timeline)
+
+	// POST /api/admin/clients/{client}/games/{gameID}/extend
+	extendGame, _ := r.NewOperationContext(http.MethodPost, "/api/admin/clients/{client}/games/{gameID}/extend")
+	extendGame.SetSummary("Extend game timer")
+	extendGame.SetDescription("Adds minutes to the game timer without resetting it, and sends a timer_extended SSE event to every team so countdowns update. Fails with 409 for ended games or games without a timer. Requires admin_session cookie.")
+	extendGame.AddReqStructure(ExtendGameRequest{})
+	extendGame.AddRespStructure(ExtendGameResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	extendGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	extendGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	extendGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	extendGame.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(extendGame)
+
+	// POST /api/admin/clients/{client}/games/{gameID}/broadcast
+	broadcast, _ := r.NewOperationContext(http.MethodPost, "/api/admin/clients/{client}/games/{gameID}/broadcast")
+	broadcast.SetSummary("Broadcast to game")
+	broadcast.SetDescription("Sends an announcement SSE event with the message and the sending admin's email to every team in the game. Messages are at most 500 characters. Fails with 409 for ended games. Requires admin_session cookie.")
+	broadcast.AddReqStructure(BroadcastRequest{})
+	broadcast.AddRespStructure(BroadcastResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	broadcast.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	broadcast.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	broadcast.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	broadcast.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(broadcast)
+
+	// GET /api/admin/clients/{client}/cleanup-preview
+	cleanupPreview, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/cleanup-preview")
+	cleanupPreview.SetSummary("Preview game retention cleanup")
+	cleanupPreview.SetDescription("Lists the ended games the GAME_RETENTION cleanup would delete now, oldest first, without deleting anything. Games with a team still connected are left out, as the cleanup skips them. Query parameter: retention=DURATION (e.g. 720h) previews another period; required when GAME_RETENTION is unset. Requires admin_session cookie.")
+	cleanupPreview.AddRespStructure(CleanupPreviewResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	cleanupPreview.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	cleanupPreview.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(cleanupPreview)
+
+	// GET /api/admin/clients/{client}/branding
+	adminGetBranding, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/branding")
+	adminGetBranding.SetSummary("Get client branding (admin)")
+	adminGetBranding.SetDescription("The client's branding as stored, without the name fallback. Requires admin_session cookie.")
+	adminGetBranding.AddRespStructure(Branding{}, openapi.WithHTTPStatus(http.StatusOK))
+	adminGetBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	adminGetBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(adminGetBranding)
+
+	// PUT /api/admin/clients/{client}/branding
+	updateBranding, _ := r.NewOperationContext(http.MethodPut, "/api/admin/clients/{client}/branding")
+	updateBranding.SetSummary("Update client branding")
+	updateBranding.SetDescription("Replaces the client's branding. name is at most 60 characters, logoUrl an uploaded image or http(s) URL, primaryColor a hex color (#rgb or #rrggbb). Requires admin_session cookie.")
+	updateBranding.AddReqStructure(Branding{})
+	updateBranding.AddRespStructure(Branding{}, openapi.WithHTTPStatus(http.StatusOK))
+	updateBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusBadRequest))
+	updateBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	updateBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(updateBranding)
+
+	// DELETE /api/admin/clients/{client}/branding
+	deleteBranding, _ := r.NewOperationContext(http.MethodDelete, "/api/admin/clients/{client}/branding")
+	deleteBranding.SetSummary("Clear client branding")
+	deleteBranding.SetDescription("Puts the client back on the default look. Requires admin_session cookie.")
+	deleteBranding.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusOK))
+	deleteBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	deleteBranding.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(deleteBranding)
+
+	// GET /api/admin/clients/{client}/games/{gameID}/teams/{teamID}/sessions
+	teamSessions, _ := r.NewOperationContext(http.MethodGet, "/api/admin/clients/{client}/games/{gameID}/teams/{teamID}/sessions")
+	teamSessions.SetSummary("List team sessions")
+	teamSessions.SetDescription("Lists the player and spectator sessions on a team, for diagnosing join problems. Requires admin_session cookie.")
+	teamSessions.AddRespStructure(AdminTeamSessions{}, openapi.WithHTTPStatus(http.StatusOK))
+	teamSessions.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	teamSessions.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(teamSessions)
+
+	// POST /api/admin/clients/{client}/games/{gameID}/teams/{teamID}/unlock
+	adminUnlock, _ := r.NewOperationContext(http.MethodPost, "/api/admin/clients/{client}/games/{gameID}/teams/{teamID}/unlock")
+	adminUnlock.SetSummary("Unlock a team's stage")
+	adminUnlock.SetDescription("Unlocks the team's current stage on its behalf, for when a QR code won't scan or the supervisor's phone is dead. Sends the same SSE event as a normal unlock and records the override on the team. Fails with 409 when the game is not active, uses no unlocks, or the stage is already open. Requires admin_session cookie.")
+	adminUnlock.AddRespStructure(AdminUnlockResponse{}, openapi.WithHTTPStatus(http.StatusOK))
+	adminUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusNotFound))
+	adminUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusConflict))
+	adminUnlock.AddRespStructure(ErrorResponse{}, openapi.WithHTTPStatus(http.StatusUnauthorized))
+	_ = r.AddOperation(adminUnlock)
+
 	return r.Spec
 }
 
+// handleOpenAPI serves the spec as JSON, or as YAML when asked for with
+// ?format=yaml or an Accept header naming a YAML media type.
 func handleOpenAPI() http.HandlerFunc {
 	spec := newOpenAPISpec()
 	data, _ := json.MarshalIndent(spec, "", "  ")
+	yamlData, _ := spec.MarshalYAML()
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		if wantsYAML(r) {
+			w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(yamlData)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(data)
 	}
 }
+
+func wantsYAML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "yaml"
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/yaml") ||
+		strings.Contains(accept, "application/x-yaml") ||
+		strings.Contains(accept, "text/yaml")
+}
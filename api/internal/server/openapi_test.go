@@ -30,3 +30,48 @@ func TestHandleOpenAPI(t *testing.T) {
 		t.Fatalf("body missing /healthz path")
 	}
 }
+
+func TestHandleOpenAPIYAML(t *testing.T) {
+	h := handleOpenAPI()
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{"query", "/openapi.json?format=yaml", ""},
+		{"accept", "/openapi.json", "application/yaml"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+
+		h(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.Contains(got, "application/yaml") {
+			t.Fatalf("%s: content-type = %q, want application/yaml", tt.name, got)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "openapi: 3.0.3") {
+			t.Fatalf("%s: body missing openapi version:\n%s", tt.name, body)
+		}
+		if !strings.Contains(body, "/healthz:") {
+			t.Fatalf("%s: body missing /healthz path", tt.name)
+		}
+	}
+
+	// ?format=json wins over a YAML Accept header.
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json?format=json", nil)
+	req.Header.Set("Accept", "application/yaml")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if got := rec.Header().Get("Content-Type"); !strings.Contains(got, "application/json") {
+		t.Fatalf("content-type = %q, want application/json", got)
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	mrand "math/rand/v2"
+	"sync"
+)
+
+// random is where IDs, join/supervisor/spectator tokens, unlock codes and team
+// secrets get their bytes: crypto/rand, unless SeedRandom swapped in a seeded
+// generator. Upload and export file names always use crypto/rand so a seeded
+// run can't overwrite files from an earlier one.
+var random io.Reader = rand.Reader
+
+// SeedRandom makes every value drawn from random repeat from run to run, so
+// a demo seeded with the same seed has the same IDs, tokens and secrets for
+// screenshots and docs. Dev only: session tokens become predictable, which is
+// why main refuses a seed when TLS is configured. A zero seed restores
+// crypto/rand.
+func SeedRandom(seed uint64) {
+	if seed == 0 {
+		random = rand.Reader
+		return
+	}
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	random = &lockedReader{r: mrand.NewChaCha8(key)}
+}
+
+// lockedReader serializes reads, since ChaCha8 isn't safe for concurrent use.
+type lockedReader struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+func (l *lockedReader) Read(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(b)
+}
+
+// randRead fills b from random.
+func randRead(b []byte) {
+	io.ReadFull(random, b)
+}
+
+// teamSecret draws a math_puzzle team secret in [100, 999].
+func teamSecret() int {
+	var b [2]byte
+	randRead(b[:])
+	return 100 + int(binary.LittleEndian.Uint16(b[:]))%900
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"path/filepath"
 	"sync"
@@ -10,30 +11,43 @@ import (
 )
 
 type Registry struct {
-	dir    string
-	mu     sync.RWMutex
-	stores map[string]*DocStore
+	dir       string
+	maxConns  int // writer pool size for each client DB, see database.Open
+	readConns int // reader pool size; 0 = reads share the writer pool
+	mu        sync.RWMutex
+	stores    map[string]*DocStore
 }
 
-func NewRegistry(dir string) *Registry {
+func NewRegistry(dir string, maxConns, readConns int) *Registry {
 	return &Registry{
-		dir:    dir,
-		stores: make(map[string]*DocStore),
+		dir:       dir,
+		maxConns:  maxConns,
+		readConns: readConns,
+		stores:    make(map[string]*DocStore),
 	}
 }
 
-func (r *Registry) Get(ctx context.Context, slug string) (*DocStore, error) {
+// Get returns the store of a registered client. It never opens a database:
+// only Create does, for clients in the admin DB, so a slug taken from a URL
+// can't conjure up an empty client DB or reach a file that isn't a client's.
+func (r *Registry) Get(slug string) (*DocStore, error) {
 	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	s, ok := r.stores[slug]
-	r.mu.RUnlock()
-	if ok {
-		return s, nil
+	if !ok {
+		return nil, ErrNotFound
 	}
+	return s, nil
+}
 
+// Create opens (creating if needed) the database of a client and registers
+// its store. Call it for clients recorded in the admin DB: at startup for the
+// existing ones, and when a client is created.
+func (r *Registry) Create(ctx context.Context, slug string) (*DocStore, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Double-check after acquiring write lock.
 	if s, ok := r.stores[slug]; ok {
 		return s, nil
 	}
@@ -46,31 +60,39 @@ func (r *Registry) Get(ctx context.Context, slug string) (*DocStore, error) {
 	return s, nil
 }
 
-func (r *Registry) Create(ctx context.Context, slug string) (*DocStore, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	if s, ok := r.stores[slug]; ok {
-		return s, nil
-	}
+// snapshot copies the slug → store map so callers can query every client's
+// DB without holding the lock.
+func (r *Registry) snapshot() map[string]*DocStore {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	s, err := r.open(ctx, slug)
-	if err != nil {
-		return nil, err
+	stores := make(map[string]*DocStore, len(r.stores))
+	for slug, s := range r.stores {
+		stores[slug] = s
 	}
-	r.stores[slug] = s
-	return s, nil
+	return stores
 }
 
 func (r *Registry) open(ctx context.Context, slug string) (*DocStore, error) {
 	dbPath := filepath.Join(r.dir, slug+".db")
-	db, err := database.Open(ctx, dbPath)
+	db, err := database.Open(ctx, dbPath, r.maxConns)
 	if err != nil {
 		return nil, fmt.Errorf("opening client db %q: %w", slug, err)
 	}
-	store, err := NewDocStore(ctx, db)
+	var readDB *sql.DB
+	if r.readConns > 0 {
+		readDB, err = database.Open(ctx, dbPath, r.readConns)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("opening client db reader %q: %w", slug, err)
+		}
+	}
+	store, err := NewDocStore(ctx, db, readDB)
 	if err != nil {
 		db.Close()
+		if readDB != nil {
+			readDB.Close()
+		}
 		return nil, fmt.Errorf("initializing client store %q: %w", slug, err)
 	}
 	return store, nil
@@ -81,7 +103,7 @@ func (r *Registry) Close() error {
 	defer r.mu.Unlock()
 
 	for slug, s := range r.stores {
-		s.db.Close()
+		s.Close()
 		delete(r.stores, slug)
 	}
 	return nil
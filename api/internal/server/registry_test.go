@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestClientIsolation runs two clients through the real routes and registry
+// and checks that nothing of one client is reachable through the other's URLs.
+func TestClientIsolation(t *testing.T) {
+	admin, _ := setupStores(t)
+	dir := t.TempDir()
+	registry := NewRegistry(dir, 1, 2)
+	t.Cleanup(func() { registry.Close() })
+
+	r := chi.NewRouter()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	addRoutes(r, NewBroker(), Options{Logger: logger, Admin: admin, Clients: registry, DataDir: dir, SSEMaxDuration: time.Minute})
+
+	body, _ := json.Marshal(AdminLoginRequest{Email: "admin@playperu.com", Password: "changeme"})
+	login := httptest.NewRequest(http.MethodPost, "/api/admin/login", bytes.NewReader(body))
+	login.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	scenarios, err := admin.ListScenarios(context.Background())
+	if err != nil || len(scenarios) == 0 {
+		t.Fatalf("list scenarios: %v (%d)", err, len(scenarios))
+	}
+
+	type tenant struct {
+		slug   string
+		gameID string
+		game   AdminGameDetail
+		team   AdminTeamItem
+	}
+	tenants := []*tenant{{slug: "alpha"}, {slug: "beta"}}
+	for _, tn := range tenants {
+		if w := do(http.MethodPost, "/api/admin/clients", CreateClientRequest{Slug: tn.slug, Name: tn.slug}); w.Code != http.StatusCreated {
+			t.Fatalf("create client %s: %d %s", tn.slug, w.Code, w.Body.String())
+		}
+		w := do(http.MethodPost, "/api/admin/clients/"+tn.slug+"/games", AdminGameRequest{ScenarioID: scenarios[0].ID, Status: "active"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create game in %s: %d %s", tn.slug, w.Code, w.Body.String())
+		}
+		json.NewDecoder(w.Body).Decode(&tn.game)
+		tn.gameID = tn.game.ID
+
+		w = do(http.MethodPost, "/api/admin/clients/"+tn.slug+"/games/"+tn.gameID+"/teams", AdminTeamRequest{Name: "Team " + tn.slug})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create team in %s: %d %s", tn.slug, w.Code, w.Body.String())
+		}
+		json.NewDecoder(w.Body).Decode(&tn.team)
+	}
+
+	for i, own := range tenants {
+		other := tenants[1-i]
+		base := "/api/admin/clients/" + own.slug
+
+		w := do(http.MethodGet, base+"/games", nil)
+		var games []AdminGameSummary
+		json.NewDecoder(w.Body).Decode(&games)
+		if len(games) != 1 || games[0].ID != own.gameID {
+			t.Errorf("%s: expected only its own game %s, got %+v", own.slug, own.gameID, games)
+		}
+
+		for _, path := range []string{
+			base + "/games/" + other.gameID,
+			base + "/games/" + other.gameID + "/status",
+			base + "/games/" + other.gameID + "/stage-stats",
+			base + "/games/" + other.gameID + "/answers",
+			base + "/games/" + other.gameID + "/results.csv",
+		} {
+			if w := do(http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+				t.Errorf("GET %s: expected 404, got %d: %s", path, w.Code, w.Body.String())
+			}
+		}
+		w = do(http.MethodGet, base+"/games/"+other.gameID+"/teams", nil)
+		if strings.Contains(w.Body.String(), other.team.ID) {
+			t.Errorf("%s: listing %s's teams leaked them: %s", own.slug, other.slug, w.Body.String())
+		}
+		// Writes resolve the game in the URL's client store only, so another
+		// client's game ID is 404 (not 403) and the game is left alone.
+		foreign := base + "/games/" + other.gameID
+		for _, tc := range []struct {
+			method, path string
+			body         any
+		}{
+			{http.MethodPut, foreign, AdminGameRequest{ScenarioID: scenarios[0].ID, Status: "ended", Version: other.game.Version}},
+			{http.MethodDelete, foreign, nil},
+			{http.MethodPost, foreign + "/regrade", RegradeRequest{Confirm: true}},
+			{http.MethodPost, foreign + "/clone", nil},
+			{http.MethodPost, foreign + "/extend", ExtendGameRequest{AddMinutes: 5}},
+			{http.MethodPost, foreign + "/broadcast", BroadcastRequest{Message: "hello"}},
+			{http.MethodPut, foreign + "/teams/" + other.team.ID, AdminTeamRequest{Name: "Hijacked", Version: other.team.Version}},
+			{http.MethodDelete, foreign + "/teams/" + other.team.ID, nil},
+			{http.MethodGet, foreign + "/teams/" + other.team.ID + "/sessions", nil},
+		} {
+			if w := do(tc.method, tc.path, tc.body); w.Code != http.StatusNotFound {
+				t.Errorf("%s %s: expected 404, got %d: %s", tc.method, tc.path, w.Code, w.Body.String())
+			}
+		}
+		w = do(http.MethodGet, "/api/admin/clients/"+other.slug+"/games/"+other.gameID, nil)
+		var still AdminGameDetail
+		json.NewDecoder(w.Body).Decode(&still)
+		if w.Code != http.StatusOK || still.Status != "active" || len(still.Teams) != 1 || still.Teams[0].Name != other.team.Name {
+			t.Errorf("%s's game changed through %s's routes: %d %+v", other.slug, own.slug, w.Code, still)
+		}
+
+		// Players can't reach the other client's teams either.
+		if w := do(http.MethodGet, "/api/"+own.slug+"/teams/"+other.team.JoinToken, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: looking up %s's join token: expected 404, got %d", own.slug, other.slug, w.Code)
+		}
+		if w := do(http.MethodPost, "/api/"+own.slug+"/join", JoinRequest{JoinToken: other.team.JoinToken, PlayerName: "Mallory"}); w.Code != http.StatusNotFound {
+			t.Errorf("%s: joining with %s's join token: expected 404, got %d", own.slug, other.slug, w.Code)
+		}
+	}
+
+	// Unknown clients are 404 and don't get a database created for them.
+	if w := do(http.MethodGet, "/api/admin/clients/gamma/games", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown client: expected 404, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/gamma/teams/whatever", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown client (player): expected 404, got %d", w.Code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gamma.db")); !os.IsNotExist(err) {
+		t.Errorf("expected no gamma.db, stat err = %v", err)
+	}
+}
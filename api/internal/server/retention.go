@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// retentionInterval is how often CleanUpGames looks for expired games.
+const retentionInterval = time.Hour
+
+// CleanUpGames deletes, in every client, the games that ended more than
+// retention ago, with their player sessions, until ctx is cancelled. It runs
+// once at startup and then every retentionInterval, and logs each game it
+// removes. Games with a team still connected to the event stream are kept
+// for the next round. A retention of zero disables the cleanup.
+func (s *Server) CleanUpGames(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	s.logger.Info("game retention enabled", "retention", retention)
+	cleanUpGames(ctx, s.logger, s.clients, s.broker, time.Now().Add(-retention))
+
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cleanUpGames(ctx, s.logger, s.clients, s.broker, now.Add(-retention))
+		}
+	}
+}
+
+// cleanUpGames is one round of CleanUpGames over every client, removing the
+// games that ended before endedBefore.
+func cleanUpGames(ctx context.Context, logger *slog.Logger, clients *Registry, broker *Broker, endedBefore time.Time) {
+	for slug, store := range clients.snapshot() {
+		expired, err := expiredGames(ctx, store, broker, endedBefore)
+		if err != nil {
+			logger.Error("listing expired games", "client", slug, "error", err)
+			continue
+		}
+		for _, g := range expired {
+			deleted, err := store.DeleteExpiredGame(ctx, g.ID, endedBefore)
+			if err != nil {
+				logger.Error("removing expired game", "client", slug, "game", g.ID, "error", err)
+				continue
+			}
+			if deleted {
+				logger.Info("removed expired game", "client", slug, "game", g.ID,
+					"scenario", g.ScenarioName, "endedAt", g.EndedAt, "players", g.Players)
+			}
+		}
+	}
+}
+
+// expiredGames returns the games the retention cleanup removes: those that
+// ended before endedBefore and have no team connected. GET cleanup-preview
+// lists the same games.
+func expiredGames(ctx context.Context, store Store, broker *Broker, endedBefore time.Time) ([]ExpiredGame, error) {
+	expired, err := store.ExpiredGames(ctx, endedBefore)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(expired, func(g ExpiredGame) bool {
+		return slices.ContainsFunc(g.teamIDs, func(id string) bool { return broker.Subscribers(id) > 0 })
+	}), nil
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCleanUpGames(t *testing.T) {
+	_, store := setupStores(t)
+	ctx := context.Background()
+	registry := NewRegistry(t.TempDir(), 1, 0)
+	registry.stores["demo"] = store
+	broker := NewBroker()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	now := time.Now()
+	cutoff := now.Add(-30 * 24 * time.Hour)
+
+	// The demo game, with a player, ended 40 days ago.
+	_, sessionID, err := store.JoinTeam(ctx, demoSeed.GameID, demoIncas.ID, "Ana", "player")
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	endedAt := func(ago time.Duration) *string {
+		s := now.Add(-ago).UTC().Format(time.RFC3339Nano)
+		return &s
+	}
+	if err := store.modifyGame(ctx, demoSeed.GameID, func(g *game) error {
+		g.Status = "ended"
+		g.EndedAt = endedAt(40 * 24 * time.Hour)
+		return nil
+	}); err != nil {
+		t.Fatalf("end demo game: %v", err)
+	}
+
+	// A game that ended yesterday, and an old one whose team is still
+	// connected.
+	old, err := store.getGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent := old
+	recent.ID, recent.Teams = "grecent", nil
+	recent.EndedAt = endedAt(24 * time.Hour)
+	connected := old
+	connected.ID = "gconnected"
+	connected.Teams = []team{{ID: "tconnected", Name: "Live", JoinToken: "live"}}
+	for _, g := range []*game{&recent, &connected} {
+		if err := store.putGame(ctx, g); err != nil {
+			t.Fatalf("put %s: %v", g.ID, err)
+		}
+	}
+	live := broker.Subscribe("tconnected", 0)
+
+	cleanUpGames(ctx, logger, registry, broker, cutoff)
+
+	if _, err := store.GetGame(ctx, demoSeed.GameID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old game: expected it removed, got %v", err)
+	}
+	if _, err := store.PlayerFromToken(ctx, sessionID); !errors.Is(err, errNoSession) {
+		t.Errorf("old game's session: expected it removed, got %v", err)
+	}
+	if _, err := store.GetGame(ctx, "grecent"); err != nil {
+		t.Errorf("recent game: expected it kept, got %v", err)
+	}
+	if _, err := store.GetGame(ctx, "gconnected"); err != nil {
+		t.Errorf("connected game: expected it kept, got %v", err)
+	}
+
+	// Once the team disconnects, the next round removes it.
+	broker.Unsubscribe("tconnected", live)
+	cleanUpGames(ctx, logger, registry, broker, cutoff)
+	if _, err := store.GetGame(ctx, "gconnected"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("disconnected game: expected it removed, got %v", err)
+	}
+	if _, err := store.GetGame(ctx, "grecent"); err != nil {
+		t.Errorf("recent game: expected it kept, got %v", err)
+	}
+}
+
+func TestAdminCleanupPreview(t *testing.T) {
+	r, login, store, _ := adminRouterWithStore(t)
+	cookies := login()
+	ctx := context.Background()
+
+	ended := func(gameID string, ago time.Duration) {
+		t.Helper()
+		at := time.Now().Add(-ago).UTC().Format(time.RFC3339Nano)
+		if err := store.modifyGame(ctx, gameID, func(g *game) error {
+			g.Status, g.EndedAt = "ended", &at
+			return nil
+		}); err != nil {
+			t.Fatalf("end %s: %v", gameID, err)
+		}
+	}
+	ended(demoSeed.GameID, 40*24*time.Hour)
+	recent, err := store.getGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent.ID, recent.Teams = "grecent", nil
+	if err := store.putGame(ctx, &recent); err != nil {
+		t.Fatal(err)
+	}
+	ended("grecent", 24*time.Hour)
+
+	preview := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/clients/demo/cleanup-preview"+query, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preview("?retention=720h")
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp CleanupPreviewResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Enabled || resp.Retention != "720h0m0s" {
+		t.Errorf("expected a disabled 720h preview, got enabled=%v retention=%s", resp.Enabled, resp.Retention)
+	}
+	if len(resp.Games) != 1 || resp.Games[0].ID != demoSeed.GameID || resp.Games[0].Teams != len(demoSeed.Teams) {
+		t.Errorf("expected only the demo game with %d teams, got %+v", len(demoSeed.Teams), resp.Games)
+	}
+
+	// Previewing removes nothing.
+	for _, id := range []string{demoSeed.GameID, "grecent"} {
+		if _, err := store.GetGame(ctx, id); err != nil {
+			t.Errorf("%s: expected it kept, got %v", id, err)
+		}
+	}
+
+	// Without GAME_RETENTION, a period has to be given.
+	if w := preview(""); w.Code != http.StatusBadRequest {
+		t.Errorf("no retention: expected 400, got %d", w.Code)
+	}
+	if w := preview("?retention=soon"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad retention: expected 400, got %d", w.Code)
+	}
+}
@@ -1,8 +1,6 @@
 package server
 
 import (
-	"database/sql"
-	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -11,22 +9,37 @@ import (
 	"github.com/swaggest/swgui/v5emb"
 )
 
-func addRoutes(r chi.Router, logger *slog.Logger, admin AdminStore, clients *Registry, adminDB *sql.DB, spaDir, dataDir string) {
-	broker := NewBroker()
+func addRoutes(r chi.Router, broker *Broker, opts Options) {
+	logger, admin, clients := opts.Logger, opts.Admin, opts.Clients
+	spaDir, dataDir, limits := opts.SPADir, opts.DataDir, opts.Limits
+	maxAnswerLen, publicOrigins, build := opts.MaxAnswerLen, opts.PublicOrigins, opts.Build
+	idem := NewIdempotencyCache()
 
 	r.Get("/openapi.json", handleOpenAPI())
 	r.Mount("/docs", v5emb.New("CityQuest API", "/openapi.json", "/docs"))
-	r.Get("/healthz", handleHealth(logger, adminDB))
+	r.Get("/healthz", handleHealth(logger, opts.AdminDB, newDiskCheck(clients.dir, opts.MinFreeDiskMB), build))
+	r.Get("/api/version", handleVersion(build))
+
+	// Public listings — no auth, readable cross-origin from publicOrigins.
+	r.With(publicCORS(publicOrigins)).Get("/api/public/scenarios", handlePublicScenarios(admin))
 
 	// Player routes — {client} resolved by clientMiddleware.
 	r.Route("/api/{client}", func(r chi.Router) {
 		r.Use(clientMiddleware(clients))
+		r.Use(requireJSON)
 		r.Get("/teams/{joinToken}", handleTeamLookup())
+		r.Get("/games/pin/{pin}", handleGamePIN())
+		r.Get("/branding", handleBranding(admin))
 		r.Post("/join", handleJoin(broker))
-		r.Get("/game/state", handleGameState())
-		r.Post("/game/answer", handleAnswer(broker))
-		r.Post("/game/unlock", handleUnlock(broker))
-		r.Get("/game/events", handleEvents(broker))
+		r.Get("/game/resume", handleResume())
+		r.With(compressJSON).Get("/game/state", handleGameState(broker))
+		r.Get("/game/recap", handleRecap())
+		r.Post("/game/answer", handleAnswer(broker, maxAnswerLen))
+		r.Post("/game/unlock", handleUnlock(broker, maxAnswerLen))
+		r.Post("/game/next", handleNext(broker))
+		r.Post("/game/giveup", handleGiveUp(broker))
+		r.Post("/game/confirm", handleConfirm(broker))
+		r.Get("/game/events", handleEvents(broker, opts.SSEMaxDuration, opts.SSEBuffer))
 	})
 
 	// Uploaded images — public, no auth.
@@ -34,11 +47,11 @@ func addRoutes(r chi.Router, logger *slog.Logger, admin AdminStore, clients *Reg
 	r.Handle("/uploads/*", http.StripPrefix("/uploads/", http.FileServer(http.Dir(uploadsDir))))
 
 	// Admin auth — shared DB.
-	r.Post("/api/admin/login", handleAdminLogin(admin))
-	r.Post("/api/admin/logout", handleAdminLogout(admin))
+	r.With(requireJSON).Post("/api/admin/login", handleAdminLogin(admin))
+	r.With(requireJSON).Post("/api/admin/logout", handleAdminLogout(admin))
 	r.Get("/api/admin/me", handleAdminMe(admin))
 	r.Get("/api/admin/clients", handleAdminListClients(admin))
-	r.Post("/api/admin/clients", handleAdminCreateClient(admin, clients))
+	r.With(requireJSON).Post("/api/admin/clients", handleAdminCreateClient(admin, clients))
 
 	// Admin file upload.
 	r.With(adminAuthMiddleware(admin)).Post("/api/admin/uploads", handleUpload(dataDir))
@@ -47,29 +60,51 @@ func addRoutes(r chi.Router, logger *slog.Logger, admin AdminStore, clients *Reg
 	r.Route("/api/admin/scenarios", func(r chi.Router) {
 		r.Use(adminAuthMiddleware(admin))
 		r.Get("/", handleAdminListScenarios(admin))
-		r.Post("/", handleAdminCreateScenario(admin))
+		r.With(requireJSON, idempotent(idem)).Post("/", handleAdminCreateScenario(admin))
+		r.With(requireJSON).Post("/validate", handleAdminValidateScenario(admin))
+		r.Get("/export-all", handleAdminExportAllScenarios(admin, dataDir))
 		r.Get("/{id}", handleAdminGetScenario(admin))
 		r.Get("/{id}/export", handleAdminExportScenario(admin, dataDir))
-		r.Put("/{id}", handleAdminUpdateScenario(admin))
+		r.Get("/{id}/usage", handleAdminScenarioUsage(admin, clients))
+		r.With(requireJSON).Put("/{id}", handleAdminUpdateScenario(admin))
+		r.With(requireJSON).Post("/{id}/mode", handleAdminChangeScenarioMode(admin))
+		r.With(requireJSON).Post("/{id}/test-unlock", handleAdminTestUnlock(admin))
 		r.Delete("/{id}", handleAdminDeleteScenario(admin, clients))
-		r.Post("/import", handleAdminImportScenario(admin, dataDir))
+		r.Post("/import", handleAdminImportScenario(admin, dataDir)) // multipart, .md or .zip
 	})
 
 	// Admin games/teams — per-client, requires admin auth.
 	r.Route("/api/admin/clients/{client}", func(r chi.Router) {
 		r.Use(adminAuthMiddleware(admin))
 		r.Use(clientMiddleware(clients))
+		r.Use(requireJSON)
 
 		r.Get("/games", handleAdminListGames())
-		r.Post("/games", handleAdminCreateGame(admin))
+		r.With(idempotent(idem)).Post("/games", handleAdminCreateGame(admin, limits))
 		r.Get("/games/{gameID}", handleAdminGetGame())
-		r.Put("/games/{gameID}", handleAdminUpdateGame(admin))
+		r.Put("/games/{gameID}", handleAdminUpdateGame(admin, limits))
 		r.Delete("/games/{gameID}", handleAdminDeleteGame())
-		r.Get("/games/{gameID}/status", handleAdminGameStatus())
+		r.Get("/games/{gameID}/status", handleAdminGameStatus(broker))
+		r.Post("/games/{gameID}/regrade", handleAdminRegradeGame())
+		r.Post("/games/{gameID}/repair-stages", handleAdminRepairStages(admin))
+		r.Post("/games/{gameID}/clone", handleAdminCloneGame(admin))
+		r.Get("/games/{gameID}/stage-stats", handleAdminStageStats())
+		r.Get("/games/{gameID}/answers", handleAdminAnswerLog())
+		r.Get("/games/{gameID}/results.csv", handleAdminGameResultsCSV())
+		r.Get("/games/{gameID}/event-log", handleAdminEventLog())
+		r.Get("/games/{gameID}/timeline", handleAdminTimeline())
+		r.Post("/games/{gameID}/extend", handleAdminExtendGame(broker, limits))
+		r.Post("/games/{gameID}/broadcast", handleAdminBroadcast(broker))
 		r.Get("/games/{gameID}/teams", handleAdminListTeams())
-		r.Post("/games/{gameID}/teams", handleAdminCreateTeam())
+		r.With(idempotent(idem)).Post("/games/{gameID}/teams", handleAdminCreateTeam(admin))
 		r.Put("/games/{gameID}/teams/{teamID}", handleAdminUpdateTeam())
 		r.Delete("/games/{gameID}/teams/{teamID}", handleAdminDeleteTeam())
+		r.Get("/games/{gameID}/teams/{teamID}/sessions", handleAdminTeamSessions())
+		r.Post("/games/{gameID}/teams/{teamID}/unlock", handleAdminUnlockTeamStage(broker))
+		r.Get("/cleanup-preview", handleAdminCleanupPreview(broker, opts.GameRetention))
+		r.Get("/branding", handleAdminGetBranding(admin))
+		r.Put("/branding", handleAdminUpdateBranding(admin))
+		r.Delete("/branding", handleAdminDeleteBranding(admin))
 	})
 
 	if spaDir != "" {
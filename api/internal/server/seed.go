@@ -5,6 +5,32 @@ import (
 	"log/slog"
 )
 
+// DemoInfo identifies the demo data SeedDemo creates.
+type DemoInfo struct {
+	ScenarioID string     `json:"scenarioId"`
+	GameID     string     `json:"gameId"`
+	Teams      []DemoTeam `json:"teams"`
+}
+
+// DemoTeam is one of the demo game's teams.
+type DemoTeam struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	JoinToken string `json:"joinToken"`
+}
+
+// demoSeed is what SeedDemo creates. The IDs never change so existing demo
+// links keep working, but they are spelled out only here: code that needs
+// them reads demoSeed, or DocStore.DemoInfo for the game as it is now.
+var demoSeed = DemoInfo{
+	ScenarioID: "s0000000deadbeef",
+	GameID:     "g0000000deadbeef",
+	Teams: []DemoTeam{
+		{ID: "t000000000incas", Name: "Los Incas", JoinToken: "incas-2025"},
+		{ID: "t00000000condor", Name: "Los Condores", JoinToken: "condores-2025"},
+	},
+}
+
 // SeedDemo creates the demo client, scenario, and game if no clients exist.
 // Idempotent: does nothing if clients already exist.
 func SeedDemo(ctx context.Context, logger *slog.Logger, admin *AdminDocStore, clients *Registry) error {
@@ -21,7 +47,7 @@ func SeedDemo(ctx context.Context, logger *slog.Logger, admin *AdminDocStore, cl
 		return err
 	}
 
-	if err := admin.CreateClient(ctx, "demo", "Demo"); err != nil {
+	if err := admin.CreateClient(ctx, ClientInfo{Slug: "demo", Name: "Demo"}); err != nil {
 		return err
 	}
 	store, err := clients.Create(ctx, "demo")
@@ -17,33 +17,66 @@ import (
 )
 
 type Server struct {
-	tcpSrv *http.Server
-	h3Srv  *http3.Server // nil when TLS not configured
-	logger *slog.Logger
+	tcpSrv  *http.Server
+	h3Srv   *http3.Server // nil when TLS not configured
+	logger  *slog.Logger
+	broker  *Broker
+	clients *Registry
 }
 
-func New(addr string, logger *slog.Logger, admin AdminStore, clients *Registry, adminDB *sql.DB, spaDir, dataDir string, tlsCert, tlsKey string) *Server {
+// Options is everything New needs to build the server. Zero values are
+// safe for the optional ones: no TLS, no SPA, no timeouts or caps.
+type Options struct {
+	Addr    string
+	Logger  *slog.Logger
+	Admin   AdminStore
+	Clients *Registry
+	AdminDB *sql.DB
+	SPADir  string
+	DataDir string
+	TLSCert string
+	TLSKey  string
+
+	SSEMaxDuration time.Duration
+	SSEBuffer      SSEBuffer
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	Limits         TimerLimits
+	MaxAnswerLen   int
+	MinFreeDiskMB  int
+	PublicOrigins  []string
+	GameRetention  time.Duration
+	Build          BuildInfo
+}
+
+func New(opts Options) *Server {
+	logger := opts.Logger
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(requestTimeouts(opts.ReadTimeout, opts.WriteTimeout))
 	r.Use(newStructuredLogger(logger))
 	r.Use(middleware.Recoverer)
+	r.Use(localizeErrors)
 
-	addRoutes(r, logger, admin, clients, adminDB, spaDir, dataDir)
+	broker := NewBroker()
+	addRoutes(r, broker, opts)
 
 	s := &Server{
 		tcpSrv: &http.Server{
-			Addr:              addr,
+			Addr:              opts.Addr,
 			Handler:           r,
 			ReadHeaderTimeout: 5 * time.Second,
 			IdleTimeout:       120 * time.Second,
 		},
-		logger: logger,
+		logger:  logger,
+		broker:  broker,
+		clients: opts.Clients,
 	}
 
-	if tlsCert != "" && tlsKey != "" {
-		cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
+	if opts.TLSCert != "" && opts.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCert, opts.TLSKey)
 		if err != nil {
 			logger.Error("failed to load TLS cert, falling back to plain HTTP", "error", err)
 			return s
@@ -57,7 +90,7 @@ func New(addr string, logger *slog.Logger, admin AdminStore, clients *Registry,
 		s.tcpSrv.TLSConfig = tlsConfig
 
 		s.h3Srv = &http3.Server{
-			Addr:      addr,
+			Addr:      opts.Addr,
 			Handler:   r,
 			TLSConfig: http3.ConfigureTLSConfig(tlsConfig.Clone()),
 		}
@@ -2,11 +2,42 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 )
 
 var ErrNotFound = errors.New("not found")
 
+// ErrAlreadyAnswered is returned when a player submits a second answer for a
+// stage that is still waiting on the rest of the team.
+var ErrAlreadyAnswered = errors.New("already answered")
+
+// ErrVersionConflict is returned when an update carries a version other than
+// the stored one, i.e. someone else saved in between.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrNothingToAdvance is returned by AdvanceTeam when the team isn't held on
+// a result (no pendingAdvance).
+var ErrNothingToAdvance = errors.New("nothing to advance")
+
+// ErrNothingToConfirm is returned by ConfirmStage when the team's stage isn't
+// unlocked yet or its timer has already started.
+var ErrNothingToConfirm = errors.New("nothing to confirm")
+
+// ErrCorruptStages is returned when a game's stored stages don't decode, e.g.
+// after a hand edit or a write by an incompatible version. ResnapshotStages
+// repairs the game from its scenario.
+var ErrCorruptStages = errors.New("game stages are corrupt")
+
+// Errors returned by ExtendGameTimer.
+var (
+	ErrGameEnded     = errors.New("game has ended")
+	ErrTimerDisabled = errors.New("game has no timer")
+	ErrTimerLimit    = errors.New("timer limit exceeded")
+)
+
 type sessionInfo struct {
 	PlayerID string
 	TeamID   string
@@ -29,20 +60,195 @@ type gameStateData struct {
 	StartStage        int
 	UnlockedStages    []int
 	StageUnlockedAt   *string
+	GameSettings
+	Outro              string
+	PendingPlayerIDs   []string // players who answered the current stage (RequireAllPlayers only)
+	PendingAdvance     bool     // answered, held on the result until POST /game/next
+	ConfirmedPlayerIDs []string // players who confirmed the unlocked stage (WaitForConfirmations only)
+	ConfirmRequired    int      // players who must confirm before the stage timer starts
+	MinPlayersToStart  int
+	MaxAttempts        int // wrong answers allowed per stage; 0 = the first answer settles it
+	TeamPlayers        int // players on the team, supervisor aside
+}
+
+// teamProgress is a team's game state with its completed stages, for
+// handlers that need both on every request.
+type teamProgress struct {
+	gameStateData
+	Completed []CompletedStage
+}
+
+// answered is how many stages the team has answered, right or wrong.
+func (p teamProgress) answered() int {
+	return len(p.Completed)
+}
+
+// score adds up the points of the team's results.
+func (p teamProgress) score() int {
+	score := 0
+	for _, c := range p.Completed {
+		score += c.Points
+	}
+	return score
+}
+
+// opensFirstStage reports whether the team's first stage should be unlocked
+// for it now: in an active firstStageUnlocked game, before the team has
+// unlocked or answered anything, and once enough players have joined to
+// start.
+func (p teamProgress) opensFirstStage() bool {
+	return p.firstStageUnlocked(p.Mode) && p.Status == "active" && !p.waitingForTeammates() &&
+		p.answered() == 0 && len(p.UnlockedStages) == 0
+}
+
+// forPlayer narrows the progress to playerID's own results in an
+// individualScoring game, where each player has their own way through the
+// stages. Other games' progress is the team's and comes back unchanged.
+func (p teamProgress) forPlayer(playerID string) teamProgress {
+	p.Completed = p.ownStages(p.Completed, playerID)
+	return p
+}
+
+// ownStages keeps playerID's completed stages in an individualScoring game;
+// otherwise every completed stage is the team's and they all count.
+func (d gameStateData) ownStages(completed []CompletedStage, playerID string) []CompletedStage {
+	if !d.individualScoring(d.Mode) {
+		return completed
+	}
+	var own []CompletedStage
+	for _, c := range completed {
+		if c.PlayerID == playerID {
+			own = append(own, c)
+		}
+	}
+	return own
+}
+
+// scenarioStages decodes the team's stages.
+func (d gameStateData) scenarioStages() ([]scenarioStage, error) {
+	var stages []scenarioStage
+	if err := json.Unmarshal([]byte(d.StagesJSON), &stages); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptStages, err)
+	}
+	return stages, nil
+}
+
+func (d gameStateData) answerRules() answerRules {
+	return answerRules{TrimPunctuation: d.TrimPunctuation}
+}
+
+// canAdvance reports whether a session with role may move the team on in a
+// manualAdvance game: the supervisor in supervised games, any player otherwise.
+func (d gameStateData) canAdvance(role string) bool {
+	if !d.ManualAdvance || !modeHasQuestion(d.Mode) || role == "spectator" {
+		return false
+	}
+	return !d.Supervised || role == "supervisor"
+}
+
+// stageLocation is the location a session with role sees for a stage: with
+// hideLocationFromPlayers it's a reminder for the supervisor only, the clue
+// being what leads players there.
+func (d gameStateData) stageLocation(role, location string) string {
+	if d.HideLocationFromPlayers && role != "supervisor" {
+		return ""
+	}
+	return location
+}
+
+// stageRemaining reports how long the team has left on its stage timer at
+// now, counted from stageUnlockedAt so every device on the team sees the same
+// countdown. running is false when no stage timer is ticking; remaining is
+// zero or less once it has run out.
+func (d gameStateData) stageRemaining(now time.Time) (remaining time.Duration, running bool) {
+	deadline, running := d.stageDeadline()
+	if !running {
+		return 0, false
+	}
+	return deadline.Sub(now), true
+}
+
+// stageDeadline is when the team's stage timer runs out: stageUnlockedAt
+// plus stageTimerMinutes. running is false when no stage timer is ticking.
+func (d gameStateData) stageDeadline() (deadline time.Time, running bool) {
+	if d.StageTimerMinutes <= 0 || d.StageUnlockedAt == nil {
+		return time.Time{}, false
+	}
+	unlockTime, err := time.Parse(time.RFC3339Nano, *d.StageUnlockedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return unlockTime.Add(time.Duration(d.StageTimerMinutes) * time.Minute), true
+}
+
+// waitingForTeammates reports whether a game with minPlayersToStart holds the
+// team because too few players have joined it yet.
+func (d gameStateData) waitingForTeammates() bool {
+	return d.MinPlayersToStart > 0 && d.TeamPlayers < d.MinPlayersToStart
+}
+
+// maxAttempts is the number of wrong answers the game allows per question, 0
+// when one answer settles it. Modes without questions have no attempts.
+func (d gameStateData) maxAttempts() int {
+	if !modeHasQuestion(d.Mode) {
+		return 0
+	}
+	return d.MaxAttempts
+}
+
+// awaitingConfirmations reports whether the current stage is unlocked but its
+// timer is waiting on players' POST /game/confirm.
+func (d gameStateData) awaitingConfirmations(currentStageNum int) bool {
+	return d.WaitForConfirmations && d.Supervised && !d.PendingAdvance &&
+		isStageUnlocked(d.UnlockedStages, currentStageNum) && d.StageUnlockedAt == nil
+}
+
+// confirmProgress reports how many players have confirmed a team's unlocked
+// stage. Started is set once they all have and the stage timer is running.
+type confirmProgress struct {
+	StageNumber int
+	PlayerName  string
+	Confirmed   int
+	Required    int
+	Started     bool
+}
+
+// answerProgress reports how far a team is through collecting every
+// player's answer for a stage. Complete is set once the team result has been
+// recorded; IsCorrect is the graded team result.
+type answerProgress struct {
+	PlayerName string
+	Answered   int
+	Required   int
+	Complete   bool
+	IsCorrect  bool
 }
 
 type Store interface {
 	PlayerFromToken(ctx context.Context, token string) (sessionInfo, error)
 
 	TeamLookup(ctx context.Context, joinToken string) (TeamLookupResponse, error)
+	GameByPIN(ctx context.Context, pin string) (GamePINResponse, error)
 	JoinTeam(ctx context.Context, gameID, teamID, playerName, role string) (playerID, sessionID string, err error)
+	SessionTeam(ctx context.Context, gameID, teamID string) (TeamLookupResponse, error)
 	GameState(ctx context.Context, gameID, teamID string) (gameStateData, error)
+	GameScenarioStatus(ctx context.Context, gameID string) (scenarioID, status string, err error)
+	ResnapshotStages(ctx context.Context, gameID string, stages []AdminStage) (version int, err error)
+	TeamProgress(ctx context.Context, gameID, teamID string) (teamProgress, error)
 	ExpireGame(ctx context.Context, gameID string) error
 	CountAnsweredStages(ctx context.Context, gameID, teamID string) (int, error)
 	CountCorrectAnswers(ctx context.Context, gameID, teamID string) (int, error)
-	RecordAnswer(ctx context.Context, gameID, teamID string, stageNumber int, answer string, isCorrect bool) error
-	UnlockStage(ctx context.Context, gameID, teamID string, stageNumber int) error
-	UnlockAndCompleteStage(ctx context.Context, gameID, teamID string, stageNumber int) error
+	RecordAnswer(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string, isCorrect bool) error
+	RecordAttempt(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string) (attempts int, failed bool, err error)
+	CountAttempts(ctx context.Context, gameID, teamID string, stageNumber int) (int, error)
+	RecordPlayerAnswer(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string, isCorrect bool) (answerProgress, error)
+	UnlockStage(ctx context.Context, gameID, teamID string, stageNumbers ...int) error
+	UnlockAndCompleteStage(ctx context.Context, gameID, teamID string, stageNumbers ...int) error
+	RecordAdminUnlock(ctx context.Context, gameID, teamID string, stageNumber int) error
+	AdvanceTeam(ctx context.Context, gameID, teamID string) (answered int, err error)
+	TimeOutStage(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string) (bool, error)
+	GiveUpStage(ctx context.Context, gameID, teamID, playerID string, stageNumber int) (bool, error)
+	ConfirmStage(ctx context.Context, gameID, teamID, playerID string) (confirmProgress, error)
 	ListPlayers(ctx context.Context, gameID, teamID string) ([]PlayerInfo, error)
 	ListCompletedStages(ctx context.Context, gameID, teamID string) ([]CompletedStage, error)
 
@@ -56,9 +262,19 @@ type Store interface {
 
 	ListTeams(ctx context.Context, gameID string) ([]AdminTeamItem, error)
 	CreateTeam(ctx context.Context, gameID string, req AdminTeamRequest, token string) (AdminTeamItem, error)
+	CreateTeams(ctx context.Context, gameID string, reqs []AdminTeamRequest, generate func() string) ([]AdminTeamItem, error)
 	UpdateTeam(ctx context.Context, gameID, teamID string, req AdminTeamRequest) (AdminTeamItem, error)
 	DeleteTeam(ctx context.Context, gameID, teamID string) error
 	TeamHasPlayers(ctx context.Context, gameID, teamID string) (bool, error)
+	TeamSessions(ctx context.Context, gameID, teamID string) ([]AdminTeamSession, error)
 	GameExists(ctx context.Context, gameID string) (bool, error)
 	GameStatus(ctx context.Context, gameID string) (AdminGameStatus, error)
+	RegradeGame(ctx context.Context, gameID string, corrections map[int]string) (flipped, checked int, err error)
+	StageStats(ctx context.Context, gameID string) ([]AdminStageStats, error)
+	AnswerLog(ctx context.Context, gameID string) ([]AdminStageAnswers, error)
+	GameResults(ctx context.Context, gameID string) (AdminGameResults, error)
+	ExtendGameTimer(ctx context.Context, gameID string, addMinutes, maxMinutes int) (AdminGameDetail, error)
+	ExpiredGames(ctx context.Context, endedBefore time.Time) ([]ExpiredGame, error)
+	LogGameEvent(ctx context.Context, gameID string, ev GameEvent) error
+	GameEventLog(ctx context.Context, gameID string) ([]GameEvent, error)
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 )
 
 type AdminStore interface {
@@ -14,19 +15,32 @@ type AdminStore interface {
 	DeleteAdminSession(ctx context.Context, sessionID string) error
 	AdminFromSession(ctx context.Context, sessionID string) (adminSession, error)
 	ListClients(ctx context.Context) ([]ClientInfo, error)
-	CreateClient(ctx context.Context, slug, name string) error
+	GetClient(ctx context.Context, slug string) (ClientInfo, error)
+	CreateClient(ctx context.Context, c ClientInfo) error
+	SetClientBranding(ctx context.Context, slug string, b Branding) error
 
 	ListScenarios(ctx context.Context) ([]AdminScenarioSummary, error)
+	ListPublicScenarios(ctx context.Context) ([]PublicScenario, error)
 	CreateScenario(ctx context.Context, req AdminScenarioRequest) (AdminScenarioDetail, error)
 	GetScenario(ctx context.Context, id string) (AdminScenarioDetail, error)
 	UpdateScenario(ctx context.Context, id string, req AdminScenarioRequest) (AdminScenarioDetail, error)
 	DeleteScenario(ctx context.Context, id string) error
 	ScenarioHasGames(ctx context.Context, scenarioID string, clients *Registry) (bool, error)
+	ScenarioUsage(ctx context.Context, scenarioID string, clients *Registry) ([]ScenarioUsage, error)
 }
 
 type ClientInfo struct {
-	Slug string `json:"slug"`
-	Name string `json:"name"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	TokenStyle  string `json:"tokenStyle"`
+	PINLength   int    `json:"pinLength,omitempty"`
+	DefaultMode string   `json:"defaultMode,omitempty"` // mode for new scenarios that don't name one
+	Branding    Branding `json:"branding"`
+}
+
+// tokenFormat is the format of the client's auto-generated join tokens.
+func (c ClientInfo) tokenFormat() JoinTokenFormat {
+	return JoinTokenFormat{Style: c.TokenStyle, PINLength: c.PINLength}
 }
 
 type adminDoc struct {
@@ -57,8 +71,12 @@ func NewAdminDocStore(ctx context.Context, db *sql.DB) (*AdminDocStore, error) {
 			data JSONB NOT NULL
 		)`,
 		`CREATE TABLE IF NOT EXISTS clients (
-			slug TEXT PRIMARY KEY,
-			name TEXT NOT NULL
+			slug        TEXT PRIMARY KEY,
+			name        TEXT NOT NULL,
+			token_style  TEXT NOT NULL DEFAULT 'hex',
+			pin_length   INTEGER NOT NULL DEFAULT 0,
+			default_mode TEXT NOT NULL DEFAULT '',
+			branding     JSONB
 		)`,
 		`CREATE TABLE IF NOT EXISTS scenarios (
 			id   TEXT PRIMARY KEY,
@@ -71,6 +89,19 @@ func NewAdminDocStore(ctx context.Context, db *sql.DB) (*AdminDocStore, error) {
 		}
 	}
 
+	// Databases created before join-token formats, default modes and
+	// branding existed lack these columns.
+	for _, col := range []struct{ name, decl string }{
+		{"token_style", `TEXT NOT NULL DEFAULT 'hex'`},
+		{"pin_length", `INTEGER NOT NULL DEFAULT 0`},
+		{"default_mode", `TEXT NOT NULL DEFAULT ''`},
+		{"branding", `JSONB`},
+	} {
+		if err := addColumnIfMissing(ctx, db, "clients", col.name, col.decl); err != nil {
+			return nil, fmt.Errorf("migrating clients: %w", err)
+		}
+	}
+
 	s := &AdminDocStore{db: db}
 	if err := s.seedIfEmpty(ctx); err != nil {
 		return nil, fmt.Errorf("seeding admin: %w", err)
@@ -78,6 +109,18 @@ func NewAdminDocStore(ctx context.Context, db *sql.DB) (*AdminDocStore, error) {
 	return s, nil
 }
 
+func addColumnIfMissing(ctx context.Context, db *sql.DB, table, column, decl string) error {
+	var n int
+	err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column,
+	).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, decl))
+	return err
+}
+
 func (s *AdminDocStore) seedIfEmpty(ctx context.Context) error {
 	var count int
 	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM admins`).Scan(&count); err != nil {
@@ -179,8 +222,25 @@ func (s *AdminDocStore) AdminFromSession(ctx context.Context, sessionID string)
 	return adminSession{AdminID: as.AdminID, Email: as.Email}, nil
 }
 
+// clientColumns are the clients columns scanClient reads, in order.
+const clientColumns = `slug, name, token_style, pin_length, default_mode, COALESCE(json(branding), '{}')`
+
+func scanClient(row interface{ Scan(...any) error }) (ClientInfo, error) {
+	var c ClientInfo
+	var branding string
+	if err := row.Scan(&c.Slug, &c.Name, &c.TokenStyle, &c.PINLength, &c.DefaultMode, &branding); err != nil {
+		return ClientInfo{}, err
+	}
+	if err := json.Unmarshal([]byte(branding), &c.Branding); err != nil {
+		return ClientInfo{}, fmt.Errorf("decoding branding of client %q: %w", c.Slug, err)
+	}
+	return c, nil
+}
+
 func (s *AdminDocStore) ListClients(ctx context.Context) ([]ClientInfo, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT slug, name FROM clients ORDER BY slug`)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+clientColumns+` FROM clients ORDER BY slug`,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -188,22 +248,60 @@ func (s *AdminDocStore) ListClients(ctx context.Context) ([]ClientInfo, error) {
 
 	var clients []ClientInfo
 	for rows.Next() {
-		var c ClientInfo
-		if err := rows.Scan(&c.Slug, &c.Name); err != nil {
+		c, err := scanClient(rows)
+		if err != nil {
 			return nil, err
 		}
 		clients = append(clients, c)
 	}
-	return clients, nil
+	return clients, rows.Err()
 }
 
-func (s *AdminDocStore) CreateClient(ctx context.Context, slug, name string) error {
+func (s *AdminDocStore) GetClient(ctx context.Context, slug string) (ClientInfo, error) {
+	c, err := scanClient(s.db.QueryRowContext(ctx,
+		`SELECT `+clientColumns+` FROM clients WHERE slug = ?`, slug,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ClientInfo{}, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *AdminDocStore) CreateClient(ctx context.Context, c ClientInfo) error {
+	if c.TokenStyle == "" {
+		c.TokenStyle = tokenStyleHex
+	}
 	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO clients (slug, name) VALUES (?, ?)`, slug, name,
+		`INSERT INTO clients (slug, name, token_style, pin_length, default_mode) VALUES (?, ?, ?, ?, ?)`,
+		c.Slug, c.Name, c.TokenStyle, c.PINLength, c.DefaultMode,
 	)
 	return err
 }
 
+// SetClientBranding replaces a client's branding; the zero Branding clears
+// it. It returns ErrNotFound for an unknown slug.
+func (s *AdminDocStore) SetClientBranding(ctx context.Context, slug string, b Branding) error {
+	var data any // NULL when cleared
+	if b != (Branding{}) {
+		raw, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		data = string(raw)
+	}
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE clients SET branding = jsonb(?) WHERE slug = ?`, data, slug,
+	)
+	if err != nil {
+		return err
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // Scenario CRUD — global, stored in admin DB.
 
 func (s *AdminDocStore) ListScenarios(ctx context.Context) ([]AdminScenarioSummary, error) {
@@ -236,6 +334,7 @@ func (s *AdminDocStore) ListScenarios(ctx context.Context) ([]AdminScenarioSumma
 			Description:  sc.Description,
 			Mode:         mode,
 				StageCount:   len(sc.Stages),
+			Public:       sc.Public,
 			CreatedAt:    sc.CreatedAt,
 		})
 	}
@@ -246,6 +345,37 @@ func (s *AdminDocStore) ListScenarios(ctx context.Context) ([]AdminScenarioSumma
 	return scenarios, nil
 }
 
+// ListPublicScenarios lists the scenarios flagged public, newest first. Only
+// the summary fields are read out of each document; stages never leave
+// SQLite.
+func (s *AdminDocStore) ListPublicScenarios(ctx context.Context) ([]PublicScenario, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id,
+		        COALESCE(json_extract(data, '$.name'), ''),
+		        COALESCE(json_extract(data, '$.city'), ''),
+		        COALESCE(json_extract(data, '$.description'), ''),
+		        COALESCE(NULLIF(json_extract(data, '$.mode'), ''), 'classic'),
+		        COALESCE(json_array_length(data, '$.stages'), 0)
+		 FROM scenarios
+		 WHERE json_extract(data, '$.public')
+		 ORDER BY json_extract(data, '$.createdAt') DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scenarios := []PublicScenario{}
+	for rows.Next() {
+		var sc PublicScenario
+		if err := rows.Scan(&sc.ID, &sc.Name, &sc.City, &sc.Description, &sc.Mode, &sc.StageCount); err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, sc)
+	}
+	return scenarios, rows.Err()
+}
+
 func (s *AdminDocStore) CreateScenario(ctx context.Context, req AdminScenarioRequest) (AdminScenarioDetail, error) {
 	id := newID()
 	now := nowUTC()
@@ -255,8 +385,11 @@ func (s *AdminDocStore) CreateScenario(ctx context.Context, req AdminScenarioReq
 		City:         req.City,
 		Description:  req.Description,
 		Mode:         req.Mode,
+		PlayCount:    req.PlayCount,
+		Public:       req.Public,
 		Stages:       req.Stages,
 		CreatedAt:    now,
+		Version:      1,
 	}
 	if err := s.putScenario(ctx, doc); err != nil {
 		return AdminScenarioDetail{}, err
@@ -267,8 +400,11 @@ func (s *AdminDocStore) CreateScenario(ctx context.Context, req AdminScenarioReq
 		City:         req.City,
 		Description:  req.Description,
 		Mode:         req.Mode,
+		PlayCount:    req.PlayCount,
+		Public:       req.Public,
 		Stages:       req.Stages,
 		CreatedAt:    now,
+		Version:      1,
 	}, nil
 }
 
@@ -291,32 +427,62 @@ func (s *AdminDocStore) GetScenario(ctx context.Context, id string) (AdminScenar
 		City:         sc.City,
 		Description:  sc.Description,
 		Mode:         mode,
+		PlayCount:    sc.PlayCount,
+		Public:       sc.Public,
 		Stages:       stages,
 		CreatedAt:    sc.CreatedAt,
+		Version:      sc.version(),
 	}, nil
 }
 
+// UpdateScenario replaces a scenario if req.Version is still the stored
+// version, and bumps it. Otherwise it returns ErrVersionConflict.
 func (s *AdminDocStore) UpdateScenario(ctx context.Context, id string, req AdminScenarioRequest) (AdminScenarioDetail, error) {
 	var sc scenario
 	if err := s.getDoc(ctx, "scenarios", id, &sc); err != nil {
 		return AdminScenarioDetail{}, err
 	}
+	if req.Version != sc.version() {
+		return AdminScenarioDetail{}, ErrVersionConflict
+	}
+	stored := sc.Version
 	sc.Name = req.Name
 	sc.City = req.City
 	sc.Description = req.Description
 	sc.Mode = req.Mode
+	sc.PlayCount = req.PlayCount
+	sc.Public = req.Public
 	sc.Stages = req.Stages
-	if err := s.putScenario(ctx, sc); err != nil {
+	sc.Version = sc.version() + 1
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return AdminScenarioDetail{}, err
+	}
+	// The version check is repeated in the UPDATE so a save that lands
+	// between our read and write still wins exactly once.
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE scenarios SET name = ?, data = jsonb(?)
+		 WHERE id = ? AND COALESCE(json_extract(data, '$.version'), 0) = ?`,
+		sc.Name, string(data), id, stored,
+	)
+	if err != nil {
 		return AdminScenarioDetail{}, err
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return AdminScenarioDetail{}, ErrVersionConflict
+	}
 	return AdminScenarioDetail{
 		ID:           id,
 		Name:         req.Name,
 		City:         req.City,
 		Description:  req.Description,
 		Mode:         req.Mode,
+		PlayCount:    req.PlayCount,
+		Public:       req.Public,
 		Stages:       req.Stages,
 		CreatedAt:    sc.CreatedAt,
+		Version:      sc.Version,
 	}, nil
 }
 
@@ -335,14 +501,7 @@ func (s *AdminDocStore) DeleteScenario(ctx context.Context, id string) error {
 }
 
 func (s *AdminDocStore) ScenarioHasGames(ctx context.Context, scenarioID string, clients *Registry) (bool, error) {
-	clients.mu.RLock()
-	stores := make([]*DocStore, 0, len(clients.stores))
-	for _, st := range clients.stores {
-		stores = append(stores, st)
-	}
-	clients.mu.RUnlock()
-
-	for _, st := range stores {
+	for _, st := range clients.snapshot() {
 		var count int
 		err := st.db.QueryRowContext(ctx,
 			`SELECT COUNT(*) FROM games WHERE scenario_id = ?`, scenarioID,
@@ -357,6 +516,48 @@ func (s *AdminDocStore) ScenarioHasGames(ctx context.Context, scenarioID string,
 	return false, nil
 }
 
+// ScenarioUsage is one game, in some client, built from a scenario.
+type ScenarioUsage struct {
+	Client string `json:"client"`
+	GameID string `json:"gameId"`
+	Status string `json:"status"`
+}
+
+// ScenarioUsage lists the games referencing a scenario across all clients,
+// ordered by client slug and game ID.
+func (s *AdminDocStore) ScenarioUsage(ctx context.Context, scenarioID string, clients *Registry) ([]ScenarioUsage, error) {
+	stores := clients.snapshot()
+	slugs := make([]string, 0, len(stores))
+	for slug := range stores {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	usage := []ScenarioUsage{}
+	for _, slug := range slugs {
+		rows, err := stores[slug].db.QueryContext(ctx,
+			`SELECT id, status FROM games WHERE scenario_id = ? ORDER BY id`, scenarioID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			u := ScenarioUsage{Client: slug}
+			if err := rows.Scan(&u.GameID, &u.Status); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			usage = append(usage, u)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
 // Internal helpers for scenario storage.
 
 func (s *AdminDocStore) getDoc(ctx context.Context, table, id string, dest any) error {
@@ -399,7 +600,7 @@ func (s *AdminDocStore) SeedDemoScenario(ctx context.Context) (*scenario, error)
 
 	now := nowUTC()
 	sc := scenario{
-		ID:          "s0000000deadbeef",
+		ID:          demoSeed.ScenarioID,
 		Name:        "Lima Centro Historico",
 		City:        "Lima",
 		Description: "Explore the historic center of Lima through four iconic landmarks.",
@@ -2,13 +2,16 @@ package server
 
 import (
 	"context"
-	"crypto/rand"
 	"database/sql"
-	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
+	mrand "math/rand/v2"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -20,27 +23,51 @@ type scenario struct {
 	City        string       `json:"city"`
 	Description string       `json:"description"`
 	Mode        string       `json:"mode"`
+	PlayCount   int          `json:"playCount,omitempty"`
+	Public      bool         `json:"public,omitempty"` // listed on the public scenarios page
 	Stages      []AdminStage `json:"stages"`
 	CreatedAt   string       `json:"createdAt"`
+	Version     int          `json:"version,omitempty"` // bumped on every update
+}
+
+// version reports the scenario's version; scenarios saved before versioning
+// count as version 1.
+func (sc scenario) version() int {
+	return max(sc.Version, 1)
 }
 
 type game struct {
-	ID                string       `json:"id"`
-	ScenarioID        string       `json:"scenarioId"`
-	ScenarioName      string       `json:"scenarioName"`
-	Status            string       `json:"status"`
-	Mode              string       `json:"mode"`
-	Language          string       `json:"language,omitempty"`
-	Supervised        bool         `json:"supervised,omitempty"`
-	TimerEnabled      bool         `json:"timerEnabled"`
-	TimerMinutes      int          `json:"timerMinutes"`
-	StageTimerMinutes int          `json:"stageTimerMinutes"`
-	Notes             string       `json:"notes,omitempty"`
+	ID                string `json:"id"`
+	ScenarioID        string `json:"scenarioId"`
+	ScenarioName      string `json:"scenarioName"`
+	Status            string `json:"status"`
+	Mode              string `json:"mode"`
+	Language          string `json:"language,omitempty"`
+	Supervised        bool   `json:"supervised,omitempty"`
+	TimerEnabled      bool   `json:"timerEnabled"`
+	TimerMinutes      int    `json:"timerMinutes"`
+	StageTimerMinutes int    `json:"stageTimerMinutes"`
+	Notes             string `json:"notes,omitempty"`
+	Outro             string `json:"outro,omitempty"`
+	GameSettings
+	AllPlayersGrading string       `json:"allPlayersGrading,omitempty"`
+	MinPlayersToStart int          `json:"minPlayersToStart,omitempty"`
+	MaxAttempts       int          `json:"maxAttempts,omitempty"`
+	PlayCount         int          `json:"playCount,omitempty"`
+	PIN               string       `json:"pin,omitempty"`
 	Stages            []AdminStage `json:"stages"`
 	StartedAt         *string      `json:"startedAt"`
 	EndedAt           *string      `json:"endedAt"`
 	CreatedAt         string       `json:"createdAt"`
 	Teams             []team       `json:"teams"`
+	Version           int          `json:"version,omitempty"`       // bumped by writes to the admin-editable settings
+	TimerWarnings     []int        `json:"timerWarnings,omitempty"` // timer_warning marks already sent, in seconds left
+}
+
+// version reports the game's version; games saved before versioning count as
+// version 1.
+func (g *game) version() int {
+	return max(g.Version, 1)
 }
 
 type team struct {
@@ -48,6 +75,7 @@ type team struct {
 	Name            string        `json:"name"`
 	JoinToken       string        `json:"joinToken"`
 	SupervisorToken string        `json:"supervisorToken,omitempty"`
+	SpectatorToken  string        `json:"spectatorToken,omitempty"`
 	GuideName       string        `json:"guideName"`
 	TeamSecret      int           `json:"teamSecret,omitempty"`
 	StartStage      int           `json:"startStage,omitempty"`
@@ -56,6 +84,83 @@ type team struct {
 	CreatedAt       string        `json:"createdAt"`
 	Players         []player      `json:"players"`
 	Results         []stageResult `json:"results"`
+	PendingAnswers  []stageResult `json:"pendingAnswers,omitempty"`
+	Attempts        []stageResult `json:"attempts,omitempty"`       // wrong answers that left the current stage open (maxAttempts only)
+	StagePool       []int         `json:"stagePool,omitempty"`      // scenario stage numbers this team plays; empty = all
+	PendingAdvance  bool          `json:"pendingAdvance,omitempty"` // answered, held on the result until POST /game/next
+	Confirmed       []string      `json:"confirmed,omitempty"`      // players who confirmed the unlocked stage (waitForConfirmations only)
+	AdminUnlocks    []adminUnlock `json:"adminUnlocks,omitempty"`   // stages an operator unlocked out-of-band
+	Version         int           `json:"version,omitempty"`        // bumped by admin edits, not by play
+}
+
+// holdsResults reports whether answering leaves a team on the result until
+// POST /game/next. Modes without answers have no result screen.
+func (g *game) holdsResults() bool {
+	return g.ManualAdvance && modeHasQuestion(g.Mode)
+}
+
+// waitsForConfirmations reports whether an unlocked stage's timer waits for
+// every player to call POST /game/confirm. Only supervised games unlock on
+// someone else's say-so, so only they wait.
+func (g *game) waitsForConfirmations() bool {
+	return g.WaitForConfirmations && g.Supervised
+}
+
+// timerRemaining reports how long the game timer has left at now. running is
+// false for games without a timer or that haven't started.
+func (g *game) timerRemaining(now time.Time) (remaining time.Duration, running bool) {
+	if g.TimerMinutes <= 0 || g.StartedAt == nil {
+		return 0, false
+	}
+	start, err := time.Parse(time.RFC3339Nano, *g.StartedAt)
+	if err != nil {
+		return 0, false
+	}
+	return start.Add(time.Duration(g.TimerMinutes) * time.Minute).Sub(now), true
+}
+
+// newTimerWarnings returns the marks (time left) the game timer has passed at
+// now that haven't been sent, in seconds. A timer that has run out passes
+// none: the game is about to end instead.
+func (g *game) newTimerWarnings(now time.Time, marks []time.Duration) []int {
+	remaining, running := g.timerRemaining(now)
+	if !running || remaining <= 0 {
+		return nil
+	}
+	var passed []int
+	for _, m := range marks {
+		secs := int(m / time.Second)
+		if secs > 0 && remaining <= m && !slices.Contains(g.TimerWarnings, secs) {
+			passed = append(passed, secs)
+		}
+	}
+	return passed
+}
+
+// resetTimerWarnings forgets the sent marks the timer is back above, after an
+// extension or a timer change, so they're sent again when it passes them.
+func (g *game) resetTimerWarnings(now time.Time) {
+	remaining, running := g.timerRemaining(now)
+	if !running {
+		g.TimerWarnings = nil
+		return
+	}
+	g.TimerWarnings = slices.DeleteFunc(g.TimerWarnings, func(secs int) bool {
+		return time.Duration(secs)*time.Second < remaining
+	})
+}
+
+// adminUnlockedStages lists the stage numbers an operator unlocked.
+func (t *team) adminUnlockedStages() []int {
+	var stages []int
+	for _, u := range t.AdminUnlocks {
+		stages = append(stages, u.StageNumber)
+	}
+	return stages
+}
+
+func (t *team) version() int {
+	return max(t.Version, 1)
 }
 
 type player struct {
@@ -66,11 +171,38 @@ type player struct {
 	JoinedAt  string `json:"joinedAt"`
 }
 
+// adminUnlock records an operator unlocking a team's stage from the admin API
+// rather than the team unlocking it in play.
+type adminUnlock struct {
+	StageNumber int    `json:"stageNumber"`
+	UnlockedAt  string `json:"unlockedAt"`
+}
+
 type stageResult struct {
 	StageNumber int    `json:"stageNumber"`
 	Answer      string `json:"answer"`
 	IsCorrect   bool   `json:"isCorrect"`
+	PlayerID    string `json:"playerId,omitempty"`
 	AnsweredAt  string `json:"answeredAt"`
+	GaveUp      bool   `json:"gaveUp,omitempty"`   // recorded by POST /game/giveup
+	TimedOut    bool   `json:"timedOut,omitempty"` // the stage timer ran out first; regrading leaves it wrong
+	// PointsAwarded is what a correct result scored; see game.awardPoints.
+	PointsAwarded int `json:"pointsAwarded,omitempty"`
+	// PlayerAnswers are the answers a requireAllPlayers team result was
+	// graded from, so a regrade can grade them again.
+	PlayerAnswers []stageResult `json:"playerAnswers,omitempty"`
+}
+
+// points is what the result scores. Correct results recorded before stages
+// had points score the default.
+func (r stageResult) points() int {
+	if !r.IsCorrect {
+		return 0
+	}
+	if r.PointsAwarded == 0 {
+		return defaultStagePoints
+	}
+	return r.PointsAwarded
 }
 
 type playerSession struct {
@@ -81,11 +213,19 @@ type playerSession struct {
 }
 
 // DocStore implements Store using per-model tables with JSONB data columns.
+// It keeps a client's games and player sessions. Writes and
+// transactions go through db; plain reads (state polling, lookups, the
+// cross-game scans) go through read so they don't queue behind the single
+// writer connection (see database.Open). Nothing writes through read.
 type DocStore struct {
-	db *sql.DB
+	db   *sql.DB
+	read *sql.DB
 }
 
-func NewDocStore(ctx context.Context, db *sql.DB) (*DocStore, error) {
+// NewDocStore creates the tables on db. readDB is a second pool on the same
+// file for reads; nil makes reads share db, which :memory: databases need
+// since every connection to one is a separate database.
+func NewDocStore(ctx context.Context, db, readDB *sql.DB) (*DocStore, error) {
 	for _, ddl := range []string{
 		`CREATE TABLE IF NOT EXISTS games (
 			id          TEXT PRIMARY KEY,
@@ -103,14 +243,29 @@ func NewDocStore(ctx context.Context, db *sql.DB) (*DocStore, error) {
 		}
 	}
 
-	return &DocStore{db: db}, nil
+	if readDB == nil {
+		readDB = db
+	}
+	s := &DocStore{db: db, read: readDB}
+	if err := createEventsTable(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the reader pool, if separate, and the writer.
+func (s *DocStore) Close() error {
+	if s.read != s.db {
+		s.read.Close()
+	}
+	return s.db.Close()
 }
 
 // Generic helpers — same shape, just take table instead of collection.
 
 func (s *DocStore) get(ctx context.Context, table, id string, dest any) error {
 	var data string
-	err := s.db.QueryRowContext(ctx,
+	err := s.read.QueryRowContext(ctx,
 		fmt.Sprintf(`SELECT json(data) FROM %s WHERE id = ?`, table), id,
 	).Scan(&data)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -138,7 +293,18 @@ func (s *DocStore) del(ctx context.Context, table, id string) error {
 
 // Per-table put methods — different columns per table.
 
-func (s *DocStore) putGame(ctx context.Context, g game) error {
+// putGame inserts or replaces a game, assigning or clearing its PIN to match
+// its status first (see syncPIN).
+func (s *DocStore) putGame(ctx context.Context, g *game) error {
+	var used map[string]bool
+	if g.needsPIN() {
+		var err error
+		if used, err = gamePINsInUse(ctx, s.db, g.ID); err != nil {
+			return err
+		}
+	}
+	g.syncPIN(used)
+
 	data, err := json.Marshal(g)
 	if err != nil {
 		return err
@@ -163,9 +329,56 @@ func (s *DocStore) putSession(ctx context.Context, table, id string, doc any) er
 	return err
 }
 
+// syncPIN gives a lobby or active game a quick-entry PIN not in used, and
+// drops the PIN once the game ends so the number can go to another game.
+// Paused games keep theirs, so players can still find the game after it
+// resumes.
+func (g *game) syncPIN(used map[string]bool) {
+	switch g.Status {
+	case "lobby", "active":
+		if g.PIN == "" || used[g.PIN] {
+			g.PIN = uniqueToken(used, generateGamePIN)
+		}
+	case "ended":
+		g.PIN = ""
+	}
+}
+
+// needsPIN reports whether syncPIN has to pick a new PIN for g, the only
+// case where it needs the PINs other games hold.
+func (g *game) needsPIN() bool {
+	return (g.Status == "lobby" || g.Status == "active") && g.PIN == ""
+}
+
+// gamePINsInUse returns the PINs held by every game except exceptID. Only
+// games that can hold one are read, and only their PIN.
+func gamePINsInUse(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}, exceptID string) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT json_extract(data, '$.pin') FROM games
+		 WHERE id != ? AND status IN ('lobby', 'active', 'paused') AND json_extract(data, '$.pin') != ''`,
+		exceptID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	used := make(map[string]bool)
+	for rows.Next() {
+		var pin string
+		if err := rows.Scan(&pin); err != nil {
+			return nil, err
+		}
+		used[pin] = true
+	}
+	return used, rows.Err()
+}
+
 func newID() string {
 	b := make([]byte, 16)
-	rand.Read(b)
+	randRead(b)
 	return hex.EncodeToString(b)
 }
 
@@ -175,7 +388,7 @@ func nowUTC() string {
 
 // allGames loads all game documents into memory.
 func (s *DocStore) allGames(ctx context.Context) ([]game, error) {
-	rows, err := s.db.QueryContext(ctx,
+	rows, err := s.read.QueryContext(ctx,
 		`SELECT json(data) FROM games ORDER BY id`,
 	)
 	if err != nil {
@@ -204,17 +417,142 @@ func (s *DocStore) getGame(ctx context.Context, id string) (game, error) {
 	var g game
 	err := s.get(ctx, "games", id, &g)
 	if err == nil {
-		if !g.TimerEnabled && g.TimerMinutes > 0 {
-			g.TimerEnabled = true
-			if g.StageTimerMinutes == 0 {
-				g.StageTimerMinutes = 10
-			}
+		g.backfill()
+	}
+	return g, corruptStages(id, err)
+}
+
+// corruptStages reports a game document whose stages don't match the stage
+// schema as ErrCorruptStages, so handlers can say what's wrong instead of a
+// bare internal error. Other errors pass through.
+func corruptStages(gameID string, err error) error {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) && (te.Field == "stages" || strings.HasPrefix(te.Field, "stages.")) {
+		return fmt.Errorf("game %s: %w: %v", gameID, ErrCorruptStages, err)
+	}
+	return err
+}
+
+// getTeamGame loads a game with only the given team in Teams, leaving it
+// empty when the team isn't in the game. SQLite picks the team out of the
+// document, so the other teams' players and results are never decoded.
+func (s *DocStore) getTeamGame(ctx context.Context, gameID, teamID string) (game, error) {
+	var data string
+	var teamData sql.NullString
+	err := s.read.QueryRowContext(ctx,
+		`SELECT json_remove(json(data), '$.teams'),
+		        (SELECT json(value) FROM json_each(data, '$.teams') WHERE json_extract(value, '$.id') = ?)
+		 FROM games WHERE id = ?`,
+		teamID, gameID,
+	).Scan(&data, &teamData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return game{}, ErrNotFound
+	}
+	if err != nil {
+		return game{}, err
+	}
+
+	var g game
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		return game{}, corruptStages(gameID, err)
+	}
+	if teamData.Valid {
+		var t team
+		if err := json.Unmarshal([]byte(teamData.String), &t); err != nil {
+			return game{}, err
+		}
+		g.Teams = []team{t}
+	}
+	g.backfill()
+	return g, nil
+}
+
+// backfill fills in settings that games created by older versions don't
+// store.
+func (g *game) backfill() {
+	if !g.TimerEnabled && g.TimerMinutes > 0 {
+		g.TimerEnabled = true
+		if g.StageTimerMinutes == 0 {
+			g.StageTimerMinutes = 10
+		}
+	}
+	if g.Mode == "" {
+		g.Mode = "classic"
+	}
+}
+
+// teamStages returns the stages a team plays, in scenario order, and the
+// team's start stage as a 1-based position within them (0 when the start
+// stage isn't part of the team's pool).
+func (g *game) teamStages(t team) ([]AdminStage, int) {
+	if len(t.StagePool) == 0 {
+		return g.Stages, t.StartStage
+	}
+	inPool := make(map[int]bool, len(t.StagePool))
+	for _, n := range t.StagePool {
+		inPool[n] = true
+	}
+	stages := make([]AdminStage, 0, len(t.StagePool))
+	start := 0
+	for _, s := range g.Stages {
+		if !inPool[s.StageNumber] {
+			continue
 		}
-		if g.Mode == "" {
-			g.Mode = "classic"
+		stages = append(stages, s)
+		if s.StageNumber == t.StartStage {
+			start = len(stages)
 		}
 	}
-	return g, err
+	return stages, start
+}
+
+func (g *game) answerRules() answerRules {
+	return answerRules{TrimPunctuation: g.TrimPunctuation}
+}
+
+// totalTeamStages is how many stages each team plays.
+func (g *game) totalTeamStages() int {
+	if g.PlayCount > 0 && g.PlayCount < len(g.Stages) {
+		return g.PlayCount
+	}
+	return len(g.Stages)
+}
+
+// progressPercent is done out of total as a whole percentage, rounded down
+// and capped at 100. A game without stages has no progress to show.
+func progressPercent(done, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	return min(done*100/total, 100)
+}
+
+// selectStagePool draws count stages for a team from the game's question
+// pool. The draw is seeded by the team ID, so the same team ID and stages
+// always give the same subset; different teams get different ones. The
+// result is stored on the team when it is created, so it doesn't change
+// mid-game. Returns nil (play every stage) when count doesn't limit anything.
+func selectStagePool(stages []AdminStage, count int, teamID string) []int {
+	if count <= 0 || count >= len(stages) {
+		return nil
+	}
+	h := fnv.New64a()
+	h.Write([]byte(teamID))
+	rng := mrand.New(mrand.NewPCG(h.Sum64(), 0))
+
+	numbers := make([]int, len(stages))
+	for i, s := range stages {
+		numbers[i] = s.StageNumber
+	}
+	// Partial Fisher-Yates; written out rather than using rng.Shuffle so the
+	// draw doesn't depend on the standard library's shuffle implementation.
+	for i := 0; i < count; i++ {
+		j := i + rng.IntN(len(numbers)-i)
+		numbers[i], numbers[j] = numbers[j], numbers[i]
+	}
+	pool := numbers[:count]
+	slices.Sort(pool)
+	return pool
 }
 
 // modifyGame loads a game, applies fn, and saves it in a transaction.
@@ -238,12 +576,19 @@ func (s *DocStore) modifyGame(ctx context.Context, gameID string, fn func(*game)
 
 	var g game
 	if err := json.Unmarshal([]byte(data), &g); err != nil {
-		return err
+		return corruptStages(gameID, err)
 	}
 
 	if err := fn(&g); err != nil {
 		return err
 	}
+	var used map[string]bool
+	if g.needsPIN() {
+		if used, err = gamePINsInUse(ctx, tx, g.ID); err != nil {
+			return err
+		}
+	}
+	g.syncPIN(used)
 
 	jsonData, err := json.Marshal(g)
 	if err != nil {
@@ -280,10 +625,14 @@ func (s *DocStore) PlayerFromToken(ctx context.Context, token string) (sessionIn
 
 // Player game flow
 
+// TeamLookup finds the team a join token belongs to in a lobby, active or
+// paused game. Lobby games are included so teams can gather before the start,
+// and paused ones so players who lose their session can rejoin mid-game;
+// answering stays blocked until the game is active.
 func (s *DocStore) TeamLookup(ctx context.Context, joinToken string) (TeamLookupResponse, error) {
-	// Materialize active games first — SQLite can't have concurrent cursors.
-	rows, err := s.db.QueryContext(ctx,
-		`SELECT json(data) FROM games WHERE status = 'active'`,
+	// Materialize the games first — SQLite can't have concurrent cursors.
+	rows, err := s.read.QueryContext(ctx,
+		`SELECT json(data) FROM games WHERE status IN ('lobby', 'active', 'paused')`,
 	)
 	if err != nil {
 		return TeamLookupResponse{}, err
@@ -305,26 +654,94 @@ func (s *DocStore) TeamLookup(ctx context.Context, joinToken string) (TeamLookup
 
 	for _, g := range games {
 		for _, t := range g.Teams {
-			if t.JoinToken == joinToken {
-				return TeamLookupResponse{
-					ID:       t.ID,
-					Name:     t.Name,
-					GameName: g.ScenarioName,
-					GameID:   g.ID,
-					Language: g.Language,
-					Role:     "player",
-				}, nil
-			}
-			if g.Supervised && t.SupervisorToken != "" && t.SupervisorToken == joinToken {
-				return TeamLookupResponse{
-					ID:       t.ID,
-					Name:     t.Name,
-					GameName: g.ScenarioName,
-					GameID:   g.ID,
-					Language: g.Language,
-					Role:     "supervisor",
-				}, nil
+			var role string
+			switch {
+			case t.JoinToken == joinToken:
+				role = "player"
+			// A game switched away from supervised keeps its teams'
+			// supervisor tokens; they no longer join anyone.
+			case g.Supervised && t.SupervisorToken != "" && t.SupervisorToken == joinToken:
+				role = "supervisor"
+			case t.SpectatorToken != "" && t.SpectatorToken == joinToken:
+				role = "spectator"
+			default:
+				continue
 			}
+			return TeamLookupResponse{
+				ID:         t.ID,
+				Name:       t.Name,
+				GameName:   g.ScenarioName,
+				GameID:     g.ID,
+				Language:   g.Language,
+				Role:       role,
+				Mode:       g.Mode,
+				Supervised: g.Supervised,
+			}, nil
+		}
+	}
+	return TeamLookupResponse{}, ErrNotFound
+}
+
+// GameByPIN finds the lobby, active or paused game with the given PIN for quick
+// entry (see TeamLookup).
+func (s *DocStore) GameByPIN(ctx context.Context, pin string) (GamePINResponse, error) {
+	if pin == "" {
+		return GamePINResponse{}, ErrNotFound
+	}
+	rows, err := s.read.QueryContext(ctx,
+		`SELECT json(data) FROM games WHERE status IN ('lobby', 'active', 'paused')`,
+	)
+	if err != nil {
+		return GamePINResponse{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return GamePINResponse{}, err
+		}
+		var g game
+		if err := json.Unmarshal([]byte(data), &g); err != nil {
+			return GamePINResponse{}, err
+		}
+		if g.PIN != pin {
+			continue
+		}
+		teams := make([]GamePINTeam, len(g.Teams))
+		for i, t := range g.Teams {
+			teams[i] = GamePINTeam{ID: t.ID, Name: t.Name}
+		}
+		return GamePINResponse{
+			GameID:   g.ID,
+			GameName: g.ScenarioName,
+			Language: g.Language,
+			Teams:    teams,
+		}, nil
+	}
+	if err := rows.Err(); err != nil {
+		return GamePINResponse{}, err
+	}
+	return GamePINResponse{}, ErrNotFound
+}
+
+// SessionTeam describes the team a session belongs to, for resuming a
+// session without joining again. Unlike TeamLookup it ignores game status:
+// a session stays valid after the game ends so the player can see results.
+func (s *DocStore) SessionTeam(ctx context.Context, gameID, teamID string) (TeamLookupResponse, error) {
+	g, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return TeamLookupResponse{}, err
+	}
+	for _, t := range g.Teams {
+		if t.ID == teamID {
+			return TeamLookupResponse{
+				ID:       t.ID,
+				Name:     t.Name,
+				GameName: g.ScenarioName,
+				GameID:   g.ID,
+				Language: g.Language,
+			}, nil
 		}
 	}
 	return TeamLookupResponse{}, ErrNotFound
@@ -335,6 +752,19 @@ func (s *DocStore) JoinTeam(ctx context.Context, gameID, teamID, playerName, rol
 	sessionID := newID()
 	now := nowUTC()
 
+	// Spectators only get a read-only session; they aren't team members.
+	if role == "spectator" {
+		err := s.putSession(ctx, "player_sessions", sessionID, playerSession{
+			TeamID: teamID,
+			GameID: gameID,
+			Role:   role,
+		})
+		if err != nil {
+			return "", "", err
+		}
+		return "", sessionID, nil
+	}
+
 	err := s.modifyGame(ctx, gameID, func(g *game) error {
 		for i := range g.Teams {
 			if g.Teams[i].ID == teamID {
@@ -374,28 +804,57 @@ func (s *DocStore) JoinTeam(ctx context.Context, gameID, teamID, playerName, rol
 }
 
 func (s *DocStore) GameState(ctx context.Context, gameID, teamID string) (gameStateData, error) {
-	g, err := s.getGame(ctx, gameID)
+	g, err := s.getTeamGame(ctx, gameID, teamID)
 	if err != nil {
 		return gameStateData{}, err
 	}
+	return g.stateData(teamID), nil
+}
 
-	stagesJSON, _ := json.Marshal(g.Stages)
+// TeamProgress is GameState plus the team's completed stages, from one read
+// of the game.
+func (s *DocStore) TeamProgress(ctx context.Context, gameID, teamID string) (teamProgress, error) {
+	g, err := s.getTeamGame(ctx, gameID, teamID)
+	if err != nil {
+		return teamProgress{}, err
+	}
+	p := teamProgress{gameStateData: g.stateData(teamID)}
+	if len(g.Teams) == 1 {
+		p.Completed = g.Teams[0].completedStages()
+	}
+	return p, nil
+}
 
+// stateData gathers what the player handlers need about the game and team.
+func (g *game) stateData(teamID string) gameStateData {
+	stages := g.Stages
 	var teamName string
 	var teamSecret int
 	var startStage int
 	var unlockedStages []int
 	var stageUnlockedAt *string
+	var pendingPlayerIDs []string
+	var pendingAdvance bool
+	var confirmed []string
+	var confirmRequired, teamPlayers int
 	for _, t := range g.Teams {
 		if t.ID == teamID {
 			teamName = t.Name
+			pendingAdvance = t.PendingAdvance
+			confirmed = t.Confirmed
+			confirmRequired = confirmationsRequired(&t)
+			teamPlayers = playerCount(&t)
 			teamSecret = t.TeamSecret
-			startStage = t.StartStage
+			stages, startStage = g.teamStages(t)
 			unlockedStages = t.UnlockedStages
 			stageUnlockedAt = t.StageUnlockedAt
+			for _, p := range t.PendingAnswers {
+				pendingPlayerIDs = append(pendingPlayerIDs, p.PlayerID)
+			}
 			break
 		}
 	}
+	stagesJSON, _ := json.Marshal(stages)
 
 	var d gameStateData
 	d.Status = g.Status
@@ -412,7 +871,54 @@ func (s *DocStore) GameState(ctx context.Context, gameID, teamID string) (gameSt
 	d.StartStage = startStage
 	d.UnlockedStages = unlockedStages
 	d.StageUnlockedAt = stageUnlockedAt
-	return d, nil
+	d.Outro = g.Outro
+	d.GameSettings = g.GameSettings
+	d.PendingAdvance = pendingAdvance
+	d.ConfirmedPlayerIDs = confirmed
+	d.ConfirmRequired = confirmRequired
+	d.MinPlayersToStart = g.MinPlayersToStart
+	d.MaxAttempts = g.MaxAttempts
+	d.TeamPlayers = teamPlayers
+	d.PendingPlayerIDs = pendingPlayerIDs
+	return d
+}
+
+// GameScenarioStatus returns the scenario a game was created from and the
+// game's status. It reads the games table columns, so it works even when the
+// game document won't decode.
+func (s *DocStore) GameScenarioStatus(ctx context.Context, gameID string) (scenarioID, status string, err error) {
+	err = s.read.QueryRowContext(ctx,
+		`SELECT scenario_id, status FROM games WHERE id = ?`, gameID,
+	).Scan(&scenarioID, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	return scenarioID, status, err
+}
+
+// ResnapshotStages replaces a game's stages with a fresh copy, normally its
+// scenario's, bumps the game version and returns it. The stages are written
+// straight into the stored document rather than through modifyGame, so this
+// repairs games whose stages are missing or no longer decode
+// (ErrCorruptStages).
+func (s *DocStore) ResnapshotStages(ctx context.Context, gameID string, stages []AdminStage) (int, error) {
+	data, err := json.Marshal(stages)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = s.db.QueryRowContext(ctx,
+		`UPDATE games SET data = jsonb_set(data,
+		        '$.stages', jsonb(?),
+		        '$.version', MAX(COALESCE(json_extract(data, '$.version'), 0), 1) + 1)
+		 WHERE id = ?
+		 RETURNING json_extract(data, '$.version')`,
+		string(data), gameID,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return version, err
 }
 
 func (s *DocStore) ExpireGame(ctx context.Context, gameID string) error {
@@ -421,13 +927,111 @@ func (s *DocStore) ExpireGame(ctx context.Context, gameID string) error {
 		if g.Status == "active" {
 			g.Status = "ended"
 			g.EndedAt = &now
+			g.Version = g.version() + 1
 		}
 		return nil
 	})
 }
 
+// ExtendGameTimer adds minutes to a game's timer, pushing its deadline back
+// without touching StartedAt or team progress. maxMinutes caps the new total
+// (0 = no cap). A game whose deadline passed but which hasn't been marked
+// ended yet can still be extended.
+func (s *DocStore) ExtendGameTimer(ctx context.Context, gameID string, addMinutes, maxMinutes int) (AdminGameDetail, error) {
+	err := s.modifyGame(ctx, gameID, func(g *game) error {
+		if g.Status == "ended" {
+			return ErrGameEnded
+		}
+		// Same backfill as getGame: old games only have TimerMinutes.
+		if !g.TimerEnabled && g.TimerMinutes == 0 {
+			return ErrTimerDisabled
+		}
+		if maxMinutes > 0 && g.TimerMinutes+addMinutes > maxMinutes {
+			return ErrTimerLimit
+		}
+		g.TimerMinutes += addMinutes
+		g.Version = g.version() + 1
+		g.resetTimerWarnings(time.Now())
+		return nil
+	})
+	if err != nil {
+		return AdminGameDetail{}, err
+	}
+	return s.GetGame(ctx, gameID)
+}
+
+// timerWarning is a game whose timer has just passed a timer_warning mark:
+// the teams to warn and how long the game has left.
+type timerWarning struct {
+	GameID    string
+	TeamIDs   []string
+	Remaining time.Duration
+}
+
+// MarkTimerWarnings records on every active game the marks (time left) its
+// timer has passed at now, so each is sent once, and returns a warning for
+// each game that passed any. Marks passed together, e.g. while the server was
+// down, make one warning.
+func (s *DocStore) MarkTimerWarnings(ctx context.Context, now time.Time, marks []time.Duration) ([]timerWarning, error) {
+	// Find the due games first; SQLite can't write while the cursor is open.
+	rows, err := s.read.QueryContext(ctx,
+		`SELECT json_remove(json(data), '$.teams', '$.stages') FROM games WHERE status = 'active'`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var due []string
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var g game
+		if err := json.Unmarshal([]byte(data), &g); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if len(g.newTimerWarnings(now, marks)) > 0 {
+			due = append(due, g.ID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var warnings []timerWarning
+	for _, id := range due {
+		var w timerWarning
+		err := s.modifyGame(ctx, id, func(g *game) error {
+			passed := g.newTimerWarnings(now, marks)
+			if g.Status != "active" || len(passed) == 0 {
+				return nil
+			}
+			g.TimerWarnings = append(g.TimerWarnings, passed...)
+			w.GameID = g.ID
+			w.Remaining, _ = g.timerRemaining(now)
+			for _, t := range g.Teams {
+				w.TeamIDs = append(w.TeamIDs, t.ID)
+			}
+			return nil
+		})
+		if errors.Is(err, ErrNotFound) {
+			continue // deleted since
+		}
+		if err != nil {
+			return warnings, err
+		}
+		if w.GameID != "" {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings, nil
+}
+
 func (s *DocStore) CountAnsweredStages(ctx context.Context, gameID, teamID string) (int, error) {
-	g, err := s.getGame(ctx, gameID)
+	g, err := s.getTeamGame(ctx, gameID, teamID)
 	if err != nil {
 		return 0, err
 	}
@@ -458,24 +1062,29 @@ func (s *DocStore) CountCorrectAnswers(ctx context.Context, gameID, teamID strin
 	return 0, nil
 }
 
-func (s *DocStore) RecordAnswer(ctx context.Context, gameID, teamID string, stageNumber int, answer string, isCorrect bool) error {
+func (s *DocStore) RecordAnswer(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string, isCorrect bool) error {
 	now := nowUTC()
 	return s.modifyGame(ctx, gameID, func(g *game) error {
 		for i := range g.Teams {
 			if g.Teams[i].ID == teamID {
-				// Deduplicate: skip if this stage was already answered.
-				for _, r := range g.Teams[i].Results {
-					if r.StageNumber == stageNumber {
-						return nil
-					}
+				// Deduplicate: skip if this stage was already answered (by
+				// this player, with individualScoring).
+				if g.hasResult(&g.Teams[i], stageNumber, playerID) {
+					return nil
 				}
-				g.Teams[i].Results = append(g.Teams[i].Results, stageResult{
+				result := stageResult{
 					StageNumber: stageNumber,
 					Answer:      answer,
 					IsCorrect:   isCorrect,
+					PlayerID:    playerID,
 					AnsweredAt:  now,
-				})
+				}
+				g.awardPoints(&g.Teams[i], &result)
+				g.Teams[i].Results = append(g.Teams[i].Results, result)
+				g.Teams[i].Attempts = nil
 				g.Teams[i].StageUnlockedAt = nil
+				g.Teams[i].Confirmed = nil
+				g.Teams[i].PendingAdvance = g.holdsResults()
 				return nil
 			}
 		}
@@ -483,55 +1092,344 @@ func (s *DocStore) RecordAnswer(ctx context.Context, gameID, teamID string, stag
 	})
 }
 
-func (s *DocStore) ListPlayers(ctx context.Context, gameID, teamID string) ([]PlayerInfo, error) {
-	g, err := s.getGame(ctx, gameID)
-	if err != nil {
-		return nil, err
-	}
-	for _, t := range g.Teams {
-		if t.ID == teamID {
-			players := make([]PlayerInfo, len(t.Players))
-			for i, p := range t.Players {
-				role := p.Role
-				if role == "" {
-					role = "player"
-				}
-				players[i] = PlayerInfo{ID: p.ID, Name: p.Name, Role: role}
+// RecordAttempt records a wrong answer to a stage in a game with maxAttempts
+// and returns how many wrong answers the team has given it. The answer that
+// uses up the last attempt becomes the stage's result and failed is set,
+// moving the team on as RecordAnswer would. Correct answers aren't attempts
+// and go through RecordAnswer. A stage that already has a result returns
+// ErrAlreadyAnswered.
+func (s *DocStore) RecordAttempt(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string) (attempts int, failed bool, err error) {
+	now := nowUTC()
+	err = s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Teams {
+			t := &g.Teams[i]
+			if t.ID != teamID {
+				continue
 			}
-			return players, nil
+			if hasResult(t.Results, stageNumber) {
+				return ErrAlreadyAnswered
+			}
+			// Drop attempts left over from an earlier stage.
+			t.Attempts = slices.DeleteFunc(t.Attempts, func(a stageResult) bool {
+				return a.StageNumber != stageNumber
+			})
+			attempt := stageResult{
+				StageNumber: stageNumber,
+				Answer:      answer,
+				PlayerID:    playerID,
+				AnsweredAt:  now,
+			}
+			t.Attempts = append(t.Attempts, attempt)
+			attempts = len(t.Attempts)
+			if attempts < max(g.MaxAttempts, 1) {
+				return nil
+			}
+			t.Results = append(t.Results, attempt)
+			t.Attempts = nil
+			t.StageUnlockedAt = nil
+			t.Confirmed = nil
+			t.PendingAdvance = g.holdsResults()
+			failed = true
+			return nil
 		}
-	}
-	return nil, nil
+		return ErrNotFound
+	})
+	return attempts, failed, err
 }
 
-func (s *DocStore) ListCompletedStages(ctx context.Context, gameID, teamID string) ([]CompletedStage, error) {
-	g, err := s.getGame(ctx, gameID)
+// CountAttempts reports how many wrong answers a team has given a stage that
+// is still open. It's 0 once the stage has a result.
+func (s *DocStore) CountAttempts(ctx context.Context, gameID, teamID string, stageNumber int) (int, error) {
+	g, err := s.getTeamGame(ctx, gameID, teamID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	for _, t := range g.Teams {
-		if t.ID == teamID {
-			var completed []CompletedStage
-			for _, r := range t.Results {
-				completed = append(completed, CompletedStage{
-					StageNumber: r.StageNumber,
-					IsCorrect:   r.IsCorrect,
-					AnsweredAt:  r.AnsweredAt,
-				})
+	count := 0
+	if len(g.Teams) == 1 {
+		for _, a := range g.Teams[0].Attempts {
+			if a.StageNumber == stageNumber {
+				count++
 			}
-			return completed, nil
 		}
 	}
-	return nil, nil
+	return count, nil
 }
 
-// Admin games
+// AdvanceTeam clears a team's pendingAdvance, moving it on from the result of
+// its last answered stage, and returns how many stages it has answered.
+func (s *DocStore) AdvanceTeam(ctx context.Context, gameID, teamID string) (int, error) {
+	var answered int
+	err := s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Teams {
+			if g.Teams[i].ID == teamID {
+				if !g.Teams[i].PendingAdvance {
+					return ErrNothingToAdvance
+				}
+				g.Teams[i].PendingAdvance = false
+				answered = len(g.Teams[i].Results)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return answered, err
+}
 
-func (s *DocStore) ListGames(ctx context.Context) ([]AdminGameSummary, error) {
-	allGames, err := s.allGames(ctx)
-	if err != nil {
-		return nil, err
-	}
+// TimeOutStage records a wrong answer, marked TimedOut, for a stage whose
+// timer ran out, moving the team on as an answer would. answer is the one
+// that arrived too late, if any, attributed to playerID. It reports false
+// when the stage already has a result, so only one caller announces the
+// timeout.
+func (s *DocStore) TimeOutStage(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string) (bool, error) {
+	return s.skipStage(ctx, gameID, teamID, stageResult{StageNumber: stageNumber, Answer: answer, PlayerID: playerID, TimedOut: true})
+}
+
+// GiveUpStage records a wrong, empty answer for a stage the team gave up on,
+// marked GaveUp and attributed to the player who gave up. Like TimeOutStage
+// it reports false when the stage already has a result.
+func (s *DocStore) GiveUpStage(ctx context.Context, gameID, teamID, playerID string, stageNumber int) (bool, error) {
+	return s.skipStage(ctx, gameID, teamID, stageResult{StageNumber: stageNumber, PlayerID: playerID, GaveUp: true})
+}
+
+// skipStage records result, a wrong answer, and moves the team on as an
+// answer would. Per-player answers still pending for the stage are
+// dropped.
+func (s *DocStore) skipStage(ctx context.Context, gameID, teamID string, result stageResult) (bool, error) {
+	var recorded bool
+	result.AnsweredAt = nowUTC()
+	err := s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Teams {
+			t := &g.Teams[i]
+			if t.ID != teamID {
+				continue
+			}
+			if g.hasResult(t, result.StageNumber, result.PlayerID) {
+				return nil
+			}
+			t.Results = append(t.Results, result)
+			t.PendingAnswers = nil
+			t.Attempts = nil
+			t.StageUnlockedAt = nil
+			t.Confirmed = nil
+			t.PendingAdvance = g.holdsResults()
+			recorded = true
+			return nil
+		}
+		return ErrNotFound
+	})
+	return recorded, err
+}
+
+// ConfirmStage records that a player has the team's unlocked stage in front
+// of them. Once every player (supervisors aside) has confirmed, the stage
+// timer starts. Confirming twice is a no-op; there's nothing to confirm
+// before the stage is unlocked or after its timer has started.
+func (s *DocStore) ConfirmStage(ctx context.Context, gameID, teamID, playerID string) (confirmProgress, error) {
+	var progress confirmProgress
+	now := nowUTC()
+	err := s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Teams {
+			t := &g.Teams[i]
+			if t.ID != teamID {
+				continue
+			}
+			progress.StageNumber = len(t.Results) + 1
+			if slices.Contains(t.Confirmed, playerID) {
+				progress.Confirmed = len(t.Confirmed)
+				progress.Required = confirmationsRequired(t)
+				progress.Started = t.StageUnlockedAt != nil
+				return nil
+			}
+			if t.PendingAdvance || !isStageUnlocked(t.UnlockedStages, progress.StageNumber) || t.StageUnlockedAt != nil {
+				return ErrNothingToConfirm
+			}
+			for _, p := range t.Players {
+				if p.ID == playerID {
+					progress.PlayerName = p.Name
+				}
+			}
+			t.Confirmed = append(t.Confirmed, playerID)
+			progress.Confirmed = len(t.Confirmed)
+			progress.Required = confirmationsRequired(t)
+			if progress.Confirmed >= progress.Required {
+				t.StageUnlockedAt = &now
+				progress.Started = true
+			}
+			return nil
+		}
+		return ErrNotFound
+	})
+	return progress, err
+}
+
+// confirmationsRequired counts the players who must confirm an unlocked
+// stage: everyone on the team but the supervisor.
+func confirmationsRequired(t *team) int {
+	return playerCount(t)
+}
+
+// playerCount counts the players on a team, leaving out the supervisor.
+// Spectators aren't team players and were never added.
+func playerCount(t *team) int {
+	n := 0
+	for _, p := range t.Players {
+		if p.Role != "supervisor" {
+			n++
+		}
+	}
+	return n
+}
+
+// RecordPlayerAnswer collects one player's answer for a game that requires
+// every player to answer. Supervisors don't count towards the team. Once the
+// last player has answered the pending answers are graded into a single team
+// result (strict majority, or any correct answer for "first_correct"), the
+// first correct answer being kept as the team's answer.
+func (s *DocStore) RecordPlayerAnswer(ctx context.Context, gameID, teamID, playerID string, stageNumber int, answer string, isCorrect bool) (answerProgress, error) {
+	var progress answerProgress
+	now := nowUTC()
+	err := s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Teams {
+			t := &g.Teams[i]
+			if t.ID != teamID {
+				continue
+			}
+			if hasResult(t.Results, stageNumber) {
+				return ErrAlreadyAnswered
+			}
+			// Drop answers left over from an earlier stage.
+			pending := t.PendingAnswers[:0]
+			for _, p := range t.PendingAnswers {
+				if p.StageNumber != stageNumber {
+					continue
+				}
+				if p.PlayerID == playerID {
+					return ErrAlreadyAnswered
+				}
+				pending = append(pending, p)
+			}
+			pending = append(pending, stageResult{
+				StageNumber: stageNumber,
+				Answer:      answer,
+				IsCorrect:   isCorrect,
+				PlayerID:    playerID,
+				AnsweredAt:  now,
+			})
+
+			for _, p := range t.Players {
+				if p.ID == playerID {
+					progress.PlayerName = p.Name
+				}
+				if p.Role != "supervisor" {
+					progress.Required++
+				}
+			}
+			progress.Answered = len(pending)
+			if progress.Answered < progress.Required {
+				t.PendingAnswers = pending
+				return nil
+			}
+
+			result := gradePlayerAnswers(slices.Clone(pending), g.AllPlayersGrading)
+			result.AnsweredAt = now
+			g.awardPoints(t, &result)
+			t.Results = append(t.Results, result)
+			t.PendingAnswers = nil
+			t.StageUnlockedAt = nil
+			t.Confirmed = nil
+			t.PendingAdvance = g.holdsResults()
+			progress.Complete = true
+			progress.IsCorrect = result.IsCorrect
+			return nil
+		}
+		return ErrNotFound
+	})
+	return progress, err
+}
+
+// gradePlayerAnswers grades a team result from every player's answer to a
+// stage: a strict majority must be correct, or any one for "first_correct".
+// The first correct answer is kept as the team's answer, else the first one.
+func gradePlayerAnswers(answers []stageResult, grading string) stageResult {
+	correct := 0
+	result := answers[0]
+	for _, p := range answers {
+		if p.IsCorrect {
+			if correct == 0 {
+				result = p
+			}
+			correct++
+		}
+	}
+	if grading == "first_correct" {
+		result.IsCorrect = correct > 0
+	} else {
+		result.IsCorrect = correct*2 > len(answers)
+	}
+	result.PlayerAnswers = answers
+	return result
+}
+
+func (s *DocStore) ListPlayers(ctx context.Context, gameID, teamID string) ([]PlayerInfo, error) {
+	g, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range g.Teams {
+		if t.ID == teamID {
+			players := make([]PlayerInfo, len(t.Players))
+			for i, p := range t.Players {
+				role := p.Role
+				if role == "" {
+					role = "player"
+				}
+				players[i] = PlayerInfo{ID: p.ID, Name: p.Name, Role: role}
+			}
+			return players, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListCompletedStages returns every recorded result in answer order, right
+// or wrong, with IsCorrect as recorded (and as changed by regrading).
+// Auto-completed stages count as correct.
+func (s *DocStore) ListCompletedStages(ctx context.Context, gameID, teamID string) ([]CompletedStage, error) {
+	g, err := s.getTeamGame(ctx, gameID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range g.Teams {
+		if t.ID == teamID {
+			return t.completedStages(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (t *team) completedStages() []CompletedStage {
+	var completed []CompletedStage
+	for _, r := range t.Results {
+		completed = append(completed, CompletedStage{
+			StageNumber: r.StageNumber,
+			IsCorrect:   r.IsCorrect,
+			AnsweredAt:  r.AnsweredAt,
+			GaveUp:      r.GaveUp,
+			Points:      r.points(),
+			Answer:      r.Answer,
+			PlayerID:    r.PlayerID,
+		})
+	}
+	return completed
+}
+
+// Admin games
+
+func (s *DocStore) ListGames(ctx context.Context) ([]AdminGameSummary, error) {
+	allGames, err := s.allGames(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var games []AdminGameSummary
 	for _, g := range allGames {
@@ -539,6 +1437,10 @@ func (s *DocStore) ListGames(ctx context.Context) ([]AdminGameSummary, error) {
 		if mode == "" {
 			mode = "classic"
 		}
+		answered := 0
+		for _, t := range g.Teams {
+			answered += len(t.Results)
+		}
 		games = append(games, AdminGameSummary{
 			ID:                g.ID,
 			ScenarioID:        g.ScenarioID,
@@ -552,6 +1454,7 @@ func (s *DocStore) ListGames(ctx context.Context) ([]AdminGameSummary, error) {
 			StageTimerMinutes: g.StageTimerMinutes,
 			Notes:             g.Notes,
 			TeamCount:         len(g.Teams),
+			ProgressPercent:   progressPercent(answered, len(g.Teams)*g.totalTeamStages()),
 			CreatedAt:         g.CreatedAt,
 		})
 	}
@@ -577,28 +1480,54 @@ func (s *DocStore) CreateGame(ctx context.Context, req AdminGameRequest, stages
 		TimerMinutes:      req.TimerMinutes,
 		StageTimerMinutes: req.StageTimerMinutes,
 		Notes:             req.Notes,
+		Outro:             req.Outro,
+		GameSettings:      req.gameSettings(),
+		AllPlayersGrading: req.AllPlayersGrading,
+		MinPlayersToStart: req.MinPlayersToStart,
+		MaxAttempts:       req.MaxAttempts,
+		PlayCount:         req.PlayCount,
 		Stages:            stages,
 		CreatedAt:         now,
 		Teams:             []team{},
+		Version:           1,
 	}
-	if err := s.putGame(ctx, doc); err != nil {
+	if err := s.putGame(ctx, &doc); err != nil {
 		return AdminGameDetail{}, err
 	}
 	return AdminGameDetail{
-		ID:                id,
-		ScenarioID:        req.ScenarioID,
-		ScenarioName:      req.ScenarioName,
-		Status:            req.Status,
-		Mode:              req.Mode,
-		Language:          req.Language,
-		Supervised:        req.Supervised,
-		TimerEnabled:      req.TimerEnabled,
-		TimerMinutes:      req.TimerMinutes,
-		StageTimerMinutes: req.StageTimerMinutes,
-		Notes:             req.Notes,
-		Stages:            stages,
-		Teams:             []AdminTeamItem{},
-		CreatedAt:         now,
+		ID:                      id,
+		ScenarioID:              req.ScenarioID,
+		ScenarioName:            req.ScenarioName,
+		Status:                  req.Status,
+		Mode:                    req.Mode,
+		Language:                req.Language,
+		Supervised:              req.Supervised,
+		TimerEnabled:            req.TimerEnabled,
+		TimerMinutes:            req.TimerMinutes,
+		StageTimerMinutes:       req.StageTimerMinutes,
+		Notes:                   req.Notes,
+		Outro:                   req.Outro,
+		RequireAllPlayers:       req.RequireAllPlayers,
+		AllPlayersGrading:       req.AllPlayersGrading,
+		HideLockedClue:          req.HideLockedClue,
+		HideLocationFromPlayers: req.HideLocationFromPlayers,
+		TrimPunctuation:         req.TrimPunctuation,
+		PlayersCanAnswer:        req.PlayersCanAnswer,
+		ManualAdvance:           req.ManualAdvance,
+		WaitForConfirmations:    req.WaitForConfirmations,
+		MinPlayersToStart:       req.MinPlayersToStart,
+		MaxAttempts:             req.MaxAttempts,
+		AllowGiveUp:             req.AllowGiveUp,
+		IndividualScoring:       req.IndividualScoring,
+		FirstStageUnlocked:      req.FirstStageUnlocked,
+		PointsDecay:             req.PointsDecay,
+		Settings:                doc.settingsMap(),
+		PlayCount:               req.PlayCount,
+		PIN:                     doc.PIN,
+		Version:                 1,
+		Stages:                  stages,
+		Teams:                   []AdminTeamItem{},
+		CreatedAt:               now,
 	}, nil
 }
 
@@ -615,128 +1544,264 @@ func (s *DocStore) GetGame(ctx context.Context, id string) (AdminGameDetail, err
 			Name:            t.Name,
 			JoinToken:       t.JoinToken,
 			SupervisorToken: t.SupervisorToken,
+			SpectatorToken:  t.SpectatorToken,
 			GuideName:       t.GuideName,
 			TeamSecret:      t.TeamSecret,
 			StartStage:      t.StartStage,
 			PlayerCount:     len(t.Players),
+			AdminUnlocks:    t.adminUnlockedStages(),
 			CreatedAt:       t.CreatedAt,
+			Version:         t.version(),
 		}
 	}
 
 	return AdminGameDetail{
-		ID:                g.ID,
-		ScenarioID:        g.ScenarioID,
-		ScenarioName:      g.ScenarioName,
-		Status:            g.Status,
-		Mode:              g.Mode,
-		Language:          g.Language,
-		Supervised:        g.Supervised,
-		TimerEnabled:      g.TimerEnabled,
-		TimerMinutes:      g.TimerMinutes,
-		StageTimerMinutes: g.StageTimerMinutes,
-		Notes:             g.Notes,
-		StartedAt:         g.StartedAt,
-		Stages:            g.Stages,
-		Teams:             teams,
-		CreatedAt:         g.CreatedAt,
+		ID:                      g.ID,
+		ScenarioID:              g.ScenarioID,
+		ScenarioName:            g.ScenarioName,
+		Status:                  g.Status,
+		Mode:                    g.Mode,
+		Language:                g.Language,
+		Supervised:              g.Supervised,
+		TimerEnabled:            g.TimerEnabled,
+		TimerMinutes:            g.TimerMinutes,
+		StageTimerMinutes:       g.StageTimerMinutes,
+		Notes:                   g.Notes,
+		Outro:                   g.Outro,
+		RequireAllPlayers:       g.RequireAllPlayers,
+		AllPlayersGrading:       g.AllPlayersGrading,
+		HideLockedClue:          g.HideLockedClue,
+		HideLocationFromPlayers: g.HideLocationFromPlayers,
+		TrimPunctuation:         g.TrimPunctuation,
+		PlayersCanAnswer:        g.PlayersCanAnswer,
+		ManualAdvance:           g.ManualAdvance,
+		WaitForConfirmations:    g.WaitForConfirmations,
+		MinPlayersToStart:       g.MinPlayersToStart,
+		MaxAttempts:             g.MaxAttempts,
+		AllowGiveUp:             g.AllowGiveUp,
+		IndividualScoring:       g.IndividualScoring,
+		FirstStageUnlocked:      g.FirstStageUnlocked,
+		PointsDecay:             g.PointsDecay,
+		Settings:                g.settingsMap(),
+		PIN:                     g.PIN,
+		Version:                 g.version(),
+		PlayCount:               g.PlayCount,
+		StartedAt:               g.StartedAt,
+		Stages:                  g.Stages,
+		Teams:                   teams,
+		CreatedAt:               g.CreatedAt,
 	}, nil
 }
 
+// UpdateGame applies an admin edit if req.Version is still the game's
+// version, and bumps it; otherwise it returns ErrVersionConflict. The check
+// runs inside modifyGame's transaction.
 func (s *DocStore) UpdateGame(ctx context.Context, id string, req AdminGameRequest, stages []AdminStage) (AdminGameDetail, error) {
-	g, err := s.getGame(ctx, id)
-	if err != nil {
-		return AdminGameDetail{}, err
+	// A game that becomes supervised needs supervisor tokens for the teams
+	// created before, which share one namespace with every other token.
+	// Turning supervision off keeps them: lookup ignores them while the game
+	// is unsupervised, and cards already printed work again if it's turned
+	// back on.
+	var existing map[string]bool
+	if req.Supervised {
+		games, err := s.allGames(ctx)
+		if err != nil {
+			return AdminGameDetail{}, err
+		}
+		existing = teamTokens(games)
 	}
 
-	oldStatus := g.Status
+	err := s.modifyGame(ctx, id, func(g *game) error {
+		if req.Version != g.version() {
+			return ErrVersionConflict
+		}
+		g.Version = g.version() + 1
+
+		oldStatus := g.Status
+
+		// Always refresh stages from scenario. Reset team progress and redraw
+		// stage pools if stages or the pool size changed.
+		if stagesChanged(g.Stages, stages) || g.PlayCount != req.PlayCount {
+			g.Stages = stages
+			g.PlayCount = req.PlayCount
+			for i := range g.Teams {
+				g.Teams[i].UnlockedStages = nil
+				g.Teams[i].Results = nil
+				g.Teams[i].PendingAnswers = nil
+				g.Teams[i].PendingAdvance = false
+				g.Teams[i].Confirmed = nil
+				g.Teams[i].AdminUnlocks = nil
+				g.Teams[i].StagePool = selectStagePool(g.Stages, g.PlayCount, g.Teams[i].ID)
+			}
+		}
 
-	// Always refresh stages from scenario. Reset team progress if stages changed.
-	if stagesChanged(g.Stages, stages) {
-		g.Stages = stages
-		for i := range g.Teams {
-			g.Teams[i].UnlockedStages = nil
-			g.Teams[i].Results = nil
+		// Backfill TeamSecret for existing teams when mode becomes math_puzzle.
+		if req.Mode == "math_puzzle" {
+			for i := range g.Teams {
+				if g.Teams[i].TeamSecret == 0 {
+					g.Teams[i].TeamSecret = teamSecret()
+				}
+			}
 		}
-	}
 
-	// Backfill TeamSecret for existing teams when mode becomes math_puzzle.
-	if req.Mode == "math_puzzle" {
-		for i := range g.Teams {
-			if g.Teams[i].TeamSecret == 0 {
-				var b [2]byte
-				rand.Read(b[:])
-				g.Teams[i].TeamSecret = 100 + int(binary.LittleEndian.Uint16(b[:]))%900
+		// Without a supervisor nobody on the team can unlock a stage.
+		if req.Supervised {
+			for i := range g.Teams {
+				if g.Teams[i].SupervisorToken == "" {
+					g.Teams[i].SupervisorToken = uniqueToken(existing, generateSupervisorToken)
+				}
 			}
 		}
-	}
 
-	g.ScenarioID = req.ScenarioID
-	g.ScenarioName = req.ScenarioName
-	g.Mode = req.Mode
-	g.Language = req.Language
-	g.Status = req.Status
-	g.Supervised = req.Supervised
-	g.TimerEnabled = req.TimerEnabled
-	g.TimerMinutes = req.TimerMinutes
-	g.StageTimerMinutes = req.StageTimerMinutes
-	g.Notes = req.Notes
-
-	// Handle status transition timestamps.
-	if req.Status != oldStatus {
-		now := nowUTC()
-		switch req.Status {
-		case "active":
-			if g.StartedAt == nil {
-				g.StartedAt = &now
+		g.ScenarioID = req.ScenarioID
+		g.ScenarioName = req.ScenarioName
+		g.Mode = req.Mode
+		g.Language = req.Language
+		g.Status = req.Status
+		g.Supervised = req.Supervised
+		g.TimerEnabled = req.TimerEnabled
+		g.TimerMinutes = req.TimerMinutes
+		g.StageTimerMinutes = req.StageTimerMinutes
+		g.Notes = req.Notes
+		g.Outro = req.Outro
+		g.AllPlayersGrading = req.AllPlayersGrading
+		g.MinPlayersToStart = req.MinPlayersToStart
+		g.MaxAttempts = req.MaxAttempts
+		g.GameSettings = req.gameSettings()
+
+		// Handle status transition timestamps.
+		if req.Status != oldStatus {
+			now := nowUTC()
+			switch req.Status {
+			case "active":
+				if g.StartedAt == nil {
+					g.StartedAt = &now
+				}
+			case "ended":
+				g.EndedAt = &now
+			case "draft":
+				g.StartedAt = nil
+				g.EndedAt = nil
 			}
-		case "ended":
-			g.EndedAt = &now
-		case "draft":
-			g.StartedAt = nil
-			g.EndedAt = nil
 		}
+		g.resetTimerWarnings(time.Now())
+		return nil
+	})
+	if err != nil {
+		return AdminGameDetail{}, err
 	}
+	// Read back rather than echo req so the PIN modifyGame assigned is included.
+	return s.GetGame(ctx, id)
+}
 
-	if err := s.putGame(ctx, g); err != nil {
-		return AdminGameDetail{}, err
+func (s *DocStore) DeleteGame(ctx context.Context, id string) error {
+	if err := s.del(ctx, "games", id); err != nil {
+		return err
 	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM events WHERE game_id = ?`, id)
+	return err
+}
 
-	teams := make([]AdminTeamItem, len(g.Teams))
-	for i, t := range g.Teams {
-		teams[i] = AdminTeamItem{
-			ID:              t.ID,
-			Name:            t.Name,
-			JoinToken:       t.JoinToken,
-			SupervisorToken: t.SupervisorToken,
-			GuideName:       t.GuideName,
-			TeamSecret:      t.TeamSecret,
-			StartStage:      t.StartStage,
-			PlayerCount:     len(t.Players),
-			CreatedAt:       t.CreatedAt,
+// ExpiredGame is an ended game past the retention period, which the
+// retention cleanup removes.
+type ExpiredGame struct {
+	ID           string   `json:"id"`
+	ScenarioName string   `json:"scenarioName"`
+	EndedAt      string   `json:"endedAt"`
+	Teams        int      `json:"teams"`
+	Players      int      `json:"players"`
+	teamIDs      []string // for checking live connections
+}
+
+// ExpiredGames returns the games that ended before endedBefore, oldest first.
+// Ended games without an endedAt are never expired, since their age is
+// unknown.
+func (s *DocStore) ExpiredGames(ctx context.Context, endedBefore time.Time) ([]ExpiredGame, error) {
+	rows, err := s.read.QueryContext(ctx,
+		`SELECT json_remove(json(data), '$.stages') FROM games WHERE status = 'ended'`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expired := []ExpiredGame{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
 		}
+		var g game
+		if err := json.Unmarshal([]byte(data), &g); err != nil {
+			return nil, err
+		}
+		if !g.endedBefore(endedBefore) {
+			continue
+		}
+		e := ExpiredGame{ID: g.ID, ScenarioName: g.ScenarioName, EndedAt: *g.EndedAt, Teams: len(g.Teams)}
+		for _, t := range g.Teams {
+			e.Players += len(t.Players)
+			e.teamIDs = append(e.teamIDs, t.ID)
+		}
+		expired = append(expired, e)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(expired, func(a, b ExpiredGame) int { return strings.Compare(a.EndedAt, b.EndedAt) })
+	return expired, nil
+}
 
-	return AdminGameDetail{
-		ID:                id,
-		ScenarioID:        req.ScenarioID,
-		ScenarioName:      req.ScenarioName,
-		Status:            req.Status,
-		Mode:              g.Mode,
-		Language:          req.Language,
-		Supervised:        req.Supervised,
-		TimerEnabled:      req.TimerEnabled,
-		TimerMinutes:      req.TimerMinutes,
-		StageTimerMinutes: req.StageTimerMinutes,
-		Notes:             req.Notes,
-		StartedAt:         g.StartedAt,
-		Stages:            g.Stages,
-		Teams:             teams,
-		CreatedAt:         g.CreatedAt,
-	}, nil
+// endedBefore reports whether the game ended before t.
+func (g *game) endedBefore(t time.Time) bool {
+	if g.Status != "ended" || g.EndedAt == nil {
+		return false
+	}
+	ended, err := time.Parse(time.RFC3339Nano, *g.EndedAt)
+	return err == nil && ended.Before(t)
 }
 
-func (s *DocStore) DeleteGame(ctx context.Context, id string) error {
-	return s.del(ctx, "games", id)
+// DeleteExpiredGame deletes a game, its player sessions and its event log if
+// it still ended before endedBefore, so a game reopened since ExpiredGames
+// listed it is kept. deleted is false when the game was kept or is already
+// gone.
+func (s *DocStore) DeleteExpiredGame(ctx context.Context, gameID string, endedBefore time.Time) (deleted bool, err error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var data string
+	err = tx.QueryRowContext(ctx,
+		`SELECT json_remove(json(data), '$.teams', '$.stages') FROM games WHERE id = ?`, gameID,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var g game
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		return false, err
+	}
+	if !g.endedBefore(endedBefore) {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM games WHERE id = ?`, gameID); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM player_sessions WHERE json_extract(data, '$.gameId') = ?`, gameID,
+	); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM events WHERE game_id = ?`, gameID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
 func (s *DocStore) GameHasPlayers(ctx context.Context, gameID string) (bool, error) {
@@ -779,93 +1844,155 @@ func (s *DocStore) ListTeams(ctx context.Context, gameID string) ([]AdminTeamIte
 			Name:            t.Name,
 			JoinToken:       t.JoinToken,
 			SupervisorToken: t.SupervisorToken,
+			SpectatorToken:  t.SpectatorToken,
 			GuideName:       t.GuideName,
 			TeamSecret:      t.TeamSecret,
 			StartStage:      t.StartStage,
 			PlayerCount:     len(t.Players),
+			AdminUnlocks:    t.adminUnlockedStages(),
 			CreatedAt:       t.CreatedAt,
+			Version:         t.version(),
 		}
 	}
 	return teams, nil
 }
 
-func (s *DocStore) CreateTeam(ctx context.Context, gameID string, req AdminTeamRequest, token string) (AdminTeamItem, error) {
-	// Check join token uniqueness across all games.
-	games, err := s.allGames(ctx)
-	if err != nil {
-		return AdminTeamItem{}, err
-	}
+// teamTokens collects every join, supervisor and spectator token in use.
+func teamTokens(games []game) map[string]bool {
+	tokens := make(map[string]bool)
 	for _, g := range games {
 		for _, t := range g.Teams {
-			if t.JoinToken == token {
-				return AdminTeamItem{}, fmt.Errorf("UNIQUE constraint failed: join_token %q", token)
+			for _, tok := range []string{t.JoinToken, t.SupervisorToken, t.SpectatorToken} {
+				if tok != "" {
+					tokens[tok] = true
+				}
 			}
 		}
 	}
+	return tokens
+}
 
-	// Look up game to check if supervised.
-	g, err := s.getGame(ctx, gameID)
+// uniqueToken calls generate until it returns a token not in existing, then
+// records it there so later calls can't hand out the same one.
+func uniqueToken(existing map[string]bool, generate func() string) string {
+	for {
+		tok := generate()
+		if !existing[tok] {
+			existing[tok] = true
+			return tok
+		}
+	}
+}
+
+// CreateTeam adds one team with the given join token. It is CreateTeams for a
+// single team.
+func (s *DocStore) CreateTeam(ctx context.Context, gameID string, req AdminTeamRequest, token string) (AdminTeamItem, error) {
+	req.JoinToken = token
+	teams, err := s.CreateTeams(ctx, gameID, []AdminTeamRequest{req}, nil)
 	if err != nil {
 		return AdminTeamItem{}, err
 	}
+	return teams[0], nil
+}
+
+// CreateTeams adds several teams to a game in one modifyGame call, so either
+// all of them are created or none are. Requests without a JoinToken get one
+// from generate. A join token already in use fails the whole batch with a
+// UNIQUE error.
+func (s *DocStore) CreateTeams(ctx context.Context, gameID string, reqs []AdminTeamRequest, generate func() string) ([]AdminTeamItem, error) {
+	// Check join token uniqueness across all games. Every kind of token is
+	// looked up in the same place (TeamLookup), so they share one namespace.
+	games, err := s.allGames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := teamTokens(games)
+
+	var items []AdminTeamItem
+	err = s.modifyGame(ctx, gameID, func(g *game) error {
+		items = make([]AdminTeamItem, 0, len(reqs))
+		for _, req := range reqs {
+			token := req.JoinToken
+			if token == "" {
+				token = uniqueToken(existing, generate)
+			} else if existing[token] {
+				return fmt.Errorf("UNIQUE constraint failed: join_token %q", token)
+			} else {
+				existing[token] = true
+			}
 
+			t := g.newTeam(req, token, existing)
+			g.Teams = append(g.Teams, t)
+			items = append(items, AdminTeamItem{
+				ID:              t.ID,
+				Name:            t.Name,
+				JoinToken:       t.JoinToken,
+				SupervisorToken: t.SupervisorToken,
+				SpectatorToken:  t.SpectatorToken,
+				GuideName:       t.GuideName,
+				TeamSecret:      t.TeamSecret,
+				StartStage:      t.StartStage,
+				PlayerCount:     0,
+				CreatedAt:       t.CreatedAt,
+				Version:         t.version(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// newTeam builds a team for g with its secret, stage pool and extra tokens.
+// existing holds the tokens in use; the new ones are added to it.
+func (g *game) newTeam(req AdminTeamRequest, token string, existing map[string]bool) team {
 	teamID := newID()
-	now := nowUTC()
-	newTeam := team{
+	t := team{
 		ID:         teamID,
 		Name:       req.Name,
 		JoinToken:  token,
 		GuideName:  req.GuideName,
 		StartStage: req.StartStage,
-		CreatedAt:  now,
+		CreatedAt:  nowUTC(),
 		Players:    []player{},
 		Results:    []stageResult{},
 	}
 	if g.Mode == "math_puzzle" {
-		var b [2]byte
-		rand.Read(b[:])
-		newTeam.TeamSecret = 100 + int(binary.LittleEndian.Uint16(b[:]))%900
+		t.TeamSecret = teamSecret()
 	}
+	t.StagePool = selectStagePool(g.Stages, g.PlayCount, teamID)
 	if g.Supervised {
-		superToken := generateSupervisorToken()
-		// Verify uniqueness of supervisor token too.
-		for _, gg := range games {
-			for _, t := range gg.Teams {
-				if t.SupervisorToken == superToken || t.JoinToken == superToken {
-					// Regenerate on collision (extremely unlikely with random tokens).
-					superToken = generateSupervisorToken()
-				}
-			}
-		}
-		newTeam.SupervisorToken = superToken
+		t.SupervisorToken = uniqueToken(existing, generateSupervisorToken)
 	}
+	// Every team gets a read-only token for projecting its progress.
+	t.SpectatorToken = uniqueToken(existing, generateSpectatorToken)
+	return t
+}
 
-	err = s.modifyGame(ctx, gameID, func(g *game) error {
-		g.Teams = append(g.Teams, newTeam)
-		return nil
-	})
+func (s *DocStore) UpdateTeam(ctx context.Context, gameID, teamID string, req AdminTeamRequest) (AdminTeamItem, error) {
+	// A new join token must be free in every game, as on create.
+	games, err := s.allGames(ctx)
 	if err != nil {
 		return AdminTeamItem{}, err
 	}
+	existing := teamTokens(games)
 
-	return AdminTeamItem{
-		ID:              teamID,
-		Name:            req.Name,
-		JoinToken:       token,
-		SupervisorToken: newTeam.SupervisorToken,
-		GuideName:       req.GuideName,
-		TeamSecret:      newTeam.TeamSecret,
-		StartStage:      req.StartStage,
-		PlayerCount:     0,
-		CreatedAt:       now,
-	}, nil
-}
-
-func (s *DocStore) UpdateTeam(ctx context.Context, gameID, teamID string, req AdminTeamRequest) (AdminTeamItem, error) {
 	var result AdminTeamItem
-	err := s.modifyGame(ctx, gameID, func(g *game) error {
+	err = s.modifyGame(ctx, gameID, func(g *game) error {
 		for i := range g.Teams {
 			if g.Teams[i].ID == teamID {
+				if req.Version != g.Teams[i].version() {
+					return ErrVersionConflict
+				}
+				if req.JoinToken != "" && req.JoinToken != g.Teams[i].JoinToken {
+					if existing[req.JoinToken] {
+						return fmt.Errorf("UNIQUE constraint failed: join_token %q", req.JoinToken)
+					}
+					g.Teams[i].JoinToken = req.JoinToken
+				}
+				g.Teams[i].Version = g.Teams[i].version() + 1
 				g.Teams[i].Name = req.Name
 				g.Teams[i].GuideName = req.GuideName
 				g.Teams[i].StartStage = req.StartStage
@@ -874,11 +2001,13 @@ func (s *DocStore) UpdateTeam(ctx context.Context, gameID, teamID string, req Ad
 					Name:            req.Name,
 					JoinToken:       g.Teams[i].JoinToken,
 					SupervisorToken: g.Teams[i].SupervisorToken,
+					SpectatorToken:  g.Teams[i].SpectatorToken,
 					GuideName:       req.GuideName,
 					TeamSecret:      g.Teams[i].TeamSecret,
 					StartStage:      req.StartStage,
 					PlayerCount:     len(g.Teams[i].Players),
 					CreatedAt:       g.Teams[i].CreatedAt,
+					Version:         g.Teams[i].Version,
 				}
 				return nil
 			}
@@ -888,6 +2017,63 @@ func (s *DocStore) UpdateTeam(ctx context.Context, gameID, teamID string, req Ad
 	return result, err
 }
 
+// TeamSessions lists the player sessions that point at a team, including
+// spectator sessions which have no player entry.
+func (s *DocStore) TeamSessions(ctx context.Context, gameID, teamID string) ([]AdminTeamSession, error) {
+	g, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	players := make(map[string]player)
+	found := false
+	for _, t := range g.Teams {
+		if t.ID == teamID {
+			found = true
+			for _, p := range t.Players {
+				players[p.ID] = p
+			}
+			break
+		}
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.read.QueryContext(ctx,
+		`SELECT json(data) FROM player_sessions
+		 WHERE json_extract(data, '$.gameId') = ? AND json_extract(data, '$.teamId') = ?`,
+		gameID, teamID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []AdminTeamSession{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var ps playerSession
+		if err := json.Unmarshal([]byte(data), &ps); err != nil {
+			return nil, err
+		}
+		role := ps.Role
+		if role == "" {
+			role = "player"
+		}
+		p := players[ps.PlayerID]
+		sessions = append(sessions, AdminTeamSession{
+			PlayerID:   ps.PlayerID,
+			PlayerName: p.Name,
+			Role:       role,
+			JoinedAt:   p.JoinedAt,
+		})
+	}
+	return sessions, rows.Err()
+}
+
 func (s *DocStore) DeleteTeam(ctx context.Context, gameID, teamID string) error {
 	return s.modifyGame(ctx, gameID, func(g *game) error {
 		for i := range g.Teams {
@@ -918,7 +2104,7 @@ func (s *DocStore) TeamHasPlayers(ctx context.Context, gameID, teamID string) (b
 
 func (s *DocStore) GameExists(ctx context.Context, gameID string) (bool, error) {
 	var n int
-	err := s.db.QueryRowContext(ctx,
+	err := s.read.QueryRowContext(ctx,
 		`SELECT 1 FROM games WHERE id = ?`, gameID,
 	).Scan(&n)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -948,11 +2134,33 @@ func (s *DocStore) GameStatus(ctx context.Context, gameID string) (AdminGameStat
 			}
 		}
 
-		completed := 0
-		for _, r := range t.Results {
+		names := make(map[string]string, len(t.Players))
+		for _, p := range t.Players {
+			names[p.ID] = p.Name
+		}
+
+		completed, score := 0, 0
+		results := make([]AdminStageResult, len(t.Results))
+		for j, r := range t.Results {
 			if r.IsCorrect {
 				completed++
 			}
+			score += r.points()
+			results[j] = AdminStageResult{
+				StageNumber: r.StageNumber,
+				Answer:      r.Answer,
+				IsCorrect:   r.IsCorrect,
+				Points:      r.points(),
+				PlayerName:  names[r.PlayerID],
+				AnsweredAt:  r.AnsweredAt,
+			}
+		}
+
+		// With individualScoring every player works through the stages, so
+		// the team is done when they all are.
+		total := g.totalTeamStages()
+		if g.individualScoring(g.Mode) {
+			total *= len(t.Players)
 		}
 
 		teams[i] = AdminTeamStatus{
@@ -960,7 +2168,10 @@ func (s *DocStore) GameStatus(ctx context.Context, gameID string) (AdminGameStat
 			Name:            t.Name,
 			GuideName:       t.GuideName,
 			CompletedStages: completed,
+			Score:           score,
+			ProgressPercent: progressPercent(len(t.Results), total),
 			Players:         players,
+			Results:         results,
 		}
 	}
 
@@ -974,11 +2185,197 @@ func (s *DocStore) GameStatus(ctx context.Context, gameID string) (AdminGameStat
 		TimerMinutes:      g.TimerMinutes,
 		StageTimerMinutes: g.StageTimerMinutes,
 		StartedAt:         g.StartedAt,
-		TotalStages:       len(g.Stages),
+		TotalStages:       g.totalTeamStages(),
 		Teams:             teams,
 	}, nil
 }
 
+// StageStats counts recorded answers per scenario stage across all teams.
+// Auto-completed stages carry no answer text and aren't counted; stages no
+// team has answered are returned with zeros.
+func (s *DocStore) StageStats(ctx context.Context, gameID string) ([]AdminStageStats, error) {
+	g, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]AdminStageStats, len(g.Stages))
+	byNumber := make(map[int]*AdminStageStats, len(g.Stages))
+	for i, st := range g.Stages {
+		stats[i] = AdminStageStats{StageNumber: st.StageNumber, Location: st.Location}
+		byNumber[st.StageNumber] = &stats[i]
+	}
+
+	for _, t := range g.Teams {
+		stages, startStage := g.teamStages(t)
+		if len(stages) == 0 {
+			continue
+		}
+		for _, r := range t.Results {
+			if r.Answer == "" || r.StageNumber < 1 || r.StageNumber > len(stages) {
+				continue
+			}
+			st := byNumber[stages[rotatedStageIndex(r.StageNumber, startStage, len(stages))].StageNumber]
+			st.Attempts++
+			if r.IsCorrect {
+				st.Correct++
+			} else {
+				st.Wrong++
+			}
+		}
+	}
+
+	for i := range stats {
+		if stats[i].Correct > 0 {
+			stats[i].AvgAttemptsToCorrect = float64(stats[i].Attempts) / float64(stats[i].Correct)
+		}
+	}
+	return stats, nil
+}
+
+// AnswerLog groups every team's recorded answers by scenario stage and answer
+// text (see AdminStageAnswers). Auto-completed stages carry no answer text and
+// are skipped.
+func (s *DocStore) AnswerLog(ctx context.Context, gameID string) ([]AdminStageAnswers, error) {
+	g, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	rules := g.answerRules()
+
+	stages := make([]AdminStageAnswers, len(g.Stages))
+	byNumber := make(map[int]*AdminStageAnswers, len(g.Stages))
+	groups := make(map[int]map[string]int, len(g.Stages)) // stage → answer key → index in Answers
+	for i, st := range g.Stages {
+		stages[i] = AdminStageAnswers{StageNumber: st.StageNumber, Location: st.Location, Answers: []AdminAnswerGroup{}}
+		byNumber[st.StageNumber] = &stages[i]
+		groups[st.StageNumber] = make(map[string]int)
+	}
+
+	for _, t := range g.Teams {
+		played, startStage := g.teamStages(t)
+		if len(played) == 0 {
+			continue
+		}
+		for _, r := range t.Results {
+			if r.Answer == "" || r.StageNumber < 1 || r.StageNumber > len(played) {
+				continue
+			}
+			stage := played[rotatedStageIndex(r.StageNumber, startStage, len(played))]
+			number := stage.StageNumber
+			st := byNumber[number]
+			key := stage.answerKey(r.Answer, rules)
+			i, ok := groups[number][key]
+			if !ok {
+				i = len(st.Answers)
+				groups[number][key] = i
+				st.Answers = append(st.Answers, AdminAnswerGroup{Answer: strings.TrimSpace(r.Answer), IsCorrect: r.IsCorrect})
+			}
+			st.Answers[i].TeamCount++
+			st.Answers[i].Teams = append(st.Answers[i].Teams, AdminAnswerTeam{ID: t.ID, Name: t.Name})
+		}
+	}
+
+	for i := range stages {
+		slices.SortStableFunc(stages[i].Answers, func(a, b AdminAnswerGroup) int {
+			return b.TeamCount - a.TeamCount
+		})
+	}
+	return stages, nil
+}
+
+// GameResults lists every team's results (see AdminResultRow), team by team
+// in the order they were recorded.
+func (s *DocStore) GameResults(ctx context.Context, gameID string) (AdminGameResults, error) {
+	g, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return AdminGameResults{}, err
+	}
+	results := AdminGameResults{ScenarioName: g.ScenarioName, Rows: []AdminResultRow{}}
+	for _, t := range g.Teams {
+		played, startStage := g.teamStages(t)
+		for _, r := range t.Results {
+			number := r.StageNumber
+			if r.StageNumber >= 1 && r.StageNumber <= len(played) {
+				number = played[rotatedStageIndex(r.StageNumber, startStage, len(played))].StageNumber
+			}
+			results.Rows = append(results.Rows, AdminResultRow{
+				Team:        t.Name,
+				StageNumber: number,
+				Answer:      r.Answer,
+				IsCorrect:   r.IsCorrect,
+				AnsweredAt:  r.AnsweredAt,
+			})
+		}
+	}
+	return results, nil
+}
+
+// RegradeGame applies answer-key corrections (keyed by scenario stage number)
+// to the game's stage snapshot, then re-evaluates every recorded answer.
+// Auto-completed stages carry no answer text and are left untouched, as are
+// answers that came in after the stage timer ran out. requireAllPlayers team
+// results are graded again from their players' answers.
+func (s *DocStore) RegradeGame(ctx context.Context, gameID string, corrections map[int]string) (int, int, error) {
+	flipped, checked := 0, 0
+	err := s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Stages {
+			if answer, ok := corrections[g.Stages[i].StageNumber]; ok {
+				g.Stages[i].CorrectAnswer = answer
+				if g.Stages[i].AnswerType == answerTypeList {
+					g.Stages[i].AcceptedAnswers = splitListAnswer(answer, g.Stages[i].ListDelimiter)
+				}
+			}
+		}
+		if len(g.Stages) == 0 {
+			return nil
+		}
+		for i := range g.Teams {
+			t := &g.Teams[i]
+			stages, startStage := g.teamStages(*t)
+			for j := range t.Results {
+				r := &t.Results[j]
+				if r.Answer == "" || r.TimedOut {
+					continue
+				}
+				idx := rotatedStageIndex(r.StageNumber, startStage, len(stages))
+				var isCorrect bool
+				switch {
+				case len(r.PlayerAnswers) > 0:
+					// A team result is graded again from every player's
+					// answer, the way RecordPlayerAnswer first graded it.
+					for k := range r.PlayerAnswers {
+						p := &r.PlayerAnswers[k]
+						p.IsCorrect = stages[idx].matchesAnswer(p.Answer, g.answerRules())
+					}
+					graded := gradePlayerAnswers(r.PlayerAnswers, g.AllPlayersGrading)
+					r.Answer, r.PlayerID = graded.Answer, graded.PlayerID
+					isCorrect = graded.IsCorrect
+				case g.RequireAllPlayers && !g.Supervised:
+					// A team result recorded before the players' answers
+					// were kept: one answer can't say how the team voted.
+					continue
+				default:
+					isCorrect = stages[idx].matchesAnswer(r.Answer, g.answerRules())
+				}
+				checked++
+				if isCorrect != r.IsCorrect {
+					// The answer's timing is gone, so an answer regraded
+					// correct scores the stage's points without decay.
+					r.IsCorrect = isCorrect
+					r.PointsAwarded = 0
+					if isCorrect {
+						r.PointsAwarded = stages[idx].points()
+					}
+					flipped++
+				}
+			}
+		}
+		return nil
+	})
+	return flipped, checked, err
+}
+
 // SeedDemoGame creates the demo game if no games exist, snapshotting the given scenario stages.
 func (s *DocStore) SeedDemoGame(ctx context.Context, sc *scenario) error {
 	var count int
@@ -991,8 +2388,19 @@ func (s *DocStore) SeedDemoGame(ctx context.Context, sc *scenario) error {
 	}
 
 	now := nowUTC()
+	teams := make([]team, len(demoSeed.Teams))
+	for i, dt := range demoSeed.Teams {
+		teams[i] = team{
+			ID:        dt.ID,
+			Name:      dt.Name,
+			JoinToken: dt.JoinToken,
+			CreatedAt: now,
+			Players:   []player{},
+			Results:   []stageResult{},
+		}
+	}
 	game := game{
-		ID:                "g0000000deadbeef",
+		ID:                demoSeed.GameID,
 		ScenarioID:        sc.ID,
 		ScenarioName:      sc.Name,
 		Status:            "active",
@@ -1003,41 +2411,49 @@ func (s *DocStore) SeedDemoGame(ctx context.Context, sc *scenario) error {
 		Stages:       sc.Stages,
 		StartedAt:    &now,
 		CreatedAt:    now,
-		Teams: []team{
-			{
-				ID:        "t000000000incas",
-				Name:      "Los Incas",
-				JoinToken: "incas-2025",
-				CreatedAt: now,
-				Players:   []player{},
-				Results:   []stageResult{},
-			},
-			{
-				ID:        "t00000000condor",
-				Name:      "Los Condores",
-				JoinToken: "condores-2025",
-				CreatedAt: now,
-				Players:   []player{},
-				Results:   []stageResult{},
-			},
-		},
-	}
-	return s.putGame(ctx, game)
-}
-
-func (s *DocStore) UnlockStage(ctx context.Context, gameID, teamID string, stageNumber int) error {
+		Teams:             teams,
+	}
+	return s.putGame(ctx, &game)
+}
+
+// DemoInfo describes the seeded demo game as it is now, with its current
+// teams and join tokens. ErrNotFound if this store was never seeded or the
+// demo game was deleted.
+func (s *DocStore) DemoInfo(ctx context.Context) (*DemoInfo, error) {
+	g, err := s.getGame(ctx, demoSeed.GameID)
+	if err != nil {
+		return nil, err
+	}
+	info := &DemoInfo{ScenarioID: g.ScenarioID, GameID: g.ID, Teams: make([]DemoTeam, len(g.Teams))}
+	for i, t := range g.Teams {
+		info.Teams[i] = DemoTeam{ID: t.ID, Name: t.Name, JoinToken: t.JoinToken}
+	}
+	return info, nil
+}
+
+// UnlockStage marks the given team stages as unlocked. Several stages are
+// unlocked at once when they share a QR code; stages already unlocked are
+// skipped.
+func (s *DocStore) UnlockStage(ctx context.Context, gameID, teamID string, stageNumbers ...int) error {
 	now := nowUTC()
 	return s.modifyGame(ctx, gameID, func(g *game) error {
 		for i := range g.Teams {
 			if g.Teams[i].ID == teamID {
-				// No-op if already unlocked.
-				for _, n := range g.Teams[i].UnlockedStages {
-					if n == stageNumber {
-						return nil
+				changed := false
+				for _, stageNumber := range stageNumbers {
+					if isStageUnlocked(g.Teams[i].UnlockedStages, stageNumber) {
+						continue
+					}
+					g.Teams[i].UnlockedStages = append(g.Teams[i].UnlockedStages, stageNumber)
+					changed = true
+				}
+				if changed {
+					g.Teams[i].StageUnlockedAt = &now
+					g.Teams[i].Confirmed = nil
+					if g.waitsForConfirmations() {
+						g.Teams[i].StageUnlockedAt = nil
 					}
 				}
-				g.Teams[i].UnlockedStages = append(g.Teams[i].UnlockedStages, stageNumber)
-				g.Teams[i].StageUnlockedAt = &now
 				return nil
 			}
 		}
@@ -1045,34 +2461,46 @@ func (s *DocStore) UnlockStage(ctx context.Context, gameID, teamID string, stage
 	})
 }
 
-func (s *DocStore) UnlockAndCompleteStage(ctx context.Context, gameID, teamID string, stageNumber int) error {
+// RecordAdminUnlock notes that an operator unlocked a team's stage.
+func (s *DocStore) RecordAdminUnlock(ctx context.Context, gameID, teamID string, stageNumber int) error {
+	now := nowUTC()
+	return s.modifyGame(ctx, gameID, func(g *game) error {
+		for i := range g.Teams {
+			if g.Teams[i].ID == teamID {
+				g.Teams[i].AdminUnlocks = append(g.Teams[i].AdminUnlocks, adminUnlock{
+					StageNumber: stageNumber,
+					UnlockedAt:  now,
+				})
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// UnlockAndCompleteStage unlocks the given team stages and records each as
+// correctly completed. Stages that already have a result are skipped.
+func (s *DocStore) UnlockAndCompleteStage(ctx context.Context, gameID, teamID string, stageNumbers ...int) error {
 	now := nowUTC()
 	return s.modifyGame(ctx, gameID, func(g *game) error {
 		for i := range g.Teams {
 			if g.Teams[i].ID == teamID {
-				// Unlock (no-op if already present).
-				found := false
-				for _, n := range g.Teams[i].UnlockedStages {
-					if n == stageNumber {
-						found = true
-						break
+				for _, stageNumber := range stageNumbers {
+					if !isStageUnlocked(g.Teams[i].UnlockedStages, stageNumber) {
+						g.Teams[i].UnlockedStages = append(g.Teams[i].UnlockedStages, stageNumber)
 					}
-				}
-				if !found {
-					g.Teams[i].UnlockedStages = append(g.Teams[i].UnlockedStages, stageNumber)
-				}
-				// Record auto-complete result (skip if already recorded).
-				for _, r := range g.Teams[i].Results {
-					if r.StageNumber == stageNumber {
-						return nil
+					if hasResult(g.Teams[i].Results, stageNumber) {
+						continue
 					}
+					result := stageResult{
+						StageNumber: stageNumber,
+						Answer:      "",
+						IsCorrect:   true,
+						AnsweredAt:  now,
+					}
+					g.awardPoints(&g.Teams[i], &result)
+					g.Teams[i].Results = append(g.Teams[i].Results, result)
 				}
-				g.Teams[i].Results = append(g.Teams[i].Results, stageResult{
-					StageNumber: stageNumber,
-					Answer:      "",
-					IsCorrect:   true,
-					AnsweredAt:  now,
-				})
 				return nil
 			}
 		}
@@ -1080,6 +2508,73 @@ func (s *DocStore) UnlockAndCompleteStage(ctx context.Context, gameID, teamID st
 	})
 }
 
+// attemptDecay is the share of a stage's points each earlier wrong attempt
+// costs a correct answer in pointsDecay games.
+const attemptDecay = 0.25
+
+// awardPoints sets what result scores: nothing when it's wrong, otherwise
+// the stage's points, decayed in pointsDecay games (see decayedPoints). Call
+// it before the team's StageUnlockedAt and Attempts are cleared.
+func (g *game) awardPoints(t *team, result *stageResult) {
+	result.PointsAwarded = 0
+	stages, startStage := g.teamStages(*t)
+	if !result.IsCorrect || result.StageNumber < 1 || result.StageNumber > len(stages) {
+		return
+	}
+	points := stages[rotatedStageIndex(result.StageNumber, startStage, len(stages))].points()
+	if !g.PointsDecay {
+		result.PointsAwarded = points
+		return
+	}
+	var taken float64
+	if g.StageTimerMinutes > 0 && t.StageUnlockedAt != nil {
+		unlocked, err1 := time.Parse(time.RFC3339Nano, *t.StageUnlockedAt)
+		answered, err2 := time.Parse(time.RFC3339Nano, result.AnsweredAt)
+		if err1 == nil && err2 == nil {
+			limit := time.Duration(g.StageTimerMinutes) * time.Minute
+			taken = float64(answered.Sub(unlocked)) / float64(limit)
+		}
+	}
+	wrong := 0
+	for _, a := range t.Attempts {
+		if a.StageNumber == result.StageNumber {
+			wrong++
+		}
+	}
+	result.PointsAwarded = decayedPoints(points, taken, wrong)
+}
+
+// decayedPoints takes points down linearly with taken, the share of the
+// stage timer used (up to half the points when it runs out), and by
+// attemptDecay for each wrong attempt. It stops at half the points, so a
+// correct answer always beats a wrong one.
+func decayedPoints(points int, taken float64, wrongAttempts int) int {
+	taken = min(max(taken, 0), 1)
+	off := float64(points) * (taken/2 + attemptDecay*float64(wrongAttempts))
+	floor := max((points+1)/2, 1)
+	return max(points-int(math.Round(off)), floor)
+}
+
+// hasResult reports whether the team has a result for a stage: any result,
+// or with individualScoring one of playerID's.
+func (g *game) hasResult(t *team, stageNumber int, playerID string) bool {
+	if !g.individualScoring(g.Mode) {
+		return hasResult(t.Results, stageNumber)
+	}
+	return slices.ContainsFunc(t.Results, func(r stageResult) bool {
+		return r.StageNumber == stageNumber && r.PlayerID == playerID
+	})
+}
+
+func hasResult(results []stageResult, stageNumber int) bool {
+	for _, r := range results {
+		if r.StageNumber == stageNumber {
+			return true
+		}
+	}
+	return false
+}
+
 // stagesChanged returns true if the two stage slices differ in content.
 func stagesChanged(old, new []AdminStage) bool {
 	oldJSON, _ := json.Marshal(old)
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// GameEvent is one entry of a game's event log: something a team did, in
+// the order it happened. TeamName and PlayerName are filled in when the log
+// is read, from the game's current teams.
+type GameEvent struct {
+	Seq         int64  `json:"seq"`
+	At          string `json:"at"`
+	Type        string `json:"type"` // joined, unlocked, answered, player_answered, advanced, timed_out, gave_up
+	TeamID      string `json:"teamId"`
+	TeamName    string `json:"teamName,omitempty"`
+	PlayerID    string `json:"playerId,omitempty"`
+	PlayerName  string `json:"playerName,omitempty"`
+	Role        string `json:"role,omitempty"`  // joined: the role the player joined as
+	Admin       string `json:"admin,omitempty"` // unlocked by an operator: their email
+	StageNumber int    `json:"stageNumber,omitempty"`
+	Answer      string `json:"answer,omitempty"`
+	IsCorrect   bool   `json:"isCorrect,omitempty"`
+	Completed   bool   `json:"completed,omitempty"` // unlocked: the unlock also completed the stage
+}
+
+// createEventsTable is run by NewDocStore. The log is append-only; a game's
+// entries go only when the game is deleted.
+func createEventsTable(ctx context.Context, s *DocStore) error {
+	for _, ddl := range []string{
+		`CREATE TABLE IF NOT EXISTS events (
+			id      INTEGER PRIMARY KEY,
+			game_id TEXT NOT NULL,
+			data    JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS events_game ON events (game_id, id)`,
+	} {
+		if _, err := s.db.ExecContext(ctx, ddl); err != nil {
+			return fmt.Errorf("creating events table: %w", err)
+		}
+	}
+	return nil
+}
+
+// LogGameEvent appends ev to the game's event log, stamped with the current
+// time unless ev.At is set.
+func (s *DocStore) LogGameEvent(ctx context.Context, gameID string, ev GameEvent) error {
+	if ev.At == "" {
+		ev.At = nowUTC()
+	}
+	ev.Seq, ev.TeamName, ev.PlayerName = 0, "", ""
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO events (game_id, data) VALUES (?, jsonb(?))`, gameID, string(data),
+	)
+	return err
+}
+
+// GameEventLog returns the game's event log in the order it was written.
+// Events of teams or players removed since keep their IDs but no names.
+func (s *DocStore) GameEventLog(ctx context.Context, gameID string) ([]GameEvent, error) {
+	// Names come from the teams alone, so a game with broken stages still
+	// shows its log.
+	var data string
+	err := s.read.QueryRowContext(ctx,
+		`SELECT json_remove(json(data), '$.stages') FROM games WHERE id = ?`, gameID,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var g game
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		return nil, err
+	}
+	teamNames := map[string]string{}
+	playerNames := map[string]string{}
+	for _, t := range g.Teams {
+		teamNames[t.ID] = t.Name
+		for _, p := range t.Players {
+			playerNames[p.ID] = p.Name
+		}
+	}
+
+	rows, err := s.read.QueryContext(ctx,
+		`SELECT id, json(data) FROM events WHERE game_id = ? ORDER BY id`, gameID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []GameEvent{}
+	for rows.Next() {
+		var seq int64
+		var data string
+		if err := rows.Scan(&seq, &data); err != nil {
+			return nil, err
+		}
+		var ev GameEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return nil, err
+		}
+		ev.Seq = seq
+		ev.TeamName = teamNames[ev.TeamID]
+		ev.PlayerName = playerNames[ev.PlayerID]
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
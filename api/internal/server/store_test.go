@@ -0,0 +1,379 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestUpdateVersionConflict checks that every versioned admin update, in both
+// the admin store and a client store, behaves the same way under two
+// operators: the first save bumps the version, a save based on the old
+// version gets ErrVersionConflict and writes nothing.
+func TestUpdateVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	admin, store := setupStores(t)
+
+	gameID, teamID := demoSeed.GameID, demoCondores.ID
+	sc, err := admin.GetScenario(ctx, demoSeed.ScenarioID)
+	if err != nil {
+		t.Fatalf("get scenario: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		// load returns the current version and the value of the edited field.
+		load func() (int, string)
+		// update saves value as an edit started from version.
+		update func(version int, value string) (int, error)
+	}{
+		{
+			name: "scenario",
+			load: func() (int, string) {
+				got, err := admin.GetScenario(ctx, sc.ID)
+				if err != nil {
+					t.Fatalf("get scenario: %v", err)
+				}
+				return got.Version, got.Description
+			},
+			update: func(version int, value string) (int, error) {
+				got, err := admin.UpdateScenario(ctx, sc.ID, AdminScenarioRequest{
+					Name: sc.Name, City: sc.City, Description: value, Mode: sc.Mode, Stages: sc.Stages, Version: version,
+				})
+				return got.Version, err
+			},
+		},
+		{
+			name: "game",
+			load: func() (int, string) {
+				got, err := store.GetGame(ctx, gameID)
+				if err != nil {
+					t.Fatalf("get game: %v", err)
+				}
+				return got.Version, got.Notes
+			},
+			update: func(version int, value string) (int, error) {
+				got, err := store.UpdateGame(ctx, gameID, AdminGameRequest{
+					ScenarioID: sc.ID, ScenarioName: sc.Name, Mode: sc.Mode, Status: "active", Notes: value, Version: version,
+				}, sc.Stages)
+				return got.Version, err
+			},
+		},
+		{
+			name: "team",
+			load: func() (int, string) {
+				got, err := store.GetGame(ctx, gameID)
+				if err != nil {
+					t.Fatalf("get game: %v", err)
+				}
+				for _, tm := range got.Teams {
+					if tm.ID == teamID {
+						return tm.Version, tm.GuideName
+					}
+				}
+				t.Fatalf("team %s not found", teamID)
+				return 0, ""
+			},
+			update: func(version int, value string) (int, error) {
+				got, err := store.UpdateTeam(ctx, gameID, teamID, AdminTeamRequest{
+					Name: "Los Condores", GuideName: value, Version: version,
+				})
+				return got.Version, err
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loaded, _ := tc.load()
+			if loaded != 1 {
+				t.Fatalf("seeded version = %d, want 1", loaded)
+			}
+
+			v, err := tc.update(loaded, "first")
+			if err != nil {
+				t.Fatalf("first update: %v", err)
+			}
+			if v != loaded+1 {
+				t.Errorf("first update: version = %d, want %d", v, loaded+1)
+			}
+
+			if _, err := tc.update(loaded, "second"); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("stale update: err = %v, want ErrVersionConflict", err)
+			}
+			if v, value := tc.load(); v != loaded+1 || value != "first" {
+				t.Errorf("after conflict: version %d value %q, want %d %q", v, value, loaded+1, "first")
+			}
+		})
+	}
+}
+
+// TestPlayDoesNotBumpTeamVersion makes sure an operator editing a team during
+// a running game isn't blocked by the players' own progress.
+func TestPlayDoesNotBumpTeamVersion(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+
+	if _, _, err := store.JoinTeam(ctx, demoSeed.GameID, demoIncas.ID, "Ana", "player"); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if err := store.RecordAnswer(ctx, demoSeed.GameID, demoIncas.ID, "", 1, "x", false); err != nil {
+		t.Fatalf("record answer: %v", err)
+	}
+
+	if _, err := store.UpdateTeam(ctx, demoSeed.GameID, demoIncas.ID, AdminTeamRequest{Name: "Los Incas", Version: 1}); err != nil {
+		t.Errorf("update after play: %v", err)
+	}
+}
+
+// TestCreateTeamsAtomic fails a batch on its second team and checks that the
+// first one wasn't written either.
+func TestCreateTeamsAtomic(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+	gameID := demoSeed.GameID
+	generate := func() string { return generateJoinToken(JoinTokenFormat{Style: tokenStyleHex}) }
+
+	_, err := store.CreateTeams(ctx, gameID, []AdminTeamRequest{
+		{Name: "Los Vicuñas"},
+		{Name: "Copycat", JoinToken: demoCondores.JoinToken}, // taken by the seeded Condores
+		{Name: "Los Pumas"},
+	}, generate)
+	if err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+		t.Fatalf("expected a UNIQUE error, got %v", err)
+	}
+	g, err := store.GetGame(ctx, gameID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	if len(g.Teams) != 2 {
+		t.Fatalf("expected only the 2 seeded teams after the failed batch, got %d: %+v", len(g.Teams), g.Teams)
+	}
+
+	// The same batch without the clash goes in whole, with distinct tokens.
+	created, err := store.CreateTeams(ctx, gameID, []AdminTeamRequest{{Name: "Los Vicuñas"}, {Name: "Los Pumas"}}, generate)
+	if err != nil {
+		t.Fatalf("create teams: %v", err)
+	}
+	if len(created) != 2 || created[0].JoinToken == created[1].JoinToken {
+		t.Fatalf("unexpected teams: %+v", created)
+	}
+	if teams, _ := store.ListTeams(ctx, gameID); len(teams) != 4 {
+		t.Errorf("expected 4 teams, got %d", len(teams))
+	}
+}
+
+// TestSupervisorTokensOnToggle turns supervision on for a game whose teams
+// already exist, then off and on again.
+func TestSupervisorTokensOnToggle(t *testing.T) {
+	ctx := context.Background()
+	admin, store := setupStores(t)
+	gameID := demoSeed.GameID
+
+	sc, err := admin.GetScenario(ctx, demoSeed.ScenarioID)
+	if err != nil {
+		t.Fatalf("get scenario: %v", err)
+	}
+	update := func(supervised bool) AdminGameDetail {
+		t.Helper()
+		g, err := store.GetGame(ctx, gameID)
+		if err != nil {
+			t.Fatalf("get game: %v", err)
+		}
+		got, err := store.UpdateGame(ctx, gameID, AdminGameRequest{
+			ScenarioID: sc.ID, ScenarioName: sc.Name, Mode: sc.Mode, Status: "active", Supervised: supervised, Version: g.Version,
+		}, sc.Stages)
+		if err != nil {
+			t.Fatalf("update game: %v", err)
+		}
+		return got
+	}
+
+	g := update(true)
+	tokens := make(map[string]string) // team ID -> supervisor token
+	for _, tm := range g.Teams {
+		if tm.SupervisorToken == "" || tm.SupervisorToken == tm.JoinToken {
+			t.Fatalf("team %s: supervisor token %q, want a token of its own", tm.ID, tm.SupervisorToken)
+		}
+		lookup, err := store.TeamLookup(ctx, tm.SupervisorToken)
+		if err != nil || lookup.ID != tm.ID || lookup.Role != "supervisor" {
+			t.Errorf("lookup %s: %+v, %v; want the team as supervisor", tm.SupervisorToken, lookup, err)
+		}
+		tokens[tm.ID] = tm.SupervisorToken
+	}
+
+	// Unsupervised, the tokens are kept but no longer let anyone in.
+	update(false)
+	for _, tok := range tokens {
+		if _, err := store.TeamLookup(ctx, tok); !errors.Is(err, ErrNotFound) {
+			t.Errorf("lookup %s while unsupervised: expected ErrNotFound, got %v", tok, err)
+		}
+	}
+
+	// Supervised again, every team has the token it had before.
+	for _, tm := range update(true).Teams {
+		if tm.SupervisorToken != tokens[tm.ID] {
+			t.Errorf("team %s: supervisor token %q, want %q", tm.ID, tm.SupervisorToken, tokens[tm.ID])
+		}
+	}
+}
+
+// TestProgressPercent answers 2 of a team's 4 stages and checks the team and
+// game summaries report 50%.
+func TestProgressPercent(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+
+	stages := []AdminStage{{Location: "A"}, {Location: "B"}, {Location: "C"}, {Location: "D"}}
+	g, err := store.CreateGame(ctx, AdminGameRequest{ScenarioName: "Four", Mode: "classic", Status: "active"}, stages)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+	team, err := store.CreateTeam(ctx, g.ID, AdminTeamRequest{Name: "Halfway"}, "halfway-2025")
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+	if err := store.modifyGame(ctx, g.ID, func(g *game) error {
+		g.Teams[0].Results = []stageResult{
+			{StageNumber: 1, Answer: "a", IsCorrect: true},
+			{StageNumber: 2, Answer: "b"},
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("record results: %v", err)
+	}
+
+	status, err := store.GameStatus(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("game status: %v", err)
+	}
+	if len(status.Teams) != 1 || status.Teams[0].ID != team.ID || status.Teams[0].ProgressPercent != 50 {
+		t.Errorf("team status = %+v, want %s at 50%%", status.Teams, team.ID)
+	}
+
+	games, err := store.ListGames(ctx)
+	if err != nil {
+		t.Fatalf("list games: %v", err)
+	}
+	for _, sum := range games {
+		if sum.ID == g.ID && sum.ProgressPercent != 50 {
+			t.Errorf("game progress = %d%%, want 50%%", sum.ProgressPercent)
+		}
+	}
+
+	if got := progressPercent(0, 0); got != 0 {
+		t.Errorf("progressPercent(0, 0) = %d, want 0", got)
+	}
+}
+
+// TestListCompletedStagesCorrectness checks that completed stages carry each
+// result's own IsCorrect, so the player recap can show right and wrong
+// answers side by side, and that regrading shows up there.
+func TestListCompletedStagesCorrectness(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+	gameID, teamID := demoSeed.GameID, demoIncas.ID
+
+	if err := store.RecordAnswer(ctx, gameID, teamID, "", 1, "1650", false); err != nil {
+		t.Fatalf("record stage 1: %v", err)
+	}
+	if err := store.RecordAnswer(ctx, gameID, teamID, "", 2, "nope", false); err != nil {
+		t.Fatalf("record stage 2: %v", err)
+	}
+	if err := store.UnlockAndCompleteStage(ctx, gameID, teamID, 3); err != nil {
+		t.Fatalf("complete stage 3: %v", err)
+	}
+
+	check := func(want ...bool) {
+		t.Helper()
+		completed, err := store.ListCompletedStages(ctx, gameID, teamID)
+		if err != nil {
+			t.Fatalf("list completed stages: %v", err)
+		}
+		got := make([]bool, len(completed))
+		for i, c := range completed {
+			got[i] = c.IsCorrect
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("isCorrect by stage = %v, want %v", got, want)
+		}
+	}
+	check(false, false, true)
+
+	// Accepting 1650 after the fact flips stage 1 only.
+	if _, _, err := store.RegradeGame(ctx, gameID, map[int]string{1: "1650"}); err != nil {
+		t.Fatalf("regrade: %v", err)
+	}
+	check(true, false, true)
+}
+
+// TestTeamProgress checks that reading one team out of the game document
+// gives the same state as loading the whole game.
+func TestTeamProgress(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+	gameID := demoSeed.GameID
+
+	if err := store.RecordAnswer(ctx, gameID, demoIncas.ID, "", 1, "1650", true); err != nil {
+		t.Fatalf("record incas: %v", err)
+	}
+	if err := store.RecordAnswer(ctx, gameID, demoCondores.ID, "", 1, "nope", false); err != nil {
+		t.Fatalf("record condores: %v", err)
+	}
+
+	full, err := store.getGame(ctx, gameID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	for _, team := range []DemoTeam{demoIncas, demoCondores} {
+		p, err := store.TeamProgress(ctx, gameID, team.ID)
+		if err != nil {
+			t.Fatalf("TeamProgress(%s): %v", team.Name, err)
+		}
+		if want := full.stateData(team.ID); !reflect.DeepEqual(p.gameStateData, want) {
+			t.Errorf("%s: state = %+v, want %+v", team.Name, p.gameStateData, want)
+		}
+		completed, _ := store.ListCompletedStages(ctx, gameID, team.ID)
+		if !reflect.DeepEqual(p.Completed, completed) || p.answered() != 1 {
+			t.Errorf("%s: completed = %+v, want %+v", team.Name, p.Completed, completed)
+		}
+	}
+
+	p, err := store.TeamProgress(ctx, gameID, "no-such-team")
+	if err != nil || p.TeamName != "" || p.answered() != 0 || p.Status != full.Status {
+		t.Errorf("unknown team: %+v, %v", p, err)
+	}
+	if _, err := store.TeamProgress(ctx, "no-such-game", demoIncas.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown game: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDemoInfo(t *testing.T) {
+	ctx := context.Background()
+	_, store := setupStores(t)
+
+	info, err := store.DemoInfo(ctx)
+	if err != nil {
+		t.Fatalf("DemoInfo: %v", err)
+	}
+	if info.GameID != demoSeed.GameID || info.ScenarioID != demoSeed.ScenarioID || !slices.Equal(info.Teams, demoSeed.Teams) {
+		t.Errorf("DemoInfo = %+v, want %+v", info, demoSeed)
+	}
+
+	// An operator changes a token: DemoInfo follows the game, demoSeed doesn't.
+	if _, err := store.UpdateTeam(ctx, demoSeed.GameID, demoIncas.ID, AdminTeamRequest{Name: demoIncas.Name, JoinToken: "incas-2026", Version: 1}); err != nil {
+		t.Fatalf("update team: %v", err)
+	}
+	info, _ = store.DemoInfo(ctx)
+	if info.Teams[0].JoinToken != "incas-2026" {
+		t.Errorf("after update, token = %q, want incas-2026", info.Teams[0].JoinToken)
+	}
+
+	if err := store.DeleteGame(ctx, demoSeed.GameID); err != nil {
+		t.Fatalf("delete game: %v", err)
+	}
+	if _, err := store.DemoInfo(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DemoInfo after delete: err = %v, want ErrNotFound", err)
+	}
+}
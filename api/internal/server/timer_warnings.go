@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// timerWarningInterval is how often WarnTimers checks the game timers. A
+// warning can go out up to this long after its mark; the event carries the
+// time actually left.
+const timerWarningInterval = 15 * time.Second
+
+// WarnTimers publishes timer_warning to every team of an active game when its
+// timer passes one of marks (time left, e.g. 10m, 5m, 1m), until ctx is
+// cancelled. Each mark is recorded on the game, so it is sent once, restarts
+// included; extending the timer back above a mark sends it again. Marks under
+// a second are ignored.
+func (s *Server) WarnTimers(ctx context.Context, marks []time.Duration) {
+	marks = slices.DeleteFunc(slices.Clone(marks), func(m time.Duration) bool { return m < time.Second })
+	if len(marks) == 0 {
+		return
+	}
+	ticker := time.NewTicker(timerWarningInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			checkTimerWarnings(ctx, s.logger, s.clients, s.broker, marks, now)
+		}
+	}
+}
+
+// checkTimerWarnings is one round of WarnTimers over every client.
+func checkTimerWarnings(ctx context.Context, logger *slog.Logger, clients *Registry, broker *Broker, marks []time.Duration, now time.Time) {
+	for slug, store := range clients.snapshot() {
+		warnings, err := store.MarkTimerWarnings(ctx, now, marks)
+		if err != nil {
+			logger.Error("checking game timers", "client", slug, "error", err)
+		}
+		for _, w := range warnings {
+			broker.PublishGame(w.TeamIDs, SSEEvent{
+				Type:             "timer_warning",
+				RemainingSeconds: int((w.Remaining + time.Second - 1) / time.Second),
+			})
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestTimerWarnings(t *testing.T) {
+	_, store := setupStores(t)
+	ctx := context.Background()
+	registry := NewRegistry(t.TempDir(), 1, 0)
+	registry.stores["demo"] = store
+	broker := NewBroker()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	g, err := store.GetGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, _ := time.Parse(time.RFC3339Nano, *g.StartedAt)
+	deadline := start.Add(time.Duration(g.TimerMinutes) * time.Minute)
+
+	incas := broker.Subscribe(demoIncas.ID, 0)
+	condores := broker.Subscribe(demoCondores.ID, 0)
+	marks := []time.Duration{10 * time.Minute, 5 * time.Minute, time.Minute}
+
+	// check runs one round at left before the deadline and returns what each
+	// team received.
+	check := func(left time.Duration) (incasEvents, condoresEvents []SSEEvent) {
+		t.Helper()
+		checkTimerWarnings(ctx, logger, registry, broker, marks, deadline.Add(-left))
+		drain := func(ch chan []byte) []SSEEvent {
+			var events []SSEEvent
+			for len(ch) > 0 {
+				var e SSEEvent
+				json.Unmarshal(<-ch, &e)
+				events = append(events, e)
+			}
+			return events
+		}
+		return drain(incas), drain(condores)
+	}
+
+	if a, b := check(30 * time.Minute); len(a)+len(b) != 0 {
+		t.Fatalf("warned with 30 minutes left: %+v %+v", a, b)
+	}
+
+	for _, tc := range []struct {
+		left time.Duration
+		want int // remainingSeconds
+	}{
+		{9*time.Minute + 30*time.Second, 570},
+		{4 * time.Minute, 240},
+		{30 * time.Second, 30},
+	} {
+		a, b := check(tc.left)
+		for team, events := range map[string][]SSEEvent{"incas": a, "condores": b} {
+			if len(events) != 1 || events[0].Type != "timer_warning" || events[0].RemainingSeconds != tc.want {
+				t.Errorf("%v left: %s got %+v, want one timer_warning with %d seconds", tc.left, team, events, tc.want)
+			}
+		}
+		// The same mark isn't sent twice.
+		if a, b := check(tc.left - time.Second); len(a)+len(b) != 0 {
+			t.Errorf("%v left: mark sent again: %+v %+v", tc.left, a, b)
+		}
+	}
+
+	if a, b := check(-time.Minute); len(a)+len(b) != 0 {
+		t.Errorf("warned after the timer ran out: %+v %+v", a, b)
+	}
+}
+
+func TestTimerWarningsSkippedMarks(t *testing.T) {
+	_, store := setupStores(t)
+	ctx := context.Background()
+	g, err := store.GetGame(ctx, demoSeed.GameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, _ := time.Parse(time.RFC3339Nano, *g.StartedAt)
+	deadline := start.Add(time.Duration(g.TimerMinutes) * time.Minute)
+	marks := []time.Duration{10 * time.Minute, 5 * time.Minute, time.Minute}
+
+	// Checking for the first time with 3 minutes left passes two marks at
+	// once: one warning, and neither mark comes back.
+	warnings, err := store.MarkTimerWarnings(ctx, deadline.Add(-3*time.Minute), marks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || len(warnings[0].TeamIDs) != len(demoSeed.Teams) {
+		t.Fatalf("warnings = %+v, want one for the demo game's teams", warnings)
+	}
+	if warnings, _ := store.MarkTimerWarnings(ctx, deadline.Add(-2*time.Minute), marks); len(warnings) != 0 {
+		t.Errorf("passed marks warned again: %+v", warnings)
+	}
+
+	// Extending the timer back above the 5 minute mark sends it again.
+	if _, err := store.ExtendGameTimer(ctx, demoSeed.GameID, 5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if warnings, _ := store.MarkTimerWarnings(ctx, deadline.Add(5*time.Minute-4*time.Minute), marks); len(warnings) != 1 {
+		t.Errorf("after extending: warnings = %+v, want the 5 minute mark again", warnings)
+	}
+}
@@ -0,0 +1,17 @@
+package server
+
+import "net/http"
+
+// BuildInfo identifies the running binary. Version and Commit are injected by
+// main at build time; GoVersion comes from the runtime.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"goVersion"`
+}
+
+func handleVersion(build BuildInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, build)
+	}
+}
@@ -20,7 +20,11 @@ cd "$REPO_ROOT/api"
 # On macOS/ARM, you need a cross-compiler (e.g. zig cc, or build on the server).
 # To build on the server instead, comment out the local build and uncomment the
 # rsync of api/ + remote build block below.
-CGO_ENABLED=1 GOOS=linux GOARCH=amd64 go build -o "$REPO_ROOT/cityquest" ./cmd/server
+VERSION="$(git describe --tags --always --dirty 2>/dev/null || echo dev)"
+COMMIT="$(git rev-parse --short HEAD 2>/dev/null || echo unknown)"
+CGO_ENABLED=1 GOOS=linux GOARCH=amd64 go build \
+    -ldflags "-X main.version=$VERSION -X main.commit=$COMMIT" \
+    -o "$REPO_ROOT/cityquest" ./cmd/server
 
 echo "==> Uploading to $SERVER..."
 rsync -avz --progress \
@@ -1,5 +1,5 @@
 import { useTranslation } from 'react-i18next'
-import type { StageInfo } from './types'
+import type { StageInfo, Confirmations } from './types'
 import type { Feedback } from './useGameState'
 import { Spinner } from './components/Spinner'
 
@@ -10,12 +10,15 @@ interface Props {
   answer: string
   onAnswerChange: (answer: string) => void
   onSubmit: (e: React.FormEvent) => void
+  onGiveUp?: () => void // set in games that allow giving up
   feedback: Feedback | null
   submitting: boolean
   canAnswer: boolean
+  confirmations?: Confirmations | null
+  onConfirm: () => void
 }
 
-export function AnswerPanel({ stage, totalStages, role, answer, onAnswerChange, onSubmit, feedback, submitting, canAnswer }: Props) {
+export function AnswerPanel({ stage, totalStages, role, answer, onAnswerChange, onSubmit, onGiveUp, feedback, submitting, canAnswer, confirmations, onConfirm }: Props) {
   const { t } = useTranslation('player')
   return (
     <div className="card">
@@ -26,14 +29,33 @@ export function AnswerPanel({ stage, totalStages, role, answer, onAnswerChange,
         <div className="mb-4">
           <p><strong>{t('question_label')}</strong> {stage.question}</p>
           {stage.questionImage && <img src={stage.questionImage} alt="" className="w-full mt-2" />}
+          {stage.attachments && stage.attachments.length > 0 && (
+            <ul className="mt-2 space-y-1">
+              {stage.attachments.map((a, ai) => (
+                <li key={ai}>
+                  <a href={a.url} target="_blank" rel="noopener noreferrer" download className="underline">{a.label}</a>
+                </li>
+              ))}
+            </ul>
+          )}
         </div>
       )}
+      {confirmations && (
+        role === 'supervisor' || confirmations.self ? (
+          <p className="text-secondary mb-4">{t('confirmations_count', { confirmed: confirmations.confirmed, required: confirmations.required })}</p>
+        ) : (
+          <button type="button" onClick={onConfirm} disabled={submitting} className="btn btn-accent w-full mb-4">
+            {submitting ? <Spinner /> : t('confirm_stage')}
+          </button>
+        )
+      )}
       {canAnswer ? (
         <form onSubmit={onSubmit} className="space-y-4">
           <div>
             <input
               className="input"
               type="text"
+              inputMode={stage.answerType === 'number' ? 'decimal' : undefined}
               value={answer}
               onChange={(e) => onAnswerChange(e.target.value)}
               placeholder={t('answer_placeholder')}
@@ -47,6 +69,11 @@ export function AnswerPanel({ stage, totalStages, role, answer, onAnswerChange,
           <button type="submit" disabled={submitting} className="btn btn-accent w-full">
             {submitting ? <Spinner /> : t('submit_answer')}
           </button>
+          {onGiveUp && (
+            <button type="button" onClick={onGiveUp} disabled={submitting} className="btn btn-secondary w-full">
+              {t('give_up')}
+            </button>
+          )}
         </form>
       ) : (
         <p className="text-secondary italic">{t('waiting_for_supervisor_answer')}</p>
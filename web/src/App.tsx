@@ -1,5 +1,6 @@
 import { useState, useEffect } from 'react'
 import { JoinPage } from './JoinPage'
+import { PinJoinPage } from './PinJoinPage'
 import { GamePage } from './GamePage'
 import { AdminLoginPage } from './admin/AdminLoginPage'
 import { AdminLayout } from './admin/AdminLayout'
@@ -13,6 +14,7 @@ import { getSession } from './lib/session'
 
 type Route =
   | { page: 'join'; client: string; token: string }
+  | { page: 'pin'; client: string }
   | { page: 'game' }
   | { page: 'home' }
   | { page: 'admin-login' }
@@ -32,6 +34,10 @@ function getRoute(): Route {
   const joinMatch = path.match(/^\/join\/([^/]+)\/(.+)$/)
   if (joinMatch) return { page: 'join', client: joinMatch[1], token: joinMatch[2] }
 
+  // /pin/{client} — enter a game PIN, then pick a team
+  const pinMatch = path.match(/^\/pin\/([^/]+)$/)
+  if (pinMatch) return { page: 'pin', client: pinMatch[1] }
+
   if (path === '/game' && getSession()) {
     return { page: 'game' }
   }
@@ -78,6 +84,8 @@ export default function App() {
   switch (route.page) {
     case 'join':
       return <JoinPage client={route.client} joinToken={route.token} />
+    case 'pin':
+      return <PinJoinPage client={route.client} />
     case 'game':
       return <GamePage />
     case 'admin-login':
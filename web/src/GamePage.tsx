@@ -1,4 +1,4 @@
-import { useEffect } from 'react'
+import { useEffect, useState } from 'react'
 import { useTranslation } from 'react-i18next'
 import { TimerDisplay } from './TimerDisplay'
 import { InterstitialPanel } from './InterstitialPanel'
@@ -10,6 +10,8 @@ import { PageContainer } from './components/PageContainer'
 import { LoadingPage } from './components/Spinner'
 import { ErrorMessage } from './components/ErrorMessage'
 import { getSession } from './lib/session'
+import { getRecap } from './api'
+import type { RecapStage } from './types'
 
 export function GamePage() {
   const { t, i18n } = useTranslation('player')
@@ -19,7 +21,7 @@ export function GamePage() {
     unlockCode, setUnlockCode,
     feedback, answerResult, submitting,
     gameRemaining, stageRemaining,
-    handleGoToStage, handleUnlock, handleSubmit, handleContinue, handleLogout,
+    handleGoToStage, handleUnlock, handleSubmit, handleGiveUp, handleContinue, handleConfirm, handleLogout,
   } = useGameState()
 
   useEffect(() => {
@@ -31,6 +33,25 @@ export function GamePage() {
     if (state?.game.language) i18n.changeLanguage(state.game.language)
   }, [state?.game.language, i18n])
 
+  // The recap (questions and the team's answers) loads while the completed
+  // stages list is open, and again as stages are answered.
+  const [recap, setRecap] = useState<RecapStage[]>([])
+  const [recapOpen, setRecapOpen] = useState(false)
+  const completedCount = state?.completedStages.length ?? 0
+  const gameOver = state?.game.phase === 'finished' || state?.game.phase === 'ended'
+  // A finished team's recap comes with game state.
+  const finishedRecap = state?.recap
+  useEffect(() => {
+    if (finishedRecap) {
+      setRecap(finishedRecap)
+      return
+    }
+    if (completedCount === 0 || !(recapOpen || gameOver)) return
+    getRecap(getSession()?.client || 'demo')
+      .then((r) => setRecap(r.stages))
+      .catch(() => {})
+  }, [recapOpen, gameOver, completedCount, finishedRecap])
+
   if (error) {
     return (
       <PageContainer>
@@ -46,9 +67,8 @@ export function GamePage() {
   }
 
   const { game, team, role, currentStage, completedStages, players } = state
-  const isEnded = game.status === 'ended' || (!currentStage && completedStages.length === game.totalStages)
-  const mode = game.mode || 'classic'
-  const canAnswer = !game.supervised || role === 'supervisor'
+  const isEnded = game.phase === 'finished' || game.phase === 'ended'
+  const canAnswer = role !== 'spectator' && (!game.supervised || game.playersCanAnswer || role === 'supervisor')
 
   return (
     <PageContainer size="md">
@@ -63,12 +83,37 @@ export function GamePage() {
         <span className="text-secondary text-sm uppercase tracking-widest font-bold">{team.name}</span>
       </nav>
 
+      {game.phase === 'lobby' && (
+        <div className="card">
+          <p className="text-secondary italic">{t('game_lobby')}</p>
+        </div>
+      )}
+
+      {game.phase === 'waiting' && (
+        <div className="card">
+          <p className="text-secondary italic">{t('game_not_started')}</p>
+        </div>
+      )}
+
+      {game.phase === 'misconfigured' && (
+        <div className="card">
+          <p className="text-secondary italic">{t('game_misconfigured')}</p>
+        </div>
+      )}
+
       {isEnded && stagePhase !== 'results' && (
         <div className="card">
           <div className="card-header">{t('game_over')}</div>
           <p>
             {t('game_over_score', { correct: completedStages.filter((s) => s.isCorrect).length, total: game.totalStages })}
           </p>
+          {state.outro && <p className="mt-3 whitespace-pre-line">{state.outro}</p>}
+        </div>
+      )}
+
+      {state.teammates && !isEnded && (
+        <div className="card">
+          <p className="text-secondary">{t('waiting_for_teammates', { joined: state.teammates.joined, required: state.teammates.required })}</p>
         </div>
       )}
 
@@ -86,7 +131,6 @@ export function GamePage() {
         <UnlockPanel
           stage={currentStage}
           totalStages={game.totalStages}
-          mode={mode}
           role={role}
           unlockCode={unlockCode}
           onUnlockCodeChange={setUnlockCode}
@@ -105,9 +149,12 @@ export function GamePage() {
           answer={answer}
           onAnswerChange={setAnswer}
           onSubmit={handleSubmit}
+          onGiveUp={game.allowGiveUp ? handleGiveUp : undefined}
           feedback={feedback}
           submitting={submitting}
           canAnswer={canAnswer}
+          confirmations={state.confirmations}
+          onConfirm={handleConfirm}
         />
       )}
 
@@ -121,14 +168,20 @@ export function GamePage() {
       )}
 
       {completedStages.length > 0 && (
-        <details open={isEnded}>
+        <details open={isEnded} onToggle={(e) => setRecapOpen(e.currentTarget.open)}>
           <summary>{t('completed_stages', { count: completedStages.length })}</summary>
           <ul className="mt-3 space-y-1">
-            {completedStages.map((s) => (
-              <li key={s.stageNumber} className={s.isCorrect ? 'text-success' : 'text-error'}>
-                {s.isCorrect ? t('stage_correct', { number: s.stageNumber }) : t('stage_incorrect', { number: s.stageNumber })}
-              </li>
-            ))}
+            {completedStages.map((s) => {
+              const r = recap.find((c) => c.stageNumber === s.stageNumber)
+              return (
+                <li key={s.stageNumber} className={s.isCorrect ? 'text-success' : 'text-error'}>
+                  {s.isCorrect ? t('stage_correct', { number: s.stageNumber }) : t('stage_incorrect', { number: s.stageNumber })}
+                  {r?.question && <p className="text-sm"><strong>{t('question_label')}</strong> {r.question}</p>}
+                  {r && <p className="text-sm">{r.answer ? t('recap_answer', { answer: r.answer }) : t('recap_no_answer')}</p>}
+                  {s.explanation && <p className="text-secondary text-sm whitespace-pre-line">{s.explanation}</p>}
+                </li>
+              )
+            })}
           </ul>
         </details>
       )}
@@ -1,9 +1,10 @@
 import { useState, useEffect } from 'react'
 import { useTranslation } from 'react-i18next'
-import { lookupTeam, joinTeam } from './api'
+import { lookupTeam, joinTeam, resumeSession } from './api'
 import type { TeamLookup } from './types'
-import { saveSession } from './lib/session'
+import { saveSession, findSession, forgetSession } from './lib/session'
 import { PageContainer } from './components/PageContainer'
+import { BrandHeader } from './components/BrandHeader'
 import { LoadingPage, Spinner } from './components/Spinner'
 import { ErrorMessage } from './components/ErrorMessage'
 
@@ -16,9 +17,29 @@ export function JoinPage({ client, joinToken }: { client: string; joinToken: str
 
   useEffect(() => {
     lookupTeam(client, joinToken)
-      .then((data) => {
-        setTeam(data)
+      .then(async (data) => {
         if (data.language) i18n.changeLanguage(data.language)
+        // Already joined this team on this device: go back to the game
+        // instead of joining again as a new player.
+        const saved = findSession(data.id, data.role)
+        if (saved && saved.client === client) {
+          try {
+            const resp = await resumeSession(client, saved.token)
+            saveSession({
+              token: resp.token,
+              client,
+              teamId: resp.teamId,
+              teamName: resp.teamName,
+              role: resp.role,
+              language: resp.language,
+            })
+            goToGame()
+            return
+          } catch {
+            forgetSession(data.id, data.role)
+          }
+        }
+        setTeam(data)
       })
       .catch((e) => setError(e.message))
   }, [client, joinToken, i18n])
@@ -38,8 +59,7 @@ export function JoinPage({ client, joinToken }: { client: string; joinToken: str
         role: resp.role,
         language: team?.language,
       })
-      window.history.replaceState(null, '', '/game')
-      window.dispatchEvent(new PopStateEvent('popstate'))
+      goToGame()
     } catch (e) {
       setError(e instanceof Error ? e.message : t('failed_to_join'))
       setJoining(false)
@@ -49,7 +69,7 @@ export function JoinPage({ client, joinToken }: { client: string; joinToken: str
   if (error && !team) {
     return (
       <PageContainer>
-        <h1>{t('app_title')}</h1>
+        <BrandHeader client={client} />
         <ErrorMessage message={error} />
       </PageContainer>
     )
@@ -61,7 +81,7 @@ export function JoinPage({ client, joinToken }: { client: string; joinToken: str
 
   return (
     <PageContainer>
-      <h1>{t('app_title')}</h1>
+      <BrandHeader client={client} />
       <div className="mb-6">
         <h2 className="mb-1">{t('join_heading', { name: team.name })}</h2>
         <p className="text-secondary">{team.gameName}</p>
@@ -71,6 +91,14 @@ export function JoinPage({ client, joinToken }: { client: string; joinToken: str
           <span className="inline-block bg-primary text-white text-xs font-bold uppercase tracking-widest px-3 py-1">
             {t('join_as_supervisor')}
           </span>
+          <span className="block text-secondary text-sm mt-2">{t('join_as_supervisor_hint')}</span>
+        </p>
+      )}
+      {team.role === 'spectator' && (
+        <p>
+          <span className="inline-block bg-primary text-white text-xs font-bold uppercase tracking-widest px-3 py-1">
+            {t('join_as_spectator')}
+          </span>
         </p>
       )}
       <form onSubmit={handleJoin} className="space-y-4">
@@ -95,3 +123,8 @@ export function JoinPage({ client, joinToken }: { client: string; joinToken: str
     </PageContainer>
   )
 }
+
+function goToGame() {
+  window.history.replaceState(null, '', '/game')
+  window.dispatchEvent(new PopStateEvent('popstate'))
+}
@@ -0,0 +1,119 @@
+import { useState } from 'react'
+import { useTranslation } from 'react-i18next'
+import { lookupGamePin, joinTeamByPin } from './api'
+import type { GamePinLookup } from './types'
+import { saveSession } from './lib/session'
+import { PageContainer } from './components/PageContainer'
+import { BrandHeader } from './components/BrandHeader'
+import { Spinner } from './components/Spinner'
+
+export function PinJoinPage({ client }: { client: string }) {
+  const { t, i18n } = useTranslation('player')
+  const [pin, setPin] = useState('')
+  const [game, setGame] = useState<GamePinLookup | null>(null)
+  const [teamId, setTeamId] = useState('')
+  const [name, setName] = useState('')
+  const [error, setError] = useState('')
+  const [busy, setBusy] = useState(false)
+
+  async function handleLookup(e: React.FormEvent) {
+    e.preventDefault()
+    setBusy(true)
+    setError('')
+    try {
+      const data = await lookupGamePin(client, pin.trim())
+      setGame(data)
+      if (data.language) i18n.changeLanguage(data.language)
+    } catch (e) {
+      setError(e instanceof Error ? e.message : t('pin_not_found'))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  async function handleJoin(e: React.FormEvent) {
+    e.preventDefault()
+    if (!game || !teamId || !name.trim()) return
+    setBusy(true)
+    setError('')
+    try {
+      const resp = await joinTeamByPin(client, pin.trim(), teamId, name.trim())
+      saveSession({
+        token: resp.token,
+        client,
+        teamId: resp.teamId,
+        teamName: resp.teamName,
+        role: resp.role,
+        language: game.language,
+      })
+      window.history.replaceState(null, '', '/game')
+      window.dispatchEvent(new PopStateEvent('popstate'))
+    } catch (e) {
+      setError(e instanceof Error ? e.message : t('failed_to_join'))
+      setBusy(false)
+    }
+  }
+
+  if (!game) {
+    return (
+      <PageContainer>
+        <BrandHeader client={client} />
+        <form onSubmit={handleLookup} className="space-y-4">
+          <div>
+            <label className="input-label" htmlFor="game-pin">{t('pin_label')}</label>
+            <input
+              id="game-pin"
+              className="input text-center tracking-widest"
+              type="text"
+              inputMode="numeric"
+              pattern="[0-9]{6}"
+              maxLength={6}
+              value={pin}
+              onChange={(e) => setPin(e.target.value)}
+              autoFocus
+              required
+            />
+          </div>
+          {error && <p className="text-feedback-error">{error}</p>}
+          <button type="submit" disabled={busy} className="btn btn-accent w-full">
+            {busy ? <Spinner /> : t('pin_continue')}
+          </button>
+        </form>
+      </PageContainer>
+    )
+  }
+
+  return (
+    <PageContainer>
+      <BrandHeader client={client} />
+      <p className="text-secondary mb-6">{game.gameName}</p>
+      <form onSubmit={handleJoin} className="space-y-4">
+        <fieldset>
+          <legend className="input-label">{t('pin_pick_team')}</legend>
+          {game.teams.map((team) => (
+            <label key={team.id} className="flex items-center gap-2 py-1">
+              <input type="radio" name="team" value={team.id} checked={teamId === team.id} onChange={() => setTeamId(team.id)} required />
+              {team.name}
+            </label>
+          ))}
+        </fieldset>
+        <div>
+          <label className="input-label" htmlFor="player-name">{t('join_name_label')}</label>
+          <input
+            id="player-name"
+            className="input"
+            type="text"
+            value={name}
+            onChange={(e) => setName(e.target.value)}
+            placeholder={t('join_name_placeholder')}
+            required
+          />
+        </div>
+        {error && <p className="text-feedback-error">{error}</p>}
+        <button type="submit" disabled={busy} className="btn btn-accent w-full">
+          {busy ? <Spinner /> : t('join_button')}
+        </button>
+      </form>
+    </PageContainer>
+  )
+}
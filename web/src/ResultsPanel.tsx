@@ -32,6 +32,7 @@ export function ResultsPanel({ stageNumber, totalStages, result, onContinue }: P
           {result.isCorrect ? t('correct') : t('incorrect')}
         </p>
         <p>{t('correct_answer_is')} <strong>{result.correctAnswer}</strong></p>
+        {result.explanation && <p className="text-secondary whitespace-pre-line">{result.explanation}</p>}
 
         {currentFact && (
           <>
@@ -48,6 +49,8 @@ export function ResultsPanel({ stageNumber, totalStages, result, onContinue }: P
           <button className="btn w-full" onClick={() => setPage((p) => p + 1)}>
             {t('next')}
           </button>
+        ) : result.held && !result.canAdvance ? (
+          <p className="text-secondary text-center">{t('waiting_for_supervisor_next')}</p>
         ) : (
           <button className="btn btn-accent w-full" onClick={onContinue}>
             {t('continue')}
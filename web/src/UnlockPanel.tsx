@@ -1,5 +1,5 @@
 import { useTranslation } from 'react-i18next'
-import type { StageInfo, ScenarioMode } from './types'
+import type { StageInfo } from './types'
 import type { Feedback } from './useGameState'
 import { QrUnlockForm } from './QrUnlockForm'
 import { MathUnlockForm } from './MathUnlockForm'
@@ -8,7 +8,6 @@ import { SupervisedUnlockForm } from './SupervisedUnlockForm'
 interface Props {
   stage: StageInfo
   totalStages: number
-  mode: ScenarioMode
   role: string
   unlockCode: string
   onUnlockCodeChange: (code: string) => void
@@ -18,7 +17,7 @@ interface Props {
   teamSecret?: number
 }
 
-export function UnlockPanel({ stage, totalStages, mode, role, unlockCode, onUnlockCodeChange, onUnlock, feedback, submitting, teamSecret }: Props) {
+export function UnlockPanel({ stage, totalStages, role, unlockCode, onUnlockCodeChange, onUnlock, feedback, submitting, teamSecret }: Props) {
   const { t } = useTranslation('player')
   const common = { onUnlock, feedback, submitting }
 
@@ -27,20 +26,20 @@ export function UnlockPanel({ stage, totalStages, mode, role, unlockCode, onUnlo
       <div className="card-header">
         {t('stage_of', { current: stage.stageNumber, total: totalStages })}{role === 'supervisor' && <> &mdash; {stage.location}</>}
       </div>
-      {mode !== 'supervised' && (
+      {stage.unlockMethod !== 'supervisor' && (
         <div className="mb-4">
           <p><strong>{t('clue_label')}</strong> {stage.clue}</p>
           {stage.clueImage && <img src={stage.clueImage} alt="" className="w-full mt-2" />}
         </div>
       )}
 
-      {(mode === 'qr_quiz' || mode === 'qr_hunt') && (
+      {stage.unlockMethod === 'scan' && (
         <QrUnlockForm {...common} unlockCode={unlockCode} onUnlockCodeChange={onUnlockCodeChange} />
       )}
-      {mode === 'math_puzzle' && (
+      {stage.unlockMethod === 'code' && (
         <MathUnlockForm {...common} unlockCode={unlockCode} onUnlockCodeChange={onUnlockCodeChange} teamSecret={teamSecret} locationNumber={stage.locationNumber} />
       )}
-      {mode === 'supervised' && (
+      {stage.unlockMethod === 'supervisor' && (
         <SupervisedUnlockForm {...common} role={role} clue={stage.clue} clueImage={stage.clueImage} />
       )}
     </div>
@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react'
 import { useTranslation } from 'react-i18next'
-import { listClients, createClient, type ClientInfo } from './adminApi'
+import { listClients, createClient, updateBranding, type Branding, type ClientInfo, type TokenStyle } from './adminApi'
 import { LoadingPage, Spinner } from '../components/Spinner'
 import { ErrorMessage } from '../components/ErrorMessage'
 
@@ -16,7 +16,12 @@ export function AdminClientsPage() {
   const [error, setError] = useState('')
   const [newSlug, setNewSlug] = useState('')
   const [newName, setNewName] = useState('')
+  const [tokenStyle, setTokenStyle] = useState<TokenStyle>('hex')
+  const [pinLength, setPinLength] = useState(6)
+  const [defaultMode, setDefaultMode] = useState('')
   const [creating, setCreating] = useState(false)
+  const [branding, setBranding] = useState<{ slug: string; value: Branding } | null>(null)
+  const [savingBranding, setSavingBranding] = useState(false)
 
   useEffect(() => {
     listClients()
@@ -31,10 +36,12 @@ export function AdminClientsPage() {
     setCreating(true)
     setError('')
     try {
-      const client = await createClient(newSlug.trim(), newName.trim())
+      const client = await createClient(newSlug.trim(), newName.trim(), tokenStyle, tokenStyle === 'pin' ? pinLength : undefined, defaultMode || undefined)
       setClients((prev) => [...prev, client])
       setNewSlug('')
       setNewName('')
+      setTokenStyle('hex')
+      setDefaultMode('')
     } catch (e) {
       setError(e instanceof Error ? e.message : t('clients_create_failed'))
     } finally {
@@ -42,6 +49,26 @@ export function AdminClientsPage() {
     }
   }
 
+  async function handleSaveBranding(e: React.FormEvent) {
+    e.preventDefault()
+    if (!branding) return
+    setSavingBranding(true)
+    setError('')
+    try {
+      const saved = await updateBranding(branding.slug, branding.value)
+      setClients((prev) => prev.map((c) => (c.slug === branding.slug ? { ...c, branding: saved } : c)))
+      setBranding(null)
+    } catch (e) {
+      setError(e instanceof Error ? e.message : t('clients_branding_failed'))
+    } finally {
+      setSavingBranding(false)
+    }
+  }
+
+  function setBrandingField(field: keyof Branding, value: string) {
+    setBranding((prev) => prev && { ...prev, value: { ...prev.value, [field]: value || undefined } })
+  }
+
   if (loading) {
     return <LoadingPage message={t('clients_loading')} />
   }
@@ -73,11 +100,17 @@ export function AdminClientsPage() {
                     {t('clients_scenarios')}
                   </button>
                   <button
-                    className="btn-ghost btn-sm"
+                    className="btn-ghost btn-sm mr-1"
                     onClick={() => navigate(`/admin/clients/${c.slug}/games`)}
                   >
                     {t('clients_games')}
                   </button>
+                  <button
+                    className="btn-ghost btn-sm"
+                    onClick={() => setBranding({ slug: c.slug, value: c.branding ?? {} })}
+                  >
+                    {t('clients_branding')}
+                  </button>
                 </td>
               </tr>
             ))}
@@ -85,6 +118,32 @@ export function AdminClientsPage() {
         </table>
       )}
 
+      {branding && (
+        <form onSubmit={handleSaveBranding} className="card mb-6 space-y-4">
+          <div className="card-header">{t('clients_branding_title', { slug: branding.slug })}</div>
+          <div>
+            <label className="input-label" htmlFor="brandName">{t('clients_branding_name')}</label>
+            <input id="brandName" className="input" type="text" maxLength={60} value={branding.value.name ?? ''} onChange={(e) => setBrandingField('name', e.target.value)} />
+          </div>
+          <div>
+            <label className="input-label" htmlFor="brandLogo">{t('clients_branding_logo')}</label>
+            <input id="brandLogo" className="input" type="text" value={branding.value.logoUrl ?? ''} onChange={(e) => setBrandingField('logoUrl', e.target.value)} placeholder="https://" />
+          </div>
+          <div>
+            <label className="input-label" htmlFor="brandColor">{t('clients_branding_color')}</label>
+            <input id="brandColor" className="input" type="text" value={branding.value.primaryColor ?? ''} onChange={(e) => setBrandingField('primaryColor', e.target.value)} placeholder="#1a73e8" />
+          </div>
+          <div className="flex gap-2">
+            <button type="submit" disabled={savingBranding} className="btn">
+              {savingBranding ? <Spinner /> : t('clients_branding_save')}
+            </button>
+            <button type="button" className="btn btn-secondary" onClick={() => setBranding(null)}>
+              {t('clients_branding_cancel')}
+            </button>
+          </div>
+        </form>
+      )}
+
       <details>
         <summary>{t('clients_add')}</summary>
         <form onSubmit={handleCreate} className="mt-4 space-y-4">
@@ -97,6 +156,29 @@ export function AdminClientsPage() {
             <input id="slug" className="input text-sm" type="text" value={newSlug} onChange={(e) => setNewSlug(e.target.value)} placeholder={t('clients_slug_placeholder')} required />
             <p className="text-secondary text-xs mt-1">{t('clients_slug_hint')}</p>
           </div>
+          <div>
+            <label className="input-label" htmlFor="tokenStyle">{t('clients_token_style')}</label>
+            <select id="tokenStyle" className="input" value={tokenStyle} onChange={(e) => setTokenStyle(e.target.value as TokenStyle)}>
+              <option value="hex">{t('clients_token_hex')}</option>
+              <option value="words">{t('clients_token_words')}</option>
+              <option value="pin">{t('clients_token_pin')}</option>
+            </select>
+          </div>
+          {tokenStyle === 'pin' && (
+            <div>
+              <label className="input-label" htmlFor="pinLength">{t('clients_pin_length')}</label>
+              <input id="pinLength" className="input" type="number" min={6} max={12} value={pinLength} onChange={(e) => setPinLength(Number(e.target.value))} />
+            </div>
+          )}
+          <div>
+            <label className="input-label" htmlFor="defaultMode">{t('clients_default_mode')}</label>
+            <select id="defaultMode" className="input" value={defaultMode} onChange={(e) => setDefaultMode(e.target.value)}>
+              <option value="">{t('clients_default_mode_none')}</option>
+              {['classic', 'qr_quiz', 'qr_hunt', 'math_puzzle', 'supervised'].map((m) => (
+                <option key={m} value={m}>{t(`mode_${m}`)}</option>
+              ))}
+            </select>
+          </div>
           <button type="submit" disabled={creating} className="btn">
             {creating ? <Spinner /> : t('clients_create')}
           </button>
@@ -10,7 +10,7 @@ function navigate(path: string) {
   window.dispatchEvent(new PopStateEvent('popstate'))
 }
 
-const statuses = ['draft', 'active', 'paused', 'ended'] as const
+const statuses = ['draft', 'lobby', 'active', 'paused', 'ended'] as const
 
 export function AdminGameEditorPage({ client, id }: { client: string; id?: string }) {
   const { t } = useTranslation('admin')
@@ -19,11 +19,27 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
   const [status, setStatus] = useState('draft')
   const [language, setLanguage] = useState('ru')
   const [supervised, setSupervised] = useState(true)
+  const [playersCanAnswer, setPlayersCanAnswer] = useState(false)
   const [timerEnabled, setTimerEnabled] = useState(false)
   const [timerMinutes, setTimerMinutes] = useState(120)
   const [stageTimerMinutes, setStageTimerMinutes] = useState(10)
   const [notes, setNotes] = useState('')
+  const [outro, setOutro] = useState('')
+  const [requireAllPlayers, setRequireAllPlayers] = useState(false)
+  const [allPlayersGrading, setAllPlayersGrading] = useState('majority')
+  const [hideLockedClue, setHideLockedClue] = useState(false)
+  const [hideLocationFromPlayers, setHideLocationFromPlayers] = useState(false)
+  const [manualAdvance, setManualAdvance] = useState(false)
+  const [waitForConfirmations, setWaitForConfirmations] = useState(false)
+  const [minPlayersToStart, setMinPlayersToStart] = useState(0)
+  const [maxAttempts, setMaxAttempts] = useState(0)
+  const [allowGiveUp, setAllowGiveUp] = useState(false)
+  const [individualScoring, setIndividualScoring] = useState(false)
+  const [firstStageUnlocked, setFirstStageUnlocked] = useState(false)
+  const [pointsDecay, setPointsDecay] = useState(false)
+  const [trimPunctuation, setTrimPunctuation] = useState(false)
   const [startedAt, setStartedAt] = useState<string | null>(null)
+  const [pin, setPin] = useState('')
   const [stages, setStages] = useState<Stage[]>([])
   const [teams, setTeams] = useState<TeamItem[]>([])
   const [loading, setLoading] = useState(!!id)
@@ -36,6 +52,7 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
   const [newTeamGuide, setNewTeamGuide] = useState('')
   const [newTeamStartStage, setNewTeamStartStage] = useState(0)
   const [addingTeam, setAddingTeam] = useState(false)
+  const [version, setVersion] = useState(0)
 
   useEffect(() => {
     const loads: Promise<void>[] = [
@@ -52,13 +69,30 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
           setStatus(g.status)
           setLanguage(g.language || 'ru')
           setSupervised(g.supervised)
+          setPlayersCanAnswer(g.playersCanAnswer)
           setTimerEnabled(g.timerEnabled)
           setTimerMinutes(g.timerMinutes || 120)
           setStageTimerMinutes(g.stageTimerMinutes || 10)
           setNotes(g.notes || '')
+          setOutro(g.outro || '')
+          setRequireAllPlayers(g.requireAllPlayers)
+          setAllPlayersGrading(g.allPlayersGrading || 'majority')
+          setHideLockedClue(g.hideLockedClue)
+          setHideLocationFromPlayers(g.hideLocationFromPlayers)
+          setManualAdvance(g.manualAdvance)
+          setWaitForConfirmations(g.waitForConfirmations)
+          setMinPlayersToStart(g.minPlayersToStart || 0)
+          setMaxAttempts(g.maxAttempts || 0)
+          setAllowGiveUp(!!g.allowGiveUp)
+          setIndividualScoring(!!g.individualScoring)
+          setFirstStageUnlocked(!!g.firstStageUnlocked)
+          setPointsDecay(!!g.pointsDecay)
+          setTrimPunctuation(g.trimPunctuation)
           setStartedAt(g.startedAt)
+          setPin(g.pin ?? '')
           setStages(g.stages || [])
           setTeams(g.teams)
+          setVersion(g.version)
         })
       )
     }
@@ -75,14 +109,16 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
     setSaving(true)
     setError('')
 
-    const data: GameRequest = { scenarioId, language, status, supervised, timerEnabled, timerMinutes, stageTimerMinutes, notes }
+    const data: GameRequest = { scenarioId, language, status, supervised, playersCanAnswer, timerEnabled, timerMinutes, stageTimerMinutes, notes, outro, requireAllPlayers, allPlayersGrading, trimPunctuation, hideLockedClue, hideLocationFromPlayers, manualAdvance, waitForConfirmations, minPlayersToStart, maxAttempts, allowGiveUp, individualScoring, firstStageUnlocked, pointsDecay, ...(id ? { version } : {}) }
 
     try {
       if (id) {
         const updated = await updateGame(client, id, data)
         setStartedAt(updated.startedAt)
+        setPin(updated.pin ?? '')
         setStages(updated.stages || [])
         setTeams(updated.teams)
+        setVersion(updated.version)
       } else {
         const created = await createGame(client, data)
         setSaving(false)
@@ -124,7 +160,7 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
     const guideName = prompt(t('teams_prompt_guide'), team.guideName) ?? ''
 
     try {
-      const updated = await updateTeam(client, id, team.id, { name: name.trim(), joinToken: team.joinToken, guideName: guideName.trim(), startStage: team.startStage })
+      const updated = await updateTeam(client, id, team.id, { name: name.trim(), joinToken: team.joinToken, guideName: guideName.trim(), startStage: team.startStage, version: team.version })
       setTeams((prev) => prev.map((t) => (t.id === team.id ? updated : t)))
     } catch (e) {
       alert(e instanceof Error ? e.message : t('game_update_failed'))
@@ -160,6 +196,9 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
       {startedAt && (
         <p className="text-secondary text-sm mb-4">{t('game_started', { date: new Date(startedAt).toLocaleString() })}</p>
       )}
+      {pin && (
+        <p className="text-secondary text-sm mb-4">{t('game_pin', { pin, path: `/pin/${client}` })}</p>
+      )}
       <form onSubmit={handleSubmit} className="space-y-4">
         <div>
           <label className="input-label">{t('game_scenario')}</label>
@@ -191,6 +230,24 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
           <input type="checkbox" checked={supervised} onChange={(e) => setSupervised(e.target.checked)} />
           <span className="text-sm">{t('game_supervised')}</span>
         </label>
+        {supervised && (
+          <label className="flex items-center gap-2 cursor-pointer">
+            <input type="checkbox" checked={playersCanAnswer} onChange={(e) => setPlayersCanAnswer(e.target.checked)} />
+            <span className="text-sm">{t('game_players_can_answer')}</span>
+          </label>
+        )}
+        {supervised && (
+          <label className="flex items-center gap-2 cursor-pointer">
+            <input type="checkbox" checked={waitForConfirmations} onChange={(e) => setWaitForConfirmations(e.target.checked)} />
+            <span className="text-sm">{t('game_wait_for_confirmations')}</span>
+          </label>
+        )}
+        {supervised && (
+          <label className="flex items-center gap-2 cursor-pointer">
+            <input type="checkbox" checked={hideLocationFromPlayers} onChange={(e) => setHideLocationFromPlayers(e.target.checked)} />
+            <span className="text-sm">{t('game_hide_location_from_players')}</span>
+          </label>
+        )}
         <label className="flex items-center gap-2 cursor-pointer">
           <input type="checkbox" checked={timerEnabled} onChange={(e) => setTimerEnabled(e.target.checked)} />
           <span className="text-sm">{t('game_timer_enable')}</span>
@@ -208,11 +265,68 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
           </div>
         )}
 
+        <div>
+          <label className="input-label">{t('game_min_players_to_start')}</label>
+          <input className="input" type="number" min="0" max="50" value={minPlayersToStart} onChange={(e) => setMinPlayersToStart(parseInt(e.target.value) || 0)} />
+        </div>
+
+        <div>
+          <label className="input-label">{t('game_max_attempts')}</label>
+          <input className="input" type="number" min="0" max="10" value={maxAttempts} onChange={(e) => setMaxAttempts(parseInt(e.target.value) || 0)} />
+        </div>
+
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={trimPunctuation} onChange={(e) => setTrimPunctuation(e.target.checked)} />
+          <span className="text-sm">{t('game_trim_punctuation')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={hideLockedClue} onChange={(e) => setHideLockedClue(e.target.checked)} />
+          <span className="text-sm">{t('game_hide_locked_clue')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={manualAdvance} onChange={(e) => setManualAdvance(e.target.checked)} />
+          <span className="text-sm">{t('game_manual_advance')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={allowGiveUp} onChange={(e) => setAllowGiveUp(e.target.checked)} />
+          <span className="text-sm">{t('game_allow_give_up')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={individualScoring} onChange={(e) => setIndividualScoring(e.target.checked)} />
+          <span className="text-sm">{t('game_individual_scoring')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={firstStageUnlocked} onChange={(e) => setFirstStageUnlocked(e.target.checked)} />
+          <span className="text-sm">{t('game_first_stage_unlocked')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={pointsDecay} onChange={(e) => setPointsDecay(e.target.checked)} />
+          <span className="text-sm">{t('game_points_decay')}</span>
+        </label>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={requireAllPlayers} onChange={(e) => setRequireAllPlayers(e.target.checked)} />
+          <span className="text-sm">{t('game_require_all_players')}</span>
+        </label>
+        {requireAllPlayers && (
+          <div>
+            <label className="input-label">{t('game_all_players_grading')}</label>
+            <select className="input" value={allPlayersGrading} onChange={(e) => setAllPlayersGrading(e.target.value)}>
+              <option value="majority">{t('grading_majority')}</option>
+              <option value="first_correct">{t('grading_first_correct')}</option>
+            </select>
+          </div>
+        )}
+
         <div>
           <label className="input-label">{t('game_notes')}</label>
           <textarea className="input" rows={3} value={notes} onChange={(e) => setNotes(e.target.value)} placeholder={t('game_notes_placeholder')} />
         </div>
 
+        <div>
+          <label className="input-label">{t('game_outro')}</label>
+          <textarea className="input" rows={3} maxLength={2000} value={outro} onChange={(e) => setOutro(e.target.value)} placeholder={t('game_outro_placeholder')} />
+        </div>
+
         <div className="flex gap-4">
           <button type="submit" disabled={saving} className="btn">
             {saving ? <Spinner /> : id ? t('game_update') : t('game_create')}
@@ -237,6 +351,7 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
                   <th>{t('teams_col_name')}</th>
                   <th>{t('teams_col_join_link')}</th>
                   {supervised && <th>{t('teams_col_supervisor_link')}</th>}
+                  <th>{t('teams_col_spectator_link')}</th>
                   {selectedMode === 'math_puzzle' && <th>{t('teams_col_team_secret')}</th>}
                   <th>{t('teams_col_start_stage')}</th>
                   <th>{t('teams_col_guide')}</th>
@@ -269,6 +384,13 @@ export function AdminGameEditorPage({ client, id }: { client: string; id?: strin
                         })() : '-'}
                       </td>
                     )}
+                    <td>
+                      {tm.spectatorToken ? (
+                        <a href={`/join/${client}/${tm.spectatorToken}`} target="_blank" rel="noopener noreferrer" className="text-xs break-all">
+                          {`${window.location.origin}/join/${client}/${tm.spectatorToken}`}
+                        </a>
+                      ) : '-'}
+                    </td>
                     {selectedMode === 'math_puzzle' && <td>{tm.teamSecret || '-'}</td>}
                     <td>{tm.startStage ? t('teams_stage_location', { number: tm.startStage, location: stages.find(s => s.stageNumber === tm.startStage)?.location || '' }) : t('teams_default_stage')}</td>
                     <td>{tm.guideName || '-'}</td>
@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react'
 import { useTranslation } from 'react-i18next'
-import { getGameStatus } from './adminApi'
+import { getGameStatus, extendGame } from './adminApi'
 import type { GameStatus } from './adminTypes'
 import { LoadingPage } from '../components/Spinner'
 import { ErrorMessage } from '../components/ErrorMessage'
@@ -29,6 +29,15 @@ export function AdminGameStatusPage({ client, id }: { client: string; id: string
     return () => { active = false; clearInterval(interval) }
   }, [client, id])
 
+  async function handleExtend(minutes: number) {
+    try {
+      const res = await extendGame(client, id, minutes)
+      setGame((g) => g && { ...g, timerMinutes: res.timerMinutes })
+    } catch (e) {
+      setError(e instanceof Error ? e.message : String(e))
+    }
+  }
+
   if (error) return <ErrorMessage message={error} />
   if (!game) return <LoadingPage />
 
@@ -63,6 +72,14 @@ export function AdminGameStatusPage({ client, id }: { client: string; id: string
         <button className="btn-secondary btn-sm" onClick={() => navigate(`/admin/clients/${client}/games/${id}/edit`)}>
           {t('status_edit_game')}
         </button>
+        <a className="btn-secondary btn-sm" href={`/api/admin/clients/${client}/games/${id}/results.csv`} download>
+          {t('status_download_results')}
+        </a>
+        {game.timerEnabled && game.status !== 'ended' && (
+          <button className="btn-secondary btn-sm" onClick={() => handleExtend(15)}>
+            {t('status_extend_timer', { minutes: 15 })}
+          </button>
+        )}
         <button className="btn-ghost btn-sm" onClick={() => navigate(`/admin/clients/${client}/games`)}>
           {t('status_back_to_games')}
         </button>
@@ -84,12 +101,17 @@ export function AdminGameStatusPage({ client, id }: { client: string; id: string
             </thead>
             <tbody>
               {[...game.teams]
-                .sort((a, b) => b.completedStages - a.completedStages)
+                .sort((a, b) => b.score - a.score || b.completedStages - a.completedStages)
                 .map((team) => (
                   <tr key={team.id}>
                     <td><strong>{team.name}</strong></td>
-                    <td>{team.completedStages}</td>
-                    <td>{t('scoreboard_progress', { completed: team.completedStages, total: game.totalStages })}</td>
+                    <td>{team.score}</td>
+                    <td>
+                      {t('scoreboard_progress', { completed: team.completedStages, total: game.totalStages })}
+                      <div className="h-1.5 mt-1 bg-gray-200 rounded-full">
+                        <div className="h-1.5 bg-blue-600 rounded-full" style={{ width: `${team.progressPercent}%` }} />
+                      </div>
+                    </td>
                     <td>{team.players.length}</td>
                   </tr>
                 ))}
@@ -103,8 +125,9 @@ export function AdminGameStatusPage({ client, id }: { client: string; id: string
                 <div>
                   <strong>{team.name}</strong>
                   {team.guideName && <span className="text-secondary"> &mdash; {t('team_guide', { name: team.guideName })}</span>}
+                  {team.droppedEvents > 0 && <span className="text-secondary text-sm"> &middot; {t('team_dropped_events', { count: team.droppedEvents })}</span>}
                 </div>
-                <span className="font-bold">{t('scoreboard_pts', { count: team.completedStages })}</span>
+                <span className="font-bold">{t('scoreboard_pts', { count: team.score })}</span>
               </div>
               {team.players.length === 0 ? (
                 <p className="text-secondary text-sm m-0">{t('team_no_players')}</p>
@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react'
 import { useTranslation } from 'react-i18next'
-import { listGames, deleteGame } from './adminApi'
+import { listGames, deleteGame, cloneGame } from './adminApi'
 import type { GameSummary } from './adminTypes'
 import { LoadingPage } from '../components/Spinner'
 import { ErrorMessage } from '../components/ErrorMessage'
@@ -34,6 +34,15 @@ export function AdminGamesPage({ client }: { client: string }) {
     }
   }
 
+  async function handleClone(id: string) {
+    try {
+      const clone = await cloneGame(client, id)
+      navigate(`/admin/clients/${client}/games/${clone.id}/edit`)
+    } catch (e) {
+      alert(e instanceof Error ? e.message : t('games_clone_failed'))
+    }
+  }
+
   if (loading) {
     return <LoadingPage message={t('games_loading')} />
   }
@@ -82,7 +91,10 @@ export function AdminGamesPage({ client }: { client: string }) {
                 <td>{t('games_timer_minutes', { minutes: g.timerMinutes })}</td>
                 <td>{g.teamCount}</td>
                 <td>{new Date(g.createdAt).toLocaleDateString()}</td>
-                <td>
+                <td className="whitespace-nowrap">
+                  <button className="btn-ghost btn-sm mr-1" onClick={() => handleClone(g.id)}>
+                    {t('games_clone')}
+                  </button>
                   <button
                     className="btn-danger btn-sm"
                     onClick={() => handleDelete(g.id, g.scenarioName)}
@@ -1,7 +1,7 @@
 import { useState, useEffect, useRef } from 'react'
 import { useTranslation } from 'react-i18next'
-import { getScenario, createScenario, updateScenario, uploadImage, exportScenario } from './adminApi'
-import type { Stage, ScenarioRequest, FunFact } from './adminTypes'
+import { getScenario, createScenario, updateScenario, validateScenario, uploadImage, exportScenario } from './adminApi'
+import type { Stage, ScenarioRequest, FunFact, Attachment } from './adminTypes'
 import { LoadingPage, Spinner } from '../components/Spinner'
 import { ErrorMessage } from '../components/ErrorMessage'
 
@@ -69,10 +69,15 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
   const [city, setCity] = useState('')
   const [description, setDescription] = useState('')
   const [mode, setMode] = useState('supervised')
+  const [playCount, setPlayCount] = useState(0)
+  const [isPublic, setIsPublic] = useState(false)
   const [stages, setStages] = useState<Stage[]>([emptyStage()])
+  const [version, setVersion] = useState(0)
   const [loading, setLoading] = useState(!!id)
   const [saving, setSaving] = useState(false)
   const [error, setError] = useState('')
+  const [warnings, setWarnings] = useState<string[] | null>(null)
+  const [checking, setChecking] = useState(false)
 
   const modeLabels: Record<string, string> = {
     supervised: t('mode_supervised'),
@@ -90,6 +95,9 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
         setCity(s.city)
         setDescription(s.description)
         setMode(s.mode || 'supervised')
+        setPlayCount(s.playCount ?? 0)
+        setIsPublic(s.public ?? false)
+        setVersion(s.version)
         const loaded = s.stages.length > 0 ? s.stages : [emptyStage()]
         setStages(loaded.map((st) => ({ ...st, funFacts: normalizeFunFacts(st.funFacts) })))
       })
@@ -124,6 +132,29 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
     }))
   }
 
+  function updateAttachment(stageIndex: number, attachmentIndex: number, field: keyof Attachment, value: string) {
+    setStages((prev) => prev.map((s, i) => {
+      if (i !== stageIndex) return s
+      const attachments = [...(s.attachments || [])]
+      attachments[attachmentIndex] = { ...attachments[attachmentIndex], [field]: value }
+      return { ...s, attachments }
+    }))
+  }
+
+  function addAttachment(stageIndex: number) {
+    setStages((prev) => prev.map((s, i) => {
+      if (i !== stageIndex) return s
+      return { ...s, attachments: [...(s.attachments || []), { label: '', url: '' }] }
+    }))
+  }
+
+  function removeAttachment(stageIndex: number, attachmentIndex: number) {
+    setStages((prev) => prev.map((s, i) => {
+      if (i !== stageIndex) return s
+      return { ...s, attachments: (s.attachments || []).filter((_, ai) => ai !== attachmentIndex) }
+    }))
+  }
+
   function addStage() {
     setStages((prev) => [...prev, emptyStage()])
   }
@@ -142,18 +173,53 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
     })
   }
 
-  async function handleSubmit(e: React.FormEvent) {
-    e.preventDefault()
-    setSaving(true)
-    setError('')
+  // List answers are edited as one delimited string in the correct answer
+  // field; the server derives correctAnswer back from the parts. Number
+  // answers keep their tolerance; other types drop the type-specific fields.
+  function answerTypeFields(s: Stage): Partial<Stage> {
+    const cleared = { answerType: undefined, acceptedAnswers: undefined, listDelimiter: undefined, listUnordered: undefined, numericTolerance: undefined }
+    if (!modeNeedsQuestion(mode)) return cleared
+    if (s.answerType === 'number') {
+      return { ...cleared, answerType: 'number', numericTolerance: s.numericTolerance || undefined }
+    }
+    if (s.answerType !== 'list') return cleared
+    const delimiter = s.listDelimiter || ','
+    return { numericTolerance: undefined, listDelimiter: delimiter, acceptedAnswers: s.correctAnswer.split(delimiter).map((p) => p.trim()).filter(Boolean) }
+  }
 
-    const data: ScenarioRequest = {
+  function buildRequest(): ScenarioRequest {
+    return {
       name,
       city,
       description,
       mode,
-      stages: stages.map((s, i) => ({ ...s, stageNumber: i + 1 })),
+      playCount,
+      public: isPublic,
+      stages: stages.map((s, i) => ({ ...s, stageNumber: i + 1, caseSensitive: modeNeedsQuestion(mode) && s.caseSensitive, strictAnswers: modeNeedsQuestion(mode) && s.strictAnswers, explanation: modeNeedsQuestion(mode) ? s.explanation : undefined, ...answerTypeFields(s) })),
+      ...(id ? { version } : {}),
+    }
+  }
+
+  async function handleCheck() {
+    setChecking(true)
+    setError('')
+    try {
+      const res = await validateScenario(buildRequest())
+      setError(res.error ?? '')
+      setWarnings(res.valid ? res.warnings : null)
+    } catch (e) {
+      setError(e instanceof Error ? e.message : t('scenario_check_failed'))
+    } finally {
+      setChecking(false)
     }
+  }
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault()
+    setSaving(true)
+    setError('')
+
+    const data = buildRequest()
 
     try {
       if (id) {
@@ -183,6 +249,20 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
         )}
       </div>
       {error && <ErrorMessage message={error} />}
+      {warnings && (
+        <div className="card">
+          {warnings.length === 0 ? (
+            <p className="text-sm">{t('scenario_no_warnings')}</p>
+          ) : (
+            <>
+              <strong className="text-sm">{t('scenario_warnings')}</strong>
+              <ul className="text-sm list-disc pl-5">
+                {warnings.map((w) => <li key={w}>{w}</li>)}
+              </ul>
+            </>
+          )}
+        </div>
+      )}
       <form onSubmit={handleSubmit} className="space-y-4">
         <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
           <div>
@@ -208,7 +288,15 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
               ))}
             </select>
           </div>
+          <div>
+            <label className="input-label" htmlFor="sc-play-count">{t('scenario_play_count')}</label>
+            <input id="sc-play-count" className="input" type="number" min="0" max={stages.length - 1} value={playCount} onChange={(e) => setPlayCount(parseInt(e.target.value) || 0)} />
+          </div>
         </div>
+        <label className="flex items-center gap-2 cursor-pointer">
+          <input type="checkbox" checked={isPublic} onChange={(e) => setIsPublic(e.target.checked)} />
+          <span className="text-sm">{t('scenario_public')}</span>
+        </label>
 
         <h3 className="mt-8">{t('scenario_stages')}</h3>
         {stages.map((stage, i) => (
@@ -256,9 +344,66 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
                     <input className="input" type="text" value={stage.question} onChange={(e) => updateStage(i, 'question', e.target.value)} required />
                   </div>
                   <ImageUpload label={t('scenario_question_image')} value={stage.questionImage} onChange={(url) => updateStage(i, 'questionImage', url ?? '')} />
+                  <div>
+                    <label className="input-label">{t('scenario_attachments')}</label>
+                    <div className="space-y-2">
+                      {(stage.attachments || []).map((a, ai) => (
+                        <div key={ai} className="flex gap-2">
+                          <input className="input flex-1" type="text" maxLength={100} value={a.label} onChange={(e) => updateAttachment(i, ai, 'label', e.target.value)} placeholder={t('scenario_attachment_label')} required />
+                          <input className="input flex-1" type="text" value={a.url} onChange={(e) => updateAttachment(i, ai, 'url', e.target.value)} placeholder={t('scenario_attachment_url')} required />
+                          <button type="button" className="btn-danger btn-sm" onClick={() => removeAttachment(i, ai)}>
+                            &times;
+                          </button>
+                        </div>
+                      ))}
+                      {(stage.attachments || []).length < 10 && (
+                        <button type="button" className="btn-ghost btn-sm" onClick={() => addAttachment(i)}>
+                          {t('scenario_add_attachment')}
+                        </button>
+                      )}
+                    </div>
+                  </div>
                   <div>
                     <label className="input-label">{t('scenario_correct_answer')}</label>
-                    <input className="input" type="text" value={stage.correctAnswer} onChange={(e) => updateStage(i, 'correctAnswer', e.target.value)} required />
+                    <input className="input" type="text" value={stage.correctAnswer} onChange={(e) => updateStage(i, 'correctAnswer', e.target.value)} placeholder={stage.answerType === 'list' ? t('scenario_list_answer_placeholder', { delimiter: stage.listDelimiter || ',' }) : undefined} required />
+                  </div>
+                  <label className="flex items-center gap-2">
+                    <span className="text-sm">{t('scenario_answer_type')}</span>
+                    <select className="input w-auto" value={stage.answerType ?? 'text'} onChange={(e) => updateStage(i, 'answerType', e.target.value === 'text' ? undefined : e.target.value)}>
+                      <option value="text">{t('scenario_answer_type_text')}</option>
+                      <option value="list">{t('scenario_list_answer')}</option>
+                      <option value="number">{t('scenario_answer_type_number')}</option>
+                    </select>
+                  </label>
+                  {stage.answerType === 'number' && (
+                    <label className="flex items-center gap-2 pl-6">
+                      <span className="text-sm">{t('scenario_numeric_tolerance')}</span>
+                      <input className="input w-24" type="number" min={0} step="any" value={stage.numericTolerance ?? 0} onChange={(e) => updateStage(i, 'numericTolerance', Number(e.target.value))} />
+                    </label>
+                  )}
+                  {stage.answerType === 'list' && (
+                    <div className="flex items-center gap-4 pl-6">
+                      <label className="flex items-center gap-2">
+                        <span className="text-sm">{t('scenario_list_delimiter')}</span>
+                        <input className="input w-16" type="text" value={stage.listDelimiter ?? ','} onChange={(e) => updateStage(i, 'listDelimiter', e.target.value)} />
+                      </label>
+                      <label className="flex items-center gap-2 cursor-pointer">
+                        <input type="checkbox" checked={!!stage.listUnordered} onChange={(e) => updateStage(i, 'listUnordered', e.target.checked)} />
+                        <span className="text-sm">{t('scenario_list_unordered')}</span>
+                      </label>
+                    </div>
+                  )}
+                  <label className="flex items-center gap-2 cursor-pointer">
+                    <input type="checkbox" checked={!!stage.caseSensitive} onChange={(e) => updateStage(i, 'caseSensitive', e.target.checked)} />
+                    <span className="text-sm">{t('scenario_case_sensitive')}</span>
+                  </label>
+                  <label className="flex items-center gap-2 cursor-pointer">
+                    <input type="checkbox" checked={!!stage.strictAnswers} onChange={(e) => updateStage(i, 'strictAnswers', e.target.checked)} />
+                    <span className="text-sm">{t('scenario_strict_answers')}</span>
+                  </label>
+                  <div>
+                    <label className="input-label">{t('scenario_explanation')}</label>
+                    <textarea className="input" rows={2} maxLength={1000} value={stage.explanation ?? ''} onChange={(e) => updateStage(i, 'explanation', e.target.value)} placeholder={t('scenario_explanation_placeholder')} />
                   </div>
                   <div>
                     <label className="input-label">{t('scenario_fun_facts')}</label>
@@ -292,6 +437,10 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
                   </div>
                 </>
               )}
+              <label className="flex items-center gap-2">
+                <span className="text-sm">{t('scenario_points')}</span>
+                <input className="input w-24" type="number" min={1} max={10000} value={stage.points || 100} onChange={(e) => updateStage(i, 'points', parseInt(e.target.value) || undefined)} />
+              </label>
               <div className="grid grid-cols-2 gap-4">
                 <div>
                   <label className="input-label">{t('scenario_latitude')}</label>
@@ -314,6 +463,9 @@ export function AdminScenarioEditorPage({ id }: { id?: string }) {
           <button type="submit" disabled={saving} className="btn">
             {saving ? <Spinner /> : id ? t('scenario_update') : t('scenario_create')}
           </button>
+          <button type="button" className="btn-secondary" onClick={handleCheck} disabled={checking}>
+            {checking ? <Spinner /> : t('scenario_check')}
+          </button>
           <button type="button" className="btn-secondary" onClick={() => navigate('/admin/scenarios')}>
             {t('scenario_cancel')}
           </button>
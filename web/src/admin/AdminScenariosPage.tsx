@@ -1,6 +1,6 @@
 import { useState, useEffect, useRef } from 'react'
 import { useTranslation } from 'react-i18next'
-import { listScenarios, deleteScenario, exportScenario, importScenario } from './adminApi'
+import { listScenarios, deleteScenario, getScenarioUsage, exportScenario, exportAllScenarios, importScenario } from './adminApi'
 import type { ScenarioSummary } from './adminTypes'
 import { LoadingPage } from '../components/Spinner'
 import { ErrorMessage } from '../components/ErrorMessage'
@@ -33,12 +33,28 @@ export function AdminScenariosPage() {
     }
   }
 
+  async function handleExportAll() {
+    try {
+      await exportAllScenarios()
+    } catch (e) {
+      alert(e instanceof Error ? e.message : t('scenarios_export_failed'))
+    }
+  }
+
   async function handleImport(e: React.ChangeEvent<HTMLInputElement>) {
     const file = e.target.files?.[0]
     if (!file) return
     e.target.value = ''
     try {
       const created = await importScenario(file)
+      if (Array.isArray(created)) {
+        const lines = created.map((r) => `${r.file}: ${t(`scenarios_import_${r.status}`)}${r.error ? ` (${r.error})` : ''}`)
+        alert(lines.join('\n') || t('scenarios_import_empty'))
+        if (created.some((r) => r.status === 'created')) {
+          setScenarios((await listScenarios()).sort((a, b) => b.createdAt.localeCompare(a.createdAt)))
+        }
+        return
+      }
       setScenarios((prev) => [{ id: created.id, name: created.name, city: created.city, description: created.description, mode: created.mode, stageCount: created.stages.length, createdAt: created.createdAt }, ...prev])
     } catch (err) {
       alert(err instanceof Error ? err.message : t('scenarios_import_failed'))
@@ -51,6 +67,12 @@ export function AdminScenariosPage() {
       await deleteScenario(id)
       setScenarios((prev) => prev.filter((s) => s.id !== id))
     } catch (e) {
+      const usage = await getScenarioUsage(id).catch(() => [])
+      if (usage.length > 0) {
+        const games = usage.map((u) => `${u.client}/${u.gameId} (${u.status})`).join('\n')
+        alert(t('scenarios_delete_in_use', { games }))
+        return
+      }
       alert(e instanceof Error ? e.message : t('scenarios_delete_failed'))
     }
   }
@@ -71,13 +93,16 @@ export function AdminScenariosPage() {
           <input
             ref={fileInputRef}
             type="file"
-            accept=".md"
+            accept=".md,.zip"
             className="hidden"
             onChange={handleImport}
           />
           <button onClick={() => fileInputRef.current?.click()} className="btn-secondary btn-sm">
             {t('scenarios_import')}
           </button>
+          <button onClick={handleExportAll} className="btn-secondary btn-sm">
+            {t('scenarios_export_all')}
+          </button>
           <button onClick={() => navigate('/admin/scenarios/new')} className="btn">
             {t('scenarios_new')}
           </button>
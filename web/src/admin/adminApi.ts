@@ -1,4 +1,4 @@
-import type { AdminMe, ScenarioSummary, ScenarioDetail, ScenarioRequest, GameSummary, GameDetail, GameRequest, GameStatus, TeamItem, TeamRequest } from './adminTypes'
+import type { AdminMe, ScenarioSummary, ScenarioDetail, ScenarioRequest, ScenarioValidation, GameSummary, GameDetail, GameRequest, GameStatus, TeamItem, TeamRequest } from './adminTypes'
 
 const BASE = '/api/admin'
 
@@ -30,20 +30,41 @@ export function getMe(): Promise<AdminMe> {
   return request('/me')
 }
 
+export type TokenStyle = 'hex' | 'words' | 'pin'
+
 export interface ClientInfo {
   slug: string
   name: string
+  tokenStyle: TokenStyle
+  pinLength?: number
+  defaultMode?: string
+  branding: Branding
+}
+
+// How the client's join screen looks; empty fields keep the default.
+export interface Branding {
+  name?: string
+  logoUrl?: string
+  primaryColor?: string
 }
 
 export function listClients(): Promise<ClientInfo[]> {
   return request('/clients')
 }
 
-export function createClient(slug: string, name: string): Promise<ClientInfo> {
+export function createClient(slug: string, name: string, tokenStyle: TokenStyle, pinLength?: number, defaultMode?: string): Promise<ClientInfo> {
   return request('/clients', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ slug, name }),
+    body: JSON.stringify({ slug, name, tokenStyle, pinLength, defaultMode }),
+  })
+}
+
+export function updateBranding(client: string, branding: Branding): Promise<Branding> {
+  return request(`/clients/${client}/branding`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(branding),
   })
 }
 
@@ -83,6 +104,14 @@ export function createScenario(data: ScenarioRequest): Promise<ScenarioDetail> {
   })
 }
 
+export function validateScenario(data: ScenarioRequest): Promise<ScenarioValidation> {
+  return request('/scenarios/validate', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(data),
+  })
+}
+
 export function updateScenario(id: string, data: ScenarioRequest): Promise<ScenarioDetail> {
   return request(`/scenarios/${id}`, {
     method: 'PUT',
@@ -95,6 +124,17 @@ export function deleteScenario(id: string): Promise<void> {
   return request(`/scenarios/${id}`, { method: 'DELETE' })
 }
 
+export interface ScenarioUsage {
+  client: string
+  gameId: string
+  status: GameStatus
+}
+
+// getScenarioUsage lists the games, in any client, built from a scenario.
+export function getScenarioUsage(id: string): Promise<ScenarioUsage[]> {
+  return request(`/scenarios/${id}/usage`)
+}
+
 export async function exportScenario(id: string, name: string): Promise<void> {
   const res = await fetch(BASE + `/scenarios/${id}/export`, {
     credentials: 'same-origin',
@@ -112,7 +152,34 @@ export async function exportScenario(id: string, name: string): Promise<void> {
   URL.revokeObjectURL(url)
 }
 
-export async function importScenario(file: File): Promise<ScenarioDetail> {
+export async function exportAllScenarios(): Promise<void> {
+  const res = await fetch(BASE + '/scenarios/export-all', {
+    credentials: 'same-origin',
+  })
+  if (!res.ok) {
+    const body = await res.json().catch(() => ({}))
+    throw new Error(body.error || `HTTP ${res.status}`)
+  }
+  const blob = await res.blob()
+  const url = URL.createObjectURL(blob)
+  const a = document.createElement('a')
+  a.href = url
+  a.download = 'scenarios.zip'
+  a.click()
+  URL.revokeObjectURL(url)
+}
+
+export interface ScenarioImportResult {
+  file: string
+  status: 'created' | 'collision' | 'invalid'
+  id?: string
+  name?: string
+  error?: string
+}
+
+// importScenario uploads a single .md export (returns the scenario) or a
+// .zip from exportAllScenarios (returns one result per file).
+export async function importScenario(file: File): Promise<ScenarioDetail | ScenarioImportResult[]> {
   const form = new FormData()
   form.append('file', file)
   const res = await fetch(BASE + '/scenarios/import', {
@@ -157,6 +224,18 @@ export function deleteGame(client: string, id: string): Promise<void> {
   return request(`/clients/${client}/games/${id}`, { method: 'DELETE' })
 }
 
+export function cloneGame(client: string, id: string): Promise<GameDetail> {
+  return request(`/clients/${client}/games/${id}/clone`, { method: 'POST' })
+}
+
+export function extendGame(client: string, id: string, addMinutes: number): Promise<{ timerMinutes: number; deadline: string | null; remainingSeconds: number }> {
+  return request(`/clients/${client}/games/${id}/extend`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ addMinutes }),
+  })
+}
+
 export function getGameStatus(client: string, id: string): Promise<GameStatus> {
   return request(`/clients/${client}/games/${id}/status`)
 }
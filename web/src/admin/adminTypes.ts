@@ -18,6 +18,11 @@ export interface FunFact {
   image?: string
 }
 
+export interface Attachment {
+  label: string
+  url: string
+}
+
 export interface Stage {
   stageNumber: number
   location: string
@@ -26,8 +31,18 @@ export interface Stage {
   question: string
   questionImage?: string
   correctAnswer: string
+  explanation?: string
   unlockCode?: string
   locationNumber?: number
+  caseSensitive?: boolean
+  strictAnswers?: boolean
+  answerType?: 'text' | 'list' | 'number'
+  acceptedAnswers?: string[]
+  listDelimiter?: string
+  listUnordered?: boolean
+  numericTolerance?: number
+  points?: number // defaults to 100
+  attachments?: Attachment[]
   funFacts?: FunFact[]
   lat: number
   lng: number
@@ -39,8 +54,18 @@ export interface ScenarioDetail {
   city: string
   description: string
   mode: string
+  playCount?: number
+  public?: boolean
   stages: Stage[]
   createdAt: string
+  version: number
+  warnings?: string[]
+}
+
+export interface ScenarioValidation {
+  valid: boolean
+  error?: string
+  warnings: string[]
 }
 
 export interface ScenarioRequest {
@@ -48,7 +73,10 @@ export interface ScenarioRequest {
   city: string
   description: string
   mode: string
+  playCount?: number
+  public: boolean // listed on GET /api/public/scenarios
   stages: Stage[]
+  version?: number // required on update: the version the edit was loaded at
 }
 
 export interface GameSummary {
@@ -64,6 +92,7 @@ export interface GameSummary {
   stageTimerMinutes: number
   notes?: string
   teamCount: number
+  progressPercent: number
   createdAt: string
 }
 
@@ -72,11 +101,14 @@ export interface TeamItem {
   name: string
   joinToken: string
   supervisorToken: string
+  spectatorToken?: string
   guideName: string
   teamSecret?: number
   startStage: number
   playerCount: number
+  adminUnlocks?: number[]
   createdAt: string
+  version: number
 }
 
 export interface GameDetail {
@@ -91,10 +123,28 @@ export interface GameDetail {
   timerMinutes: number
   stageTimerMinutes: number
   notes?: string
+  outro?: string
+  requireAllPlayers: boolean
+  allPlayersGrading?: string
+  hideLockedClue: boolean
+  hideLocationFromPlayers: boolean
+  trimPunctuation: boolean
+  playersCanAnswer: boolean
+  manualAdvance: boolean
+  waitForConfirmations: boolean
+  minPlayersToStart: number
+  maxAttempts: number
+  allowGiveUp: boolean
+  individualScoring: boolean
+  firstStageUnlocked: boolean
+  pointsDecay: boolean
+  settings: Record<string, boolean> // every on/off setting by name
+  pin?: string
   startedAt: string | null
   stages: Stage[]
   teams: TeamItem[]
   createdAt: string
+  version: number
 }
 
 export interface GameRequest {
@@ -106,6 +156,23 @@ export interface GameRequest {
   timerMinutes: number
   stageTimerMinutes: number
   notes: string
+  outro: string
+  requireAllPlayers: boolean
+  allPlayersGrading: string
+  hideLockedClue: boolean
+  hideLocationFromPlayers: boolean
+  trimPunctuation: boolean
+  playersCanAnswer: boolean
+  manualAdvance: boolean
+  waitForConfirmations: boolean
+  minPlayersToStart: number
+  maxAttempts: number
+  allowGiveUp: boolean
+  individualScoring: boolean
+  firstStageUnlocked: boolean
+  pointsDecay: boolean
+  settings?: Record<string, boolean> // overrides the flat fields above
+  version?: number // required on update: the version the edit was loaded at
 }
 
 export interface TeamRequest {
@@ -113,6 +180,7 @@ export interface TeamRequest {
   joinToken: string
   guideName: string
   startStage: number
+  version?: number // required on update
 }
 
 export interface GameStatus {
@@ -133,7 +201,20 @@ export interface TeamStatus {
   name: string
   guideName: string
   completedStages: number
+  progressPercent: number
+  score: number
   players: PlayerStatus[]
+  results: StageResult[]
+  droppedEvents: number
+}
+
+export interface StageResult {
+  stageNumber: number
+  answer: string
+  isCorrect: boolean
+  points: number
+  playerName?: string
+  answeredAt: string
 }
 
 export interface PlayerStatus {
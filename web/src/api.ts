@@ -1,4 +1,4 @@
-import type { TeamLookup, JoinResponse, GameState, AnswerResponse, UnlockResponse } from './types'
+import type { Branding, TeamLookup, GamePinLookup, JoinResponse, ResumeResponse, GameState, AnswerResponse, ConfirmResponse, NextResponse, RecapResponse, UnlockResponse } from './types'
 import { getSession } from './lib/session'
 
 async function request<T>(path: string, opts?: RequestInit): Promise<T> {
@@ -15,6 +15,10 @@ function authHeaders(): HeadersInit {
   return session ? { Authorization: `Bearer ${session.token}` } : {}
 }
 
+export function getBranding(client: string): Promise<Branding> {
+  return request(`/api/${client}/branding`)
+}
+
 export function lookupTeam(client: string, joinToken: string): Promise<TeamLookup> {
   return request(`/api/${client}/teams/${joinToken}`)
 }
@@ -27,10 +31,30 @@ export function joinTeam(client: string, joinToken: string, playerName: string):
   })
 }
 
+export function lookupGamePin(client: string, pin: string): Promise<GamePinLookup> {
+  return request(`/api/${client}/games/pin/${pin}`)
+}
+
+export function joinTeamByPin(client: string, pin: string, teamId: string, playerName: string): Promise<JoinResponse> {
+  return request(`/api/${client}/join`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ pin, teamId, playerName }),
+  })
+}
+
+export function resumeSession(client: string, token: string): Promise<ResumeResponse> {
+  return request(`/api/${client}/game/resume?token=${encodeURIComponent(token)}`)
+}
+
 export function getGameState(client: string): Promise<GameState> {
   return request(`/api/${client}/game/state`, { headers: authHeaders() })
 }
 
+export function getRecap(client: string): Promise<RecapResponse> {
+  return request(`/api/${client}/game/recap`, { headers: authHeaders() })
+}
+
 export function submitAnswer(client: string, answer: string): Promise<AnswerResponse> {
   return request(`/api/${client}/game/answer`, {
     method: 'POST',
@@ -39,6 +63,14 @@ export function submitAnswer(client: string, answer: string): Promise<AnswerResp
   })
 }
 
+export function giveUp(client: string): Promise<AnswerResponse> {
+  return request(`/api/${client}/game/giveup`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', ...authHeaders() },
+    body: '{}',
+  })
+}
+
 export function unlockStage(client: string, code: string): Promise<UnlockResponse> {
   return request(`/api/${client}/game/unlock`, {
     method: 'POST',
@@ -46,3 +78,19 @@ export function unlockStage(client: string, code: string): Promise<UnlockRespons
     body: JSON.stringify({ code }),
   })
 }
+
+export function confirmStage(client: string): Promise<ConfirmResponse> {
+  return request(`/api/${client}/game/confirm`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', ...authHeaders() },
+    body: '{}',
+  })
+}
+
+export function advanceStage(client: string): Promise<NextResponse> {
+  return request(`/api/${client}/game/next`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', ...authHeaders() },
+    body: '{}',
+  })
+}
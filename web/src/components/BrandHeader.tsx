@@ -0,0 +1,29 @@
+import { useEffect, useState } from 'react'
+import { useTranslation } from 'react-i18next'
+import { getBranding } from '../api'
+import type { Branding } from '../types'
+
+// The join screen's title: the client's logo and name, with its primary
+// color applied to the page. Without branding it's the plain app title.
+export function BrandHeader({ client }: { client: string }) {
+  const { t } = useTranslation('player')
+  const [branding, setBranding] = useState<Branding | null>(null)
+
+  useEffect(() => {
+    getBranding(client).then(setBranding).catch(() => setBranding(null))
+  }, [client])
+
+  useEffect(() => {
+    if (!branding?.primaryColor) return
+    const root = document.documentElement
+    root.style.setProperty('--color-primary', branding.primaryColor)
+    return () => root.style.removeProperty('--color-primary')
+  }, [branding?.primaryColor])
+
+  return (
+    <>
+      {branding?.logoUrl && <img src={branding.logoUrl} alt="" className="h-12 mb-4" />}
+      <h1>{branding?.name || t('app_title')}</h1>
+    </>
+  )
+}
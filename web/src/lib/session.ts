@@ -40,6 +40,21 @@ export function getSession(): SessionData | null {
   }
 }
 
+/** Returns the session saved for a team and role, whichever tab saved it. */
+export function findSession(teamId: string, role: string): SessionData | null {
+  const raw = localStorage.getItem(storageKey(teamId, role))
+  if (!raw) return null
+  try {
+    return JSON.parse(raw)
+  } catch {
+    return null
+  }
+}
+
+export function forgetSession(teamId: string, role: string): void {
+  localStorage.removeItem(storageKey(teamId, role))
+}
+
 export function clearSession(): void {
   const key = sessionStorage.getItem(ACTIVE_KEY)
   if (key) localStorage.removeItem(key)
@@ -1,9 +1,24 @@
+// A client's look for the join screen; empty fields keep the default.
+export interface Branding {
+  name?: string
+  logoUrl?: string
+  primaryColor?: string
+}
+
 export interface TeamLookup {
   id: string
   name: string
   gameName: string
-  role: string
+  role: 'player' | 'supervisor' | 'spectator'
+  language?: string
+  mode: ScenarioMode
+  supervised: boolean
+}
+
+export interface GamePinLookup {
+  gameName: string
   language?: string
+  teams: { id: string; name: string }[]
 }
 
 export interface JoinResponse {
@@ -14,10 +29,23 @@ export interface JoinResponse {
   role: string
 }
 
+export interface ResumeResponse {
+  token: string
+  playerId: string
+  teamId: string
+  teamName: string
+  gameName: string
+  role: string
+  language?: string
+}
+
 export type ScenarioMode = 'classic' | 'qr_quiz' | 'qr_hunt' | 'math_puzzle' | 'supervised'
 
+export type GamePhase = 'lobby' | 'waiting' | 'playing' | 'finished' | 'ended' | 'misconfigured'
+
 export interface GameInfo {
   status: string
+  phase: GamePhase
   mode: ScenarioMode
   language?: string
   supervised: boolean
@@ -26,6 +54,22 @@ export interface GameInfo {
   stageTimerMinutes: number
   startedAt: string | null
   totalStages: number
+  requireAllPlayers?: boolean
+  playersCanAnswer?: boolean
+  manualAdvance?: boolean
+  waitForConfirmations?: boolean
+  allowGiveUp?: boolean
+  maxAttempts?: number // wrong answers allowed per question; unset = one answer settles it
+  individualScoring?: boolean // each player answers for themselves; the state is this player's
+  capabilities: Capabilities
+}
+
+// What the game's mode uses, worked out by the server.
+export interface Capabilities {
+  usesUnlock: boolean
+  usesQuestions: boolean
+  supervisorControlled: boolean
+  usesTeamSecret: boolean
 }
 
 export interface TeamInfo {
@@ -41,13 +85,32 @@ export interface StageInfo {
   questionImage?: string
   location: string
   locked: boolean
+  unlockMethod: UnlockMethod
   locationNumber?: number
+  answerType?: AnswerType // set with the question
+  attachments?: Attachment[] // set with the question
+  stageDeadline?: string // when the stage timer runs out, while it's ticking
+}
+
+// A file to download with a stage's question.
+export interface Attachment {
+  label: string
+  url: string
 }
 
+// How the answer is typed: 'number' gets a number pad.
+export type AnswerType = 'text' | 'list' | 'number'
+
+// How a locked stage opens, derived from the game mode.
+export type UnlockMethod = 'scan' | 'code' | 'supervisor' | 'none'
+
 export interface CompletedStage {
   stageNumber: number
   isCorrect: boolean
   answeredAt: string
+  gaveUp?: boolean
+  explanation?: string
+  points?: number
 }
 
 export interface PlayerInfo {
@@ -65,6 +128,7 @@ export interface LastStageResult {
   stageNumber: number
   isCorrect: boolean
   correctAnswer: string
+  explanation?: string
   funFacts?: FunFact[]
 }
 
@@ -74,10 +138,33 @@ export interface GameState {
   role: string
   teamSecret?: number
   stageUnlockedAt?: string | null
+  stageRemainingSeconds?: number
+  attemptsRemaining?: number
   currentStage: StageInfo | null
   lastResult?: LastStageResult | null
+  pendingAdvance?: boolean
+  canAdvance?: boolean
+  confirmations?: Confirmations | null
+  teammates?: Teammates | null
   completedStages: CompletedStage[]
+  teamScore: number
   players: PlayerInfo[]
+  outro?: string
+  recap?: RecapStage[]
+}
+
+export interface RecapStage {
+  stageNumber: number
+  location?: string
+  question: string
+  answer: string
+  isCorrect: boolean
+  explanation?: string
+  answeredAt: string
+}
+
+export interface RecapResponse {
+  stages: RecapStage[]
 }
 
 export interface AnswerResponse {
@@ -86,7 +173,39 @@ export interface AnswerResponse {
   nextStage: StageInfo | null
   gameComplete: boolean
   correctAnswer: string
+  explanation?: string
   funFacts?: FunFact[]
+  canAdvance?: boolean
+  waiting?: boolean
+  answeredPlayers?: number
+  requiredPlayers?: number
+  attemptsRemaining?: number // set when a wrong answer left the stage open
+}
+
+// Set while a waitForConfirmations game holds the unlocked stage's timer.
+export interface Confirmations {
+  confirmed: number
+  required: number
+  self: boolean
+}
+
+// Set while a minPlayersToStart game waits for more players to join the team.
+export interface Teammates {
+  joined: number
+  required: number
+}
+
+export interface ConfirmResponse {
+  stageNumber: number
+  confirmed: number
+  required: number
+  started: boolean
+  stageUnlockedAt?: string
+}
+
+export interface NextResponse {
+  stageNumber: number
+  gameComplete: boolean
 }
 
 export interface UnlockResponse {
@@ -96,10 +215,25 @@ export interface UnlockResponse {
   nextStage?: StageInfo
   gameComplete?: boolean
   question?: string
+  questionImage?: string
+  answerType?: AnswerType
+  attachments?: Attachment[]
 }
 
 export interface SSEEvent {
-  type: 'stage_completed' | 'stage_unlocked' | 'wrong_answer' | 'player_joined' | 'game_ended'
+  type: 'stage_completed' | 'stage_unlocked' | 'wrong_answer' | 'player_joined' | 'player_answered' | 'game_ended' | 'timer_extended' | 'timer_warning' | 'stage_advanced' | 'player_confirmed' | 'stage_started' | 'stage_timeout' | 'stage_gaveup' | 'wrong_attempt' | 'stage_failed' | 'announcement'
   stageNumber?: number
   playerName?: string
+  player?: PlayerInfo
+  remainingSeconds?: number
+  attemptsRemaining?: number
+  question?: string
+  questionImage?: string
+  answerType?: AnswerType
+  attachments?: Attachment[]
+  stageUnlockedAt?: string
+  clue?: string
+  locked?: boolean
+  message?: string
+  from?: string
 }
@@ -1,22 +1,30 @@
 import { useEffect } from 'react'
 import { getSession } from './lib/session'
+import type { SSEEvent } from './types'
 
-export function useGameEvents(client: string, onEvent: (eventType?: string) => void) {
+export function useGameEvents(client: string, onEvent: (eventType?: string, event?: SSEEvent) => void) {
   useEffect(() => {
     const session = getSession()
     if (!session) return
 
     const es = new EventSource(`/api/${client}/game/events?token=${session.token}`)
+    let opened = false
+
+    // The server closes streams after a max lifetime; refetch on reopen so
+    // nothing published while disconnected is missed.
+    es.onopen = () => {
+      if (opened) onEvent('reconnect')
+      opened = true
+    }
 
     es.addEventListener('state', (e) => {
-      let eventType: string | undefined
+      let event: SSEEvent | undefined
       try {
-        const data = JSON.parse(e.data)
-        eventType = data.type
+        event = JSON.parse(e.data)
       } catch {
         // ignore parse errors
       }
-      onEvent(eventType)
+      onEvent(event?.type, event)
     })
 
     es.onerror = () => {
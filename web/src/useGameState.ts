@@ -1,14 +1,35 @@
-import { useState, useEffect, useCallback, useRef } from 'react'
+import { useState, useEffect, useCallback, useRef, useMemo } from 'react'
 import { useTranslation } from 'react-i18next'
-import { getGameState, submitAnswer, unlockStage } from './api'
+import { getGameState, submitAnswer, giveUp, unlockStage, advanceStage, confirmStage } from './api'
 import { useGameEvents } from './useGameEvents'
 import { useCountdown } from './TimerDisplay'
 import { getSession, clearSession } from './lib/session'
-import type { GameState, FunFact } from './types'
+import type { GameState, FunFact, SSEEvent } from './types'
 
 export type StagePhase = 'interstitial' | 'unlocking' | 'answering' | 'results'
 export type Feedback = { correct: boolean; message: string }
-export type AnswerResult = { isCorrect: boolean; correctAnswer: string; funFacts?: FunFact[] }
+// held: the game keeps the team on this result until someone calls next;
+// canAdvance: this player may do so (otherwise wait for stage_advanced).
+export type AnswerResult = {
+  isCorrect: boolean
+  correctAnswer: string
+  explanation?: string
+  funFacts?: FunFact[]
+  held?: boolean
+  canAdvance?: boolean
+}
+
+function lastResultOf(s: GameState): AnswerResult | null {
+  if (!s.lastResult) return null
+  return {
+    isCorrect: s.lastResult.isCorrect,
+    correctAnswer: s.lastResult.correctAnswer,
+    explanation: s.lastResult.explanation,
+    funFacts: s.lastResult.funFacts,
+    held: s.pendingAdvance,
+    canAdvance: s.canAdvance,
+  }
+}
 
 export function useGameState() {
   const { t } = useTranslation('player')
@@ -23,6 +44,10 @@ export function useGameState() {
   const stagePhaseRef = useRef<StagePhase>('interstitial')
   const [answerResult, setAnswerResult] = useState<AnswerResult | null>(null)
   const answeringRef = useRef(false) // true while submitAnswer is in-flight
+  const stateRef = useRef<GameState | null>(null) // read by the SSE callback
+  useEffect(() => {
+    stateRef.current = state
+  }, [state])
 
   const fetchState = useCallback(() => {
     getGameState(client)
@@ -33,9 +58,20 @@ export function useGameState() {
       .catch((e) => setError(e.message))
   }, [client])
 
+  // On load, a team held on a result (manualAdvance) goes back to it.
   useEffect(() => {
-    fetchState()
-  }, [fetchState])
+    getGameState(client)
+      .then((s) => {
+        setState(s)
+        setError('')
+        const result = s.pendingAdvance ? lastResultOf(s) : null
+        if (result) {
+          setAnswerResult(result)
+          updateStagePhase('results')
+        }
+      })
+      .catch((e) => setError(e.message))
+  }, [client])
 
   // Keep ref in sync so SSE callback can read current phase without re-creating.
   function updateStagePhase(phase: StagePhase) {
@@ -46,8 +82,17 @@ export function useGameState() {
   // SSE handler: refetch state, and if stage was unlocked, transition phase.
   // Skip refetch during 'results' phase — we already have the answer data and
   // the server has already advanced currentStage, which would reset the phase.
-  const onSSEEvent = useCallback((eventType?: string) => {
-    if (eventType === 'stage_unlocked') {
+  const onSSEEvent = useCallback((eventType?: string, event?: SSEEvent) => {
+    const player = event?.player
+    if (eventType === 'player_joined' && player && !stateRef.current?.teammates && !stateRef.current?.confirmations) {
+      // Deltas carry all that changed, so they skip the refetch. A join only
+      // adds to the roster unless the team is still gathering or confirming.
+      setState((s) => (s && !s.players.some((p) => p.id === player.id) ? { ...s, players: [...s.players, player] } : s))
+    } else if (eventType === 'wrong_attempt') {
+      setState((s) => (s ? { ...s, attemptsRemaining: event?.attemptsRemaining } : s))
+    } else if (eventType === 'timer_warning' || eventType === 'announcement') {
+      // Nothing in the game state changes.
+    } else if (eventType === 'stage_unlocked') {
       fetchState()
       setStagePhase((prev) => {
         const next = prev === 'unlocking' ? 'answering' : prev
@@ -55,22 +100,26 @@ export function useGameState() {
         return next
       })
       setUnlockCode('')
-    } else if (eventType === 'stage_completed' || eventType === 'wrong_answer') {
+    } else if (eventType === 'stage_completed' || eventType === 'wrong_answer' || eventType === 'stage_timeout' || eventType === 'stage_gaveup' || eventType === 'stage_failed') {
       // For non-submitters: fetch new state and show results from server.
       if (stagePhaseRef.current !== 'results' && !answeringRef.current) {
         getGameState(client).then((s) => {
           setState(s)
           setError('')
-          if (s.lastResult) {
-            setAnswerResult({
-              isCorrect: s.lastResult.isCorrect,
-              correctAnswer: s.lastResult.correctAnswer,
-              funFacts: s.lastResult.funFacts,
-            })
+          const result = lastResultOf(s)
+          if (result) {
+            setAnswerResult(result)
             updateStagePhase('results')
           }
         }).catch((e) => setError(e.message))
       }
+    } else if (eventType === 'stage_advanced') {
+      // Someone moved the team on from the result (manualAdvance games).
+      if (stagePhaseRef.current === 'results') {
+        leaveResults()
+      } else {
+        fetchState()
+      }
     } else if (stagePhaseRef.current !== 'results' && !answeringRef.current) {
       fetchState()
     }
@@ -93,12 +142,11 @@ export function useGameState() {
   // If the current stage arrives already unlocked, skip from unlocking to answering.
   useEffect(() => {
     if (!state?.currentStage) return
-    const mode = state.game.mode
-    if (mode === 'classic') return
+    if (!state.game.capabilities.usesUnlock) return
     if (!state.currentStage.locked && stagePhase === 'unlocking') {
       updateStagePhase('answering')
     }
-  }, [state?.currentStage?.locked, state?.game.mode, stagePhase])
+  }, [state?.currentStage?.locked, state?.game.capabilities.usesUnlock, stagePhase])
 
   // Compute timer deadlines.
   const timerActive = state?.game.timerEnabled && state.game.status === 'active'
@@ -107,17 +155,25 @@ export function useGameState() {
     ? new Date(state.game.startedAt).getTime() + state.game.timerMinutes * 60000
     : null
 
-  const stageDeadline = (timerActive && state.stageUnlockedAt && state.game.stageTimerMinutes)
-    ? new Date(state.stageUnlockedAt).getTime() + state.game.stageTimerMinutes * 60000
-    : null
+  // The server counts the stage timer from the team's unlock, so every device
+  // shows the same countdown whatever its own clock says.
+  const stageDeadline = useMemo(
+    () => (timerActive && state?.stageRemainingSeconds ? Date.now() + state.stageRemainingSeconds * 1000 : null),
+    [timerActive, state],
+  )
 
   const gameRemaining = useCountdown(gameDeadline)
   const stageRemaining = useCountdown(stageDeadline)
 
+  // When the stage timer runs out, fetching state makes the server record the
+  // timeout; the stage_timeout event then brings everyone to the result.
+  useEffect(() => {
+    if (stageDeadline !== null && stageRemaining === 0) fetchState()
+  }, [stageDeadline, stageRemaining, fetchState])
+
   function handleGoToStage() {
-    const mode = state?.game.mode || 'classic'
     setFeedback(null)
-    if (mode === 'classic') {
+    if (!state?.game.capabilities.usesUnlock) {
       updateStagePhase('answering')
     } else {
       if (state?.currentStage && !state.currentStage.locked) {
@@ -134,8 +190,7 @@ export function useGameState() {
     setSubmitting(true)
     setFeedback(null)
     try {
-      const mode = state!.game.mode
-      const code = mode === 'supervised' ? '' : unlockCode.trim()
+      const code = state!.game.capabilities.supervisorControlled ? '' : unlockCode.trim()
       const resp = await unlockStage(client, code)
       setUnlockCode('')
       if (resp.stageComplete) {
@@ -163,10 +218,23 @@ export function useGameState() {
     try {
       const resp = await submitAnswer(client, answer.trim())
       setAnswer('')
+      if (resp.waiting) {
+        // Result arrives over SSE once the rest of the team has answered.
+        setFeedback({ correct: true, message: t('waiting_for_team', { answered: resp.answeredPlayers, required: resp.requiredPlayers }) })
+        return
+      }
+      if (resp.attemptsRemaining) {
+        // Wrong, but the stage stays open for another try.
+        setFeedback({ correct: false, message: t('wrong_attempt', { count: resp.attemptsRemaining }) })
+        return
+      }
       setAnswerResult({
         isCorrect: resp.isCorrect,
         correctAnswer: resp.correctAnswer,
+        explanation: resp.explanation,
         funFacts: resp.funFacts,
+        held: state?.game.manualAdvance,
+        canAdvance: resp.canAdvance,
       })
       updateStagePhase('results')
     } catch (e) {
@@ -177,13 +245,61 @@ export function useGameState() {
     }
   }
 
-  function handleContinue() {
+  async function handleGiveUp() {
+    if (submitting || !confirm(t('give_up_confirm'))) return
+    setSubmitting(true)
+    setFeedback(null)
+    answeringRef.current = true
+    try {
+      const resp = await giveUp(client)
+      setAnswer('')
+      setAnswerResult({
+        isCorrect: false,
+        correctAnswer: resp.correctAnswer,
+        explanation: resp.explanation,
+        funFacts: resp.funFacts,
+        held: state?.game.manualAdvance,
+        canAdvance: resp.canAdvance,
+      })
+      updateStagePhase('results')
+    } catch (e) {
+      setFeedback({ correct: false, message: e instanceof Error ? e.message : t('error_generic') })
+    } finally {
+      answeringRef.current = false
+      setSubmitting(false)
+    }
+  }
+
+  function leaveResults() {
     setFeedback(null)
     setAnswerResult(null)
     updateStagePhase('interstitial')
     fetchState()
   }
 
+  async function handleContinue() {
+    if (answerResult?.held) {
+      // A teammate may have moved the team on already; the refetch in
+      // leaveResults shows where it is either way.
+      await advanceStage(client).catch(() => {})
+    }
+    leaveResults()
+  }
+
+  // Tell the supervisor this player has the unlocked stage (waitForConfirmations).
+  async function handleConfirm() {
+    if (submitting) return
+    setSubmitting(true)
+    try {
+      await confirmStage(client)
+    } catch (e) {
+      setFeedback({ correct: false, message: e instanceof Error ? e.message : t('error_generic') })
+    } finally {
+      setSubmitting(false)
+      fetchState()
+    }
+  }
+
   function handleLogout() {
     clearSession()
     window.history.replaceState(null, '', '/')
@@ -206,7 +322,9 @@ export function useGameState() {
     handleGoToStage,
     handleUnlock,
     handleSubmit,
+    handleGiveUp,
     handleContinue,
+    handleConfirm,
     handleLogout,
   }
 }